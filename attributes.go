@@ -0,0 +1,293 @@
+package gitlabvulnreceiver
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// Attribute types that CSV columns can be converted to
+const (
+	attributeTypeString    = "string"
+	attributeTypeInt       = "int"
+	attributeTypeDouble    = "double"
+	attributeTypeBool      = "bool"
+	attributeTypeTimestamp = "timestamp"
+)
+
+// defaultAttributeTypes are the column conversions applied unless overridden by
+// attribute_types
+var defaultAttributeTypes = map[string]string{
+	"Activity":      attributeTypeBool,
+	"CVSS Score":    attributeTypeDouble,
+	"Line":          attributeTypeInt,
+	"Start Line":    attributeTypeInt,
+	"End Line":      attributeTypeInt,
+	"Detected At":   attributeTypeTimestamp,
+	"Discovered At": attributeTypeTimestamp,
+	"Confirmed At":  attributeTypeTimestamp,
+	"Resolved At":   attributeTypeTimestamp,
+	"Dismissed At":  attributeTypeTimestamp,
+	"detected_at":   attributeTypeTimestamp,
+	"discovered_at": attributeTypeTimestamp,
+	"confirmed_at":  attributeTypeTimestamp,
+	"resolved_at":   attributeTypeTimestamp,
+	"dismissed_at":  attributeTypeTimestamp,
+}
+
+// lifecycleDateAttributes maps lowercase lifecycle date columns of CSV exports
+// and API records to the attribute they're emitted under, after the prefix
+var lifecycleDateAttributes = map[string]string{
+	"detected at":   "detected_at",
+	"detected_at":   "detected_at",
+	"discovered at": "detected_at",
+	"discovered_at": "detected_at",
+	"confirmed at":  "confirmed_at",
+	"confirmed_at":  "confirmed_at",
+	"resolved at":   "resolved_at",
+	"resolved_at":   "resolved_at",
+	"dismissed at":  "dismissed_at",
+	"dismissed_at":  "dismissed_at",
+}
+
+// Handling of the empty columns of a finding
+const (
+	emptyValuesOmit  = "omit"
+	emptyValuesEmpty = "empty"
+	emptyValuesNull  = "null"
+)
+
+func isValidEmptyValues(mode string) bool {
+	switch mode {
+	case "", emptyValuesOmit, emptyValuesEmpty, emptyValuesNull:
+		return true
+	}
+	return false
+}
+
+// booleanAttributes maps lowercase flag columns of CSV exports and API records
+// to the boolean attribute they're also emitted as. Like the other derived
+// attributes, their names don't follow attribute_prefix.
+var booleanAttributes = map[string]string{
+	"activity":       "vulnerability.is_active",
+	"false positive": "vulnerability.is_false_positive",
+	"false_positive": "vulnerability.is_false_positive",
+	"dismissed":      "vulnerability.is_dismissed",
+}
+
+// putBooleanAttributes sets the flags of a finding as booleans under consistent
+// names, whatever type attribute_types gives their columns. Values that aren't
+// booleans are left out.
+func putBooleanAttributes(attrs pcommon.Map, header []string, record []string) {
+	for i, field := range header {
+		name, ok := booleanAttributes[strings.ToLower(field)]
+		if !ok || i >= len(record) {
+			continue
+		}
+		if b, ok := parseBool(strings.TrimSpace(record[i])); ok {
+			attrs.PutBool(name, b)
+		}
+	}
+}
+
+// semconvAttributeNames maps lowercase CSV column names to standard security
+// attribute names, used instead of the normalized header when semconv_mapping is
+// set. Each column has its own name; Location is named by scanner type.
+var semconvAttributeNames = map[string]string{
+	"vulnerability id": "vulnerability.id",
+	"vulnerability":    "vulnerability.name",
+	"title":            "vulnerability.title",
+	"details":          "vulnerability.details",
+	"description":      "vulnerability.description",
+	"severity":         "vulnerability.severity",
+	"cvss score":       "vulnerability.score.base",
+	"tool":             "vulnerability.scanner.type",
+	"scanner name":     "vulnerability.scanner.vendor",
+	"status":           "vulnerability.state",
+	"package name":     "package.name",
+	"package version":  "package.version",
+}
+
+// semconvLocationNames maps scanner types to the standard attribute name of the
+// Location column, for locations without a structured mapping. Other scanner
+// types keep the normalized name.
+var semconvLocationNames = map[string]string{
+	"":                           "file.path",
+	reportTypeSAST:               "file.path",
+	reportTypeSecretDetection:    "file.path",
+	reportTypeDependencyScanning: "file.path",
+	reportTypeContainerScanning:  "container.image.name",
+	reportTypeDAST:               "url.full",
+}
+
+// Columns that may contain vulnerability identifiers or advisory links
+var identifierColumns = []string{"CVE", "CWE", "Identifiers", "Other Identifiers"}
+
+// Identifier columns that are emitted only as slice attributes
+var identifierSliceColumns = map[string]bool{
+	"cve": true,
+	"cwe": true,
+}
+
+// CVE records are linked on cve.org
+const cveRecordURL = "https://www.cve.org/CVERecord?id="
+
+var (
+	cvePattern = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	cwePattern = regexp.MustCompile(`(?i)\bCWE-\d+\b`)
+	urlPattern = regexp.MustCompile(`https?://[^\s,;"]+`)
+	numPattern = regexp.MustCompile(`^\d+$`)
+)
+
+// putIdentifierAttributes parses the identifier columns of a record into
+// vulnerability.cve_ids, vulnerability.cwe_ids and vulnerability.identifier_urls
+func putIdentifierAttributes(attrs pcommon.Map, header []string, record []string) {
+	var cves, cwes, urls []string
+	for _, column := range identifierColumns {
+		value, ok := findField(header, record, column)
+		if !ok || value == "" {
+			continue
+		}
+
+		// URLs first, so identifiers embedded in advisory links aren't double counted
+		for _, u := range urlPattern.FindAllString(value, -1) {
+			if !slices.Contains(urls, u) {
+				urls = append(urls, u)
+			}
+		}
+		rest := urlPattern.ReplaceAllString(value, "")
+
+		for _, id := range cvePattern.FindAllString(rest, -1) {
+			if id = strings.ToUpper(id); !slices.Contains(cves, id) {
+				cves = append(cves, id)
+			}
+		}
+		for _, id := range cwePattern.FindAllString(rest, -1) {
+			if id = strings.ToUpper(id); !slices.Contains(cwes, id) {
+				cwes = append(cwes, id)
+			}
+		}
+
+		// The CWE column may hold bare numbers
+		if strings.EqualFold(column, "CWE") {
+			for _, part := range strings.FieldsFunc(rest, isIdentifierSeparator) {
+				if id := "CWE-" + part; numPattern.MatchString(part) && !slices.Contains(cwes, id) {
+					cwes = append(cwes, id)
+				}
+			}
+		}
+	}
+
+	putStringSlice(attrs, "vulnerability.cve_ids", cves)
+	putStringSlice(attrs, "vulnerability.cwe_ids", cwes)
+	putStringSlice(attrs, "vulnerability.identifier_urls", urls)
+
+	cveURLs := make([]string, 0, len(cves))
+	for _, id := range cves {
+		cveURLs = append(cveURLs, cveRecordURL+id)
+	}
+	putStringSlice(attrs, "vulnerability.cve_urls", cveURLs)
+}
+
+// putVulnerabilityURL sets the link to the finding's page on GitLab, when the
+// record has its numeric vulnerability ID and project path
+func (r *vulnerabilityReceiver) putVulnerabilityURL(attrs pcommon.Map, header []string, record []string) {
+	projectPath := firstField(header, record, "Full Path", "Project Full Path")
+	if projectPath == "" {
+		return
+	}
+	for _, column := range state.IDColumns {
+		id, ok := findField(header, record, column)
+		if id = strings.TrimSpace(id); ok && numPattern.MatchString(id) {
+			attrs.PutStr("gitlab.vulnerability.url", fmt.Sprintf("%s/%s/-/security/vulnerabilities/%s",
+				strings.TrimRight(r.cfg.instanceURL(), "/"), strings.Trim(projectPath, "/"), id))
+			return
+		}
+	}
+}
+
+func isIdentifierSeparator(r rune) bool {
+	return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t'
+}
+
+func putStringSlice(attrs pcommon.Map, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	slice := attrs.PutEmptySlice(key)
+	slice.EnsureCapacity(len(values))
+	for _, v := range values {
+		slice.AppendEmpty().SetStr(v)
+	}
+}
+
+func isValidAttributeType(typ string) bool {
+	switch typ {
+	case attributeTypeString, attributeTypeInt, attributeTypeDouble, attributeTypeBool, attributeTypeTimestamp:
+		return true
+	}
+	return false
+}
+
+// resolveAttributeTypes merges the configured column types over the defaults,
+// keyed by lowercase column name so lookups ignore header casing
+func resolveAttributeTypes(configured map[string]string) map[string]string {
+	types := make(map[string]string, len(defaultAttributeTypes)+len(configured))
+	for column, typ := range defaultAttributeTypes {
+		types[strings.ToLower(column)] = typ
+	}
+	for column, typ := range configured {
+		types[strings.ToLower(column)] = typ
+	}
+	return types
+}
+
+// putTypedAttribute converts value to the given type and stores it under key.
+// Values that don't parse are kept as strings so no data is lost.
+func putTypedAttribute(attrs pcommon.Map, key, value, typ string, timestamps *timestampParser) {
+	trimmed := strings.TrimSpace(value)
+
+	switch typ {
+	case attributeTypeInt:
+		if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			attrs.PutInt(key, i)
+			return
+		}
+	case attributeTypeDouble:
+		if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			attrs.PutDouble(key, f)
+			return
+		}
+	case attributeTypeBool:
+		if b, ok := parseBool(trimmed); ok {
+			attrs.PutBool(key, b)
+			return
+		}
+	case attributeTypeTimestamp:
+		// Normalize to UTC so timestamps compare correctly as strings
+		if t, ok := timestamps.parse(trimmed); ok {
+			attrs.PutStr(key, t.UTC().Format(time.RFC3339Nano))
+			return
+		}
+	}
+
+	attrs.PutStr(key, value)
+}
+
+// parseBool accepts the boolean spellings found in GitLab exports
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		return true, true
+	case "false", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
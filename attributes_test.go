@@ -0,0 +1,195 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestPutTypedAttribute(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		typ      string
+		expected interface{}
+	}{
+		{
+			name:     "int",
+			value:    " 42 ",
+			typ:      attributeTypeInt,
+			expected: int64(42),
+		},
+		{
+			name:     "double",
+			value:    "9.8",
+			typ:      attributeTypeDouble,
+			expected: 9.8,
+		},
+		{
+			name:     "bool",
+			value:    "TRUE",
+			typ:      attributeTypeBool,
+			expected: true,
+		},
+		{
+			name:     "timestamp normalized to UTC",
+			value:    "2024-02-12T05:34:02+02:00",
+			typ:      attributeTypeTimestamp,
+			expected: "2024-02-12T03:34:02Z",
+		},
+		{
+			name:     "timestamp with zone abbreviation",
+			value:    "2024-02-12 03:34:02 UTC",
+			typ:      attributeTypeTimestamp,
+			expected: "2024-02-12T03:34:02Z",
+		},
+		{
+			name:     "date",
+			value:    "2024-02-12",
+			typ:      attributeTypeTimestamp,
+			expected: "2024-02-12T00:00:00Z",
+		},
+		{
+			name:     "unparseable value kept as string",
+			value:    "not a number",
+			typ:      attributeTypeInt,
+			expected: "not a number",
+		},
+		{
+			name:     "untyped column",
+			value:    "High",
+			typ:      "",
+			expected: "High",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := pcommon.NewMap()
+			putTypedAttribute(attrs, "key", tt.value, tt.typ, defaultTimestampParser)
+
+			v, ok := attrs.Get("key")
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, v.AsRaw())
+		})
+	}
+}
+
+func TestResolveAttributeTypes(t *testing.T) {
+	assert.Equal(t, attributeTypeBool, resolveAttributeTypes(nil)["activity"])
+
+	types := resolveAttributeTypes(map[string]string{
+		"activity":   attributeTypeString,
+		"CVSS Score": attributeTypeString,
+		"Risk Rank":  attributeTypeInt,
+	})
+
+	assert.Equal(t, attributeTypeString, types["activity"])
+	assert.Equal(t, attributeTypeString, types["cvss score"], "configured type should override the default")
+	assert.Equal(t, attributeTypeInt, types["risk rank"])
+	assert.Equal(t, attributeTypeTimestamp, types["detected at"])
+}
+
+func TestPutBooleanAttributes(t *testing.T) {
+	attrs := pcommon.NewMap()
+	header := []string{"Activity", "false_positive", "Dismissed", "Title"}
+	putBooleanAttributes(attrs, header, []string{"TRUE", "false", "maybe", "yes"})
+
+	assert.Equal(t, map[string]interface{}{
+		"vulnerability.is_active":         true,
+		"vulnerability.is_false_positive": false,
+	}, attrs.AsRaw())
+}
+
+func TestPutIdentifierAttributes(t *testing.T) {
+	header := []string{"Title", "CVE", "CWE", "Other Identifiers"}
+	record := []string{
+		"Test Vuln",
+		"CVE-2024-1234, cve-2023-99999",
+		"79; CWE-89",
+		"GHSA-xxxx-yyyy, https://nvd.nist.gov/vuln/detail/CVE-2024-1234 https://cwe.mitre.org/data/definitions/79.html",
+	}
+
+	attrs := pcommon.NewMap()
+	putIdentifierAttributes(attrs, header, record)
+
+	cves, ok := attrs.Get("vulnerability.cve_ids")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"CVE-2024-1234", "CVE-2023-99999"}, cves.Slice().AsRaw())
+
+	cwes, ok := attrs.Get("vulnerability.cwe_ids")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"CWE-89", "CWE-79"}, cwes.Slice().AsRaw())
+
+	urls, ok := attrs.Get("vulnerability.identifier_urls")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{
+		"https://nvd.nist.gov/vuln/detail/CVE-2024-1234",
+		"https://cwe.mitre.org/data/definitions/79.html",
+	}, urls.Slice().AsRaw())
+
+	cveURLs, ok := attrs.Get("vulnerability.cve_urls")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{
+		"https://www.cve.org/CVERecord?id=CVE-2024-1234",
+		"https://www.cve.org/CVERecord?id=CVE-2023-99999",
+	}, cveURLs.Slice().AsRaw())
+}
+
+func TestPutVulnerabilityURL(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.BaseURL = "https://gitlab.example.com/"
+	recv := &vulnerabilityReceiver{cfg: cfg}
+
+	tests := []struct {
+		name     string
+		header   []string
+		values   []string
+		expected string
+	}{
+		{
+			name:     "export row",
+			header:   []string{"Vulnerability ID", "Full Path"},
+			values:   []string{"42", "group/app"},
+			expected: "https://gitlab.example.com/group/app/-/security/vulnerabilities/42",
+		},
+		{
+			name:     "API record",
+			header:   []string{"id", "Project Full Path"},
+			values:   []string{"7", "group/sub/app"},
+			expected: "https://gitlab.example.com/group/sub/app/-/security/vulnerabilities/7",
+		},
+		{
+			name:   "UUID only",
+			header: []string{"UUID", "Full Path"},
+			values: []string{"b1f3", "group/app"},
+		},
+		{
+			name:   "no project path",
+			header: []string{"Vulnerability ID", "Project Name"},
+			values: []string{"42", "App"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := pcommon.NewMap()
+			recv.putVulnerabilityURL(attrs, tt.header, tt.values)
+			url, ok := attrs.Get("gitlab.vulnerability.url")
+			if tt.expected == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, url.Str())
+		})
+	}
+}
+
+func TestPutIdentifierAttributes_NoIdentifiers(t *testing.T) {
+	attrs := pcommon.NewMap()
+	putIdentifierAttributes(attrs, []string{"Title", "CVE"}, []string{"Test Vuln", ""})
+	assert.Equal(t, 0, attrs.Len())
+}
@@ -0,0 +1,191 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// Report type of state transition records, distinguishing them from vulnerabilities
+const reportTypeStateTransition = "state_transition"
+
+// How long emitted state transitions are remembered
+const stateTransitionRetention = 90 * 24 * time.Hour
+
+// processStateTransitions emits the state transitions of a project's
+// vulnerabilities made since the last poll as audit events. Each event carries
+// the hash of the previous one, so a missing or altered event breaks the chain.
+// The trail starts when it's first enabled; earlier transitions aren't emitted.
+func (r *vulnerabilityReceiver) processStateTransitions(ctx context.Context, projectID string) error {
+	cursor, ok := r.stateManager.GetAuditCursor(projectID)
+	if !ok {
+		if err := r.stateManager.SetAuditCursor(projectID, state.AuditCursor{Since: time.Now().UTC()}); err != nil {
+			return fmt.Errorf("failed to save audit cursor: %w", err)
+		}
+		return nil
+	}
+
+	// A state transition updates its vulnerability, so only those updated since
+	// the cursor are listed, in api_order. Paging stops at the first one older
+	// than the cursor once the order is confirmed most recently updated first.
+	latest := cursor.Since
+	order := newUpdateOrder()
+	var ids []string
+	titles := make(map[string]string)
+pages:
+	for page := 1; page != 0; {
+		vulnerabilities, nextPage, err := r.client.ListVulnerabilities(ctx, projectID, cursor.Since, page)
+		if err != nil {
+			return err
+		}
+		for _, vulnerability := range vulnerabilities {
+			updatedAt, ok := vulnerabilityUpdatedAt(vulnerability)
+			if !ok {
+				continue
+			}
+			descending := order.add(updatedAt)
+			if updatedAt.Before(cursor.Since) {
+				if descending {
+					break pages
+				}
+				continue
+			}
+			id := fmt.Sprint(vulnerability["id"])
+			ids = append(ids, id)
+			titles[id], _ = vulnerability["title"].(string)
+			if updatedAt.After(latest) {
+				latest = updatedAt
+			}
+		}
+		page = nextPage
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	transitions, err := r.client.GetStateTransitions(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	type auditEvent struct {
+		vulnerabilityID string
+		key             string
+		transition      StateTransition
+	}
+	var events []auditEvent
+	for _, id := range ids {
+		for _, transition := range transitions[id] {
+			if transition.CreatedAt.Before(cursor.Since) {
+				continue
+			}
+			key := fmt.Sprintf("transition:%s:%s:%s:%s", projectID, id,
+				transition.CreatedAt.UTC().Format(time.RFC3339Nano), transition.ToState)
+			if r.stateManager.IsSeen(key) {
+				continue
+			}
+			events = append(events, auditEvent{id, key, transition})
+		}
+	}
+	slices.SortStableFunc(events, func(a, b auditEvent) int {
+		return a.transition.CreatedAt.Compare(b.transition.CreatedAt)
+	})
+
+	batchSize := r.batchSize()
+	batch := newLogBatch()
+	var keys []string
+	flush := func() error {
+		if batch.records == 0 {
+			return nil
+		}
+		if err := r.consumeLogs(ctx, batch.take()); err != nil {
+			return fmt.Errorf("failed to consume logs: %w", err)
+		}
+		if err := r.stateManager.MarkSeen(keys, stateTransitionRetention); err != nil {
+			return fmt.Errorf("failed to save seen state transitions: %w", err)
+		}
+		keys = keys[:0]
+		if err := r.stateManager.SetAuditCursor(projectID, cursor); err != nil {
+			return fmt.Errorf("failed to save audit cursor: %w", err)
+		}
+		return nil
+	}
+
+	for _, event := range events {
+		logs, hash := r.stateTransitionRecord(projectID, event.vulnerabilityID, titles[event.vulnerabilityID], event.transition, cursor.LastHash)
+		cursor.LastHash = hash
+		batch.add(projectID, logs)
+		keys = append(keys, event.key)
+		if batch.records >= batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	r.logger.Debug("Emitted vulnerability state transitions",
+		zap.String("projectID", projectID),
+		zap.Time("since", cursor.Since),
+		zap.Int("transitions", len(events)))
+
+	cursor.Since = latest
+	if err := r.stateManager.SetAuditCursor(projectID, cursor); err != nil {
+		return fmt.Errorf("failed to save audit cursor: %w", err)
+	}
+	return nil
+}
+
+// stateTransitionRecord converts a state transition to an audit event chained
+// to the previous one, returning it with its hash
+func (r *vulnerabilityReceiver) stateTransitionRecord(projectID, vulnerabilityID, title string, transition StateTransition, previousHash string) (plog.Logs, string) {
+	logs, attrs := r.newComplianceRecord(projectID, reportTypeStateTransition, "gitlab.vulnerability.state_transition")
+	lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(transition.CreatedAt))
+
+	var author string
+	if transition.Author != nil {
+		author = transition.Author.Username
+	}
+	actor := author
+	if actor == "" {
+		actor = "GitLab"
+	}
+	lr.Body().SetStr(fmt.Sprintf("%s changed vulnerability %s from %s to %s",
+		actor, vulnerabilityID, strings.ToLower(transition.FromState), strings.ToLower(transition.ToState)))
+
+	attrs.PutStr("vulnerability.id", vulnerabilityID)
+	putNonEmpty(attrs, "vulnerability.title", title)
+	attrs.PutStr("gitlab.vulnerability.state.from", strings.ToLower(transition.FromState))
+	attrs.PutStr("gitlab.vulnerability.state.to", strings.ToLower(transition.ToState))
+	putNonEmpty(attrs, "gitlab.vulnerability.dismissal_reason", strings.ToLower(transition.DismissalReason))
+	putNonEmpty(attrs, "gitlab.vulnerability.transition.comment", transition.Comment)
+	putNonEmpty(attrs, "user.name", author)
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		previousHash,
+		projectID,
+		vulnerabilityID,
+		transition.FromState,
+		transition.ToState,
+		transition.CreatedAt.UTC().Format(time.RFC3339Nano),
+		author,
+		transition.DismissalReason,
+		transition.Comment,
+	}, "\x00")))
+	putNonEmpty(attrs, "gitlab.audit.previous_hash", previousHash)
+	attrs.PutStr("gitlab.audit.hash", hex.EncodeToString(hash[:]))
+	return logs, hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,137 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+func TestProcessStateTransitions(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	dismissed := StateTransition{
+		FromState:       "DETECTED",
+		ToState:         "DISMISSED",
+		CreatedAt:       since.Add(2 * time.Minute),
+		Comment:         "Test fixture",
+		DismissalReason: "USED_IN_TESTS",
+	}
+	dismissed.Author = &struct {
+		Username string `json:"username"`
+	}{Username: "jdoe"}
+	resolved := StateTransition{FromState: "DETECTED", ToState: "RESOLVED", CreatedAt: since.Add(time.Minute)}
+
+	var requested []string
+	mockClient := &mockGitLabClient{
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			return []map[string]interface{}{
+				{"id": 11, "title": "SQL injection", "updated_at": since.Add(2 * time.Minute).Format(time.RFC3339)},
+				{"id": 12, "title": "XSS", "updated_at": since.Add(time.Minute).Format(time.RFC3339)},
+				{"id": 13, "title": "Old", "updated_at": since.Add(-time.Hour).Format(time.RFC3339)},
+			}, 0, nil
+		},
+		getStateTransitionsFunc: func(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error) {
+			requested = vulnerabilityIDs
+			return map[string][]StateTransition{
+				"11": {{FromState: "CONFIRMED", ToState: "DETECTED", CreatedAt: since.Add(-time.Hour)}, dismissed},
+				"12": {resolved},
+			}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.AuditTrail = true
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	// The first poll only starts the trail
+	require.NoError(t, receiver.processStateTransitions(context.Background(), "12345"))
+	assert.Equal(t, 0, sink.LogRecordCount())
+	_, ok := receiver.stateManager.GetAuditCursor("12345")
+	require.True(t, ok)
+
+	require.NoError(t, receiver.stateManager.SetAuditCursor("12345", state.AuditCursor{Since: since}))
+	require.NoError(t, receiver.processStateTransitions(context.Background(), "12345"))
+	assert.Equal(t, []string{"11", "12"}, requested)
+	require.Equal(t, 2, sink.LogRecordCount())
+
+	records := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	first := records.At(0).Attributes().AsRaw()
+	assert.Equal(t, "gitlab.vulnerability.state_transition", first["event.name"])
+	assert.Equal(t, "12", first["vulnerability.id"])
+	assert.Equal(t, "resolved", first["gitlab.vulnerability.state.to"])
+	assert.NotContains(t, first, "gitlab.audit.previous_hash")
+
+	second := records.At(1)
+	raw := second.Attributes().AsRaw()
+	assert.Equal(t, "jdoe", raw["user.name"])
+	assert.Equal(t, "dismissed", raw["gitlab.vulnerability.state.to"])
+	assert.Equal(t, "used_in_tests", raw["gitlab.vulnerability.dismissal_reason"])
+	assert.Equal(t, "Test fixture", raw["gitlab.vulnerability.transition.comment"])
+	assert.Equal(t, "SQL injection", raw["vulnerability.title"])
+	assert.Equal(t, first["gitlab.audit.hash"], raw["gitlab.audit.previous_hash"])
+	assert.Equal(t, dismissed.CreatedAt, second.Timestamp().AsTime())
+	assert.Equal(t, "jdoe changed vulnerability 11 from detected to dismissed", second.Body().Str())
+
+	cursor, ok := receiver.stateManager.GetAuditCursor("12345")
+	require.True(t, ok)
+	assert.Equal(t, since.Add(2*time.Minute), cursor.Since)
+	assert.Equal(t, raw["gitlab.audit.hash"], cursor.LastHash)
+
+	// Transitions are only emitted once
+	require.NoError(t, receiver.processStateTransitions(context.Background(), "12345"))
+	assert.Equal(t, 2, sink.LogRecordCount())
+}
+
+func TestProcessStateTransitions_AscendingOrder(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var pages []int
+	mockClient := &mockGitLabClient{
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			pages = append(pages, page)
+			// Least recently updated first, as with api_order sort: asc
+			if page == 1 {
+				return []map[string]interface{}{
+					{"id": 13, "title": "Old", "updated_at": since.Add(-time.Hour).Format(time.RFC3339)},
+				}, 2, nil
+			}
+			return []map[string]interface{}{
+				{"id": 12, "title": "XSS", "updated_at": since.Add(time.Minute).Format(time.RFC3339)},
+			}, 0, nil
+		},
+		getStateTransitionsFunc: func(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error) {
+			return map[string][]StateTransition{
+				"12": {{FromState: "DETECTED", ToState: "RESOLVED", CreatedAt: since.Add(time.Minute)}},
+			}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.AuditTrail = true
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+	require.NoError(t, receiver.stateManager.SetAuditCursor("12345", state.AuditCursor{Since: since}))
+
+	// An update older than the cursor doesn't stop paging in another order
+	require.NoError(t, receiver.processStateTransitions(context.Background(), "12345"))
+	assert.Equal(t, []int{1, 2}, pages)
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
@@ -0,0 +1,32 @@
+package gitlabvulnreceiver
+
+import (
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+// Messages of the errors the pipeline refuses data with under memory pressure:
+// the memory_limiter processor and exporters with a full sending queue
+var backpressureMessages = []string{
+	"data refused due to high memory usage",
+	"sending queue is full",
+}
+
+// isBackpressure reports whether err is the pipeline refusing data until it
+// has caught up, rather than failing. Exports are paused on such errors and
+// resumed from their checkpoint by the next poll.
+func isBackpressure(err error) bool {
+	var consumerErr *consumerError
+	if !errors.As(err, &consumerErr) || consumererror.IsPermanent(err) {
+		return false
+	}
+	message := consumerErr.Error()
+	for _, m := range backpressureMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestIsBackpressure(t *testing.T) {
+	refused := errors.New("data refused due to high memory usage")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"memory limiter", fmt.Errorf("failed to consume logs: %w", &consumerError{err: refused}), true},
+		{"full queue", &consumerError{err: errors.New("sending queue is full")}, true},
+		{"other consumer error", &consumerError{err: errors.New("connection refused")}, false},
+		{"permanent", &consumerError{err: consumererror.NewPermanent(refused)}, false},
+		{"not from the pipeline", refused, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isBackpressure(tt.err))
+		})
+	}
+}
+
+func TestCheckExports_PausesUnderBackpressure(t *testing.T) {
+	csvData := "Vulnerability ID,Title,Severity\n1,first,High\n2,second,Low\n3,third,Medium\n"
+	exportsCreated := 0
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			exportsCreated++
+			return &Export{ID: 123, ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+		getExportFunc: func(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+		},
+	}
+
+	// The pipeline accepts the first record, then refuses data until it's relieved
+	var titles []string
+	relieved := false
+	next, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		if len(titles) == 1 && !relieved {
+			return errors.New("data refused due to high memory usage")
+		}
+		title, _ := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("vulnerability.title")
+		titles = append(titles, title.Str())
+		return nil
+	})
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	cfg.BatchSize = 1
+	cfg.ConsumerRetry.Enabled = false
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          next,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	assert.Equal(t, []string{"first"}, titles)
+	assertNoPathErrors(t, receiver, "a paused export isn't a failure")
+	assert.Equal(t, "paused", receiver.pathStatusSnapshot()[0].ExportStatus)
+	cp, ok := receiver.stateManager.GetCheckpoint("12345")
+	require.True(t, ok)
+	assert.Equal(t, int64(1), cp.RowsProcessed)
+
+	// The next poll resumes the same export where it was paused
+	relieved = true
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	assert.Equal(t, []string{"first", "second", "third"}, titles)
+	assert.Equal(t, 1, exportsCreated)
+	_, ok = receiver.stateManager.GetCheckpoint("12345")
+	assert.False(t, ok)
+}
@@ -0,0 +1,61 @@
+package gitlabvulnreceiver
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// logBatch accumulates converted records, keeping one ResourceLogs per project
+// so resource-based routing works for group exports
+type logBatch struct {
+	logs      plog.Logs
+	resources map[string]plog.ResourceLogs
+	records   int
+}
+
+func newLogBatch() *logBatch {
+	return &logBatch{
+		logs:      plog.NewLogs(),
+		resources: make(map[string]plog.ResourceLogs),
+	}
+}
+
+// add moves the records of a single-resource Logs into the batch, merging them
+// into the resource of the same project when there already is one. The last
+// record moved is returned.
+func (b *logBatch) add(projectPath string, logs plog.Logs) plog.LogRecord {
+	src := logs.ResourceLogs().At(0)
+	b.records += logs.LogRecordCount()
+
+	rl, ok := b.resources[projectPath]
+	if ok {
+		src.ScopeLogs().At(0).LogRecords().MoveAndAppendTo(rl.ScopeLogs().At(0).LogRecords())
+	} else {
+		rl = b.logs.ResourceLogs().AppendEmpty()
+		src.MoveTo(rl)
+		b.resources[projectPath] = rl
+	}
+	records := rl.ScopeLogs().At(0).LogRecords()
+	return records.At(records.Len() - 1)
+}
+
+// appendRecord appends an empty record to the resource of a project, set up by
+// putResource with a scope when the batch has none for the project yet
+func (b *logBatch) appendRecord(projectPath string, putResource func(plog.ResourceLogs)) plog.LogRecord {
+	rl, ok := b.resources[projectPath]
+	if !ok {
+		rl = b.logs.ResourceLogs().AppendEmpty()
+		putResource(rl)
+		b.resources[projectPath] = rl
+	}
+	b.records++
+	return rl.ScopeLogs().At(0).LogRecords().AppendEmpty()
+}
+
+// take returns the batched logs and resets the batch
+func (b *logBatch) take() plog.Logs {
+	logs := b.logs
+	b.logs = plog.NewLogs()
+	clear(b.resources)
+	b.records = 0
+	return logs
+}
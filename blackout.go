@@ -0,0 +1,111 @@
+package gitlabvulnreceiver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errBlackoutWindow defers the creation of an export to after a blackout window
+var errBlackoutWindow = errors.New("export creation deferred by a blackout window")
+
+// weekdays are the day names blackout windows accept
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// blackoutWindow is a daily time range, from start to end after midnight in its
+// location, during which no export is created. A window ending before it starts
+// runs past midnight, into the day after the one it starts on.
+type blackoutWindow struct {
+	start, end time.Duration
+	// days are the days the window starts on, every day when empty
+	days     map[time.Weekday]bool
+	location *time.Location
+}
+
+// newBlackoutWindows parses the blackout windows settings
+func newBlackoutWindows(cfgs []BlackoutWindowConfig) ([]blackoutWindow, error) {
+	windows := make([]blackoutWindow, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		window, err := newBlackoutWindow(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("blackout_windows[%d]: %w", i, err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+func newBlackoutWindow(cfg BlackoutWindowConfig) (blackoutWindow, error) {
+	window := blackoutWindow{location: time.UTC}
+	var err error
+	if window.start, err = parseTimeOfDay(cfg.Start); err != nil {
+		return window, fmt.Errorf("invalid start: %w", err)
+	}
+	if window.end, err = parseTimeOfDay(cfg.End); err != nil {
+		return window, fmt.Errorf("invalid end: %w", err)
+	}
+	if window.start == window.end {
+		return window, errors.New("start and end can't be the same time")
+	}
+	if cfg.Timezone != "" {
+		if window.location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return window, fmt.Errorf("timezone %q is not a known timezone", cfg.Timezone)
+		}
+	}
+	for _, day := range cfg.Days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return window, fmt.Errorf("day must be one of 'mon', 'tue', 'wed', 'thu', 'fri', 'sat' or 'sun', got: %s", day)
+		}
+		if window.days == nil {
+			window.days = make(map[time.Weekday]bool)
+		}
+		window.days[weekday] = true
+	}
+	return window, nil
+}
+
+// parseTimeOfDay parses a HH:MM time into the time after midnight
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether t falls in the window
+func (w blackoutWindow) contains(t time.Time) bool {
+	local := t.In(w.location)
+	day := local.Weekday()
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if w.start < w.end {
+		return w.startsOn(day) && sinceMidnight >= w.start && sinceMidnight < w.end
+	}
+	// Past midnight, the window started the day before
+	return (w.startsOn(day) && sinceMidnight >= w.start) ||
+		(w.startsOn((day+6)%7) && sinceMidnight < w.end)
+}
+
+func (w blackoutWindow) startsOn(day time.Weekday) bool {
+	return len(w.days) == 0 || w.days[day]
+}
+
+// inBlackout reports whether t falls in one of the blackout windows
+func (r *vulnerabilityReceiver) inBlackout(t time.Time) bool {
+	for _, window := range r.blackouts {
+		if window.contains(t) {
+			return true
+		}
+	}
+	return false
+}
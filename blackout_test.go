@@ -0,0 +1,122 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestBlackoutWindow_Contains(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		cfg    BlackoutWindowConfig
+		at     time.Time
+		inside bool
+	}{
+		{
+			name:   "within",
+			cfg:    BlackoutWindowConfig{Start: "01:00", End: "03:30"},
+			at:     time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC),
+			inside: true,
+		},
+		{
+			name: "end is excluded",
+			cfg:  BlackoutWindowConfig{Start: "01:00", End: "03:30"},
+			at:   time.Date(2026, 10, 14, 3, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "in the window's timezone",
+			cfg:    BlackoutWindowConfig{Start: "01:00", End: "03:30", Timezone: "Europe/Berlin"},
+			at:     time.Date(2026, 10, 14, 2, 0, 0, 0, berlin),
+			inside: true,
+		},
+		{
+			name: "outside the window's timezone",
+			cfg:  BlackoutWindowConfig{Start: "01:00", End: "03:30", Timezone: "Europe/Berlin"},
+			at:   time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "past midnight, before it",
+			cfg:    BlackoutWindowConfig{Start: "22:00", End: "06:00", Days: []string{"sat"}},
+			at:     time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC),
+			inside: true,
+		},
+		{
+			name:   "past midnight, after it",
+			cfg:    BlackoutWindowConfig{Start: "22:00", End: "06:00", Days: []string{"Sat"}},
+			at:     time.Date(2026, 10, 18, 5, 0, 0, 0, time.UTC),
+			inside: true,
+		},
+		{
+			name: "past midnight, on another day",
+			cfg:  BlackoutWindowConfig{Start: "22:00", End: "06:00", Days: []string{"sat"}},
+			at:   time.Date(2026, 10, 17, 5, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := newBlackoutWindow(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.inside, window.contains(tt.at))
+		})
+	}
+}
+
+func TestNewBlackoutWindows_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     BlackoutWindowConfig
+		wantErr string
+	}{
+		{name: "start", cfg: BlackoutWindowConfig{Start: "1am", End: "03:00"}, wantErr: `blackout_windows[0]: invalid start: "1am" is not a HH:MM time`},
+		{name: "end", cfg: BlackoutWindowConfig{Start: "01:00", End: "25:00"}, wantErr: `blackout_windows[0]: invalid end: "25:00" is not a HH:MM time`},
+		{name: "empty", cfg: BlackoutWindowConfig{Start: "01:00", End: "01:00"}, wantErr: "start and end can't be the same time"},
+		{name: "day", cfg: BlackoutWindowConfig{Start: "01:00", End: "03:00", Days: []string{"monday"}}, wantErr: "got: monday"},
+		{name: "timezone", cfg: BlackoutWindowConfig{Start: "01:00", End: "03:00", Timezone: "Mars/Olympus"}, wantErr: `timezone "Mars/Olympus" is not a known timezone`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newBlackoutWindows([]BlackoutWindowConfig{tt.cfg})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckExports_BlackoutWindow(t *testing.T) {
+	var created int
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			created++
+			return &Export{ID: 1, ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	// A window around the clock, whichever day the test runs on
+	blackouts, err := newBlackoutWindows([]BlackoutWindowConfig{{Start: "00:00", End: "23:59"}, {Start: "23:59", End: "00:00"}})
+	require.NoError(t, err)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          new(consumertest.LogsSink),
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		blackouts:         blackouts,
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	assert.Zero(t, created)
+	assertNoPathErrors(t, receiver)
+	assert.Equal(t, "blackout", receiver.pathStatusSnapshot()[0].ExportStatus)
+}
@@ -0,0 +1,27 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+)
+
+// errPathBudgetExceeded cancels the work on a path once it has taken
+// max_elapsed_per_path in a poll
+var errPathBudgetExceeded = errors.New("path exceeded max_elapsed_per_path")
+
+// withPathBudget bounds the work on one path in a poll by max_elapsed_per_path,
+// so a path retrying endlessly or waiting on a slow export leaves time for the
+// others. Without a budget the path only ends with ctx.
+func (r *vulnerabilityReceiver) withPathBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.cfg.MaxElapsedPerPath <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, r.cfg.MaxElapsedPerPath, errPathBudgetExceeded)
+}
+
+// isPathBudgetExceeded reports whether ctx, a path's context, was canceled for
+// using up max_elapsed_per_path. The path's export keeps its checkpoint and is
+// resumed by the next poll.
+func isPathBudgetExceeded(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errPathBudgetExceeded)
+}
@@ -0,0 +1,83 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestCheckExports_MaxElapsedPerPath(t *testing.T) {
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			if projectID == "slow" {
+				return &Export{ID: 1, ProjectID: projectID, Status: ExportStatusCreated}, nil
+			}
+			return &Export{ID: 2, ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+		getExportFunc: func(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusStarted}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			if projectID == "slow" {
+				// GitLab never finishes the export within the budget
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\nfirst,High\n"))}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "slow", Type: "project"}, {ID: "fast", Type: "project"}}
+	cfg.MaxElapsedPerPath = 50 * time.Millisecond
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+
+	// The slow path didn't hold up the fast one
+	assert.Equal(t, 1, sink.LogRecordCount())
+	assert.True(t, receiver.stateManager.IsExportProcessed(2))
+
+	// The slow path is deferred, not failed, and keeps its export for the next poll
+	statuses := receiver.pathStatusSnapshot()
+	assert.Equal(t, "deferred", statuses[0].ExportStatus)
+	assert.Empty(t, statuses[0].LastError)
+	cp, ok := receiver.stateManager.GetCheckpoint("slow")
+	require.True(t, ok)
+	assert.Equal(t, int64(1), cp.ExportID)
+}
+
+func TestWithPathBudget(t *testing.T) {
+	receiver := &vulnerabilityReceiver{cfg: createDefaultConfig().(*Config)}
+
+	ctx, cancel := receiver.withPathBudget(context.Background())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok, "unbounded by default")
+	cancel()
+	assert.False(t, isPathBudgetExceeded(ctx))
+
+	receiver.cfg.MaxElapsedPerPath = time.Millisecond
+	ctx, cancel = receiver.withPathBudget(context.Background())
+	defer cancel()
+	<-ctx.Done()
+	assert.True(t, isPathBudgetExceeded(ctx))
+}
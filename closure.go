@@ -0,0 +1,180 @@
+package gitlabvulnreceiver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// Status of a tracked finding no longer in its path's export
+const findingStatusAbsent = "absent"
+
+// closedStatuses are the statuses of findings that no longer need remediation
+var closedStatuses = map[string]bool{
+	"dismissed":         true,
+	"resolved":          true,
+	findingStatusAbsent: true,
+}
+
+// Event names of the vulnerability records, by the lifecycle of the finding
+const (
+	eventVulnerabilityDetected  = "gitlab.vulnerability.detected"
+	eventVulnerabilityChanged   = "gitlab.vulnerability.changed"
+	eventVulnerabilityResolved  = "gitlab.vulnerability.resolved"
+	eventVulnerabilityDismissed = "gitlab.vulnerability.dismissed"
+)
+
+// findingTracker follows the status of the findings read from an export or
+// sync, emitting a closure record when a tracked open finding gets closed
+type findingTracker struct {
+	pathID string
+	// observed holds the keys of all findings read
+	observed map[string]bool
+	// pending holds the states not persisted yet, saved once their records are delivered
+	pending map[string]state.VulnerabilityState
+}
+
+func newFindingTracker(pathID string) *findingTracker {
+	return &findingTracker{
+		pathID:   pathID,
+		observed: make(map[string]bool),
+		pending:  make(map[string]state.VulnerabilityState),
+	}
+}
+
+// findingKey is the state key of a finding, GitLab's identifier when the record
+// has one. It's empty when the record has none of the identifying columns.
+func (r *vulnerabilityReceiver) findingKey(header []string, values []string) string {
+	fields := make(map[string]string, len(header))
+	for i, column := range header {
+		if i < len(values) {
+			fields[column] = values[i]
+		}
+	}
+	for _, column := range state.IDColumns {
+		if id, ok := findField(header, values, column); ok && strings.TrimSpace(id) != "" {
+			fields["ID"] = id
+			break
+		}
+	}
+	key := r.stateManager.ComputeKey(fields)
+	if strings.Trim(key, "|") == "" {
+		return ""
+	}
+	return key
+}
+
+// trackFinding records the status of a finding read, returning its closure
+// record when it was tracked open until now
+func (r *vulnerabilityReceiver) trackFinding(tracker *findingTracker, key string, record *exportRecord, export *Export) (plog.Logs, bool) {
+	if key == "" {
+		return plog.Logs{}, false
+	}
+	status := findingStatus(record)
+	title := firstField(record.header, record.values, "Title", "Vulnerability", "Name")
+	projectPath := findProjectPath(record.header, record.values)
+	tracker.observed[key] = true
+
+	previous, tracked := r.stateManager.GetFinding(key)
+	if tracked && previous.PathID == tracker.pathID && previous.Status == status && previous.ProjectPath == projectPath {
+		return plog.Logs{}, false
+	}
+	finding := previous
+	finding.Status, finding.PathID, finding.Title = status, tracker.pathID, title
+	finding.ProjectPath = projectPath
+	finding.LastScanTime = time.Now()
+	tracker.pending[key] = finding
+
+	// Findings seen for the first time are only tracked
+	if !tracked || previous.PathID != tracker.pathID || closedStatuses[previous.Status] || !closedStatuses[status] {
+		return plog.Logs{}, false
+	}
+	logs := r.convertRecord(record, export)
+	putClosureAttributes(logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0), status)
+	return logs, true
+}
+
+// findingEvent is the event name of a finding's record: resolved or dismissed
+// by its status, otherwise changed when the finding was read before and
+// detected when it's new
+func (r *vulnerabilityReceiver) findingEvent(key string, record *exportRecord) string {
+	switch findingStatus(record) {
+	case "resolved":
+		return eventVulnerabilityResolved
+	case "dismissed":
+		return eventVulnerabilityDismissed
+	}
+	if key != "" {
+		if _, tracked := r.stateManager.GetFinding(key); tracked {
+			return eventVulnerabilityChanged
+		}
+	}
+	return eventVulnerabilityDetected
+}
+
+// findingStatus is the lowercased status of a finding's record
+func findingStatus(record *exportRecord) string {
+	return strings.ToLower(strings.TrimSpace(firstField(record.header, record.values, "Status", "State")))
+}
+
+// findingClosure is the closure record of a finding and the project it's in
+type findingClosure struct {
+	projectPath string
+	logs        plog.Logs
+}
+
+// absentFindings returns closure records for the tracked open findings of the
+// path that weren't read, once a whole export has been read
+func (r *vulnerabilityReceiver) absentFindings(tracker *findingTracker, export *Export) []findingClosure {
+	var closures []findingClosure
+	for key, finding := range r.stateManager.PathFindings(tracker.pathID) {
+		if tracker.observed[key] || closedStatuses[finding.Status] {
+			continue
+		}
+		finding.Status = findingStatusAbsent
+		finding.LastScanTime = time.Now()
+		tracker.pending[key] = finding
+
+		logs := plog.NewLogs()
+		rl := logs.ResourceLogs().AppendEmpty()
+		r.putProjectResource(rl, finding.ProjectPath, export)
+		lr := rl.ScopeLogs().At(0).LogRecords().AppendEmpty()
+		now := pcommon.NewTimestampFromTime(time.Now())
+		lr.SetTimestamp(now)
+		lr.SetObservedTimestamp(now)
+		lr.SetSeverityNumber(plog.SeverityNumberInfo)
+		lr.SetSeverityText("INFO")
+		lr.Body().SetStr(fmt.Sprintf("Vulnerability %s is no longer reported", strings.TrimPrefix(key, "id:")))
+
+		attrs := lr.Attributes()
+		if id, ok := strings.CutPrefix(key, "id:"); ok {
+			attrs.PutStr("vulnerability.id", id)
+		} else {
+			attrs.PutStr("vulnerability.key", key)
+		}
+		putNonEmpty(attrs, "vulnerability.title", finding.Title)
+		putClosureAttributes(lr, findingStatusAbsent)
+		closures = append(closures, findingClosure{projectPath: finding.ProjectPath, logs: logs})
+	}
+	return closures
+}
+
+// saveFindings persists the states of the findings whose records were delivered
+func (r *vulnerabilityReceiver) saveFindings(tracker *findingTracker) error {
+	if err := r.stateManager.SetFindings(tracker.pending); err != nil {
+		return fmt.Errorf("failed to save finding states: %w", err)
+	}
+	clear(tracker.pending)
+	return nil
+}
+
+// putClosureAttributes marks a record as the closure of a finding
+func putClosureAttributes(lr plog.LogRecord, reason string) {
+	setEventName(lr, "gitlab.vulnerability.closed")
+	lr.Attributes().PutStr("gitlab.vulnerability.closure_reason", reason)
+}
@@ -0,0 +1,146 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestProcessCSVData_ClosureEvents(t *testing.T) {
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	process := func(csvData string) []plog.LogRecord {
+		sink.Reset()
+		export := &Export{ID: 123, ProjectID: "12345"}
+		require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+
+		var closures []plog.LogRecord
+		for _, logs := range sink.AllLogs() {
+			forEachLogRecord(logs, func(lr plog.LogRecord) {
+				if name, ok := lr.Attributes().Get("event.name"); ok && name.Str() == "gitlab.vulnerability.closed" {
+					closures = append(closures, lr)
+				}
+			})
+		}
+		return closures
+	}
+
+	// Findings are tracked from the first export on
+	closures := process("Vulnerability ID,Title,Status\n1,SQL injection,detected\n2,XSS,confirmed\n3,Old,dismissed\n")
+	assert.Empty(t, closures)
+
+	closures = process("Vulnerability ID,Title,Status\n1,SQL injection,dismissed\n2,XSS,confirmed\n3,Old,dismissed\n")
+	require.Len(t, closures, 1)
+	raw := closures[0].Attributes().AsRaw()
+	assert.Equal(t, "1", raw["vulnerability.vulnerability_id"])
+	assert.Equal(t, "dismissed", raw["gitlab.vulnerability.closure_reason"])
+
+	// A finding missing from the export is closed as absent
+	closures = process("Vulnerability ID,Title,Status\n1,SQL injection,dismissed\n3,Old,dismissed\n")
+	require.Len(t, closures, 1)
+	raw = closures[0].Attributes().AsRaw()
+	assert.Equal(t, "2", raw["vulnerability.id"])
+	assert.Equal(t, "XSS", raw["vulnerability.title"])
+	assert.Equal(t, "absent", raw["gitlab.vulnerability.closure_reason"])
+
+	// Each closure is only emitted once
+	closures = process("Vulnerability ID,Title,Status\n1,SQL injection,dismissed\n3,Old,dismissed\n")
+	assert.Empty(t, closures)
+}
+
+func TestProcessCSVData_AbsentFindings(t *testing.T) {
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	// closures maps the IDs of closed findings to the project of their resource
+	process := func(csvData string) map[string]string {
+		sink.Reset()
+		export := &Export{ID: 123, GroupID: "42"}
+		require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "42", export))
+
+		closures := make(map[string]string)
+		for _, logs := range sink.AllLogs() {
+			for i := 0; i < logs.ResourceLogs().Len(); i++ {
+				rl := logs.ResourceLogs().At(i)
+				project, _ := rl.Resource().Attributes().Get("gitlab.project.path")
+				records := rl.ScopeLogs().At(0).LogRecords()
+				for j := 0; j < records.Len(); j++ {
+					if id, ok := records.At(j).Attributes().Get("vulnerability.id"); ok {
+						closures[id.Str()] = project.Str()
+					}
+				}
+			}
+		}
+		return closures
+	}
+
+	process("Vulnerability ID,Title,Status,Project Name\n1,SQL injection,detected,app\n2,XSS,detected,web\n")
+
+	// A malformed row may be a finding still reported, so none is closed
+	closures := process("Vulnerability ID,Title,Status,Project Name\n1,SQL injection,detected,app\n2,XSS\n")
+	assert.Empty(t, closures)
+
+	// Absent findings are closed on the resource of their project
+	closures = process("Vulnerability ID,Title,Status,Project Name\n3,Open redirect,detected,app\n")
+	assert.Equal(t, map[string]string{"1": "app", "2": "web"}, closures)
+}
+
+func TestProcessCSVData_LifecycleEvents(t *testing.T) {
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	process := func(csvData string) map[string]string {
+		sink.Reset()
+		export := &Export{ID: 123, ProjectID: "12345"}
+		require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+
+		events := make(map[string]string)
+		for _, logs := range sink.AllLogs() {
+			forEachLogRecord(logs, func(lr plog.LogRecord) {
+				location, _ := lr.Attributes().Get("vulnerability.location")
+				name, _ := lr.Attributes().Get("event.name")
+				assert.Equal(t, name.Str(), lr.EventName())
+				if name.Str() != "gitlab.vulnerability.closed" {
+					events[location.Str()] = name.Str()
+				}
+			})
+		}
+		return events
+	}
+
+	events := process("Project Name,Tool,CVE,Location,Status\napp,sast,CVE-1,main.go,detected\napp,sast,CVE-2,db.go,detected\n")
+	assert.Equal(t, map[string]string{
+		"main.go": "gitlab.vulnerability.detected",
+		"db.go":   "gitlab.vulnerability.detected",
+	}, events)
+
+	events = process("Project Name,Tool,CVE,Location,Status\napp,sast,CVE-1,main.go,confirmed\napp,sast,CVE-2,db.go,resolved\napp,sast,CVE-3,web.go,dismissed\n")
+	assert.Equal(t, map[string]string{
+		"main.go": "gitlab.vulnerability.changed",
+		"db.go":   "gitlab.vulnerability.resolved",
+		"web.go":  "gitlab.vulnerability.dismissed",
+	}, events)
+}
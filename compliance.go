@@ -0,0 +1,243 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// Report types of compliance records, distinguishing them from vulnerabilities
+const (
+	reportTypeLicenseScanning = "license_scanning"
+	reportTypePolicyViolation = "policy_violation"
+)
+
+// complianceRetention is how long an emitted compliance record is remembered
+// after it was last seen, so one that disappears and comes back is emitted again
+const complianceRetention = 90 * 24 * time.Hour
+
+// processCompliance emits the license and policy violation records enabled in
+// the config that haven't been emitted yet. A failing source is logged rather
+// than failing the path, and one refused with a 403, e.g. because the GitLab
+// tier doesn't include it, is disabled for the project.
+func (r *vulnerabilityReceiver) processCompliance(ctx context.Context, projectID string) error {
+	sources := []struct {
+		name    string
+		enabled bool
+		process func(context.Context, string) error
+	}{
+		{reportTypeLicenseScanning, r.cfg.Compliance.Licenses, r.processLicenses},
+		{reportTypePolicyViolation, r.cfg.Compliance.PolicyViolations, r.processPolicyViolations},
+	}
+
+	for _, source := range sources {
+		if !source.enabled {
+			continue
+		}
+		key := projectID + ":" + source.name
+		if _, disabled := r.complianceDisabled.Load(key); disabled {
+			continue
+		}
+
+		err := source.process(ctx, projectID)
+		switch {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			return err
+		case isForbidden(err):
+			r.complianceDisabled.Store(key, true)
+			r.logger.Warn("Compliance source not available for project, disabling it",
+				zap.String("projectID", projectID),
+				zap.String("source", source.name),
+				zap.Error(err))
+		default:
+			r.logger.Warn("Failed to process compliance source",
+				zap.String("projectID", projectID),
+				zap.String("source", source.name),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// complianceBatch buffers compliance records with the keys marking them emitted
+type complianceBatch struct {
+	*logBatch
+	keys    []string
+	present []string
+}
+
+func newComplianceBatch() *complianceBatch {
+	return &complianceBatch{logBatch: newLogBatch()}
+}
+
+// skipComplianceRecord reports whether the record with key was already emitted, remembering
+// it as still present so it isn't forgotten while it exists
+func (r *vulnerabilityReceiver) skipComplianceRecord(batch *complianceBatch, key string) bool {
+	if !r.stateManager.IsSeen(key) {
+		return false
+	}
+	batch.present = append(batch.present, key)
+	return true
+}
+
+// processLicenses emits one record per dependency with the licenses found for it
+func (r *vulnerabilityReceiver) processLicenses(ctx context.Context, projectID string) error {
+	batch := newComplianceBatch()
+	for page := 1; page != 0; {
+		dependencies, nextPage, err := r.client.ListDependencies(ctx, projectID, page)
+		if err != nil {
+			return err
+		}
+		for _, dependency := range dependencies {
+			var licenses, urls []string
+			for _, license := range dependency.Licenses {
+				if license.Name != "" && !slices.Contains(licenses, license.Name) {
+					licenses = append(licenses, license.Name)
+				}
+				if license.URL != "" && !slices.Contains(urls, license.URL) {
+					urls = append(urls, license.URL)
+				}
+			}
+
+			// A dependency is emitted again when its version or licenses change
+			key := "license:" + projectID + ":" + generateHash(append([]string{
+				dependency.Name, dependency.Version, dependency.PackageManager, dependency.DependencyFilePath,
+			}, licenses...))
+			if r.skipComplianceRecord(batch, key) {
+				continue
+			}
+
+			logs, attrs := r.newComplianceRecord(projectID, reportTypeLicenseScanning, "gitlab.license")
+			lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			lr.Body().SetStr(strings.TrimSuffix(dependency.Name+"@"+dependency.Version, "@"))
+
+			putNonEmpty(attrs, "package.name", dependency.Name)
+			putNonEmpty(attrs, "package.version", dependency.Version)
+			putNonEmpty(attrs, "package.manager", dependency.PackageManager)
+			putNonEmpty(attrs, "code.file.path", dependency.DependencyFilePath)
+			putStringSlice(attrs, "package.licenses", licenses)
+			putStringSlice(attrs, "package.license_urls", urls)
+
+			if err := r.addComplianceRecord(ctx, batch, projectID, key, logs); err != nil {
+				return err
+			}
+		}
+		page = nextPage
+	}
+	return r.finishComplianceRecords(ctx, batch)
+}
+
+// processPolicyViolations emits one record per security policy approval rule
+// that an open merge request doesn't satisfy
+func (r *vulnerabilityReceiver) processPolicyViolations(ctx context.Context, projectID string) error {
+	batch := newComplianceBatch()
+	for page := 1; page != 0; {
+		mergeRequests, nextPage, err := r.client.ListOpenMergeRequests(ctx, projectID, page)
+		if err != nil {
+			return err
+		}
+		for _, mr := range mergeRequests {
+			rules, err := r.client.GetApprovalRules(ctx, projectID, mr.IID)
+			if err != nil {
+				return err
+			}
+			for _, rule := range rules {
+				// Only rules created by security policies carry a report type
+				if rule.ReportType == "" || rule.Approved {
+					continue
+				}
+				key := fmt.Sprintf("policy:%s:%d:%d", projectID, mr.IID, rule.ID)
+				if r.skipComplianceRecord(batch, key) {
+					continue
+				}
+				logs, attrs := r.newComplianceRecord(projectID, reportTypePolicyViolation, "gitlab.policy.violation")
+				lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+				lr.SetSeverityNumber(plog.SeverityNumberWarn)
+				lr.SetSeverityText("WARN")
+				lr.Body().SetStr(fmt.Sprintf("Merge request !%d violates policy rule %q", mr.IID, rule.Name))
+
+				attrs.PutStr("gitlab.policy.rule.name", rule.Name)
+				attrs.PutInt("gitlab.policy.rule.id", rule.ID)
+				attrs.PutStr("gitlab.policy.report_type", rule.ReportType)
+				attrs.PutInt("gitlab.merge_request.iid", mr.IID)
+				putNonEmpty(attrs, "gitlab.merge_request.title", mr.Title)
+				putNonEmpty(attrs, "gitlab.merge_request.url", mr.WebURL)
+				putNonEmpty(attrs, "gitlab.merge_request.source_branch", mr.SourceBranch)
+				putNonEmpty(attrs, "gitlab.merge_request.target_branch", mr.TargetBranch)
+
+				if err := r.addComplianceRecord(ctx, batch, projectID, key, logs); err != nil {
+					return err
+				}
+			}
+		}
+		page = nextPage
+	}
+	return r.finishComplianceRecords(ctx, batch)
+}
+
+// newComplianceRecord creates a single-record Logs for a compliance signal
+func (r *vulnerabilityReceiver) newComplianceRecord(projectID, reportType, eventName string) (plog.Logs, pcommon.Map) {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("gitlab.project.id", projectID)
+
+	sl := r.newScopeLogs(rl)
+
+	lr := sl.LogRecords().AppendEmpty()
+	now := pcommon.NewTimestampFromTime(time.Now())
+	lr.SetTimestamp(now)
+	lr.SetObservedTimestamp(now)
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.SetSeverityText("INFO")
+
+	setEventName(lr, eventName)
+	attrs := lr.Attributes()
+	attrs.PutStr("report.type", reportType)
+	return logs, attrs
+}
+
+func (r *vulnerabilityReceiver) addComplianceRecord(ctx context.Context, batch *complianceBatch, projectID, key string, logs plog.Logs) error {
+	batch.add(projectID, logs)
+	batch.keys = append(batch.keys, key)
+	if batch.records >= r.batchSize() {
+		return r.flushComplianceRecords(ctx, batch)
+	}
+	return nil
+}
+
+func (r *vulnerabilityReceiver) flushComplianceRecords(ctx context.Context, batch *complianceBatch) error {
+	if batch.records == 0 {
+		return nil
+	}
+	if err := r.consumeLogs(ctx, batch.take()); err != nil {
+		return fmt.Errorf("failed to consume logs: %w", err)
+	}
+	if err := r.stateManager.MarkSeen(batch.keys, complianceRetention); err != nil {
+		return fmt.Errorf("failed to save seen compliance records: %w", err)
+	}
+	batch.keys = batch.keys[:0]
+	return nil
+}
+
+// finishComplianceRecords flushes the remaining records and refreshes the ones
+// skipped as already emitted
+func (r *vulnerabilityReceiver) finishComplianceRecords(ctx context.Context, batch *complianceBatch) error {
+	if err := r.flushComplianceRecords(ctx, batch); err != nil {
+		return err
+	}
+	if len(batch.present) == 0 {
+		return nil
+	}
+	if err := r.stateManager.MarkSeen(batch.present, complianceRetention); err != nil {
+		return fmt.Errorf("failed to save seen compliance records: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestProcessCompliance(t *testing.T) {
+	mockClient := &mockGitLabClient{
+		listDependenciesFunc: func(ctx context.Context, projectID string, page int) ([]Dependency, int, error) {
+			return []Dependency{{
+				Name:               "lodash",
+				Version:            "4.17.20",
+				PackageManager:     "npm",
+				DependencyFilePath: "package-lock.json",
+				Licenses:           []DependencyLicense{{Name: "MIT", URL: "https://spdx.org/licenses/MIT.html"}},
+			}}, 0, nil
+		},
+		listOpenMergeRequestsFunc: func(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+			return []MergeRequest{{IID: 7, Title: "Bump lodash", TargetBranch: "main"}}, 0, nil
+		},
+		getApprovalRulesFunc: func(ctx context.Context, projectID string, iid int64) ([]ApprovalRule, error) {
+			return []ApprovalRule{
+				{ID: 1, Name: "Coverage-Check", RuleType: "code_owner"},
+				{ID: 2, Name: "Block critical findings", RuleType: "report_approver", ReportType: "scan_finding"},
+				{ID: 3, Name: "License policy", RuleType: "report_approver", ReportType: "license_scanning", Approved: true},
+			}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Compliance = ComplianceConfig{Licenses: true, PolicyViolations: true}
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	require.NoError(t, receiver.processCompliance(context.Background(), "12345"))
+	require.Equal(t, 2, sink.LogRecordCount())
+
+	// Records already emitted aren't emitted again on the next cycle
+	require.NoError(t, receiver.processCompliance(context.Background(), "12345"))
+	require.Equal(t, 2, sink.LogRecordCount())
+
+	license := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	raw := license.Attributes().AsRaw()
+	assert.Equal(t, "license_scanning", raw["report.type"])
+	assert.Equal(t, "lodash", raw["package.name"])
+	assert.Equal(t, []interface{}{"MIT"}, raw["package.licenses"])
+
+	// Only the unsatisfied policy rule is a violation
+	violation := sink.AllLogs()[1].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	raw = violation.Attributes().AsRaw()
+	assert.Equal(t, "policy_violation", raw["report.type"])
+	assert.Equal(t, "Block critical findings", raw["gitlab.policy.rule.name"])
+	assert.Equal(t, "scan_finding", raw["gitlab.policy.report_type"])
+	assert.Equal(t, int64(7), raw["gitlab.merge_request.iid"])
+	assert.Equal(t, plog.SeverityNumberWarn, violation.SeverityNumber())
+}
+
+func TestProcessCompliance_Disabled(t *testing.T) {
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:      createDefaultConfig().(*Config),
+		consumer: sink,
+		client: &mockGitLabClient{
+			listDependenciesFunc: func(ctx context.Context, projectID string, page int) ([]Dependency, int, error) {
+				t.Fatal("dependencies should not be listed")
+				return nil, 0, nil
+			},
+		},
+		logger: zap.NewNop(),
+	}
+
+	require.NoError(t, receiver.processCompliance(context.Background(), "12345"))
+	assert.Equal(t, 0, sink.LogRecordCount())
+}
+
+func TestProcessCompliance_SourceErrors(t *testing.T) {
+	var dependencyCalls, mergeRequestCalls int
+	mockClient := &mockGitLabClient{
+		listDependenciesFunc: func(ctx context.Context, projectID string, page int) ([]Dependency, int, error) {
+			dependencyCalls++
+			return nil, 0, &gitlab.APIError{StatusCode: 403, Body: `{"message":"403 Forbidden"}`}
+		},
+		listOpenMergeRequestsFunc: func(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+			mergeRequestCalls++
+			return nil, 0, errors.New("connection reset")
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Compliance = ComplianceConfig{Licenses: true, PolicyViolations: true}
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     new(consumertest.LogsSink),
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	// Failing sources don't fail the path
+	require.NoError(t, receiver.processCompliance(context.Background(), "12345"))
+	require.NoError(t, receiver.processCompliance(context.Background(), "12345"))
+
+	// A forbidden source is disabled, others are retried
+	assert.Equal(t, 1, dependencyCalls)
+	assert.Equal(t, 2, mergeRequestCalls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mockClient.listOpenMergeRequestsFunc = func(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+		return nil, 0, ctx.Err()
+	}
+	assert.ErrorIs(t, receiver.processCompliance(ctx, "12345"), context.Canceled)
+}
@@ -1,7 +1,11 @@
 package gitlabvulnreceiver
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,6 +16,72 @@ import (
 const (
 	defaultPollInterval  = 1 * time.Minute
 	defaultExportTimeout = 15 * time.Minute // Increased from 5m to 15m
+	defaultBatchSize     = 100
+
+	// Responses kept for conditional requests
+	defaultConditionalCacheSize = 1000
+
+	defaultMinExportInterval = 24 * time.Hour
+
+	defaultSBOMRef = "main"
+
+	defaultKEVURL             = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+	defaultKEVRefreshInterval = 24 * time.Hour
+
+	// Timeout of third-party enrichment requests when the HTTP client has none
+	defaultEnrichmentTimeout = 1 * time.Minute
+
+	defaultOSVURL       = "https://api.osv.dev"
+	defaultOSVCacheTTL  = 7 * 24 * time.Hour
+	defaultOSVRateLimit = 1.0
+
+	// Quarantine is off unless a threshold is set
+	defaultQuarantineFailureThreshold = 0
+	defaultQuarantineDuration         = 1 * time.Hour
+
+	defaultStuckExportThreshold = 10 * time.Minute
+
+	defaultShutdownDrainTimeout = 30 * time.Second
+
+	defaultMaintenanceBackoff = 15 * time.Minute
+
+	defaultBaseURL = "https://gitlab.com"
+
+	defaultAPIPath     = "/api/v4"
+	defaultGraphQLPath = "/api/graphql"
+
+	defaultAttributePrefix = "vulnerability."
+
+	defaultHealthFailureThreshold = 3
+
+	defaultCSVBufferSize = 64 << 10
+
+	dialerNetworkTCP  = "tcp"
+	dialerNetworkUnix = "unix"
+
+	defaultDialerTimeout = 30 * time.Second
+
+	syncModeFull        = "full"
+	syncModeIncremental = "incremental"
+
+	orderByUpdatedAt = "updated_at"
+	orderByCreatedAt = "created_at"
+	sortAsc          = "asc"
+	sortDesc         = "desc"
+
+	unknownStatusWait = "wait"
+	unknownStatusFail = "fail"
+
+	stateCompressionNone = "none"
+	stateCompressionGzip = "gzip"
+
+	defaultRetryInitialInterval = 1 * time.Second
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultRetryMaxElapsedTime  = 5 * time.Minute
+
+	defaultExportPollInitialInterval = 2 * time.Second
+	defaultExportPollMaxInterval     = time.Minute
+	defaultExportPollMultiplier      = 1.5
 )
 
 type PathConfig struct {
@@ -19,6 +89,164 @@ type PathConfig struct {
 	Type string `mapstructure:"type"` // "project" or "group"
 }
 
+// ExportPollingConfig controls the waits between status checks of an export
+// being generated
+type ExportPollingConfig struct {
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	MaxInterval     time.Duration `mapstructure:"max_interval"`
+	// Multiplier lengthens every wait after the first, 1 checks at a fixed interval
+	Multiplier float64 `mapstructure:"multiplier"`
+	// UnknownStatus is wait or fail, what is done with an export status the
+	// receiver doesn't know
+	UnknownStatus string `mapstructure:"unknown_status"`
+}
+
+// APIOrderConfig sets the order findings are read from the vulnerabilities API in
+type APIOrderConfig struct {
+	// OrderBy is updated_at or created_at, the field findings are sorted on
+	OrderBy string `mapstructure:"order_by"`
+	// Sort is desc (newest first) or asc (oldest first)
+	Sort string `mapstructure:"sort"`
+}
+
+// ConsumerRetryConfig controls retries when the pipeline rejects logs with a retryable error
+type ConsumerRetryConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	MaxInterval     time.Duration `mapstructure:"max_interval"`
+	MaxElapsedTime  time.Duration `mapstructure:"max_elapsed_time"`
+}
+
+// SBOMConfig selects the job whose CycloneDX SBOM artifacts are ingested
+type SBOMConfig struct {
+	// Job is the name of the job producing the SBOM, empty disables SBOM ingestion
+	Job string `mapstructure:"job"`
+	// Ref is the branch or tag the job ran on
+	Ref string `mapstructure:"ref"`
+}
+
+// ComplianceConfig enables sources for license and security policy signals
+type ComplianceConfig struct {
+	// Licenses emits the licenses of the project's dependencies
+	Licenses bool `mapstructure:"licenses"`
+	// PolicyViolations emits unsatisfied security policy approval rules of open merge requests
+	PolicyViolations bool `mapstructure:"policy_violations"`
+}
+
+// MergeRequestsConfig enables polling open merge requests for introduced findings
+type MergeRequestsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ReportTypes are the security reports compared, all widget reports by default
+	ReportTypes []string `mapstructure:"report_types"`
+	// DefaultBranchOnly only polls merge requests into the project's default
+	// branch, leaving out those between feature branches
+	DefaultBranchOnly bool `mapstructure:"default_branch_only"`
+}
+
+// EnrichmentConfig enables looking up extra context for each emitted finding
+type EnrichmentConfig struct {
+	// IssueLinks attaches the issues linked to a vulnerability
+	IssueLinks bool `mapstructure:"issue_links"`
+	// ProjectMetadata attaches the name, namespace, default branch, visibility
+	// and topics of a finding's project to its resource
+	ProjectMetadata bool      `mapstructure:"project_metadata"`
+	KEV             KEVConfig `mapstructure:"kev"`
+	OSV             OSVConfig `mapstructure:"osv"`
+}
+
+// OSVConfig fills in CVSS vectors, references and affected ranges from OSV for
+// findings GitLab has no CVSS vector for
+type OSVConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	// CacheDir keeps lookups across restarts, empty caches in memory only
+	CacheDir string        `mapstructure:"cache_dir"`
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+	// RateLimit is the maximum number of requests per second
+	RateLimit float64 `mapstructure:"rate_limit"`
+}
+
+// KEVConfig flags CVEs listed in the CISA Known Exploited Vulnerabilities catalog
+type KEVConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// URL of the catalog JSON feed
+	URL string `mapstructure:"url"`
+	// RefreshInterval is how long a downloaded catalog is used before it's downloaded again
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// UnknownSeverityConfig controls findings whose severity is missing or unknown
+type UnknownSeverityConfig struct {
+	// Severity is the GitLab severity whose severity number they get, none when empty
+	Severity string `mapstructure:"severity"`
+	// Action is keep, flag (with vulnerability.severity_unknown) or drop
+	Action string `mapstructure:"action"`
+}
+
+// CSVConfig controls how export downloads are read
+type CSVConfig struct {
+	// BufferSize is the size in bytes of the buffer downloads are read through
+	BufferSize int `mapstructure:"buffer_size"`
+	// MaxFieldLength is the maximum length in bytes of a field (0 is unlimited)
+	MaxFieldLength int `mapstructure:"max_field_length"`
+	// OversizedFields is truncate (cut to max_field_length, listed in
+	// vulnerability.truncated_fields) or skip (the row is skipped as malformed)
+	OversizedFields string `mapstructure:"oversized_fields"`
+}
+
+// QuarantineConfig controls suspending paths that keep failing
+type QuarantineConfig struct {
+	// FailureThreshold is the number of consecutive failed cycles before a path
+	// is quarantined (0 disables quarantine)
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	Duration         time.Duration `mapstructure:"duration"`
+}
+
+// StuckExportsConfig controls what is done with exports GitLab doesn't start
+type StuckExportsConfig struct {
+	// Threshold is how long an export may stay created before it's reported as
+	// stuck (0 disables detection)
+	Threshold time.Duration `mapstructure:"threshold"`
+	// Recreate abandons a stuck export for a new one instead of waiting on
+	Recreate bool `mapstructure:"recreate"`
+}
+
+// DialerConfig controls how connections to GitLab are opened
+type DialerConfig struct {
+	// Network is tcp or unix, the network of Address
+	Network string `mapstructure:"network"`
+	// Address is where every connection goes, whatever the host of the request
+	// (empty dials the host of the request)
+	Address string        `mapstructure:"address"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// TimestampsConfig controls how the dates of export records are read
+type TimestampsConfig struct {
+	// Timezone is the IANA name of the zone of dates without one, UTC when empty
+	Timezone string `mapstructure:"timezone"`
+	// Layouts are Go time layouts tried before the built-in ones
+	Layouts []string `mapstructure:"layouts"`
+}
+
+// BlackoutWindowConfig is a daily time range during which no export is created
+type BlackoutWindowConfig struct {
+	// Start and End are HH:MM times, an End before Start runs past midnight
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+	// Days are the days the window starts on (mon to sun), every day when empty
+	Days []string `mapstructure:"days"`
+	// Timezone is the IANA name of the zone of Start and End, UTC when empty
+	Timezone string `mapstructure:"timezone"`
+}
+
+// HealthConfig controls the health reported to the collector
+type HealthConfig struct {
+	// FailureThreshold is the number of consecutive polls with errors before the
+	// receiver reports itself degraded (0 never reports it degraded)
+	FailureThreshold int `mapstructure:"failure_threshold"`
+}
+
 type Config struct {
 	confighttp.ClientConfig `mapstructure:",squash"`
 
@@ -27,42 +255,480 @@ type Config struct {
 	Paths []PathConfig        `mapstructure:"paths"`
 
 	// Optional configurations with defaults
-	BaseURL       string        `mapstructure:"base_url"`
-	PollInterval  time.Duration `mapstructure:"poll_interval"`
-	ExportTimeout time.Duration `mapstructure:"export_timeout"`
-	StateFile     string        `mapstructure:"state_file"`
+	BaseURL string `mapstructure:"base_url"`
+	// APIPath and GraphQLPath are where the REST and GraphQL APIs are under
+	// base_url, for instances behind a reverse proxy rewriting paths
+	APIPath     string `mapstructure:"api_path"`
+	GraphQLPath string `mapstructure:"graphql_path"`
+	// Dialer opens GitLab connections to a fixed address, such as the Unix
+	// socket of a sidecar proxy, instead of the host of base_url
+	Dialer DialerConfig `mapstructure:"dialer"`
+
+	PollInterval  time.Duration       `mapstructure:"poll_interval"`
+	ExportTimeout time.Duration       `mapstructure:"export_timeout"`
+	ExportPolling ExportPollingConfig `mapstructure:"export_polling"`
+	StuckExports  StuckExportsConfig  `mapstructure:"stuck_exports"`
+	StateFile     string              `mapstructure:"state_file"`
+	// StateCompression is none or gzip, how the state file is written
+	StateCompression string `mapstructure:"state_compression"`
+	// StateFlushInterval batches writes of the state file, written every interval
+	// and at shutdown (0 writes every change right away)
+	StateFlushInterval time.Duration `mapstructure:"state_flush_interval"`
+	BatchSize          int           `mapstructure:"batch_size"`
+
+	// ExportParams are passed as they are in the body of the requests creating
+	// exports, for GitLab filters the receiver has no option for
+	ExportParams map[string]any `mapstructure:"export_params"`
+
+	// InitialDelay is the wait before the first check (0 waits one poll interval)
+	InitialDelay time.Duration `mapstructure:"initial_delay"`
+	// PollJitter is the upper bound of a random delay added to the initial delay
+	// and every poll interval, spreading out collectors started at the same time
+	PollJitter time.Duration `mapstructure:"poll_jitter"`
+
+	// ShutdownDrainTimeout is how long shutdown waits for in-flight exports to
+	// finish before canceling them (0 cancels immediately)
+	ShutdownDrainTimeout time.Duration `mapstructure:"shutdown_drain_timeout"`
+
+	// MaxElapsedPerPath bounds the time a poll spends on one path, the rest of its
+	// work is deferred to the next poll (0 is unbounded)
+	MaxElapsedPerPath time.Duration `mapstructure:"max_elapsed_per_path"`
+
+	// BlackoutWindows are the times no export is created, e.g. during GitLab
+	// backups or upgrades
+	BlackoutWindows []BlackoutWindowConfig `mapstructure:"blackout_windows"`
+
+	// MaintenanceBackoff is how long polls are held off once GitLab answers
+	// that it's under maintenance (0 treats maintenance like any other error)
+	MaintenanceBackoff time.Duration `mapstructure:"maintenance_backoff"`
+
+	// MaxExportAge is how long ago a finished export may have been generated to
+	// still be resumed from a checkpoint, older ones are replaced (0 is any age)
+	MaxExportAge time.Duration `mapstructure:"max_export_age"`
+
+	// MinExportInterval is the minimum time between a processed export and the next
+	// export of a path
+	MinExportInterval time.Duration `mapstructure:"min_export_interval"`
+
+	// SyncMode is full (process a complete export every cycle) or incremental
+	// (a complete export as baseline, then only vulnerabilities updated since)
+	SyncMode string `mapstructure:"sync_mode"`
+	// FullExportInterval reconciles incremental syncs with a complete export of
+	// the path every interval (0 only exports the baseline)
+	FullExportInterval time.Duration `mapstructure:"full_export_interval"`
+	// APIOrder is the order incremental syncs read and emit findings in
+	APIOrder APIOrderConfig `mapstructure:"api_order"`
+
+	// GroupDeduplication emits findings of group exports shared by several projects
+	// (same identifier, package and version) once, listing the affected projects
+	GroupDeduplication bool `mapstructure:"group_deduplication"`
+
+	// MaxInflightExports limits how many exports the receivers of this process
+	// generate at once on the same GitLab instance (0 means unlimited)
+	MaxInflightExports int `mapstructure:"max_inflight_exports"`
+
+	// ConditionalRequests sends API reads and export status polls with the ETag
+	// and Last-Modified of the last response, reusing it on 304 Not Modified
+	ConditionalRequests bool `mapstructure:"conditional_requests"`
+
+	// StatusPage serves a page with the live status of each path (export, last
+	// success, last error) for debugging, disabled unless an endpoint is set
+	StatusPage confighttp.ServerConfig `mapstructure:"status_page"`
+
+	// Encoding of export downloads: auto (UTF-8 with Windows-1252 fallback),
+	// utf-8 or windows-1252. BOMs are always honored.
+	Encoding string `mapstructure:"encoding"`
+
+	// SpillThreshold is the size in bytes above which export downloads are
+	// written to a temp file before they're parsed (0 parses them as they arrive)
+	SpillThreshold int64 `mapstructure:"spill_threshold"`
+	// SpillDirectory holds the temp files of spilled downloads, the system's temp
+	// directory when empty
+	SpillDirectory string    `mapstructure:"spill_directory"`
+	CSV            CSVConfig `mapstructure:"csv"`
+
+	// ParseWorkers is the number of workers parsing and converting CSV downloads
+	// spilled to disk in parallel (0 or 1 parses them sequentially)
+	ParseWorkers int `mapstructure:"parse_workers"`
+
+	ConsumerRetry ConsumerRetryConfig `mapstructure:"consumer_retry"`
+	Quarantine    QuarantineConfig    `mapstructure:"quarantine"`
+	Health        HealthConfig        `mapstructure:"health"`
+	SBOM          SBOMConfig          `mapstructure:"sbom"`
+	Compliance    ComplianceConfig    `mapstructure:"compliance"`
+	MergeRequests MergeRequestsConfig `mapstructure:"merge_requests"`
+	Enrichment    EnrichmentConfig    `mapstructure:"enrichment"`
+
+	// AuditTrail emits the state transitions of a project's vulnerabilities (who
+	// dismissed or resolved them, when and why) as they happen
+	AuditTrail bool `mapstructure:"audit_trail"`
+
+	// AttributeTypes maps CSV column names to the attribute type their values are
+	// converted to (string, int, double, bool or timestamp), overriding the defaults
+	AttributeTypes map[string]string `mapstructure:"attribute_types"`
+	Timestamps     TimestampsConfig  `mapstructure:"timestamps"`
+
+	// SemconvMapping emits standard security attribute names (vulnerability.id,
+	// package.name, file.path, ...) instead of normalized CSV headers
+	SemconvMapping bool `mapstructure:"semconv_mapping"`
+
+	// BodyFormat is the log body of a finding: default (title, description and
+	// solution) or sarif (a SARIF result object)
+	BodyFormat string `mapstructure:"body_format"`
+
+	// OutputSchema names the attributes of findings: default or ocsf (OCSF
+	// Vulnerability Finding attributes)
+	OutputSchema string `mapstructure:"output_schema"`
+
+	// PreserveRawRecord keeps the exported row of a finding in the gitlab.raw
+	// attribute, untouched by the attribute mapping
+	PreserveRawRecord bool `mapstructure:"preserve_raw_record"`
+
+	// PipelineTraceCorrelation sets the trace ID of a finding's record to the one
+	// derived from the pipeline it was detected in
+	PipelineTraceCorrelation bool `mapstructure:"pipeline_trace_correlation"`
+
+	// AttributePrefix is prepended to the normalized column names of findings,
+	// including its separator (e.g. gitlab.vuln.). Empty emits the bare names.
+	// Attributes derived from the columns (flags, CVSS, identifiers, enrichments)
+	// keep their fixed vulnerability. names.
+	AttributePrefix string `mapstructure:"attribute_prefix"`
+
+	// EmptyValues is how empty columns are emitted: omit (left out), empty (empty
+	// strings) or null (attributes without a value)
+	EmptyValues string `mapstructure:"empty_values"`
+
+	UnknownSeverity UnknownSeverityConfig `mapstructure:"unknown_severity"`
+
+	// IncludeDismissed emits findings dismissed in GitLab, which are otherwise
+	// only tracked
+	IncludeDismissed bool `mapstructure:"include_dismissed"`
+}
+
+// pathFeature is a setting only supported for one type of path
+type pathFeature struct {
+	name     string
+	pathType string
+}
+
+// pathFeatures returns the enabled settings only supported for one type of path
+func (c *Config) pathFeatures() []pathFeature {
+	var features []pathFeature
+	add := func(enabled bool, name, pathType string) {
+		if enabled {
+			features = append(features, pathFeature{name: name, pathType: pathType})
+		}
+	}
+	add(c.SyncMode == syncModeIncremental, "incremental sync_mode", "project")
+	add(c.SBOM.Job != "", "sbom", "project")
+	add(c.Compliance.Licenses || c.Compliance.PolicyViolations, "compliance", "project")
+	add(c.GroupDeduplication, "group_deduplication", "group")
+	add(c.MergeRequests.Enabled, "merge_requests", "project")
+	add(c.AuditTrail, "audit_trail", "project")
+	return features
+}
+
+// validatePathFeatures checks that the enabled settings support paths of
+// pathType. When the type isn't known yet, as for a path given as a URL, it
+// only checks that they don't need different types; the type is checked once
+// it's looked up at start.
+func (c *Config) validatePathFeatures(pathType string) error {
+	features := c.pathFeatures()
+	for _, feature := range features {
+		if pathType != "" && feature.pathType != pathType {
+			return fmt.Errorf("%s is only supported for %s paths", feature.name, feature.pathType)
+		}
+		if pathType == "" && feature.pathType != features[0].pathType {
+			return fmt.Errorf("%s is only supported for %s paths and %s for %s paths",
+				features[0].name, features[0].pathType, feature.name, feature.pathType)
+		}
+	}
+	return nil
 }
 
 func (c *Config) Validate() error {
-	if c.Token == "" {
-		return fmt.Errorf("token cannot be empty")
+	var errs []error
+	switch {
+	case c.Token == "" && c.Auth == nil:
+		errs = append(errs, errors.New("token cannot be empty unless auth is set"))
+	case c.Token != "" && c.Auth != nil:
+		errs = append(errs, errors.New("token and auth cannot both be set"))
 	}
 
 	if len(c.Paths) != 1 {
-		return fmt.Errorf("exactly one path must be configured")
+		errs = append(errs, errors.New("exactly one path must be configured"))
+	}
+	for i, path := range c.Paths {
+		if err := c.validatePath(path); err != nil {
+			errs = append(errs, fmt.Errorf("paths[%d]: %w", i, err))
+		}
 	}
 
-	path := c.Paths[0]
-	if path.ID == "" {
-		return fmt.Errorf("id cannot be empty")
+	if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("base_url must be an http or https URL, got: %q", c.BaseURL))
 	}
-	if path.Type != "project" && path.Type != "group" {
-		return fmt.Errorf("type must be either 'project' or 'group', got: %s", path.Type)
+	for name, apiPath := range map[string]string{"api_path": c.APIPath, "graphql_path": c.GraphQLPath} {
+		if apiPath != "" && !strings.HasPrefix(apiPath, "/") {
+			errs = append(errs, fmt.Errorf("%s must start with '/', got: %q", name, apiPath))
+		}
 	}
 
-	if c.BaseURL == "" {
-		c.BaseURL = "https://gitlab.com"
+	switch c.Dialer.Network {
+	case "", dialerNetworkTCP, dialerNetworkUnix:
+	default:
+		errs = append(errs, fmt.Errorf("dialer network must be either 'tcp' or 'unix', got: %s", c.Dialer.Network))
+	}
+	if c.Dialer.Network == dialerNetworkUnix && c.Dialer.Address == "" {
+		errs = append(errs, errors.New("dialer address cannot be empty with the unix network"))
+	}
+	if c.Dialer.Address != "" {
+		if c.ProxyURL != "" {
+			errs = append(errs, errors.New("dialer address cannot be used with proxy_url"))
+		}
+		if c.Compression.IsCompressed() {
+			errs = append(errs, errors.New("dialer address cannot be used with compression"))
+		}
+		if c.HTTP2ReadIdleTimeout > 0 {
+			errs = append(errs, errors.New("dialer address cannot be used with http2_read_idle_timeout"))
+		}
+		if c.Cookies != nil && c.Cookies.Enabled {
+			errs = append(errs, errors.New("dialer address cannot be used with cookies"))
+		}
+	}
+	if c.Dialer.Timeout < 0 {
+		errs = append(errs, errors.New("dialer timeout cannot be negative"))
 	}
 
-	if c.PollInterval <= 0 {
-		c.PollInterval = defaultPollInterval
+	switch c.SyncMode {
+	case "", syncModeFull, syncModeIncremental:
+	default:
+		errs = append(errs, fmt.Errorf("sync_mode must be either 'full' or 'incremental', got: %s", c.SyncMode))
+	}
+	if _, err := json.Marshal(c.ExportParams); err != nil {
+		errs = append(errs, fmt.Errorf("export_params must be JSON-encodable: %w", err))
+	}
+	switch c.APIOrder.OrderBy {
+	case "", orderByUpdatedAt, orderByCreatedAt:
+	default:
+		errs = append(errs, fmt.Errorf("api_order order_by must be either 'updated_at' or 'created_at', got: %s", c.APIOrder.OrderBy))
+	}
+	switch c.APIOrder.Sort {
+	case "", sortAsc, sortDesc:
+	default:
+		errs = append(errs, fmt.Errorf("api_order sort must be either 'asc' or 'desc', got: %s", c.APIOrder.Sort))
+	}
+	if c.FullExportInterval != 0 && c.SyncMode != syncModeIncremental {
+		errs = append(errs, errors.New("full_export_interval requires incremental sync_mode"))
+	}
+
+	switch c.StateCompression {
+	case "", stateCompressionNone, stateCompressionGzip:
+	default:
+		errs = append(errs, fmt.Errorf("state_compression must be either 'none' or 'gzip', got: %s", c.StateCompression))
 	}
 
+	if c.PollInterval <= 0 {
+		errs = append(errs, errors.New("poll_interval must be positive"))
+	}
 	if c.ExportTimeout <= 0 {
-		c.ExportTimeout = defaultExportTimeout
+		errs = append(errs, errors.New("export_timeout must be positive"))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, errors.New("batch_size must be positive"))
+	}
+	if c.SpillThreshold < 0 {
+		errs = append(errs, errors.New("spill_threshold cannot be negative"))
+	}
+	if c.ParseWorkers < 0 {
+		errs = append(errs, errors.New("parse_workers cannot be negative"))
+	}
+	if c.CSV.BufferSize <= 0 {
+		errs = append(errs, errors.New("csv buffer_size must be positive"))
+	}
+	if c.CSV.MaxFieldLength < 0 {
+		errs = append(errs, errors.New("csv max_field_length cannot be negative"))
+	}
+	if !isValidOversizedFields(c.CSV.OversizedFields) {
+		errs = append(errs, fmt.Errorf("csv oversized_fields must be either 'truncate' or 'skip', got: %s", c.CSV.OversizedFields))
 	}
 
-	return nil
+	for name, duration := range map[string]time.Duration{
+		"state_flush_interval":   c.StateFlushInterval,
+		"min_export_interval":    c.MinExportInterval,
+		"initial_delay":          c.InitialDelay,
+		"poll_jitter":            c.PollJitter,
+		"shutdown_drain_timeout": c.ShutdownDrainTimeout,
+		"max_elapsed_per_path":   c.MaxElapsedPerPath,
+		"maintenance_backoff":    c.MaintenanceBackoff,
+		"full_export_interval":   c.FullExportInterval,
+		"max_export_age":         c.MaxExportAge,
+	} {
+		if duration < 0 {
+			errs = append(errs, fmt.Errorf("%s cannot be negative", name))
+		}
+	}
+
+	if polling := c.ExportPolling; polling.InitialInterval <= 0 || polling.MaxInterval <= 0 {
+		errs = append(errs, errors.New("export_polling initial_interval and max_interval must be positive"))
+	} else if polling.MaxInterval < polling.InitialInterval {
+		errs = append(errs, errors.New("export_polling max_interval cannot be shorter than initial_interval"))
+	}
+	if c.ExportPolling.Multiplier < 1 {
+		errs = append(errs, errors.New("export_polling multiplier must be at least 1"))
+	}
+	switch c.ExportPolling.UnknownStatus {
+	case "", unknownStatusWait, unknownStatusFail:
+	default:
+		errs = append(errs, fmt.Errorf("export_polling unknown_status must be either 'wait' or 'fail', got: %s", c.ExportPolling.UnknownStatus))
+	}
+
+	if retry := c.ConsumerRetry; retry.Enabled {
+		if retry.InitialInterval <= 0 || retry.MaxInterval <= 0 || retry.MaxElapsedTime <= 0 {
+			errs = append(errs, errors.New("consumer_retry initial_interval, max_interval and max_elapsed_time must be positive"))
+		} else if retry.MaxInterval < retry.InitialInterval {
+			errs = append(errs, errors.New("consumer_retry max_interval cannot be shorter than initial_interval"))
+		}
+	}
+
+	if c.SBOM.Job != "" && c.SBOM.Ref == "" {
+		errs = append(errs, errors.New("sbom ref cannot be empty"))
+	}
+
+	for _, reportType := range c.MergeRequests.ReportTypes {
+		if !slices.Contains(defaultMergeRequestReportTypes, reportType) {
+			errs = append(errs, fmt.Errorf("invalid merge request report type: %s", reportType))
+		}
+	}
+
+	if c.StuckExports.Threshold < 0 {
+		errs = append(errs, errors.New("stuck_exports threshold cannot be negative"))
+	}
+	if c.Quarantine.FailureThreshold < 0 {
+		errs = append(errs, errors.New("quarantine failure_threshold cannot be negative"))
+	}
+	if c.Quarantine.FailureThreshold > 0 && c.Quarantine.Duration <= 0 {
+		errs = append(errs, errors.New("quarantine duration must be positive"))
+	}
+
+	if c.Health.FailureThreshold < 0 {
+		errs = append(errs, errors.New("health failure_threshold cannot be negative"))
+	}
+
+	if c.MaxInflightExports < 0 {
+		errs = append(errs, errors.New("max_inflight_exports cannot be negative"))
+	}
+
+	if kev := c.Enrichment.KEV; kev.Enabled {
+		if kev.URL == "" {
+			errs = append(errs, errors.New("enrichment kev url cannot be empty"))
+		}
+		if kev.RefreshInterval <= 0 {
+			errs = append(errs, errors.New("enrichment kev refresh_interval must be positive"))
+		}
+	}
+	if osv := c.Enrichment.OSV; osv.Enabled {
+		if osv.URL == "" {
+			errs = append(errs, errors.New("enrichment osv url cannot be empty"))
+		}
+		if osv.CacheTTL <= 0 {
+			errs = append(errs, errors.New("enrichment osv cache_ttl must be positive"))
+		}
+		if osv.RateLimit <= 0 {
+			errs = append(errs, errors.New("enrichment osv rate_limit must be positive"))
+		}
+	}
+
+	if !isValidEncoding(c.Encoding) {
+		errs = append(errs, fmt.Errorf("encoding must be one of 'auto', 'utf-8' or 'windows-1252', got: %s", c.Encoding))
+	}
+
+	if _, ok := severityNumber(c.UnknownSeverity.Severity); c.UnknownSeverity.Severity != "" && !ok {
+		errs = append(errs, fmt.Errorf("unknown_severity severity must be one of 'critical', 'high', 'medium', 'low' or 'info', got: %s", c.UnknownSeverity.Severity))
+	}
+	if !isValidUnknownSeverityAction(c.UnknownSeverity.Action) {
+		errs = append(errs, fmt.Errorf("unknown_severity action must be one of 'keep', 'flag' or 'drop', got: %s", c.UnknownSeverity.Action))
+	}
+
+	if !isValidEmptyValues(c.EmptyValues) {
+		errs = append(errs, fmt.Errorf("empty_values must be one of 'omit', 'empty' or 'null', got: %s", c.EmptyValues))
+	}
+
+	if !isValidBodyFormat(c.BodyFormat) {
+		errs = append(errs, fmt.Errorf("body_format must be either 'default' or 'sarif', got: %s", c.BodyFormat))
+	}
+
+	if !isValidOutputSchema(c.OutputSchema) {
+		errs = append(errs, fmt.Errorf("output_schema must be either 'default' or 'ocsf', got: %s", c.OutputSchema))
+	}
+	if c.OutputSchema == outputSchemaOCSF && c.SemconvMapping {
+		errs = append(errs, errors.New("semconv_mapping cannot be combined with output_schema 'ocsf'"))
+	}
+	if c.OutputSchema == outputSchemaOCSF && c.AttributePrefix != defaultAttributePrefix {
+		errs = append(errs, fmt.Errorf("attribute_prefix cannot be changed with output_schema 'ocsf'"))
+	}
+
+	for column, typ := range c.AttributeTypes {
+		if !isValidAttributeType(typ) {
+			errs = append(errs, fmt.Errorf("invalid attribute type %q for column %q", typ, column))
+		}
+	}
+	if c.Timestamps.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timestamps.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("timestamps timezone %q is not a known timezone", c.Timestamps.Timezone))
+		}
+	}
+	for _, layout := range c.Timestamps.Layouts {
+		if strings.TrimSpace(layout) == "" {
+			errs = append(errs, errors.New("timestamps layouts cannot be empty"))
+			break
+		}
+	}
+	if _, err := newBlackoutWindows(c.BlackoutWindows); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePath checks a path and the features enabled for its type
+func (c *Config) validatePath(path PathConfig) error {
+	if path.ID == "" {
+		return errors.New("id cannot be empty")
+	}
+	// The type of a path given as a URL is looked up when it's not set
+	isURL := isPathURL(path.ID)
+	if path.Type != "project" && path.Type != "group" && (!isURL || path.Type != "") {
+		return fmt.Errorf("type must be either 'project' or 'group', got: %s", path.Type)
+	}
+	if isURL {
+		if _, _, err := parsePathURL(path.ID, c.pathBaseURL()); err != nil {
+			return err
+		}
+	}
+	return c.validatePathFeatures(path.Type)
+}
+
+// pathBaseURL is the base_url paths given as URLs must be on, empty when it's
+// left at its default and the URLs set the instance
+func (c *Config) pathBaseURL() string {
+	if c.BaseURL == defaultBaseURL {
+		return ""
+	}
+	return c.BaseURL
+}
+
+// instanceURL is the URL of the GitLab instance, the instance of the path when
+// it's given as a URL and base_url is left at its default
+func (c *Config) instanceURL() string {
+	for _, path := range c.Paths {
+		if !isPathURL(path.ID) {
+			continue
+		}
+		if baseURL, _, err := parsePathURL(path.ID, c.pathBaseURL()); err == nil {
+			return baseURL
+		}
+	}
+	return c.BaseURL
 }
 
 // GetPath returns the GitLab path from the URL
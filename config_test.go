@@ -2,58 +2,132 @@ package gitlabvulnreceiver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configauth"
+	"go.opentelemetry.io/collector/config/configcompression"
 )
 
 func TestConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
-		config  Config
+		config  func(cfg *Config)
 		wantErr bool
 		errMsg  string
 	}{
 		{
 			name: "valid project config",
-			config: Config{
-				Token: "test-token",
-				Paths: []PathConfig{
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
 					{
 						ID:   "12345",
 						Type: "project",
 					},
-				},
+				}
 			},
 			wantErr: false,
 		},
+		{
+			name: "project URL without type",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID: "https://gitlab.example.com/mygroup/myproject",
+					},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "project URL without type with project features",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID: "https://gitlab.example.com/mygroup/myproject",
+					},
+				}
+				cfg.SyncMode = syncModeIncremental
+				cfg.AuditTrail = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "URL without type with project and group features",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID: "https://gitlab.example.com/mygroup",
+					},
+				}
+				cfg.SyncMode = syncModeIncremental
+				cfg.GroupDeduplication = true
+			},
+			wantErr: true,
+			errMsg:  "incremental sync_mode is only supported for project paths and group_deduplication for group paths",
+		},
+		{
+			name: "group URL with project features",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "https://gitlab.example.com/mygroup",
+						Type: "group",
+					},
+				}
+				cfg.SBOM.Job = "cyclonedx"
+			},
+			wantErr: true,
+			errMsg:  "sbom is only supported for project paths",
+		},
+		{
+			name: "URL on another instance",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.BaseURL = "https://gitlab.internal.example.com"
+				cfg.Paths = []PathConfig{
+					{
+						ID: "https://gitlab.example.com/mygroup/myproject",
+					},
+				}
+			},
+			wantErr: true,
+			errMsg:  "is not on base_url",
+		},
 		{
 			name: "valid group config",
-			config: Config{
-				Token: "test-token",
-				Paths: []PathConfig{
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
 					{
 						ID:   "67890",
 						Type: "group",
 					},
-				},
+				}
 			},
 			wantErr: false,
 		},
 		{
 			name: "no paths",
-			config: Config{
-				Token: "test-token",
-				Paths: []PathConfig{},
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{}
 			},
 			wantErr: true,
 			errMsg:  "exactly one path must be configured",
 		},
 		{
 			name: "multiple paths",
-			config: Config{
-				Token: "test-token",
-				Paths: []PathConfig{
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
 					{
 						ID:   "12345",
 						Type: "project",
@@ -62,57 +136,673 @@ func TestConfig_Validate(t *testing.T) {
 						ID:   "67890",
 						Type: "group",
 					},
-				},
+				}
 			},
 			wantErr: true,
 			errMsg:  "exactly one path must be configured",
 		},
 		{
 			name: "missing token",
-			config: Config{
-				Paths: []PathConfig{
+			config: func(cfg *Config) {
+				cfg.Paths = []PathConfig{
 					{
 						ID:   "67890",
 						Type: "group",
 					},
-				},
+				}
 			},
 			wantErr: true,
 			errMsg:  "token cannot be empty",
 		},
 		{
 			name: "invalid type",
-			config: Config{
-				Token: "test-token",
-				Paths: []PathConfig{
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
 					{
 						ID:   "67890",
 						Type: "invalid",
 					},
-				},
+				}
 			},
 			wantErr: true,
 			errMsg:  "type must be either 'project' or 'group'",
 		},
 		{
 			name: "empty id",
-			config: Config{
-				Token: "test-token",
-				Paths: []PathConfig{
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
 					{
 						ID:   "",
 						Type: "project",
 					},
-				},
+				}
 			},
 			wantErr: true,
 			errMsg:  "id cannot be empty",
 		},
+		{
+			name: "incremental sync for group",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "group",
+					},
+				}
+				cfg.SyncMode = syncModeIncremental
+			},
+			wantErr: true,
+			errMsg:  "incremental sync_mode is only supported for project paths",
+		},
+		{
+			name: "negative state flush interval",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.StateFlushInterval = -time.Second
+			},
+			wantErr: true,
+			errMsg:  "state_flush_interval cannot be negative",
+		},
+		{
+			name: "export polling max interval shorter than initial interval",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.ExportPolling.InitialInterval = time.Minute
+				cfg.ExportPolling.MaxInterval = time.Second
+			},
+			wantErr: true,
+			errMsg:  "export_polling max_interval cannot be shorter than initial_interval",
+		},
+		{
+			name: "export polling multiplier below 1",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.ExportPolling.Multiplier = 0.5
+			},
+			wantErr: true,
+			errMsg:  "export_polling multiplier must be at least 1",
+		},
+		{
+			name: "invalid export polling unknown status",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.ExportPolling.UnknownStatus = "ignore"
+			},
+			wantErr: true,
+			errMsg:  "export_polling unknown_status must be either 'wait' or 'fail', got: ignore",
+		},
+		{
+			name: "negative stuck export threshold",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.StuckExports.Threshold = -time.Minute
+			},
+			wantErr: true,
+			errMsg:  "stuck_exports threshold cannot be negative",
+		},
+		{
+			name: "unknown timestamps timezone",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Timestamps.Timezone = "Mars/Olympus"
+			},
+			wantErr: true,
+			errMsg:  `timestamps timezone "Mars/Olympus" is not a known timezone`,
+		},
+		{
+			name: "invalid state compression",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.StateCompression = "zstd"
+			},
+			wantErr: true,
+			errMsg:  "state_compression must be either 'none' or 'gzip', got: zstd",
+		},
+		{
+			name: "negative poll jitter",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.PollJitter = -time.Second
+			},
+			wantErr: true,
+			errMsg:  "poll_jitter cannot be negative",
+		},
+		{
+			name: "negative max elapsed per path",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.MaxElapsedPerPath = -time.Minute
+			},
+			wantErr: true,
+			errMsg:  "max_elapsed_per_path cannot be negative",
+		},
+		{
+			name: "negative maintenance backoff",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.MaintenanceBackoff = -time.Minute
+			},
+			wantErr: true,
+			errMsg:  "maintenance_backoff cannot be negative",
+		},
+		{
+			name: "full export interval without incremental sync",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.FullExportInterval = 24 * time.Hour
+			},
+			wantErr: true,
+			errMsg:  "full_export_interval requires incremental sync_mode",
+		},
+		{
+			name: "negative max export age",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.MaxExportAge = -time.Hour
+			},
+			wantErr: true,
+			errMsg:  "max_export_age cannot be negative",
+		},
+		{
+			name: "export params not encodable",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.ExportParams = map[string]any{"report_type": make(chan int)}
+			},
+			wantErr: true,
+			errMsg:  "export_params must be JSON-encodable",
+		},
+		{
+			name: "invalid api order sort",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.APIOrder.Sort = "oldest"
+			},
+			wantErr: true,
+			errMsg:  "api_order sort must be either 'asc' or 'desc', got: oldest",
+		},
+		{
+			name: "invalid api order field",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.APIOrder.OrderBy = "severity"
+			},
+			wantErr: true,
+			errMsg:  "api_order order_by must be either 'updated_at' or 'created_at', got: severity",
+		},
+		{
+			name: "invalid blackout window",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.BlackoutWindows = []BlackoutWindowConfig{{Start: "02:00", End: "2am"}}
+			},
+			wantErr: true,
+			errMsg:  `blackout_windows[0]: invalid end: "2am" is not a HH:MM time`,
+		},
+		{
+			name: "invalid api path",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.APIPath = "api/v4"
+			},
+			wantErr: true,
+			errMsg:  `api_path must start with '/', got: "api/v4"`,
+		},
+		{
+			name: "unix dialer without address",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Dialer.Network = "unix"
+			},
+			wantErr: true,
+			errMsg:  "dialer address cannot be empty with the unix network",
+		},
+		{
+			name: "invalid dialer network",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Dialer.Network = "udp"
+			},
+			wantErr: true,
+			errMsg:  "dialer network must be either 'tcp' or 'unix', got: udp",
+		},
+		{
+			name: "dialer address with proxy",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Dialer.Address = "127.0.0.1:8080"
+				cfg.ProxyURL = "http://proxy.example.com:3128"
+			},
+			wantErr: true,
+			errMsg:  "dialer address cannot be used with proxy_url",
+		},
+		{
+			name: "dialer address with compression",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Dialer.Address = "127.0.0.1:8080"
+				cfg.Compression = configcompression.TypeGzip
+			},
+			wantErr: true,
+			errMsg:  "dialer address cannot be used with compression",
+		},
+		{
+			name: "invalid merge request report type",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.MergeRequests = MergeRequestsConfig{
+					Enabled:     true,
+					ReportTypes: []string{"fuzzing"},
+				}
+			},
+			wantErr: true,
+			errMsg:  "invalid merge request report type: fuzzing",
+		},
+		{
+			name: "group deduplication for project",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.GroupDeduplication = true
+			},
+			wantErr: true,
+			errMsg:  "group_deduplication is only supported for group paths",
+		},
+		{
+			name: "audit trail for group",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "67890",
+						Type: "group",
+					},
+				}
+				cfg.AuditTrail = true
+			},
+			wantErr: true,
+			errMsg:  "audit_trail is only supported for project paths",
+		},
+		{
+			name: "negative health failure threshold",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Health = HealthConfig{FailureThreshold: -1}
+			},
+			wantErr: true,
+			errMsg:  "health failure_threshold cannot be negative",
+		},
+		{
+			name: "negative max inflight exports",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.MaxInflightExports = -1
+			},
+			wantErr: true,
+			errMsg:  "max_inflight_exports cannot be negative",
+		},
+		{
+			name: "negative spill threshold",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.SpillThreshold = -1
+			},
+			wantErr: true,
+			errMsg:  "spill_threshold cannot be negative",
+		},
+		{
+			name: "negative parse workers",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.ParseWorkers = -1
+			},
+			wantErr: true,
+			errMsg:  "parse_workers cannot be negative",
+		},
+		{
+			name: "invalid oversized fields",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.CSV.OversizedFields = "drop"
+			},
+			wantErr: true,
+			errMsg:  "csv oversized_fields must be either 'truncate' or 'skip', got: drop",
+		},
+		{
+			name: "invalid encoding",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.Encoding = "latin-9"
+			},
+			wantErr: true,
+			errMsg:  "encoding must be one of",
+		},
+		{
+			name: "invalid body format",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.BodyFormat = "cyclonedx"
+			},
+			wantErr: true,
+			errMsg:  "body_format must be either 'default' or 'sarif'",
+		},
+		{
+			name: "ocsf with semconv mapping",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.OutputSchema = outputSchemaOCSF
+				cfg.SemconvMapping = true
+			},
+			wantErr: true,
+			errMsg:  "semconv_mapping cannot be combined with output_schema 'ocsf'",
+		},
+		{
+			name: "invalid unknown severity",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.UnknownSeverity = UnknownSeverityConfig{Severity: "severe", Action: "ignore"}
+			},
+			wantErr: true,
+			errMsg:  "unknown_severity severity must be one of 'critical', 'high', 'medium', 'low' or 'info'",
+		},
+		{
+			name: "invalid empty values",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.EmptyValues = "blank"
+			},
+			wantErr: true,
+			errMsg:  "empty_values must be one of 'omit', 'empty' or 'null'",
+		},
+		{
+			name: "ocsf with attribute prefix",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.OutputSchema = outputSchemaOCSF
+				cfg.AttributePrefix = "gitlab.vuln."
+			},
+			wantErr: true,
+			errMsg:  "attribute_prefix cannot be changed with output_schema 'ocsf'",
+		},
+		{
+			name: "invalid attribute type",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{
+					{
+						ID:   "12345",
+						Type: "project",
+					},
+				}
+				cfg.AttributeTypes = map[string]string{
+					"CVSS Score": "float",
+				}
+			},
+			wantErr: true,
+			errMsg:  `invalid attribute type "float" for column "CVSS Score"`,
+		},
+		{
+			name: "auth without token",
+			config: func(cfg *Config) {
+				cfg.Auth = &configauth.Authentication{AuthenticatorID: component.MustNewID("bearertokenauth")}
+				cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "token and auth",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Auth = &configauth.Authentication{AuthenticatorID: component.MustNewID("bearertokenauth")}
+				cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+			},
+			wantErr: true,
+			errMsg:  "token and auth cannot both be set",
+		},
+		{
+			name: "invalid base url",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.BaseURL = "gitlab.example.com"
+				cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+			},
+			wantErr: true,
+			errMsg:  `base_url must be an http or https URL, got: "gitlab.example.com"`,
+		},
+		{
+			name: "zero poll interval",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+				cfg.PollInterval = 0
+			},
+			wantErr: true,
+			errMsg:  "poll_interval must be positive",
+		},
+		{
+			name: "consumer retry max interval below initial interval",
+			config: func(cfg *Config) {
+				cfg.Token = "test-token"
+				cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+				cfg.ConsumerRetry.InitialInterval = time.Minute
+				cfg.ConsumerRetry.MaxInterval = time.Second
+			},
+			wantErr: true,
+			errMsg:  "consumer_retry max_interval cannot be shorter than initial_interval",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.config.Validate()
+			cfg := createDefaultConfig().(*Config)
+			tt.config(cfg)
+			err := cfg.Validate()
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.errMsg)
@@ -123,6 +813,37 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateReportsAllErrors(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.BaseURL = "://gitlab"
+	cfg.ExportTimeout = 0
+	cfg.Paths = []PathConfig{{ID: "", Type: "project"}, {ID: "67890", Type: "invalid"}}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, msg := range []string{
+		"token cannot be empty",
+		"exactly one path must be configured",
+		"paths[0]: id cannot be empty",
+		"paths[1]: type must be either 'project' or 'group'",
+		"base_url must be an http or https URL",
+		"export_timeout must be positive",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestConfig_ValidateKeepsDefaults(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Token = "test-token"
+	cfg.Paths = []PathConfig{{ID: "https://gitlab.example.com/mygroup/myproject"}}
+	before := *cfg
+
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, before, *cfg)
+	assert.Equal(t, "https://gitlab.example.com", cfg.instanceURL())
+}
+
 func TestConfig_GetPath(t *testing.T) {
 	tests := []struct {
 		name     string
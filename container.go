@@ -0,0 +1,107 @@
+package gitlabvulnreceiver
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Matches the target version of solutions like "Upgrade openssl from 1.1.1 to 1.1.1k"
+var fixedVersionPattern = regexp.MustCompile(`(?i)\bto\s+(?:version\s+)?([\w.:~+-]+)`)
+
+// containerLocation is the location of a container scanning finding
+type containerLocation struct {
+	Image           string             `json:"image"`
+	OperatingSystem string             `json:"operating_system"`
+	Dependency      dependencyLocation `json:"dependency"`
+}
+
+// putContainerAttributes maps the image, OS package and fixed version of a
+// container scanning finding to dedicated attributes. It returns the lowercased
+// columns it consumed so they aren't emitted again as generic attributes.
+func putContainerAttributes(attrs pcommon.Map, header []string, record []string) map[string]bool {
+	if reportType(header, record) != reportTypeContainerScanning {
+		return nil
+	}
+	consumed := make(map[string]bool)
+	field := func(columns ...string) string {
+		for _, column := range columns {
+			if value, ok := findField(header, record, column); ok && value != "" {
+				consumed[strings.ToLower(column)] = true
+				return value
+			}
+		}
+		return ""
+	}
+
+	// The location is a JSON object in some exports and the image reference in others
+	var location containerLocation
+	locationValue, _ := findField(header, record, "Location")
+	if strings.HasPrefix(strings.TrimSpace(locationValue), "{") {
+		if json.Unmarshal([]byte(locationValue), &location) == nil {
+			consumed["location"] = true
+		}
+	}
+
+	image := field("Image")
+	if image == "" {
+		image = location.Image
+	}
+	if image == "" && !consumed["location"] && locationValue != "" {
+		image = locationValue
+		consumed["location"] = true
+	}
+	if image != "" {
+		name, tag := parseImageReference(image)
+		attrs.PutStr("container.image.name", name)
+		putNonEmpty(attrs, "container.image.tag", tag)
+	}
+
+	operatingSystem := field("Operating System")
+	if operatingSystem == "" {
+		operatingSystem = location.OperatingSystem
+	}
+	putNonEmpty(attrs, "os.description", operatingSystem)
+
+	packageName := field("Package Name", "Package")
+	if packageName == "" {
+		packageName = location.Dependency.Package.Name
+	}
+	putNonEmpty(attrs, "package.name", packageName)
+
+	packageVersion := field("Package Version")
+	if packageVersion == "" {
+		packageVersion = location.Dependency.Version
+	}
+	putNonEmpty(attrs, "package.version", packageVersion)
+
+	// The fixed version is set with the other remediation attributes
+	field("Fixed Version")
+	return consumed
+}
+
+// parseImageReference splits an image reference into its name and tag, dropping
+// any digest
+func parseImageReference(ref string) (string, string) {
+	ref = strings.TrimSpace(ref)
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	// A colon before the last slash belongs to the registry port
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// firstField returns the first non-empty value of the given columns
+func firstField(header []string, record []string, columns ...string) string {
+	for _, column := range columns {
+		if value, ok := findField(header, record, column); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
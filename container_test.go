@@ -0,0 +1,97 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConvertToLogs_ContainerScanning(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []string
+		record   []string
+		expected map[string]string
+		absent   []string
+	}{
+		{
+			name:   "json location",
+			header: []string{"Tool", "Location", "Solution"},
+			record: []string{
+				"container_scanning",
+				`{"image":"registry.example.com:5000/group/app:1.2.3","operating_system":"alpine 3.18","dependency":{"package":{"name":"openssl"},"version":"3.1.0-r1"}}`,
+				"Upgrade openssl from 3.1.0-r1 to 3.1.4-r0.",
+			},
+			expected: map[string]string{
+				"container.image.name":        "registry.example.com:5000/group/app",
+				"container.image.tag":         "1.2.3",
+				"os.description":              "alpine 3.18",
+				"package.name":                "openssl",
+				"package.version":             "3.1.0-r1",
+				"vulnerability.fixed_version": "3.1.4-r0",
+			},
+			absent: []string{"vulnerability.location"},
+		},
+		{
+			name:   "flat columns",
+			header: []string{"Tool", "Location", "Package Name", "Package Version", "Fixed Version"},
+			record: []string{"Container Scanning", "nginx@sha256:abcd", "zlib", "1.2.11", "1.2.13"},
+			expected: map[string]string{
+				"container.image.name":        "nginx",
+				"package.name":                "zlib",
+				"package.version":             "1.2.11",
+				"vulnerability.fixed_version": "1.2.13",
+			},
+			absent: []string{
+				"vulnerability.location",
+				"vulnerability.package_name",
+				"vulnerability.package_version",
+			},
+		},
+		{
+			name:   "image column",
+			header: []string{"Tool", "Image", "Location", "Package Name"},
+			record: []string{"container_scanning", "alpine:3.18", "lib/apk/db/installed", "musl"},
+			expected: map[string]string{
+				"container.image.name":   "alpine",
+				"container.image.tag":    "3.18",
+				"package.name":           "musl",
+				"vulnerability.location": "lib/apk/db/installed",
+			},
+			absent: []string{"vulnerability.image", "vulnerability.package_name"},
+		},
+	}
+
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := recv.convertToLogs(tt.header, tt.record, &Export{ID: 123, ProjectID: "test-project"})
+			attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+			for key, value := range tt.expected {
+				v, ok := attrs.Get(key)
+				require.True(t, ok, "missing attribute %s", key)
+				assert.Equal(t, value, v.Str())
+			}
+			_, hasTag := attrs.Get("container.image.tag")
+			assert.Equal(t, tt.expected["container.image.tag"] != "", hasTag)
+			for _, key := range tt.absent {
+				assert.NotContains(t, attrs.AsRaw(), key)
+			}
+		})
+	}
+}
+
+func TestConvertToLogs_NotContainerScanning(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+	}
+	logs := recv.convertToLogs([]string{"Tool", "Location"}, []string{"sast", "app/main.go"}, &Export{ID: 123})
+	_, ok := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("container.image.name")
+	assert.False(t, ok)
+}
@@ -0,0 +1,85 @@
+package gitlabvulnreceiver
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// What happens to fields longer than the csv max_field_length
+const (
+	oversizedFieldsTruncate = "truncate"
+	oversizedFieldsSkip     = "skip"
+)
+
+func isValidOversizedFields(action string) bool {
+	switch action {
+	case "", oversizedFieldsTruncate, oversizedFieldsSkip:
+		return true
+	}
+	return false
+}
+
+// Attribute listing the attributes of a finding cut to max_field_length
+const truncatedFieldsAttribute = "vulnerability.truncated_fields"
+
+// limitFields applies the csv max_field_length to the fields of a record,
+// cutting them or rejecting the row as malformed
+func (r *vulnerabilityReceiver) limitFields(record *exportRecord) error {
+	limit := r.cfg.CSV.MaxFieldLength
+	if limit <= 0 {
+		return nil
+	}
+	for i, value := range record.values {
+		if len(value) <= limit {
+			continue
+		}
+		column := ""
+		if i < len(record.header) {
+			column = record.header[i]
+		}
+		if r.cfg.CSV.OversizedFields == oversizedFieldsSkip {
+			return &malformedRowError{err: fmt.Errorf("field %q is %d bytes long, more than max_field_length", column, len(value))}
+		}
+		record.values[i] = truncateField(value, limit)
+		record.truncated = append(record.truncated, column)
+	}
+	return nil
+}
+
+// truncateField cuts a value to at most n bytes without splitting a character
+func truncateField(value string, n int) string {
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n]
+}
+
+// putTruncatedFields lists the attributes of the columns cut from a record
+func (r *vulnerabilityReceiver) putTruncatedFields(lr plog.LogRecord, record *exportRecord) {
+	if len(record.truncated) == 0 {
+		return
+	}
+	fields := lr.Attributes().PutEmptySlice(truncatedFieldsAttribute)
+	for _, column := range record.truncated {
+		fields.AppendEmpty().SetStr(r.attributeName(column))
+	}
+}
+
+// fieldLimitDecoder applies the csv max_field_length to the records of a decoder
+type fieldLimitDecoder struct {
+	exportDecoder
+	receiver *vulnerabilityReceiver
+}
+
+func (d *fieldLimitDecoder) Read() (*exportRecord, error) {
+	record, err := d.exportDecoder.Read()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.receiver.limitFields(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,98 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestTruncateField(t *testing.T) {
+	assert.Equal(t, "abc", truncateField("abcdef", 3))
+	// "é" is two bytes, it isn't split
+	assert.Equal(t, "ab", truncateField("abé", 3))
+	assert.Equal(t, "abé", truncateField("abéd", 4))
+}
+
+func TestLimitFields(t *testing.T) {
+	header := []string{"Title", "Details"}
+	newRecord := func() *exportRecord {
+		return &exportRecord{header: header, values: []string{"short", strings.Repeat("x", 20)}}
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		r := &vulnerabilityReceiver{cfg: createDefaultConfig().(*Config)}
+		record := newRecord()
+		require.NoError(t, r.limitFields(record))
+		assert.Len(t, record.values[1], 20)
+		assert.Empty(t, record.truncated)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		cfg := createDefaultConfig().(*Config)
+		cfg.CSV.MaxFieldLength = 10
+		r := &vulnerabilityReceiver{cfg: cfg}
+		record := newRecord()
+		require.NoError(t, r.limitFields(record))
+		assert.Equal(t, []string{"short", strings.Repeat("x", 10)}, record.values)
+		assert.Equal(t, []string{"Details"}, record.truncated)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		cfg := createDefaultConfig().(*Config)
+		cfg.CSV.MaxFieldLength = 10
+		cfg.CSV.OversizedFields = oversizedFieldsSkip
+		r := &vulnerabilityReceiver{cfg: cfg}
+		err := r.limitFields(newRecord())
+		var malformed *malformedRowError
+		require.ErrorAs(t, err, &malformed)
+		assert.ErrorContains(t, err, `field "Details" is 20 bytes long`)
+	})
+}
+
+func TestProcessRecords_OversizedFields(t *testing.T) {
+	csvData := "Vulnerability ID,Title,Details,Severity\n" +
+		"1,first," + strings.Repeat("a", 100) + ",high\n" +
+		"2,second,short,low\n"
+
+	for _, tt := range []struct {
+		action  string
+		records int
+	}{
+		{oversizedFieldsTruncate, 2},
+		{oversizedFieldsSkip, 1},
+	} {
+		t.Run(tt.action, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.CSV.MaxFieldLength = 16
+			cfg.CSV.OversizedFields = tt.action
+			sink := new(consumertest.LogsSink)
+			receiver := &vulnerabilityReceiver{
+				cfg:          cfg,
+				consumer:     sink,
+				logger:       zap.NewNop(),
+				stateManager: newTestStateManager(t),
+			}
+
+			decoder := &fieldLimitDecoder{exportDecoder: newCSVDecoder(csv.NewReader(strings.NewReader(csvData))), receiver: receiver}
+			require.NoError(t, receiver.processRecords(context.Background(), decoder, "12345", &Export{ID: 1, ProjectID: "12345"}))
+			require.Equal(t, tt.records, sink.LogRecordCount())
+			if tt.action == oversizedFieldsSkip {
+				return
+			}
+
+			attrs := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+			details, ok := attrs.Get("vulnerability.details")
+			require.True(t, ok)
+			assert.Equal(t, strings.Repeat("a", 16), details.Str())
+			truncated, ok := attrs.Get(truncatedFieldsAttribute)
+			require.True(t, ok)
+			assert.Equal(t, []interface{}{"vulnerability.details"}, truncated.Slice().AsRaw())
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package gitlabvulnreceiver
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Columns that may contain a CVSS vector string
+var cvssColumns = []string{"CVSS Vectors", "CVSS Vector", "CVSS"}
+
+// Exports may prefix vectors with the vendor, e.g. "GitLab=CVSS:3.1/AV:N/..."
+var cvssVectorPattern = regexp.MustCompile(`CVSS:3\.[01](/[A-Za-z]+:[A-Za-z])+`)
+
+// cvssMetric describes the values of one CVSS v3 base metric
+type cvssMetric struct {
+	attribute string
+	values    map[string]string
+}
+
+var cvssBaseMetrics = map[string]cvssMetric{
+	"AV": {"attack_vector", map[string]string{"N": "network", "A": "adjacent_network", "L": "local", "P": "physical"}},
+	"AC": {"attack_complexity", map[string]string{"L": "low", "H": "high"}},
+	"PR": {"privileges_required", map[string]string{"N": "none", "L": "low", "H": "high"}},
+	"UI": {"user_interaction", map[string]string{"N": "none", "R": "required"}},
+	"S":  {"scope", map[string]string{"U": "unchanged", "C": "changed"}},
+	"C":  {"confidentiality_impact", map[string]string{"N": "none", "L": "low", "H": "high"}},
+	"I":  {"integrity_impact", map[string]string{"N": "none", "L": "low", "H": "high"}},
+	"A":  {"availability_impact", map[string]string{"N": "none", "L": "low", "H": "high"}},
+}
+
+// cvssVector is a parsed CVSS v3.x vector
+type cvssVector struct {
+	raw     string
+	version string
+	metrics map[string]string
+}
+
+// parseCVSSVector decodes a CVSS v3.0/v3.1 vector string. All base metrics must be present.
+func parseCVSSVector(vector string) (*cvssVector, error) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3.") {
+		return nil, fmt.Errorf("unsupported CVSS vector: %s", vector)
+	}
+
+	v := &cvssVector{
+		raw:     vector,
+		version: strings.TrimPrefix(parts[0], "CVSS:"),
+		metrics: make(map[string]string),
+	}
+	for _, part := range parts[1:] {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed CVSS metric %q", part)
+		}
+		metric, known := cvssBaseMetrics[name]
+		if !known {
+			// Temporal and environmental metrics don't affect the base score
+			continue
+		}
+		if _, valid := metric.values[value]; !valid {
+			return nil, fmt.Errorf("invalid value %q for CVSS metric %s", value, name)
+		}
+		v.metrics[name] = value
+	}
+
+	for name := range cvssBaseMetrics {
+		if _, ok := v.metrics[name]; !ok {
+			return nil, fmt.Errorf("CVSS vector is missing base metric %s", name)
+		}
+	}
+	return v, nil
+}
+
+// baseScore computes the base score with the formula of the vector's CVSS version
+func (v *cvssVector) baseScore() float64 {
+	changed := v.metrics["S"] == "C"
+
+	av := map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}[v.metrics["AV"]]
+	ac := map[string]float64{"L": 0.77, "H": 0.44}[v.metrics["AC"]]
+	ui := map[string]float64{"N": 0.85, "R": 0.62}[v.metrics["UI"]]
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}[v.metrics["PR"]]
+	if changed {
+		pr = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}[v.metrics["PR"]]
+	}
+
+	cia := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	iss := 1 - (1-cia[v.metrics["C"]])*(1-cia[v.metrics["I"]])*(1-cia[v.metrics["A"]])
+
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0
+	}
+
+	roundUp := cvssRoundUp
+	if v.version == "3.0" {
+		roundUp = cvss30RoundUp
+	}
+
+	exploitability := 8.22 * av * ac * pr * ui
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10))
+	}
+	return roundUp(math.Min(impact+exploitability, 10))
+}
+
+// cvss30RoundUp implements the Roundup function from the CVSS v3.0 specification
+func cvss30RoundUp(value float64) float64 {
+	return math.Ceil(value*10) / 10
+}
+
+// cvssRoundUp implements the Roundup function from the CVSS v3.1 specification,
+// which avoids the floating point errors of the v3.0 one
+func cvssRoundUp(value float64) float64 {
+	intInput := int64(math.Round(value * 100000))
+	if intInput%10000 == 0 {
+		return float64(intInput) / 100000
+	}
+	return (math.Floor(float64(intInput)/10000) + 1) / 10
+}
+
+// cvssSeverity returns the qualitative severity rating for a base score
+func cvssSeverity(score float64) string {
+	switch {
+	case score == 0:
+		return "none"
+	case score < 4:
+		return "low"
+	case score < 7:
+		return "medium"
+	case score < 9:
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+// putCVSSAttributes decodes the first CVSS v3 vector found in the record into
+// score and per-metric attributes. The score GitLab reports is kept over the
+// one computed from the vector. The names are fixed, whatever attribute_prefix
+// is, as OSV enrichment and OCSF output read them back.
+func putCVSSAttributes(attrs pcommon.Map, header []string, record []string) {
+	if value, ok := findField(header, record, "CVSS Score"); ok {
+		if score, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			attrs.PutDouble("vulnerability.score.base", score)
+		}
+	}
+	for _, column := range cvssColumns {
+		value, ok := findField(header, record, column)
+		if !ok {
+			continue
+		}
+		raw := cvssVectorPattern.FindString(value)
+		if raw == "" {
+			continue
+		}
+		vector, err := parseCVSSVector(raw)
+		if err != nil {
+			continue
+		}
+
+		putCVSSVector(attrs, vector)
+		return
+	}
+}
+
+// putCVSSVector sets the score and per-metric attributes of a CVSS v3 vector.
+// A base score already set is kept, and the severity is rated from it.
+func putCVSSVector(attrs pcommon.Map, vector *cvssVector) {
+	score, ok := reportedScore(attrs)
+	if !ok {
+		score = vector.baseScore()
+		attrs.PutDouble("vulnerability.score.base", score)
+	}
+	attrs.PutStr("vulnerability.cvss.vector", vector.raw)
+	attrs.PutStr("vulnerability.cvss.version", vector.version)
+	attrs.PutStr("vulnerability.cvss.severity", cvssSeverity(score))
+	for name, metric := range cvssBaseMetrics {
+		attrs.PutStr("vulnerability.cvss."+metric.attribute, metric.values[vector.metrics[name]])
+	}
+}
+
+// reportedScore returns the base score already set on a finding
+func reportedScore(attrs pcommon.Map) (float64, bool) {
+	value, ok := attrs.Get("vulnerability.score.base")
+	if !ok {
+		return 0, false
+	}
+	switch value.Type() {
+	case pcommon.ValueTypeDouble:
+		return value.Double(), true
+	case pcommon.ValueTypeInt:
+		return float64(value.Int()), true
+	case pcommon.ValueTypeStr:
+		score, err := strconv.ParseFloat(strings.TrimSpace(value.Str()), 64)
+		return score, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,97 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestParseCVSSVector_BaseScore(t *testing.T) {
+	tests := []struct {
+		vector   string
+		score    float64
+		severity string
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, "critical"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, "medium"},
+		{"CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 5.5, "medium"},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:N", 0, "none"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O", 9.8, "critical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			v, err := parseCVSSVector(tt.vector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.score, v.baseScore())
+			assert.Equal(t, tt.severity, cvssSeverity(v.baseScore()))
+		})
+	}
+}
+
+func TestParseCVSSVector_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		vector string
+		errMsg string
+	}{
+		{"cvss v2", "AV:N/AC:L/Au:N/C:P/I:P/A:P", "unsupported CVSS vector"},
+		{"missing metric", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", "missing base metric A"},
+		{"invalid value", "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", `invalid value "X"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCVSSVector(tt.vector)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestPutCVSSAttributes(t *testing.T) {
+	header := []string{"Title", "CVSS Vectors"}
+	record := []string{"Test Vuln", "GitLab=CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N"}
+
+	attrs := pcommon.NewMap()
+	putCVSSAttributes(attrs, header, record)
+
+	expected := map[string]interface{}{
+		"vulnerability.cvss.vector":              "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N",
+		"vulnerability.cvss.version":             "3.1",
+		"vulnerability.score.base":               6.5,
+		"vulnerability.cvss.severity":            "medium",
+		"vulnerability.cvss.attack_vector":       "network",
+		"vulnerability.cvss.privileges_required": "low",
+		"vulnerability.cvss.scope":               "unchanged",
+		"vulnerability.cvss.integrity_impact":    "none",
+	}
+	for key, value := range expected {
+		v, ok := attrs.Get(key)
+		require.True(t, ok, "missing attribute %s", key)
+		assert.Equal(t, value, v.AsRaw())
+	}
+}
+
+func TestPutCVSSAttributes_ReportedScore(t *testing.T) {
+	header := []string{"Title", "CVSS Score", "CVSS Vectors"}
+	record := []string{"Test Vuln", "7.1", "GitLab=CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N"}
+
+	attrs := pcommon.NewMap()
+	putCVSSAttributes(attrs, header, record)
+
+	raw := attrs.AsRaw()
+	assert.Equal(t, 7.1, raw["vulnerability.score.base"])
+	assert.Equal(t, "high", raw["vulnerability.cvss.severity"])
+	assert.Equal(t, "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", raw["vulnerability.cvss.vector"])
+}
+
+func TestCVSSRoundUp(t *testing.T) {
+	// v3.0 rounds floating point noise up, v3.1 doesn't
+	assert.Equal(t, 4.1, cvss30RoundUp(4.000001))
+	assert.Equal(t, 4.0, cvssRoundUp(4.000001))
+	assert.Equal(t, 4.1, cvss30RoundUp(4.02))
+	assert.Equal(t, 4.1, cvssRoundUp(4.02))
+}
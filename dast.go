@@ -0,0 +1,82 @@
+package gitlabvulnreceiver
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const reportTypeDAST = "dast"
+
+// dastLocation is the location of a DAST finding
+type dastLocation struct {
+	Hostname string `json:"hostname"`
+	Method   string `json:"method"`
+	Param    string `json:"param"`
+	Path     string `json:"path"`
+}
+
+// Columns DAST attributes are read from, in order of preference
+var (
+	dastURLColumns       = []string{"DAST URL", "URL", "Target URL"}
+	dastMethodColumns    = []string{"DAST Method", "Method", "HTTP Method"}
+	dastParameterColumns = []string{"DAST Parameter", "Parameter", "Param"}
+	dastEvidenceColumns  = []string{"DAST Evidence", "Evidence"}
+)
+
+// putDASTAttributes maps the target URL, HTTP method, parameter and evidence of
+// a DAST finding to structured attributes. It returns the lowercased columns it
+// consumed so they aren't emitted again as generic attributes.
+func putDASTAttributes(attrs pcommon.Map, header []string, record []string) map[string]bool {
+	if reportType(header, record) != reportTypeDAST {
+		return nil
+	}
+	consumed := make(map[string]bool)
+	field := func(columns []string) string {
+		for _, column := range columns {
+			if value, ok := findField(header, record, column); ok && value != "" {
+				consumed[strings.ToLower(column)] = true
+				return value
+			}
+		}
+		return ""
+	}
+
+	// The location is a JSON object in some exports
+	var location dastLocation
+	if value, ok := findField(header, record, "Location"); ok && strings.HasPrefix(strings.TrimSpace(value), "{") {
+		if json.Unmarshal([]byte(value), &location) == nil {
+			consumed["location"] = true
+		}
+	}
+
+	target := field(dastURLColumns)
+	if target == "" && location.Hostname != "" {
+		target = strings.TrimRight(location.Hostname, "/") + location.Path
+	}
+	if target != "" {
+		attrs.PutStr("url.full", target)
+		if u, err := url.Parse(target); err == nil {
+			putNonEmpty(attrs, "url.scheme", u.Scheme)
+			putNonEmpty(attrs, "url.domain", u.Hostname())
+			putNonEmpty(attrs, "url.path", u.Path)
+		}
+	}
+
+	method := field(dastMethodColumns)
+	if method == "" {
+		method = location.Method
+	}
+	putNonEmpty(attrs, "http.request.method", strings.ToUpper(method))
+
+	parameter := field(dastParameterColumns)
+	if parameter == "" {
+		parameter = location.Param
+	}
+	putNonEmpty(attrs, "vulnerability.dast.parameter", parameter)
+
+	putNonEmpty(attrs, "vulnerability.dast.evidence", field(dastEvidenceColumns))
+	return consumed
+}
@@ -0,0 +1,75 @@
+package gitlabvulnreceiver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConvertToLogs_DAST(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+	}
+
+	header := []string{"Tool", "Vulnerability", "Location", "Evidence"}
+	record := []string{
+		"dast",
+		"Reflected XSS",
+		`{"hostname":"https://app.example.com","method":"get","param":"q","path":"/search"}`,
+		"<script>alert(1)</script> reflected in response",
+	}
+	logs := recv.convertToLogs(header, record, &Export{ID: 123, ProjectID: "test-project"})
+	attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+
+	expected := map[string]string{
+		"url.full":                     "https://app.example.com/search",
+		"url.scheme":                   "https",
+		"url.domain":                   "app.example.com",
+		"url.path":                     "/search",
+		"http.request.method":          "GET",
+		"vulnerability.dast.parameter": "q",
+		"vulnerability.dast.evidence":  "<script>alert(1)</script> reflected in response",
+	}
+	for key, value := range expected {
+		v, ok := attrs.Get(key)
+		require.True(t, ok, "missing attribute %s", key)
+		assert.Equal(t, value, v.Str())
+	}
+
+	// Mapped columns aren't dumped into generic attributes
+	for _, key := range []string{"vulnerability.location", "vulnerability.evidence"} {
+		_, ok := attrs.Get(key)
+		assert.False(t, ok, "unexpected attribute %s", key)
+	}
+	_, ok := attrs.Get("vulnerability.vulnerability")
+	assert.True(t, ok)
+}
+
+func TestConvertRecord_DASTJSON(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+	}
+
+	decoder := newJSONDecoder(strings.NewReader(`{
+		"report_type": "dast",
+		"name": "Missing CSP header",
+		"location": {"hostname": "http://app.example.com", "method": "POST", "param": "token", "path": "/login"},
+		"evidence": {"summary": "Content-Security-Policy header missing"}
+	}`))
+	record, err := decoder.Read()
+	require.NoError(t, err)
+
+	logs := recv.convertRecord(record, &Export{ID: 123, ProjectID: "test-project"})
+	raw := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+
+	assert.Equal(t, "http://app.example.com/login", raw["url.full"])
+	assert.Equal(t, "POST", raw["http.request.method"])
+	assert.Equal(t, "token", raw["vulnerability.dast.parameter"])
+	assert.Equal(t, "Content-Security-Policy header missing", raw["vulnerability.dast.evidence"])
+	assert.NotContains(t, raw, "vulnerability.dast_url")
+}
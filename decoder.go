@@ -0,0 +1,392 @@
+package gitlabvulnreceiver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// exportRecord is one vulnerability read from an export. Flat values are kept
+// as columns so CSV and JSON exports share the same conversion; JSON objects
+// and arrays are kept in nested.
+type exportRecord struct {
+	header []string
+	values []string
+	nested map[string]interface{}
+	// source is the JSON object the record was flattened from, nil for CSV rows
+	source map[string]interface{}
+	// sourceJSON holds the bytes of the object as exported, for JSON exports
+	sourceJSON json.RawMessage
+	// truncated holds the columns cut to the csv max_field_length
+	truncated []string
+	// logs is the record converted ahead of processRecords when converted is set
+	logs      plog.Logs
+	converted bool
+}
+
+// exportDecoder reads vulnerability records from an export, returning io.EOF at the end
+type exportDecoder interface {
+	Read() (*exportRecord, error)
+}
+
+// newExportDecoder picks a decoder based on the export format, falling back to
+// the Content-Type of the download, and CSV otherwise
+func newExportDecoder(r io.Reader, contentType string, format string) exportDecoder {
+	if isJSONFormat(format) || isJSONFormat(contentType) {
+		return newJSONDecoder(r)
+	}
+	return newCSVDecoder(csv.NewReader(r))
+}
+
+// errNotExport is returned for a download that isn't an export, typically a
+// login page served instead when the token is wrong or SSO intercepts requests
+var errNotExport = errors.New("export download is not CSV or JSON")
+
+// Number of bytes of a download sniffed for its content
+const sniffLength = 512
+
+// checkExportPayload rejects downloads whose Content-Type or first bytes show
+// an HTML or XML page instead of an export. The sniffed bytes aren't consumed.
+func checkExportPayload(r *bufio.Reader, contentType string) error {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "text/html", "application/xhtml+xml", "text/xml", "application/xml":
+			return fmt.Errorf("%w: the server sent %s", errNotExport, mediaType)
+		}
+	}
+
+	head, err := r.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fmt.Errorf("failed to read export download: %w", err)
+	}
+	sniffed := http.DetectContentType(head)
+	if strings.HasPrefix(sniffed, "text/html") || strings.HasPrefix(sniffed, "text/xml") {
+		return fmt.Errorf("%w: the download looks like %s", errNotExport, strings.Split(sniffed, ";")[0])
+	}
+	return nil
+}
+
+// isJSONFormat matches json, ndjson and jsonl formats and content types
+func isJSONFormat(format string) bool {
+	return strings.Contains(strings.ToLower(format), "json")
+}
+
+// malformedRowError is returned for a row that can't be decoded when the rows
+// after it can still be read
+type malformedRowError struct {
+	err error
+}
+
+func (e *malformedRowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *malformedRowError) Unwrap() error {
+	return e.err
+}
+
+// csvDecoder reads records from a CSV export, the first row being the header
+type csvDecoder struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVDecoder(reader *csv.Reader) *csvDecoder {
+	return &csvDecoder{reader: reader}
+}
+
+func (d *csvDecoder) Read() (*exportRecord, error) {
+	if d.header == nil {
+		header, err := d.reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		d.header = header
+	}
+
+	values, err := d.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		// The reader resumes at the next line
+		return nil, &malformedRowError{err: fmt.Errorf("failed to read CSV record: %w", err)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV record: %w", err)
+	}
+	return &exportRecord{header: d.header, values: values}, nil
+}
+
+// raw encodes the record as it was exported: the CSV row or the JSON object.
+// Matched secrets are hashed, as in the attributes.
+func (record *exportRecord) raw() (string, error) {
+	if record.sourceJSON != nil {
+		return rawJSON(record.sourceJSON)
+	}
+	if record.source != nil {
+		redactNestedSecrets(record.source)
+		data, err := json.Marshal(record.source)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode JSON record: %w", err)
+		}
+		return string(data), nil
+	}
+
+	var buf strings.Builder
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(redactSecrets(record.header, record.values)); err != nil {
+		return "", fmt.Errorf("failed to encode CSV record: %w", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to encode CSV record: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// rawJSON returns the bytes of an exported JSON object, re-encoded only when
+// secrets had to be hashed. Numbers keep their exported form either way.
+func rawJSON(data json.RawMessage) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("failed to decode JSON record: %w", err)
+	}
+	if !redactNestedSecrets(value) {
+		return string(data), nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON record: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// jsonDecoder reads records from a JSON array or newline-delimited JSON export
+type jsonDecoder struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+	inArray bool
+}
+
+func newJSONDecoder(r io.Reader) *jsonDecoder {
+	reader := bufio.NewReader(r)
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	return &jsonDecoder{reader: reader, decoder: decoder}
+}
+
+func (d *jsonDecoder) Read() (*exportRecord, error) {
+	if d.reader != nil {
+		// A JSON array holds all records, otherwise objects follow each other
+		first, err := peekNonSpace(d.reader)
+		d.reader = nil
+		if err != nil {
+			return nil, err
+		}
+		if first == '[' {
+			if _, err := d.decoder.Token(); err != nil {
+				return nil, fmt.Errorf("failed to read JSON array: %w", err)
+			}
+			d.inArray = true
+		}
+	}
+
+	if d.inArray && !d.decoder.More() {
+		return nil, io.EOF
+	}
+
+	// The bytes are kept as exported, before numbers and secrets are converted
+	var data json.RawMessage
+	if err := d.decoder.Decode(&data); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to decode JSON record: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		// A value that isn't an object is skipped whole, unlike invalid JSON
+		return nil, &malformedRowError{err: fmt.Errorf("failed to decode JSON record: %w", err)}
+	}
+	record := flattenJSONRecord(obj)
+	record.sourceJSON = data
+	return record, nil
+}
+
+// flattenJSONRecord splits a JSON vulnerability into flat columns and nested values
+func flattenJSONRecord(obj map[string]interface{}) *exportRecord {
+	record := &exportRecord{nested: make(map[string]interface{}), source: obj}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch v := obj[key].(type) {
+		case nil:
+		case string:
+			record.add(key, v)
+		case json.Number:
+			record.add(key, v.String())
+		case bool:
+			record.add(key, strconv.FormatBool(v))
+		default:
+			record.nested[key] = normalizeJSONValue(v)
+		}
+	}
+
+	// Surface nested values that the CSV conversion knows how to handle, under
+	// column names that don't collide with the nested attributes
+	if identifiers, ok := obj["identifiers"].([]interface{}); ok {
+		var refs []string
+		var secretRuleID string
+		for _, item := range identifiers {
+			if identifier, ok := item.(map[string]interface{}); ok {
+				for _, field := range []string{"name", "external_id", "url"} {
+					if s, ok := identifier[field].(string); ok && s != "" {
+						refs = append(refs, s)
+					}
+				}
+				if identifier["external_type"] == "gitleaks_rule_id" {
+					secretRuleID, _ = identifier["external_id"].(string)
+				}
+			}
+		}
+		record.add("Other Identifiers", strings.Join(refs, ", "))
+		if secretRuleID != "" {
+			record.add("Secret Rule ID", secretRuleID)
+		}
+	}
+	if scanner, ok := obj["scanner"].(map[string]interface{}); ok {
+		if name, ok := scanner["name"].(string); ok {
+			record.add("Scanner Name", name)
+		}
+	}
+	if project, ok := obj["project"].(map[string]interface{}); ok {
+		if fullPath, ok := project["full_path"].(string); ok {
+			record.add("Project Full Path", fullPath)
+		}
+	}
+	if pipeline, ok := obj["pipeline"].(map[string]interface{}); ok {
+		if id, ok := pipeline["id"].(int64); ok {
+			record.add("Pipeline ID", strconv.FormatInt(id, 10))
+		}
+		if sha, ok := pipeline["sha"].(string); ok {
+			record.add("Pipeline SHA", sha)
+		}
+	}
+	if evidence, ok := obj["evidence"].(map[string]interface{}); ok {
+		if summary, ok := evidence["summary"].(string); ok {
+			record.add("DAST Evidence", summary)
+		}
+	}
+	if location, ok := obj["location"].(map[string]interface{}); ok {
+		if file, ok := location["file"].(string); ok {
+			record.add("File", file)
+		}
+		if line, ok := location["start_line"].(int64); ok {
+			record.add("Start Line", strconv.FormatInt(line, 10))
+		}
+		if line, ok := location["end_line"].(int64); ok {
+			record.add("End Line", strconv.FormatInt(line, 10))
+		}
+		if image, ok := location["image"].(string); ok {
+			record.add("Image", image)
+		}
+		if operatingSystem, ok := location["operating_system"].(string); ok {
+			record.add("Operating System", operatingSystem)
+		}
+		if dependency, ok := location["dependency"].(map[string]interface{}); ok {
+			if pkg, ok := dependency["package"].(map[string]interface{}); ok {
+				if name, ok := pkg["name"].(string); ok {
+					record.add("Package Name", name)
+				}
+			}
+			if version, ok := dependency["version"].(string); ok {
+				record.add("Package Version", version)
+			}
+		}
+		if hostname, ok := location["hostname"].(string); ok {
+			path, _ := location["path"].(string)
+			record.add("DAST URL", strings.TrimRight(hostname, "/")+path)
+			if method, ok := location["method"].(string); ok {
+				record.add("DAST Method", method)
+			}
+			if param, ok := location["param"].(string); ok {
+				record.add("DAST Parameter", param)
+			}
+		}
+		if commit, ok := location["commit"].(map[string]interface{}); ok {
+			if sha, ok := commit["sha"].(string); ok {
+				record.add("Commit", sha)
+			}
+		}
+	}
+
+	return record
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			if _, err := reader.ReadByte(); err != nil {
+				return 0, err
+			}
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func (r *exportRecord) add(column, value string) {
+	r.header = append(r.header, column)
+	r.values = append(r.values, value)
+}
+
+// normalizeJSONValue converts json.Number values so nested data can be stored with FromRaw
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONValue(item)
+		}
+		return v
+	}
+	return value
+}
@@ -0,0 +1,183 @@
+package gitlabvulnreceiver
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAllRecords(t *testing.T, decoder exportDecoder) []*exportRecord {
+	var records []*exportRecord
+	for {
+		record, err := decoder.Read()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestNewExportDecoder(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+		json        bool
+	}{
+		{"csv format", "csv", "text/csv", false},
+		{"json format", "json", "", true},
+		{"ndjson content type", "", "application/x-ndjson; charset=utf-8", true},
+		{"unknown defaults to csv", "", "application/octet-stream", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, isJSON := newExportDecoder(strings.NewReader(""), tt.contentType, tt.format).(*jsonDecoder)
+			assert.Equal(t, tt.json, isJSON)
+		})
+	}
+}
+
+func TestJSONDecoder(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "array",
+			input: `[{"id": 1, "severity": "high", "false_positive": false}, {"id": 2.5, "severity": "low"}]`,
+		},
+		{
+			name:  "newline delimited",
+			input: "{\"id\": 1, \"severity\": \"high\", \"false_positive\": false}\n\n{\"id\": 2.5, \"severity\": \"low\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := readAllRecords(t, newJSONDecoder(strings.NewReader(tt.input)))
+			require.Len(t, records, 2)
+
+			assert.Equal(t, []string{"false_positive", "id", "severity"}, records[0].header)
+			assert.Equal(t, []string{"false", "1", "high"}, records[0].values)
+			assert.Equal(t, []string{"2.5", "low"}, records[1].values)
+		})
+	}
+}
+
+func TestJSONDecoder_NestedFields(t *testing.T) {
+	input := `{"id": 1, "scanner": {"id": "gemnasium", "name": "Gemnasium"}, "identifiers": [{"name": "CVE-2024-1234", "url": "https://example.com/CVE-2024-1234"}], "location": {"file": "go.sum", "start_line": 3}}`
+	records := readAllRecords(t, newJSONDecoder(strings.NewReader(input)))
+	require.Len(t, records, 1)
+
+	record := records[0]
+	value, ok := findField(record.header, record.values, "Scanner Name")
+	require.True(t, ok)
+	assert.Equal(t, "Gemnasium", value)
+
+	value, ok = findField(record.header, record.values, "Other Identifiers")
+	require.True(t, ok)
+	assert.Equal(t, "CVE-2024-1234, https://example.com/CVE-2024-1234", value)
+
+	value, ok = findField(record.header, record.values, "File")
+	require.True(t, ok)
+	assert.Equal(t, "go.sum", value)
+
+	assert.Equal(t, map[string]interface{}{"file": "go.sum", "start_line": int64(3)}, record.nested["location"])
+}
+
+func TestCSVDecoder_EmptyExport(t *testing.T) {
+	decoder := newExportDecoder(strings.NewReader(""), "text/csv", "csv")
+	_, err := decoder.Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read CSV header")
+}
+
+func TestDecoder_MalformedRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoder exportDecoder
+	}{
+		{"csv", newExportDecoder(strings.NewReader("ID,Title\n1,first\n2,\"bad\"quote\n3,third\n"), "", "csv")},
+		{"json", newExportDecoder(strings.NewReader(`{"id": 1}`+"\n"+`["not", "an", "object"]`+"\n"+`{"id": 3}`), "", "jsonl")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var read, malformed int
+			for {
+				_, err := tt.decoder.Read()
+				if err == io.EOF {
+					break
+				}
+				var rowErr *malformedRowError
+				if errors.As(err, &rowErr) {
+					malformed++
+					continue
+				}
+				require.NoError(t, err)
+				read++
+			}
+			assert.Equal(t, 2, read)
+			assert.Equal(t, 1, malformed)
+		})
+	}
+
+	// Invalid JSON can't be read past
+	_, err := newJSONDecoder(strings.NewReader(`{"id": `)).Read()
+	var rowErr *malformedRowError
+	assert.False(t, errors.As(err, &rowErr))
+}
+
+func TestCheckExportPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{name: "csv", contentType: "text/csv", body: "Title,Severity\nfirst,High\n"},
+		{name: "json lines", contentType: "application/x-ndjson", body: `{"id": 1}` + "\n"},
+		{name: "empty", contentType: "text/csv"},
+		{name: "no content type", body: "Title,Severity\n"},
+		{
+			name:        "html content type",
+			contentType: "text/html; charset=utf-8",
+			body:        "Title,Severity\n",
+			wantErr:     "export download is not CSV or JSON: the server sent text/html",
+		},
+		{
+			name:        "sniffed login page",
+			contentType: "application/octet-stream",
+			body:        "\n  <!DOCTYPE html>\n<html><head><title>Sign in</title></head></html>",
+			wantErr:     "export download is not CSV or JSON: the download looks like text/html",
+		},
+		{
+			name:    "sniffed xml",
+			body:    `<?xml version="1.0"?><error>denied</error>`,
+			wantErr: "export download is not CSV or JSON: the download looks like text/xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.body))
+			err := checkExportPayload(reader, tt.contentType)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, errNotExport)
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			// The sniffed bytes are still read
+			rest, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(rest))
+		})
+	}
+}
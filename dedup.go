@@ -0,0 +1,102 @@
+package gitlabvulnreceiver
+
+import (
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// findingAggregate is a finding shared by several projects of a group export
+type findingAggregate struct {
+	logs     plog.Logs
+	projects []string
+	// seenKeys are the seen items of the rows merged into the finding
+	seenKeys []string
+}
+
+// maxAggregatedFindings bounds the distinct findings held in memory while a
+// group export is read
+const maxAggregatedFindings = 10000
+
+// findingAggregator merges group export findings with the same identifier,
+// package and version, so a CVE of a shared base image is emitted once
+type findingAggregator struct {
+	aggregates map[string]*findingAggregate
+	order      []string
+	limit      int
+}
+
+func newFindingAggregator(limit int) *findingAggregator {
+	return &findingAggregator{
+		aggregates: make(map[string]*findingAggregate),
+		limit:      limit,
+	}
+}
+
+// dedupKey returns the (identifier, package, version) key of a finding. Findings
+// without an identifier or a package (SAST, DAST, ...) aren't deduplicated.
+func dedupKey(header []string, record []string) (string, bool) {
+	var identifier string
+	for _, column := range identifierColumns {
+		value, ok := findField(header, record, column)
+		if !ok || value == "" {
+			continue
+		}
+		if cve := cvePattern.FindString(value); cve != "" {
+			identifier = strings.ToUpper(cve)
+			break
+		}
+		if identifier == "" {
+			identifier = strings.TrimSpace(value)
+		}
+	}
+
+	packageName := firstField(header, record, "Package Name", "Package")
+	if identifier == "" || packageName == "" {
+		return "", false
+	}
+	return identifier + "|" + packageName + "|" + firstField(header, record, "Package Version"), true
+}
+
+// add records that a project has the finding, from the row with the seen item
+// seenKey. It reports whether the finding was aggregated and whether it's the
+// first occurrence, in which case logs are kept as the emitted record. New
+// findings aren't aggregated once the limit is reached.
+func (a *findingAggregator) add(key, projectPath, seenKey string, logs plog.Logs) (aggregated, first bool) {
+	aggregate, ok := a.aggregates[key]
+	if !ok {
+		if len(a.aggregates) >= a.limit {
+			return false, false
+		}
+		aggregate = &findingAggregate{logs: logs}
+		a.aggregates[key] = aggregate
+		a.order = append(a.order, key)
+	}
+	if !slices.Contains(aggregate.projects, projectPath) {
+		aggregate.projects = append(aggregate.projects, projectPath)
+	}
+	aggregate.seenKeys = append(aggregate.seenKeys, seenKey)
+	return true, !ok
+}
+
+// take returns one aggregate per finding, in the order they were first seen,
+// its record holding the list and count of affected projects
+func (a *findingAggregator) take() []*findingAggregate {
+	result := make([]*findingAggregate, 0, len(a.order))
+	for _, key := range a.order {
+		aggregate := a.aggregates[key]
+		rl := aggregate.logs.ResourceLogs().At(0)
+
+		// The record stands for every affected project
+		rl.Resource().Attributes().Remove("gitlab.project.path")
+		attrs := rl.ScopeLogs().At(0).LogRecords().At(0).Attributes()
+		putStringSlice(attrs, "gitlab.affected_projects", aggregate.projects)
+		attrs.PutInt("gitlab.affected_projects.count", int64(len(aggregate.projects)))
+
+		result = append(result, aggregate)
+	}
+	clear(a.aggregates)
+	a.order = nil
+	return result
+}
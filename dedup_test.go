@@ -0,0 +1,158 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+func TestDedupKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		record  []string
+		wantKey string
+		wantOK  bool
+	}{
+		{
+			name:    "cve and package",
+			header:  []string{"Identifiers", "Package Name", "Package Version"},
+			record:  []string{"GHSA-jfh8-c2jp-5v3q; cve-2021-44228", "log4j-core", "2.14.1"},
+			wantKey: "CVE-2021-44228|log4j-core|2.14.1",
+			wantOK:  true,
+		},
+		{
+			name:    "other identifier",
+			header:  []string{"Identifiers", "Package", "Package Version"},
+			record:  []string{"DLA-3842-1", "openssl", "1.1.1n"},
+			wantKey: "DLA-3842-1|openssl|1.1.1n",
+			wantOK:  true,
+		},
+		{
+			name:   "no package",
+			header: []string{"CWE", "Location"},
+			record: []string{"CWE-89", "app/models/user.rb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := dedupKey(tt.header, tt.record)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantKey, key)
+		})
+	}
+}
+
+func TestProcessCSVData_GroupDeduplication(t *testing.T) {
+	csvData := "Full Path,Title,CVE,Package Name,Package Version\n" +
+		"mygroup/api,Log4Shell,CVE-2021-44228,log4j-core,2.14.1\n" +
+		"mygroup/web,Log4Shell,CVE-2021-44228,log4j-core,2.14.1\n" +
+		"mygroup/web,SQL injection,,,\n" +
+		"mygroup/jobs,Log4Shell,CVE-2021-44228,log4j-core,2.14.1\n" +
+		"mygroup/jobs,Log4Shell,CVE-2021-44228,log4j-core,2.16.0\n"
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.GroupDeduplication = true
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	export := &Export{ID: 123, GroupID: "67890"}
+	err := receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "67890", export)
+	require.NoError(t, err)
+	require.Equal(t, 3, sink.LogRecordCount())
+
+	records := make(map[string]plog.LogRecord)
+	for _, logs := range sink.AllLogs() {
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			lrs := logs.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+			for j := 0; j < lrs.Len(); j++ {
+				version, _ := lrs.At(j).Attributes().Get("vulnerability.package_version")
+				title, _ := lrs.At(j).Attributes().Get("vulnerability.title")
+				records[title.Str()+" "+version.Str()] = lrs.At(j)
+			}
+		}
+	}
+
+	shared := records["Log4Shell 2.14.1"].Attributes().AsRaw()
+	assert.Equal(t, []interface{}{"mygroup/api", "mygroup/web", "mygroup/jobs"}, shared["gitlab.affected_projects"])
+	assert.Equal(t, int64(3), shared["gitlab.affected_projects.count"])
+
+	patched := records["Log4Shell 2.16.0"].Attributes().AsRaw()
+	assert.Equal(t, int64(1), patched["gitlab.affected_projects.count"])
+
+	assert.NotContains(t, records["SQL injection "].Attributes().AsRaw(), "gitlab.affected_projects")
+}
+
+func TestProcessCSVData_GroupDeduplicationDrainFailure(t *testing.T) {
+	csvData := "Full Path,Title,CVE,Package Name,Package Version\n" +
+		"mygroup/web,SQL injection,,,\n" +
+		"mygroup/api,Log4Shell,CVE-2021-44228,log4j-core,2.14.1\n" +
+		"mygroup/web,Log4Shell,CVE-2021-44228,log4j-core,2.14.1\n" +
+		"mygroup/jobs,Spring4Shell,CVE-2022-22965,spring-beans,5.3.17\n"
+
+	calls := 0
+	next, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		calls++
+		if calls > 2 {
+			return errors.New("pipeline unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.GroupDeduplication = true
+	cfg.BatchSize = 1
+	cfg.ConsumerRetry.Enabled = false
+	sm := newTestStateManager(t)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     next,
+		logger:       zap.NewNop(),
+		stateManager: sm,
+	}
+
+	// The second aggregated record is rejected while draining
+	export := &Export{ID: 123, GroupID: "67890"}
+	err = receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "67890", export)
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	// No cursor is saved, so the next cycle reads the whole export again
+	_, ok := sm.GetCheckpoint("67890")
+	assert.False(t, ok)
+	header := []string{"Full Path", "Title", "CVE", "Package Name", "Package Version"}
+	assert.False(t, sm.IsSeen(state.ProcessedKeyPrefix+generateVulnID(header, []string{"mygroup/web", "SQL injection", "", "", ""})))
+}
+
+func TestFindingAggregator_Limit(t *testing.T) {
+	aggregator := newFindingAggregator(1)
+
+	aggregated, first := aggregator.add("a", "mygroup/api", "processed:1", plog.NewLogs())
+	assert.True(t, aggregated)
+	assert.True(t, first)
+	aggregated, first = aggregator.add("a", "mygroup/web", "processed:2", plog.NewLogs())
+	assert.True(t, aggregated)
+	assert.False(t, first)
+
+	// Findings beyond the limit are emitted per project
+	aggregated, _ = aggregator.add("b", "mygroup/web", "processed:3", plog.NewLogs())
+	assert.False(t, aggregated)
+}
@@ -0,0 +1,106 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+// dialContext returns the dialer set by the dialer settings, nil when
+// connections go to the host of the request
+func (c *Config) dialContext() gitlab.DialContextFunc {
+	if c.Dialer.Address == "" {
+		return nil
+	}
+	network := c.Dialer.Network
+	if network == "" {
+		network = dialerNetworkTCP
+	}
+	return gitlab.NewFixedDialer(network, c.Dialer.Address, c.Dialer.Timeout)
+}
+
+// newHTTPClient creates the client of GitLab requests from the HTTP client
+// settings. confighttp has no setting for how connections are opened, so with
+// a dialer the transport is built here with the same TLS and connection
+// settings, and wrapped with the auth extension and headers. The settings
+// needing confighttp's own wrappers are rejected with a dialer by Validate.
+func newHTTPClient(ctx context.Context, cfg *Config, host component.Host, settings component.TelemetrySettings) (*http.Client, error) {
+	clientCfg := cfg.ClientConfig
+	clientCfg.Endpoint = ""
+	dial := cfg.dialContext()
+	if dial == nil {
+		return clientCfg.ToClient(ctx, host, settings)
+	}
+
+	tlsCfg, err := clientCfg.TLSSetting.LoadTLSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS settings: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dial
+	if tlsCfg != nil {
+		transport.TLSClientConfig = tlsCfg
+	}
+	if clientCfg.ReadBufferSize > 0 {
+		transport.ReadBufferSize = clientCfg.ReadBufferSize
+	}
+	if clientCfg.WriteBufferSize > 0 {
+		transport.WriteBufferSize = clientCfg.WriteBufferSize
+	}
+	if clientCfg.MaxIdleConns != nil {
+		transport.MaxIdleConns = *clientCfg.MaxIdleConns
+	}
+	if clientCfg.MaxIdleConnsPerHost != nil {
+		transport.MaxIdleConnsPerHost = *clientCfg.MaxIdleConnsPerHost
+	}
+	if clientCfg.MaxConnsPerHost != nil {
+		transport.MaxConnsPerHost = *clientCfg.MaxConnsPerHost
+	}
+	if clientCfg.IdleConnTimeout != nil {
+		transport.IdleConnTimeout = *clientCfg.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = clientCfg.DisableKeepAlives
+
+	httpClient := &http.Client{Transport: transport, Timeout: clientCfg.Timeout}
+	if clientCfg.Auth != nil {
+		extensions := host.GetExtensions()
+		if extensions == nil {
+			return nil, errors.New("extensions configuration not found")
+		}
+		authenticator, err := clientCfg.Auth.GetClientAuthenticator(ctx, extensions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get auth extension: %w", err)
+		}
+		if httpClient.Transport, err = authenticator.RoundTripper(httpClient.Transport); err != nil {
+			return nil, fmt.Errorf("failed to set up auth extension: %w", err)
+		}
+	}
+	if len(clientCfg.Headers) > 0 {
+		httpClient.Transport = &headerTransport{next: httpClient.Transport, headers: clientCfg.Headers}
+	}
+	return httpClient, nil
+}
+
+// headerTransport sets the headers settings on every request
+type headerTransport struct {
+	next    http.RoundTripper
+	headers map[string]configopaque.String
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			req.Host = string(value)
+			continue
+		}
+		req.Header.Set(name, string(value))
+	}
+	return t.next.RoundTrip(req)
+}
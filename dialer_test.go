@@ -0,0 +1,70 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configauth"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/extension/auth"
+)
+
+func TestNewHTTPClient_Dialer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gitlab")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "proxy.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gitlab.example.com", r.Host)
+		assert.Equal(t, "collector", r.Header.Get("X-Sidecar-Client"))
+		assert.Equal(t, "Bearer extension-token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	authID := component.MustNewID("bearertokenauth")
+	host := &extensionHost{
+		Host: componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{
+			authID: auth.NewClient(auth.WithClientRoundTripper(func(base http.RoundTripper) (http.RoundTripper, error) {
+				return &bearerRoundTripper{next: base, token: "extension-token"}, nil
+			})),
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Auth = &configauth.Authentication{AuthenticatorID: authID}
+	cfg.Dialer.Network = dialerNetworkUnix
+	cfg.Dialer.Address = socket
+	cfg.Headers = map[string]configopaque.String{"X-Sidecar-Client": "collector"}
+
+	client, err := newHTTPClient(context.Background(), cfg, host, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	resp, err := client.Get("http://gitlab.example.com/api/v4/version")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestNewHTTPClient_NoDialer(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	assert.Nil(t, cfg.dialContext())
+
+	client, err := newHTTPClient(context.Background(), cfg, componenttest.NewNopHost(), componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	assert.NotNil(t, client.Transport)
+}
@@ -0,0 +1,148 @@
+package gitlabvulnreceiver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings that can be configured for export downloads
+const (
+	encodingAuto        = "auto"
+	encodingUTF8        = "utf-8"
+	encodingWindows1252 = "windows-1252"
+)
+
+// windows1252 maps bytes 0x80-0x9F to their Unicode code points. The rest of
+// the upper half matches Latin-1; undefined bytes map to the C1 control codes.
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+func isValidEncoding(encoding string) bool {
+	switch encoding {
+	case "", encodingAuto, encodingUTF8, encodingWindows1252:
+		return true
+	}
+	return false
+}
+
+// newCharsetReader returns a reader producing UTF-8 from an export download.
+// A UTF-8 BOM is stripped and UTF-16 (detected by its BOM) is transcoded. In
+// auto mode, bytes that aren't valid UTF-8 are decoded as Windows-1252.
+func newCharsetReader(r io.Reader, encoding string) io.Reader {
+	br := bufio.NewReader(r)
+
+	bom, _ := br.Peek(3)
+	switch {
+	case len(bom) >= 3 && bom[0] == 0xEF && bom[1] == 0xBB && bom[2] == 0xBF:
+		_, _ = br.Discard(3)
+	case len(bom) >= 2 && bom[0] == 0xFF && bom[1] == 0xFE:
+		_, _ = br.Discard(2)
+		return &utf16Reader{reader: br, order: binary.LittleEndian}
+	case len(bom) >= 2 && bom[0] == 0xFE && bom[1] == 0xFF:
+		_, _ = br.Discard(2)
+		return &utf16Reader{reader: br, order: binary.BigEndian}
+	}
+
+	switch encoding {
+	case encodingUTF8:
+		return br
+	case encodingWindows1252:
+		return &windows1252Reader{reader: br, decodeAll: true}
+	default:
+		return &windows1252Reader{reader: br}
+	}
+}
+
+// windows1252Reader decodes Windows-1252 bytes to UTF-8. Unless decodeAll is
+// set, valid UTF-8 sequences are passed through and only invalid bytes are decoded.
+type windows1252Reader struct {
+	reader    *bufio.Reader
+	decodeAll bool
+	buf       []byte
+}
+
+func (w *windows1252Reader) Read(p []byte) (int, error) {
+	var err error
+	for len(w.buf) < len(p) {
+		var b byte
+		if b, err = w.reader.ReadByte(); err != nil {
+			break
+		}
+		if b < utf8.RuneSelf {
+			w.buf = append(w.buf, b)
+			continue
+		}
+
+		if !w.decodeAll {
+			_ = w.reader.UnreadByte()
+			r, size, _ := w.reader.ReadRune()
+			if r != utf8.RuneError || size != 1 {
+				w.buf = utf8.AppendRune(w.buf, r)
+				continue
+			}
+		}
+
+		if b < 0xA0 {
+			w.buf = utf8.AppendRune(w.buf, windows1252[b-0x80])
+		} else {
+			w.buf = utf8.AppendRune(w.buf, rune(b))
+		}
+	}
+
+	n := copy(p, w.buf)
+	w.buf = w.buf[n:]
+	if n > 0 {
+		return n, nil
+	}
+	return 0, err
+}
+
+// utf16Reader transcodes UTF-16 to UTF-8
+type utf16Reader struct {
+	reader *bufio.Reader
+	order  binary.ByteOrder
+	buf    []byte
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	var err error
+	for len(u.buf) < len(p) {
+		var r rune
+		if r, err = u.readUnit(); err != nil {
+			break
+		}
+		if utf16.IsSurrogate(r) {
+			var low rune
+			if low, err = u.readUnit(); err != nil {
+				break
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		u.buf = utf8.AppendRune(u.buf, r)
+	}
+
+	n := copy(p, u.buf)
+	u.buf = u.buf[n:]
+	if n > 0 {
+		return n, nil
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return 0, err
+}
+
+func (u *utf16Reader) readUnit() (rune, error) {
+	var unit [2]byte
+	if _, err := io.ReadFull(u.reader, unit[:]); err != nil {
+		return 0, err
+	}
+	return rune(u.order.Uint16(unit[:])), nil
+}
@@ -0,0 +1,75 @@
+package gitlabvulnreceiver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utf16Bytes(s string, order binary.ByteOrder, bom []byte) []byte {
+	buf := bytes.NewBuffer(bom)
+	for _, unit := range utf16.Encode([]rune(s)) {
+		_ = binary.Write(buf, order, unit)
+	}
+	return buf.Bytes()
+}
+
+func TestNewCharsetReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		encoding string
+		expected string
+	}{
+		{
+			name:     "utf-8 bom stripped",
+			input:    append([]byte{0xEF, 0xBB, 0xBF}, []byte("Title,Severity")...),
+			expected: "Title,Severity",
+		},
+		{
+			name:     "valid utf-8 passed through",
+			input:    []byte("Title\nCafé ✓"),
+			expected: "Title\nCafé ✓",
+		},
+		{
+			name:     "invalid bytes decoded as windows-1252",
+			input:    []byte("Caf\xe9 \x93quoted\x94 \x80 ✓"),
+			expected: "Café “quoted” € ✓",
+		},
+		{
+			name:     "explicit windows-1252",
+			input:    []byte("\xc3\xa9"),
+			encoding: encodingWindows1252,
+			expected: "Ã©",
+		},
+		{
+			name:     "explicit utf-8 keeps invalid bytes",
+			input:    []byte("Caf\xe9"),
+			encoding: encodingUTF8,
+			expected: "Caf\xe9",
+		},
+		{
+			name:     "utf-16 little endian",
+			input:    utf16Bytes("Title,Severity\n𝄞 high", binary.LittleEndian, []byte{0xFF, 0xFE}),
+			expected: "Title,Severity\n𝄞 high",
+		},
+		{
+			name:     "utf-16 big endian",
+			input:    utf16Bytes("Title", binary.BigEndian, []byte{0xFE, 0xFF}),
+			expected: "Title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := io.ReadAll(newCharsetReader(bytes.NewReader(tt.input), tt.encoding))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+// Categories of the errors reported in metrics and logs
+const (
+	errorCategoryAuth      = "auth"
+	errorCategoryRateLimit = "rate_limit"
+	errorCategoryServer    = "server"
+	errorCategoryNetwork   = "network"
+	errorCategoryParse     = "parse"
+	errorCategoryConsumer  = "consumer"
+	errorCategoryCanceled  = "canceled"
+	errorCategoryOther     = "other"
+)
+
+// consumerError is an error returned by the pipeline the receiver feeds
+type consumerError struct {
+	err error
+}
+
+func (e *consumerError) Error() string {
+	return e.err.Error()
+}
+
+func (e *consumerError) Unwrap() error {
+	return e.err
+}
+
+// errorCategory classifies an error, telling apart e.g. GitLab being down
+// (server) from an expired token (auth)
+func errorCategory(err error) string {
+	var consumerErr *consumerError
+	var netErr net.Error
+	var csvErr *csv.ParseError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errorCategoryCanceled
+	case errors.As(err, &consumerErr):
+		return errorCategoryConsumer
+	}
+
+	if code, ok := gitlab.StatusCode(err); ok {
+		switch {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return errorCategoryAuth
+		case code == http.StatusTooManyRequests:
+			return errorCategoryRateLimit
+		case code >= 500:
+			return errorCategoryServer
+		}
+	}
+
+	switch {
+	case errors.Is(err, errNotExport):
+		// A login page is served instead of the export
+		return errorCategoryAuth
+	case errors.As(err, &netErr), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, context.DeadlineExceeded):
+		return errorCategoryNetwork
+	case errors.As(err, &csvErr), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return errorCategoryParse
+	}
+	return errorCategoryOther
+}
@@ -0,0 +1,67 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestErrorCategory(t *testing.T) {
+	_, csvErr := csv.NewReader(strings.NewReader("a,\"b\n")).Read()
+	jsonErr := json.Unmarshal([]byte("{"), &struct{}{})
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"unauthorized", fmt.Errorf("failed to create export: %w", &gitlab.APIError{StatusCode: 401, Body: "{}"}), errorCategoryAuth},
+		{"forbidden", fmt.Errorf("failed to list vulnerabilities: %w", &gitlab.APIError{StatusCode: 403}), errorCategoryAuth},
+		{"rate limited", &gitlab.APIError{StatusCode: 429}, errorCategoryRateLimit},
+		{"server error", fmt.Errorf("failed to get export: %w", &gitlab.APIError{StatusCode: 503}), errorCategoryServer},
+		{"network", fmt.Errorf("failed to create export: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), errorCategoryNetwork},
+		{"truncated download", fmt.Errorf("failed to read CSV record: %w", io.ErrUnexpectedEOF), errorCategoryNetwork},
+		{"csv", fmt.Errorf("failed to read CSV record: %w", csvErr), errorCategoryParse},
+		{"json", fmt.Errorf("failed to decode JSON record: %w", jsonErr), errorCategoryParse},
+		{"consumer", fmt.Errorf("failed to consume logs: %w", &consumerError{err: errors.New("queue full")}), errorCategoryConsumer},
+		{"login page", fmt.Errorf("%w: the server sent text/html", errNotExport), errorCategoryAuth},
+		{"canceled", fmt.Errorf("failed to wait for export: %w", context.Canceled), errorCategoryCanceled},
+		{"not found", errors.New("project ID 12345 not found"), errorCategoryOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorCategory(tt.err))
+		})
+	}
+}
+
+func TestConsumeLogs_ConsumerErrorCategory(t *testing.T) {
+	next, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		return errors.New("queue full")
+	})
+	require.NoError(t, err)
+	receiver := &vulnerabilityReceiver{
+		cfg:      createDefaultConfig().(*Config),
+		consumer: next,
+		logger:   zap.NewNop(),
+	}
+	receiver.cfg.ConsumerRetry.Enabled = false
+
+	err = receiver.consumeLogs(context.Background(), plog.NewLogs())
+	require.EqualError(t, err, "queue full")
+	assert.Equal(t, errorCategoryConsumer, errorCategory(err))
+}
@@ -0,0 +1,92 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestProcessProjectExports_RecreatesExpiredExport(t *testing.T) {
+	sm := newTestStateManager(t)
+	require.NoError(t, sm.SetCheckpoint("12345", state.ExportCheckpoint{ExportID: 1, RowsProcessed: 1}))
+
+	var created int
+	mockClient := &mockGitLabClient{
+		getExportFunc: func(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			created++
+			return &Export{ID: 2, ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			export := &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}
+			export.Links.Download = fmt.Sprintf("https://gitlab.example.com/exports/%d/download", exportID)
+			return export, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			if strings.Contains(url, "/exports/1/") {
+				return nil, fmt.Errorf("failed to download export: %w: %w", gitlab.ErrExportGone, &gitlab.APIError{StatusCode: 404})
+			}
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\nfirst,High\nsecond,Low\n"))}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               createDefaultConfig().(*Config),
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      sm,
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 1, created)
+	// The fresh export is read from its first row
+	assert.Equal(t, 2, sink.LogRecordCount())
+	assert.True(t, sm.IsExportProcessed(2))
+	_, ok := sm.GetCheckpoint("12345")
+	assert.False(t, ok)
+}
+
+func TestProcessProjectExports_ExpiredTwice(t *testing.T) {
+	var created int
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			created++
+			return &Export{ID: int64(created), ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return nil, fmt.Errorf("failed to get export: %w: %w", gitlab.ErrExportGone, &gitlab.APIError{StatusCode: 410})
+		},
+	}
+
+	receiver := &vulnerabilityReceiver{
+		cfg:               createDefaultConfig().(*Config),
+		consumer:          new(consumertest.LogsSink),
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	// A replacement is only created once per cycle
+	err := receiver.processProjectExports(context.Background(), "12345")
+	assert.ErrorIs(t, err, gitlab.ErrExportGone)
+	assert.Equal(t, 2, created)
+}
@@ -20,13 +20,86 @@ func NewFactory() receiver.Factory {
 	return receiver.NewFactory(
 		typeID,
 		createDefaultConfig,
-		receiver.WithLogs(createLogsReceiver, component.StabilityLevelBeta))
+		receiver.WithLogs(createLogsReceiver, component.StabilityLevelBeta),
+		receiver.WithMetrics(createMetricsReceiver, component.StabilityLevelAlpha))
 }
 
 func createDefaultConfig() component.Config {
 	return &Config{
+		BaseURL:       defaultBaseURL,
+		APIPath:       defaultAPIPath,
+		GraphQLPath:   defaultGraphQLPath,
 		PollInterval:  defaultPollInterval,
 		ExportTimeout: defaultExportTimeout,
+		ExportPolling: ExportPollingConfig{
+			InitialInterval: defaultExportPollInitialInterval,
+			MaxInterval:     defaultExportPollMaxInterval,
+			Multiplier:      defaultExportPollMultiplier,
+			UnknownStatus:   unknownStatusWait,
+		},
+		StuckExports: StuckExportsConfig{
+			Threshold: defaultStuckExportThreshold,
+		},
+		BatchSize: defaultBatchSize,
+		Encoding:  encodingAuto,
+		CSV: CSVConfig{
+			BufferSize:      defaultCSVBufferSize,
+			OversizedFields: oversizedFieldsTruncate,
+		},
+		SyncMode:         syncModeFull,
+		StateCompression: stateCompressionNone,
+		BodyFormat:       bodyFormatDefault,
+		OutputSchema:     outputSchemaDefault,
+
+		ConditionalRequests: true,
+
+		Dialer: DialerConfig{
+			Network: dialerNetworkTCP,
+			Timeout: defaultDialerTimeout,
+		},
+
+		APIOrder: APIOrderConfig{
+			OrderBy: orderByUpdatedAt,
+			Sort:    sortDesc,
+		},
+
+		AttributePrefix: defaultAttributePrefix,
+		EmptyValues:     emptyValuesOmit,
+		UnknownSeverity: UnknownSeverityConfig{
+			Action: unknownSeverityKeep,
+		},
+		IncludeDismissed: true,
+
+		MinExportInterval:    defaultMinExportInterval,
+		ShutdownDrainTimeout: defaultShutdownDrainTimeout,
+		MaintenanceBackoff:   defaultMaintenanceBackoff,
+		ConsumerRetry: ConsumerRetryConfig{
+			Enabled:         true,
+			InitialInterval: defaultRetryInitialInterval,
+			MaxInterval:     defaultRetryMaxInterval,
+			MaxElapsedTime:  defaultRetryMaxElapsedTime,
+		},
+		SBOM: SBOMConfig{
+			Ref: defaultSBOMRef,
+		},
+		Quarantine: QuarantineConfig{
+			FailureThreshold: defaultQuarantineFailureThreshold,
+			Duration:         defaultQuarantineDuration,
+		},
+		Health: HealthConfig{
+			FailureThreshold: defaultHealthFailureThreshold,
+		},
+		Enrichment: EnrichmentConfig{
+			KEV: KEVConfig{
+				URL:             defaultKEVURL,
+				RefreshInterval: defaultKEVRefreshInterval,
+			},
+			OSV: OSVConfig{
+				URL:       defaultOSVURL,
+				CacheTTL:  defaultOSVCacheTTL,
+				RateLimit: defaultOSVRateLimit,
+			},
+		},
 	}
 }
 
@@ -38,16 +111,52 @@ func createLogsReceiver(
 ) (receiver.Logs, error) {
 	rCfg := cfg.(*Config)
 
-	client := NewGitLabClient(rCfg, set.TelemetrySettings)
+	telemetry, err := newReceiverTelemetry(set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	timestamps, err := newTimestampParser(rCfg.Timestamps)
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := newBlackoutWindows(rCfg.BlackoutWindows)
+	if err != nil {
+		return nil, err
+	}
 
 	return &vulnerabilityReceiver{
 		cfg:               rCfg,
 		settings:          set.TelemetrySettings,
+		buildInfo:         set.BuildInfo,
 		consumer:          consumer,
-		client:            client,
 		logger:            set.Logger,
+		attributeTypes:    resolveAttributeTypes(rCfg.AttributeTypes),
+		timestamps:        timestamps,
+		maintenance:       newMaintenanceWindow(rCfg.MaintenanceBackoff, set.Logger),
+		blackouts:         blackouts,
 		lastExportTime:    make(map[string]time.Time),
 		exportsInProgress: make(map[string]bool),
+		groupPaths:        make(map[string]string),
 		exportMutex:       sync.RWMutex{},
+		telemetry:         telemetry,
+		id:                set.ID,
+	}, nil
+}
+
+func createMetricsReceiver(
+	ctx context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	rCfg := cfg.(*Config)
+
+	return &statisticsReceiver{
+		id:        set.ID,
+		cfg:       rCfg,
+		settings:  set.TelemetrySettings,
+		buildInfo: set.BuildInfo,
+		consumer:  consumer,
+		logger:    set.Logger,
 	}, nil
 }
@@ -20,6 +20,10 @@ func TestCreateDefaultConfig(t *testing.T) {
 	assert.Empty(t, gCfg.Paths, "default paths should be empty")
 	assert.Equal(t, defaultPollInterval, gCfg.PollInterval)
 	assert.Equal(t, defaultExportTimeout, gCfg.ExportTimeout)
+	assert.Equal(t, defaultBatchSize, gCfg.BatchSize)
+	assert.Equal(t, defaultShutdownDrainTimeout, gCfg.ShutdownDrainTimeout)
+	assert.Equal(t, defaultMinExportInterval, gCfg.MinExportInterval)
+	assert.Equal(t, defaultQuarantineFailureThreshold, gCfg.Quarantine.FailureThreshold)
 }
 
 func TestCreateLogsReceiver(t *testing.T) {
@@ -41,3 +45,22 @@ func TestCreateLogsReceiver(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, receiver)
 }
+
+func TestCreateMetricsReceiver(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	cfg.(*Config).Token = "test-token"
+	cfg.(*Config).Paths = []PathConfig{{
+		ID:   "12345",
+		Type: "group",
+	}}
+
+	receiver, err := factory.CreateMetrics(
+		context.Background(),
+		receivertest.NewNopSettings(),
+		cfg,
+		consumertest.NewNop())
+
+	require.NoError(t, err)
+	assert.NotNil(t, receiver)
+}
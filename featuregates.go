@@ -0,0 +1,37 @@
+package gitlabvulnreceiver
+
+import "go.opentelemetry.io/collector/featuregate"
+
+var (
+	// semconvMappingGate emits standard security attribute names by default, as
+	// semconv_mapping does, ahead of them becoming the default
+	semconvMappingGate = featuregate.GlobalRegistry().MustRegister(
+		"receiver.gitlabvuln.semconvMapping",
+		featuregate.StageAlpha,
+		featuregate.WithRegisterDescription("When enabled, findings use standard security attribute names (vulnerability.id, package.name, ...) unless output_schema is ocsf"),
+	)
+
+	// batchEmitGate sends records to the pipeline in batches of batch_size.
+	// Disabling it sends them one at a time, as before batching was added.
+	batchEmitGate = featuregate.GlobalRegistry().MustRegister(
+		"receiver.gitlabvuln.batchEmit",
+		featuregate.StageBeta,
+		featuregate.WithRegisterDescription("When enabled, log records are sent to the pipeline in batches of batch_size records"),
+	)
+)
+
+// batchSize is the number of records sent to the pipeline at once
+func (r *vulnerabilityReceiver) batchSize() int {
+	if !batchEmitGate.IsEnabled() {
+		return 1
+	}
+	return max(r.cfg.BatchSize, 1)
+}
+
+// semconvMapping reports whether findings use standard security attribute names
+func (r *vulnerabilityReceiver) semconvMapping() bool {
+	if r.cfg.SemconvMapping {
+		return true
+	}
+	return semconvMappingGate.IsEnabled() && r.cfg.OutputSchema != outputSchemaOCSF
+}
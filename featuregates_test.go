@@ -0,0 +1,61 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/featuregate"
+	"go.uber.org/zap"
+)
+
+// setFeatureGate sets a feature gate for the duration of a test
+func setFeatureGate(t *testing.T, gate *featuregate.Gate, enabled bool) {
+	previous := gate.IsEnabled()
+	require.NoError(t, featuregate.GlobalRegistry().Set(gate.ID(), enabled))
+	t.Cleanup(func() {
+		require.NoError(t, featuregate.GlobalRegistry().Set(gate.ID(), previous))
+	})
+}
+
+func TestSemconvMappingGate(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	receiver := &vulnerabilityReceiver{cfg: cfg}
+	assert.False(t, receiver.semconvMapping())
+
+	setFeatureGate(t, semconvMappingGate, true)
+	assert.True(t, receiver.semconvMapping())
+	assert.Equal(t, "package.name", receiver.attributeName("Package Name"))
+
+	// OCSF attributes take precedence
+	cfg.OutputSchema = outputSchemaOCSF
+	assert.False(t, receiver.semconvMapping())
+}
+
+func TestBatchEmitGate(t *testing.T) {
+	csvData := "Title,Severity\nfirst,High\nsecond,Low\nthird,Medium\n"
+	process := func() int {
+		sink := new(consumertest.LogsSink)
+		receiver := &vulnerabilityReceiver{
+			cfg:          createDefaultConfig().(*Config),
+			consumer:     sink,
+			logger:       zap.NewNop(),
+			stateManager: newTestStateManager(t),
+		}
+		export := &Export{ID: 123, ProjectID: "12345"}
+		require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+		require.Equal(t, 3, sink.LogRecordCount())
+		return len(sink.AllLogs())
+	}
+
+	assert.True(t, batchEmitGate.IsEnabled())
+	assert.Equal(t, 1, process())
+
+	// Without batching every record is sent on its own
+	setFeatureGate(t, batchEmitGate, false)
+	assert.Equal(t, 3, process())
+}
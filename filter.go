@@ -0,0 +1,13 @@
+package gitlabvulnreceiver
+
+// filtered reports whether a finding is left out of the emitted records, for
+// its unknown severity or for being dismissed. Filtered findings still have
+// their status tracked.
+func (r *vulnerabilityReceiver) filtered(record *exportRecord) bool {
+	return r.dropsSeverity(record) || r.dropsDismissed(record)
+}
+
+// dropsDismissed reports whether a finding is dropped for being dismissed
+func (r *vulnerabilityReceiver) dropsDismissed(record *exportRecord) bool {
+	return !r.cfg.IncludeDismissed && findingStatus(record) == "dismissed"
+}
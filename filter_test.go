@@ -0,0 +1,59 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestProcessCSVData_IncludeDismissed(t *testing.T) {
+	csvData := "Vulnerability ID,Title,Severity,Status\n1,SQL injection,High,detected\n2,Weak hash,Low,dismissed\n3,Old library,Medium,Dismissed\n"
+
+	tests := []struct {
+		name             string
+		includeDismissed bool
+		want             int
+	}{
+		{name: "included", includeDismissed: true, want: 3},
+		{name: "excluded", includeDismissed: false, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := new(consumertest.LogsSink)
+			cfg := createDefaultConfig().(*Config)
+			cfg.IncludeDismissed = tt.includeDismissed
+			receiver := &vulnerabilityReceiver{
+				cfg:          cfg,
+				consumer:     sink,
+				logger:       zap.NewNop(),
+				stateManager: newTestStateManager(t),
+			}
+
+			export := &Export{ID: 123, ProjectID: "12345"}
+			require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+			assert.Equal(t, tt.want, sink.LogRecordCount())
+		})
+	}
+}
+
+func TestFiltered(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.IncludeDismissed = false
+	receiver := &vulnerabilityReceiver{cfg: cfg}
+
+	// API records carry a state instead of a status
+	dismissed := flattenJSONRecord(map[string]interface{}{"title": "Weak hash", "severity": "low", "state": "dismissed"})
+	assert.True(t, receiver.filtered(dismissed))
+	confirmed := flattenJSONRecord(map[string]interface{}{"title": "SQL injection", "severity": "high", "state": "confirmed"})
+	assert.False(t, receiver.filtered(confirmed))
+
+	cfg.UnknownSeverity.Action = unknownSeverityDrop
+	unknown := flattenJSONRecord(map[string]interface{}{"title": "Old library", "severity": "unknown", "state": "detected"})
+	assert.True(t, receiver.filtered(unknown))
+}
@@ -0,0 +1,344 @@
+// Package gitlabvulnreceivertest provides a fake GitLab serving the API used by
+// the GitLab vulnerability receiver, so collector distributions can run
+// integration tests of the receiver without a GitLab instance.
+package gitlabvulnreceivertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Export statuses reported by the fake
+const (
+	StatusCreated  = "created"
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+	StatusFailed   = "failed"
+)
+
+// Server is a fake GitLab running on a local httptest server. Point the
+// receiver's base_url at its URL.
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// token is the PRIVATE-TOKEN required, any token is accepted when empty
+	token string
+	// exports holds the CSV served for the exports of a project or group
+	exports map[string]string
+	// pendingPolls is the number of status checks an export stays running for
+	pendingPolls int
+	failExports  bool
+	// rateLimit is the number of API requests allowed before answering 429, unlimited when 0
+	rateLimit   int
+	rateLimited int
+	retryAfter  time.Duration
+	// failures are the statuses of the next API requests to fail
+	failures []int
+
+	nextExportID int64
+	created      map[int64]*export
+	requests     []string
+}
+
+type export struct {
+	pathID  string
+	isGroup bool
+	// csv is the content of the export, the path's CSV when it was created
+	csv       string
+	createdAt time.Time
+	polls     int
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithToken makes the server require the token in the PRIVATE-TOKEN header or
+// as a bearer token, answering 401 to requests without it
+func WithToken(token string) Option {
+	return func(s *Server) { s.token = token }
+}
+
+// WithProjectExport sets the CSV served for the exports of a project
+func WithProjectExport(projectID, csv string) Option {
+	return func(s *Server) { s.exports[projectKey(projectID)] = csv }
+}
+
+// WithGroupExport sets the CSV served for the exports of a group
+func WithGroupExport(groupID, csv string) Option {
+	return func(s *Server) { s.exports[groupKey(groupID)] = csv }
+}
+
+// WithPendingPolls keeps exports running for the given number of status
+// checks before they finish. Exports finish on their first check by default.
+func WithPendingPolls(polls int) Option {
+	return func(s *Server) { s.pendingPolls = polls }
+}
+
+// WithFailedExports makes every export fail instead of finishing
+func WithFailedExports() Option {
+	return func(s *Server) { s.failExports = true }
+}
+
+// WithRateLimit allows the given number of API requests, answering 429 with a
+// Retry-After header to the following ones. Responses carry the
+// RateLimit-Remaining header.
+func WithRateLimit(limit int, retryAfter time.Duration) Option {
+	return func(s *Server) {
+		s.rateLimit = limit
+		s.retryAfter = retryAfter
+	}
+}
+
+// NewServer starts a fake GitLab. It's closed when the test ends.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	s := &Server{
+		exports:      make(map[string]string),
+		created:      make(map[int64]*export),
+		nextExportID: 1,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v4/security/projects/{id}/vulnerability_exports", s.handleCreateExport(false))
+	mux.HandleFunc("POST /api/v4/security/groups/{id}/vulnerability_exports", s.handleCreateExport(true))
+	mux.HandleFunc("GET /api/v4/security/vulnerability_exports/{exportID}", s.handleGetExport)
+	mux.HandleFunc("GET /api/v4/security/vulnerability_exports/{exportID}/download", s.handleDownload)
+	mux.HandleFunc("GET /api/v4/projects/{id}", s.handleGetProject)
+	mux.HandleFunc("GET /api/v4/groups/{id}", s.handleGetGroup)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// FailNext makes the next API requests fail with the given statuses, one
+// request per status
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// SetProjectExport replaces the CSV served for the next exports of a project
+func (s *Server) SetProjectExport(projectID, csv string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports[projectKey(projectID)] = csv
+}
+
+// SetGroupExport replaces the CSV served for the next exports of a group
+func (s *Server) SetGroupExport(groupID, csv string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports[groupKey(groupID)] = csv
+}
+
+// ExportsCreated returns the number of exports created
+func (s *Server) ExportsCreated() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.created)
+}
+
+// Requests returns the method and path of the requests received, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// middleware records requests and answers the ones failed by token checks,
+// rate limiting or injected failures
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+		if s.token != "" && r.Header.Get("PRIVATE-TOKEN") != s.token && r.Header.Get("Authorization") != "Bearer "+s.token {
+			s.mu.Unlock()
+			writeError(w, http.StatusUnauthorized, "401 Unauthorized")
+			return
+		}
+
+		if s.rateLimit > 0 {
+			remaining := max(s.rateLimit-s.rateLimited, 0)
+			if remaining == 0 {
+				s.mu.Unlock()
+				w.Header().Set("RateLimit-Remaining", "0")
+				w.Header().Set("Retry-After", strconv.Itoa(int(s.retryAfter.Seconds())))
+				writeError(w, http.StatusTooManyRequests, "Retry later")
+				return
+			}
+			s.rateLimited++
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining-1))
+		}
+
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			s.mu.Unlock()
+			writeError(w, status, http.StatusText(status))
+			return
+		}
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleCreateExport(isGroup bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathID := r.PathValue("id")
+		key := projectKey(pathID)
+		if isGroup {
+			key = groupKey(pathID)
+		}
+
+		s.mu.Lock()
+		csv, ok := s.exports[key]
+		if !ok {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "404 Not Found")
+			return
+		}
+		id := s.nextExportID
+		s.nextExportID++
+		e := &export{pathID: pathID, isGroup: isGroup, csv: csv, createdAt: time.Now().UTC()}
+		s.created[id] = e
+		body := s.exportJSON(id, e, StatusCreated)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusCreated, body)
+	}
+}
+
+func (s *Server) handleGetExport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, e, ok := s.lookupExport(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "404 Not Found")
+		return
+	}
+
+	status := StatusRunning
+	switch {
+	case e.polls < s.pendingPolls:
+		e.polls++
+	case s.failExports:
+		status = StatusFailed
+	default:
+		status = StatusFinished
+	}
+	writeJSON(w, http.StatusOK, s.exportJSON(id, e, status))
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	_, e, ok := s.lookupExport(r)
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "404 Not Found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(e.csv))
+}
+
+func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	_, ok := s.exports[projectKey(id)]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "404 Project Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                  numericID(id),
+		"name":                "project-" + id,
+		"path_with_namespace": "group/project-" + id,
+		"namespace":           map[string]string{"full_path": "group"},
+		"default_branch":      "main",
+		"visibility":          "private",
+	})
+}
+
+func (s *Server) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	_, ok := s.exports[groupKey(id)]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "404 Group Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": numericID(id), "full_path": "group-" + id})
+}
+
+// lookupExport returns the export a request is about, with the lock held
+func (s *Server) lookupExport(r *http.Request) (int64, *export, bool) {
+	id, err := strconv.ParseInt(r.PathValue("exportID"), 10, 64)
+	if err != nil {
+		return 0, nil, false
+	}
+	e, ok := s.created[id]
+	return id, e, ok
+}
+
+// exportJSON is the API representation of an export, with the lock held
+func (s *Server) exportJSON(id int64, e *export, status string) map[string]interface{} {
+	body := map[string]interface{}{
+		"id":         id,
+		"status":     status,
+		"format":     "csv",
+		"created_at": e.createdAt,
+		"_links": map[string]string{
+			"self":     fmt.Sprintf("%s/api/v4/security/vulnerability_exports/%d", s.URL, id),
+			"download": fmt.Sprintf("%s/api/v4/security/vulnerability_exports/%d/download", s.URL, id),
+		},
+	}
+	if e.isGroup {
+		body["group_id"] = numericID(e.pathID)
+	} else {
+		body["project_id"] = numericID(e.pathID)
+	}
+	if status == StatusFinished || status == StatusFailed {
+		body["started_at"] = e.createdAt
+		body["finished_at"] = time.Now().UTC()
+	}
+	return body
+}
+
+func projectKey(id string) string { return "project:" + id }
+
+func groupKey(id string) string { return "group:" + id }
+
+// numericID returns an ID as a number when it's one, like GitLab does
+func numericID(id string) interface{} {
+	if n, err := strconv.Atoi(id); err == nil {
+		return n
+	}
+	return id
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
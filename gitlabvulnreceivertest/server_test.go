@@ -0,0 +1,95 @@
+package gitlabvulnreceivertest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = "Vulnerability ID,Title,Severity\n1,SQL injection,high\n"
+
+func request(t *testing.T, method, url, token string) (*http.Response, string) {
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("PRIVATE-TOKEN", token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestServer_ExportLifecycle(t *testing.T) {
+	server := NewServer(t, WithToken("test-token"), WithProjectExport("12345", testCSV), WithPendingPolls(1))
+
+	resp, body := request(t, http.MethodPost, server.URL+"/api/v4/security/projects/12345/vulnerability_exports", "test-token")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var export struct {
+		ID        int64  `json:"id"`
+		ProjectID int    `json:"project_id"`
+		Status    string `json:"status"`
+		Links     struct {
+			Self     string `json:"self"`
+			Download string `json:"download"`
+		} `json:"_links"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &export))
+	assert.Equal(t, 12345, export.ProjectID)
+	assert.Equal(t, StatusCreated, export.Status)
+
+	_, body = request(t, http.MethodGet, export.Links.Self, "test-token")
+	assert.Contains(t, body, `"status":"running"`)
+	_, body = request(t, http.MethodGet, export.Links.Self, "test-token")
+	assert.Contains(t, body, `"status":"finished"`)
+
+	// The export keeps the CSV it was created with
+	server.SetProjectExport("12345", "Vulnerability ID\n")
+	resp, body = request(t, http.MethodGet, export.Links.Download, "test-token")
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	assert.Equal(t, testCSV, body)
+	assert.Equal(t, 1, server.ExportsCreated())
+
+	// Unknown projects aren't found
+	resp, _ = request(t, http.MethodPost, server.URL+"/api/v4/security/projects/999/vulnerability_exports", "test-token")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_FailedExports(t *testing.T) {
+	server := NewServer(t, WithGroupExport("67890", testCSV), WithFailedExports())
+
+	_, body := request(t, http.MethodPost, server.URL+"/api/v4/security/groups/67890/vulnerability_exports", "")
+	assert.Contains(t, body, `"group_id":67890`)
+	_, body = request(t, http.MethodGet, server.URL+"/api/v4/security/vulnerability_exports/1", "")
+	assert.Contains(t, body, `"status":"failed"`)
+}
+
+func TestServer_Errors(t *testing.T) {
+	server := NewServer(t, WithToken("test-token"), WithProjectExport("12345", testCSV), WithRateLimit(3, time.Second))
+	projectURL := server.URL + "/api/v4/projects/12345"
+
+	resp, _ := request(t, http.MethodGet, projectURL, "wrong-token")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	server.FailNext(http.StatusBadGateway)
+	resp, _ = request(t, http.MethodGet, projectURL, "test-token")
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("RateLimit-Remaining"))
+
+	resp, body := request(t, http.MethodGet, projectURL, "test-token")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"id":12345`)
+	request(t, http.MethodGet, projectURL, "test-token")
+
+	resp, _ = request(t, http.MethodGet, projectURL, "test-token")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	assert.Equal(t, "GET /api/v4/projects/12345", server.Requests()[0])
+}
@@ -0,0 +1,46 @@
+package gitlabvulnreceiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sync"
+)
+
+// fieldHasher hashes fields without joining them, copying them to the hash
+// through a fixed scratch buffer so wide rows don't allocate
+type fieldHasher struct {
+	hash    hash.Hash
+	scratch [512]byte
+	sum     [sha256.Size]byte
+}
+
+var fieldHashers = sync.Pool{
+	New: func() any {
+		return &fieldHasher{hash: sha256.New()}
+	},
+}
+
+func (h *fieldHasher) writeString(s string) {
+	for len(s) > 0 {
+		n := copy(h.scratch[:], s)
+		h.hash.Write(h.scratch[:n])
+		s = s[n:]
+	}
+}
+
+// generateHash creates a hash of a slice of strings joined by pipes
+func generateHash(ids []string) string {
+	h := fieldHashers.Get().(*fieldHasher)
+	defer fieldHashers.Put(h)
+
+	h.hash.Reset()
+	for i, id := range ids {
+		if i > 0 {
+			h.scratch[0] = '|'
+			h.hash.Write(h.scratch[:1])
+		}
+		h.writeString(id)
+	}
+	return hex.EncodeToString(h.hash.Sum(h.sum[:0]))
+}
@@ -0,0 +1,31 @@
+package gitlabvulnreceiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateHash(t *testing.T) {
+	for _, fields := range [][]string{
+		nil,
+		{""},
+		{"Test Vuln", "High"},
+		{"sast", strings.Repeat("long details ", 200), "", "main.go"},
+	} {
+		sum := sha256.Sum256([]byte(strings.Join(fields, "|")))
+		assert.Equal(t, hex.EncodeToString(sum[:]), generateHash(fields))
+	}
+}
+
+func BenchmarkGenerateHash(b *testing.B) {
+	fields := []string{"sast", "High", strings.Repeat("long details ", 5000), "main.go", "CVE-2024-1"}
+
+	b.ReportAllocs()
+	for range b.N {
+		generateHash(fields)
+	}
+}
@@ -0,0 +1,38 @@
+package gitlabvulnreceiver
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.uber.org/zap"
+)
+
+// reportPollHealth reports the receiver's health to the host after a poll, for
+// extensions like healthcheckv2 to surface. Authentication errors make the
+// receiver unhealthy until restarted, health.failure_threshold consecutive polls
+// with errors degrade it, and a poll without errors makes it healthy again.
+func (r *vulnerabilityReceiver) reportPollHealth(errs []error) {
+	if r.host == nil || r.unhealthy {
+		return
+	}
+
+	for _, err := range errs {
+		if isUnauthorized(err) {
+			r.unhealthy = true
+			r.logger.Error("GitLab rejected the token, reporting the receiver unhealthy", zap.Error(err))
+			componentstatus.ReportStatus(r.host, componentstatus.NewPermanentErrorEvent(err))
+			return
+		}
+	}
+
+	if len(errs) == 0 {
+		r.failedPolls = 0
+		componentstatus.ReportStatus(r.host, componentstatus.NewEvent(componentstatus.StatusOK))
+		return
+	}
+
+	r.failedPolls++
+	if threshold := r.cfg.Health.FailureThreshold; threshold > 0 && r.failedPolls >= threshold {
+		componentstatus.ReportStatus(r.host, componentstatus.NewRecoverableErrorEvent(errors.Join(errs...)))
+	}
+}
@@ -0,0 +1,61 @@
+package gitlabvulnreceiver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+// statusHost records the statuses reported by a component
+type statusHost struct {
+	component.Host
+	statuses []componentstatus.Status
+}
+
+func (h *statusHost) Report(event *componentstatus.Event) {
+	h.statuses = append(h.statuses, event.Status())
+}
+
+func TestReportPollHealth(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Health.FailureThreshold = 2
+	host := &statusHost{}
+	receiver := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop(), host: host}
+
+	receiver.reportPollHealth(nil)
+	assert.Equal(t, []componentstatus.Status{componentstatus.StatusOK}, host.statuses)
+
+	// Degraded once failure_threshold polls in a row had errors
+	failure := fmt.Errorf("failed to create export: %w", &gitlab.APIError{StatusCode: 500})
+	receiver.reportPollHealth([]error{failure})
+	assert.Len(t, host.statuses, 1)
+	receiver.reportPollHealth([]error{failure})
+	assert.Equal(t, componentstatus.StatusRecoverableError, host.statuses[1])
+
+	// and healthy again after a clean poll
+	receiver.reportPollHealth(nil)
+	assert.Equal(t, componentstatus.StatusOK, host.statuses[2])
+
+	// Authentication errors are reported right away and for good
+	receiver.reportPollHealth([]error{fmt.Errorf("failed to validate project: %w", &gitlab.APIError{StatusCode: 401})})
+	assert.Equal(t, componentstatus.StatusPermanentError, host.statuses[3])
+	receiver.reportPollHealth(nil)
+	assert.Len(t, host.statuses, 4)
+}
+
+func TestIsUnauthorized(t *testing.T) {
+	assert.True(t, isUnauthorized(fmt.Errorf("failed to list vulnerabilities: %w", &gitlab.APIError{StatusCode: 401})))
+	assert.True(t, isUnauthorized(fmt.Errorf("failed to create export: %w", &gitlab.APIError{StatusCode: 401, Body: "{}"})))
+	// The status is only read from an APIError, not from the message
+	assert.False(t, isUnauthorized(errors.New("failed to create export, status: 401, body: {}")))
+	assert.False(t, isUnauthorized(&gitlab.APIError{StatusCode: 403}))
+	assert.False(t, isUnauthorized(&gitlab.APIError{StatusCode: 500}))
+	assert.False(t, isUnauthorized(nil))
+}
@@ -0,0 +1,206 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// hasSyncBaseline reports whether incremental syncs can continue from a cursor
+func (r *vulnerabilityReceiver) hasSyncBaseline(pathID string) bool {
+	if r.cfg.SyncMode != syncModeIncremental || r.stateManager == nil {
+		return false
+	}
+	_, ok := r.stateManager.GetSyncCursor(pathID)
+	return ok
+}
+
+// fullExportDue reports whether a path synced incrementally needs a complete
+// export: one interrupted to resume, or a reconciliation once
+// full_export_interval has passed since the last export was processed
+func (r *vulnerabilityReceiver) fullExportDue(pathID string) bool {
+	if _, ok := r.stateManager.GetCheckpoint(pathID); ok {
+		return true
+	}
+	if r.cfg.FullExportInterval <= 0 {
+		return false
+	}
+	r.exportMutex.RLock()
+	last, ok := r.lastExportTime[pathID]
+	r.exportMutex.RUnlock()
+	return !ok || time.Since(last) >= r.cfg.FullExportInterval
+}
+
+// setSyncBaseline starts incremental syncs from when the baseline export was created
+func (r *vulnerabilityReceiver) setSyncBaseline(pathID string, export *Export) error {
+	cursor := export.CreatedAt
+	if cursor.IsZero() {
+		cursor = time.Now()
+	}
+	if err := r.stateManager.SetSyncCursor(pathID, cursor.UTC(), nil); err != nil {
+		return fmt.Errorf("failed to save sync cursor: %w", err)
+	}
+	return nil
+}
+
+// syncVulnerabilityUpdates emits the vulnerabilities of a project updated since
+// the sync cursor, then moves the cursor to the latest update seen. The cursor
+// only moves once everything was delivered, so a failed sync is retried.
+// Only vulnerabilities updated since the cursor are requested, in api_order,
+// most recently updated first by default, in which case paging also stops at
+// the first one older than the cursor once the pages seen so far confirm the
+// order. Other orders read every page of updates.
+func (r *vulnerabilityReceiver) syncVulnerabilityUpdates(ctx context.Context, projectID string) error {
+	since, _ := r.stateManager.GetSyncCursor(projectID)
+
+	// Updates sharing the cursor's timestamp may arrive after the cursor was
+	// saved, so the cursor is inclusive and the IDs already synced at it are skipped
+	latest, latestIDs := since, r.stateManager.SyncCursorIDs(projectID)
+	syncedAtCursor := make(map[string]bool)
+	for _, id := range latestIDs {
+		syncedAtCursor[id] = true
+	}
+
+	export := &Export{ProjectID: projectID, Format: "api"}
+	batchSize := r.batchSize()
+	batch := newLogBatch()
+	issueLinks := newIssueLinkCache()
+	findings := newFindingTracker(projectID)
+	// batched are the seen items of the updates in the batch, recorded if the
+	// pipeline rejects it
+	var batched []string
+	flush := func() error {
+		_, err := r.deliverBatch(ctx, projectID, batch, issueLinks)
+		if consumererror.IsPermanent(err) {
+			rejected := state.RejectedRows{Keys: batched, Error: err.Error()}
+			if err := r.stateManager.AddRejectedRows(projectID, rejected); err != nil {
+				return fmt.Errorf("failed to save rejected rows: %w", err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to consume logs: %w", err)
+		}
+		batched = nil
+		return r.saveFindings(findings)
+	}
+	var updates int
+	var moved bool
+	order := newUpdateOrder()
+
+pages:
+	for page := 1; page != 0; {
+		vulnerabilities, nextPage, err := r.client.ListVulnerabilities(ctx, projectID, since, page)
+		if err != nil {
+			return err
+		}
+
+		for _, vulnerability := range vulnerabilities {
+			updatedAt, ok := vulnerabilityUpdatedAt(vulnerability)
+			if !ok {
+				continue
+			}
+			descending := order.add(updatedAt)
+			if updatedAt.Before(since) {
+				if descending {
+					break pages
+				}
+				continue
+			}
+			id := fmt.Sprint(vulnerability["id"])
+			if updatedAt.Equal(since) && syncedAtCursor[id] {
+				continue
+			}
+			switch {
+			case updatedAt.After(latest):
+				latest, latestIDs = updatedAt, []string{id}
+				moved = true
+			case updatedAt.Equal(latest):
+				latestIDs = append(latestIDs, id)
+				moved = true
+			}
+
+			record := flattenJSONRecord(vulnerability)
+			key := r.stateManager.ComputeKey(map[string]string{"ID": id})
+			event := r.findingEvent(key, record)
+			if closure, closed := r.trackFinding(findings, key, record, export); closed {
+				batch.add(findProjectPath(record.header, record.values), closure)
+			}
+			if r.filtered(record) {
+				continue
+			}
+			lr := r.batchRecord(batch, findProjectPath(record.header, record.values), record, export)
+			setEventName(lr, event)
+			r.enrichRecord(lr)
+			batched = append(batched, state.ProcessedKeyPrefix+id)
+			updates++
+
+			if batch.records >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		page = nextPage
+	}
+
+	if batch.records > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	r.logger.Debug("Synced vulnerability updates",
+		zap.String("projectID", projectID),
+		zap.Time("since", since),
+		zap.Int("updates", updates))
+
+	// The cursor moves past filtered updates too, or they'd be read every poll
+	if !moved {
+		return nil
+	}
+	if err := r.stateManager.SetSyncCursor(projectID, latest, latestIDs); err != nil {
+		return fmt.Errorf("failed to save sync cursor: %w", err)
+	}
+	return nil
+}
+
+// vulnerabilityUpdatedAt returns when a vulnerability from the API last changed
+func vulnerabilityUpdatedAt(vulnerability map[string]interface{}) (time.Time, bool) {
+	value, ok := vulnerability["updated_at"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// updateOrder tells from the update times of the vulnerabilities listed so far
+// whether they're listed most recently updated first, whatever order was
+// requested, so paging can stop at the first one older than a cursor
+type updateOrder struct {
+	previous   time.Time
+	ordered    bool
+	descending bool
+}
+
+func newUpdateOrder() *updateOrder {
+	return &updateOrder{ordered: true}
+}
+
+// add records the update time of the next vulnerability listed and reports
+// whether the updates so far confirm a most recently updated first order
+func (o *updateOrder) add(updatedAt time.Time) bool {
+	if !o.previous.IsZero() {
+		o.ordered = o.ordered && !updatedAt.After(o.previous)
+		o.descending = o.descending || updatedAt.Before(o.previous)
+	}
+	o.previous = updatedAt
+	return o.ordered && o.descending
+}
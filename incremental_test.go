@@ -0,0 +1,352 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+func TestIncrementalSync(t *testing.T) {
+	baselineAt := time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC)
+	exportsCreated := 0
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			exportsCreated++
+			return &Export{ID: 123, ProjectID: projectID, CreatedAt: baselineAt}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished, CreatedAt: baselineAt}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\nfirst,High\nsecond,Low\n"))}, nil
+		},
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			switch page {
+			case 1:
+				return []map[string]interface{}{
+					{"id": json.Number("1"), "title": "first", "updated_at": "2024-02-11T00:00:00Z"},
+					{"id": json.Number("2"), "title": "second", "updated_at": "2024-02-13T00:00:00Z"},
+				}, 2, nil
+			default:
+				return []map[string]interface{}{
+					{"id": json.Number("3"), "title": "third", "updated_at": "2024-02-14T00:00:00Z", "project": map[string]interface{}{"full_path": "group/project"}},
+				}, 0, nil
+			}
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SyncMode = syncModeIncremental
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	// The first cycle ingests the whole export as baseline
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 2, sink.LogRecordCount())
+	cursor, ok := receiver.stateManager.GetSyncCursor("12345")
+	require.True(t, ok)
+	assert.Equal(t, baselineAt, cursor)
+
+	// Later cycles only emit vulnerabilities updated since the cursor
+	sink.Reset()
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 1, exportsCreated)
+	require.Equal(t, 2, sink.LogRecordCount())
+
+	var titles []string
+	for _, logs := range sink.AllLogs() {
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			rl := logs.ResourceLogs().At(i)
+			_, hasExportID := rl.ScopeLogs().At(0).Scope().Attributes().Get("gitlab.export.id")
+			assert.False(t, hasExportID)
+
+			records := rl.ScopeLogs().At(0).LogRecords()
+			for j := 0; j < records.Len(); j++ {
+				title, _ := records.At(j).Attributes().Get("vulnerability.title")
+				titles = append(titles, title.Str())
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"second", "third"}, titles)
+
+	cursor, _ = receiver.stateManager.GetSyncCursor("12345")
+	assert.Equal(t, time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), cursor)
+
+	// Nothing changed since, so nothing is emitted
+	sink.Reset()
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 0, sink.LogRecordCount())
+}
+
+func TestIncrementalSync_StopsAtCursor(t *testing.T) {
+	var pages []int
+	var updates []map[string]interface{}
+	mockClient := &mockGitLabClient{
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			pages = append(pages, page)
+			if page == 1 {
+				return updates, 2, nil
+			}
+			return []map[string]interface{}{
+				{"id": json.Number("1"), "title": "first", "updated_at": "2024-02-01T00:00:00Z"},
+			}, 0, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SyncMode = syncModeIncremental
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+	cursor := time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, receiver.stateManager.SetSyncCursor("12345", cursor, nil))
+
+	// Most recent first: paging stops once past the cursor
+	updates = []map[string]interface{}{
+		{"id": json.Number("4"), "title": "fourth", "updated_at": "2024-02-12T00:00:00Z"},
+		{"id": json.Number("3"), "title": "third", "updated_at": "2024-02-11T00:00:00Z"},
+		{"id": json.Number("2"), "title": "second", "updated_at": "2024-02-10T00:00:00Z"},
+	}
+	require.NoError(t, receiver.syncVulnerabilityUpdates(context.Background(), "12345"))
+	assert.Equal(t, []int{1}, pages)
+	assert.Equal(t, 1, sink.LogRecordCount())
+	assert.Equal(t, []string{"4"}, receiver.stateManager.SyncCursorIDs("12345"))
+
+	// A later update with the cursor's timestamp is still emitted, the synced one isn't
+	sink.Reset()
+	pages = nil
+	updates = []map[string]interface{}{
+		{"id": json.Number("5"), "title": "fifth", "updated_at": "2024-02-12T00:00:00Z"},
+		{"id": json.Number("4"), "title": "fourth", "updated_at": "2024-02-12T00:00:00Z"},
+		{"id": json.Number("3"), "title": "third", "updated_at": "2024-02-11T00:00:00Z"},
+	}
+	require.NoError(t, receiver.syncVulnerabilityUpdates(context.Background(), "12345"))
+	require.Equal(t, 1, sink.LogRecordCount())
+	title, _ := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("vulnerability.title")
+	assert.Equal(t, "fifth", title.Str())
+	assert.ElementsMatch(t, []string{"4", "5"}, receiver.stateManager.SyncCursorIDs("12345"))
+
+	// Without a confirmed order every page is read
+	sink.Reset()
+	pages = nil
+	updates = []map[string]interface{}{
+		{"id": json.Number("3"), "title": "third", "updated_at": "2024-02-11T00:00:00Z"},
+		{"id": json.Number("6"), "title": "sixth", "updated_at": "2024-02-13T00:00:00Z"},
+	}
+	require.NoError(t, receiver.syncVulnerabilityUpdates(context.Background(), "12345"))
+	assert.Equal(t, []int{1, 2}, pages)
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestIncrementalSync_MovesCursorPastUndelivered(t *testing.T) {
+	var updates []map[string]interface{}
+	mockClient := &mockGitLabClient{
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			return updates, 0, nil
+		},
+	}
+	calls := 0
+	next, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		calls++
+		return consumererror.NewPermanent(fmt.Errorf("rejected"))
+	})
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SyncMode = syncModeIncremental
+	cfg.IncludeDismissed = false
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     next,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+	require.NoError(t, receiver.stateManager.SetSyncCursor("12345", time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC), nil))
+
+	// Filtered updates aren't emitted, but aren't read again either
+	updates = []map[string]interface{}{
+		{"id": json.Number("1"), "title": "first", "state": "dismissed", "updated_at": "2024-02-13T00:00:00Z"},
+	}
+	require.NoError(t, receiver.syncVulnerabilityUpdates(context.Background(), "12345"))
+	assert.Equal(t, 0, calls)
+	cursor, _ := receiver.stateManager.GetSyncCursor("12345")
+	assert.Equal(t, time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC), cursor)
+
+	// Updates the pipeline permanently rejects are recorded and the cursor moves on
+	updates = []map[string]interface{}{
+		{"id": json.Number("2"), "title": "second", "updated_at": "2024-02-14T00:00:00Z"},
+	}
+	require.NoError(t, receiver.syncVulnerabilityUpdates(context.Background(), "12345"))
+	assert.Equal(t, 1, calls)
+	cursor, _ = receiver.stateManager.GetSyncCursor("12345")
+	assert.Equal(t, time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), cursor)
+	rejected := receiver.stateManager.GetRejectedRows("12345")
+	require.Len(t, rejected, 1)
+	assert.Equal(t, []string{state.ProcessedKeyPrefix + "2"}, rejected[0].Keys)
+	assert.False(t, receiver.stateManager.IsSeen(state.ProcessedKeyPrefix+"2"))
+}
+
+func TestCheckExports_IncrementalSkipsCooldownSources(t *testing.T) {
+	artifactRequests := 0
+	mockClient := &mockGitLabClient{
+		getLatestJobFunc: func(ctx context.Context, projectID, ref, name string) (*Job, error) {
+			artifactRequests++
+			return nil, fmt.Errorf("forbidden")
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SyncMode = syncModeIncremental
+	cfg.SBOM.Job = "dependency-scanning"
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          new(consumertest.LogsSink),
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    map[string]time.Time{"12345": time.Now()},
+		exportsInProgress: make(map[string]bool),
+	}
+	require.NoError(t, receiver.stateManager.SetSyncCursor("12345", time.Now(), nil))
+
+	// The incremental sync runs, the SBOM waits for the export cooldown
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	assert.Zero(t, artifactRequests)
+	assertNoPathErrors(t, receiver)
+}
+
+func TestIncrementalSync_FullExportInterval(t *testing.T) {
+	var exportsCreated, listed int
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			exportsCreated++
+			return &Export{ID: int64(exportsCreated), ProjectID: projectID, CreatedAt: time.Now()}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished, CreatedAt: time.Now()}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\nfirst,High\n"))}, nil
+		},
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			listed++
+			return nil, 0, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SyncMode = syncModeIncremental
+	cfg.FullExportInterval = time.Hour
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          new(consumertest.LogsSink),
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	// Baseline, then incremental checks until the interval has passed
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	baseline, ok := receiver.stateManager.GetSyncCursor("12345")
+	require.True(t, ok)
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 1, exportsCreated)
+	assert.Equal(t, 1, listed)
+
+	// A day later a full export reconciles the sync, keeping its cursor
+	receiver.lastExportTime["12345"] = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 2, exportsCreated)
+	assert.Equal(t, 1, listed)
+	assert.True(t, receiver.stateManager.IsExportProcessed(2))
+	cursor, _ := receiver.stateManager.GetSyncCursor("12345")
+	assert.Equal(t, baseline, cursor)
+
+	// And incremental checks resume
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+	assert.Equal(t, 2, exportsCreated)
+	assert.Equal(t, 2, listed)
+}
+
+func TestIncrementalSync_OldestFirst(t *testing.T) {
+	var pages int
+	mockClient := &mockGitLabClient{
+		listVulnerabilitiesFunc: func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+			// Only the updates since the cursor are requested
+			assert.Equal(t, time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC), updatedAfter)
+			pages++
+			switch page {
+			case 1:
+				return []map[string]interface{}{
+					{"id": json.Number("1"), "title": "first", "updated_at": "2024-02-11T00:00:00Z"},
+					{"id": json.Number("2"), "title": "second", "updated_at": "2024-02-13T00:00:00Z"},
+				}, 2, nil
+			default:
+				return []map[string]interface{}{
+					{"id": json.Number("3"), "title": "third", "updated_at": "2024-02-14T00:00:00Z"},
+				}, 0, nil
+			}
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SyncMode = syncModeIncremental
+	cfg.APIOrder.Sort = sortAsc
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		exportsInProgress: make(map[string]bool),
+	}
+	require.NoError(t, receiver.stateManager.SetSyncCursor("12345", time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC), nil))
+
+	// Older vulnerabilities come first, so every page of updates is read
+	require.NoError(t, receiver.syncVulnerabilityUpdates(context.Background(), "12345"))
+	assert.Equal(t, 2, pages)
+
+	var titles []string
+	for _, logs := range sink.AllLogs() {
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		for i := 0; i < records.Len(); i++ {
+			title, _ := records.At(i).Attributes().Get("vulnerability.title")
+			titles = append(titles, title.Str())
+		}
+	}
+	assert.Equal(t, []string{"second", "third"}, titles)
+
+	cursor, _ := receiver.stateManager.GetSyncCursor("12345")
+	assert.Equal(t, time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), cursor)
+}
@@ -0,0 +1,104 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configauth"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/extension/auth"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/gitlabvulnreceivertest"
+)
+
+func TestReceiver_FakeGitLab(t *testing.T) {
+	server := gitlabvulnreceivertest.NewServer(t,
+		gitlabvulnreceivertest.WithToken("test-token"),
+		gitlabvulnreceivertest.WithProjectExport("12345", "Vulnerability ID,Title,Severity\n1,SQL injection,high\n2,XSS,medium\n"),
+	)
+
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Token = "test-token"
+	cfg.BaseURL = server.URL
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	cfg.InitialDelay = time.Millisecond
+	require.NoError(t, cfg.Validate())
+
+	sink := new(consumertest.LogsSink)
+	receiver, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(), cfg, sink)
+	require.NoError(t, err)
+	require.NoError(t, receiver.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() { require.NoError(t, receiver.Shutdown(context.Background())) }()
+
+	assert.Eventually(t, func() bool { return sink.LogRecordCount() == 2 }, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, server.ExportsCreated())
+}
+
+// extensionHost is a host providing extensions
+type extensionHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *extensionHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+// bearerRoundTripper authenticates requests with a bearer token like an auth extension
+type bearerRoundTripper struct {
+	next  http.RoundTripper
+	token string
+}
+
+func (rt *bearerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+rt.token)
+	return rt.next.RoundTrip(req)
+}
+
+func TestReceiver_AuthExtension(t *testing.T) {
+	server := gitlabvulnreceivertest.NewServer(t,
+		gitlabvulnreceivertest.WithToken("extension-token"),
+		gitlabvulnreceivertest.WithProjectExport("12345", "Vulnerability ID,Title,Severity\n1,SQL injection,high\n"),
+	)
+
+	authID := component.MustNewID("bearertokenauth")
+	host := &extensionHost{
+		Host: componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{
+			authID: auth.NewClient(auth.WithClientRoundTripper(func(base http.RoundTripper) (http.RoundTripper, error) {
+				return &bearerRoundTripper{next: base, token: "extension-token"}, nil
+			})),
+		},
+	}
+
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Auth = &configauth.Authentication{AuthenticatorID: authID}
+	cfg.BaseURL = server.URL
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	cfg.InitialDelay = time.Millisecond
+	require.NoError(t, cfg.Validate())
+
+	sink := new(consumertest.LogsSink)
+	receiver, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(), cfg, sink)
+	require.NoError(t, err)
+	require.NoError(t, receiver.Start(context.Background(), host))
+	defer func() { require.NoError(t, receiver.Shutdown(context.Background())) }()
+
+	assert.Eventually(t, func() bool { return sink.LogRecordCount() == 1 }, 10*time.Second, 10*time.Millisecond)
+
+	// An unknown authenticator fails the start
+	cfg.Auth = &configauth.Authentication{AuthenticatorID: component.MustNewID("oauth2clientauth")}
+	other, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(), cfg, sink)
+	require.NoError(t, err)
+	require.ErrorContains(t, other.Start(context.Background(), host), "failed to create GitLab HTTP client")
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package state
+
+import "os"
+
+// lockFile is a no-op on platforms without file locking
+func lockFile(*os.File) error {
+	return nil
+}
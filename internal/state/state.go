@@ -1,11 +1,17 @@
 package state
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,32 +21,274 @@ import (
 type VulnerabilityState struct {
 	LastSeenHash string    `json:"last_seen_hash"`
 	LastScanTime time.Time `json:"last_scan_time"`
-	ProcessedIDs []string  `json:"processed_ids"`
+	// ProcessedIDs is only read from version 1 files
+	ProcessedIDs []string `json:"processed_ids,omitempty"`
+	// Status is the last status seen of a tracked finding, PathID the path
+	// it's exported from and ProjectPath the project it's in for group paths
+	Status      string `json:"status,omitempty"`
+	PathID      string `json:"path_id,omitempty"`
+	ProjectPath string `json:"project_path,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// ExportCheckpoint records how far processing of an export has progressed
+type ExportCheckpoint struct {
+	ExportID      int64     `json:"export_id"`
+	RowsProcessed int64     `json:"rows_processed"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ProcessedExport records an export whose records were all emitted
+type ProcessedExport struct {
+	PathID      string    `json:"path_id"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// AuditCursor records how far the state transition audit trail of a path has
+// been emitted and the hash the next transition is chained to
+type AuditCursor struct {
+	Since    time.Time `json:"since"`
+	LastHash string    `json:"last_hash,omitempty"`
+}
+
+// RejectedRows records findings of a path the pipeline permanently rejected.
+// Their keys aren't marked seen, so they're emitted again by the next export
+// once the pipeline accepts them.
+type RejectedRows struct {
+	// ExportID and the 1-based FirstRow and LastRow locate the rows in the
+	// export, unset for findings synced from the vulnerabilities API
+	ExportID   int64     `json:"export_id,omitempty"`
+	FirstRow   int64     `json:"first_row,omitempty"`
+	LastRow    int64     `json:"last_row,omitempty"`
+	Keys       []string  `json:"keys"`
+	Error      string    `json:"error"`
+	RejectedAt time.Time `json:"rejected_at"`
+}
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("lock held by another process")
+
+// ProcessedKeyPrefix prefixes the seen items of emitted vulnerabilities
+const ProcessedKeyPrefix = "processed:"
+
+// RemediatedKeyPrefix prefixes the seen items of resolved vulnerabilities whose
+// time to remediate was recorded
+const RemediatedKeyPrefix = "remediated:"
+
+// Changes deferred by periodic flushing before the state is written right away
+const maxPendingSaves = 1000
+
+// Rejected batches kept per path, the oldest being dropped first
+const maxRejectedRows = 100
+
+// How long processed exports are remembered
+const processedExportRetention = 30 * 24 * time.Hour
+
+// stateVersion is the layout version of the state files written
+const stateVersion = 3
+
+// How long items seen before version 3 files are remembered, the longest
+// retention they may have been marked with
+const legacySeenRetention = 90 * 24 * time.Hour
+
+// stateMigrations upgrade a parsed state file from the version of their key to
+// the next one, given the raw file
+var stateMigrations = map[int]func(data []byte, persisted *persistedState) error{
+	0: migrateStateV0,
+	1: migrateStateV1,
+	2: migrateStateV2,
+}
+
+// migrateStateV0 reads version 0 files that stored the vulnerability map at the
+// top level instead of under states
+func migrateStateV0(data []byte, persisted *persistedState) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if _, ok := fields["states"]; ok {
+		return nil
+	}
+	*persisted = persistedState{}
+	return json.Unmarshal(data, &persisted.States)
+}
+
+// migrateStateV1 moves the IDs of processed vulnerabilities, kept in lists that
+// grew without bound, to seen items that expire
+func migrateStateV1(_ []byte, persisted *persistedState) error {
+	now := time.Now()
+	for key, state := range persisted.States {
+		if len(state.ProcessedIDs) == 0 {
+			continue
+		}
+		if persisted.Seen == nil {
+			persisted.Seen = make(map[string]time.Time)
+		}
+		for _, id := range state.ProcessedIDs {
+			if id != "" {
+				persisted.Seen[ProcessedKeyPrefix+id] = now
+			}
+		}
+		state.ProcessedIDs = nil
+		// Entries only holding processed IDs carry nothing else
+		if state.Status == "" && state.PathID == "" {
+			delete(persisted.States, key)
+			continue
+		}
+		persisted.States[key] = state
+	}
+	return nil
+}
+
+// migrateStateV2 turns the times items were seen into the times they expire.
+// Items of every retention shared one map, pruned with the retention of
+// whichever caller marked items last.
+func migrateStateV2(_ []byte, persisted *persistedState) error {
+	if len(persisted.Seen) == 0 {
+		return nil
+	}
+	persisted.SeenUntil = make(map[string]time.Time, len(persisted.Seen))
+	for key, seenAt := range persisted.Seen {
+		persisted.SeenUntil[key] = seenAt.Add(legacySeenRetention)
+	}
+	persisted.Seen = nil
+	return nil
+}
+
+// persistedState is the on-disk layout of the state file
+type persistedState struct {
+	Version          int                           `json:"version"`
+	States           map[string]VulnerabilityState `json:"states"`
+	Checkpoints      map[string]ExportCheckpoint   `json:"checkpoints,omitempty"`
+	ProcessedExports map[int64]ProcessedExport     `json:"processed_exports,omitempty"`
+	SyncCursors      map[string]time.Time          `json:"sync_cursors,omitempty"`
+	SyncCursorIDs    map[string][]string           `json:"sync_cursor_ids,omitempty"`
+	// Seen, when items were seen, is only read from version 2 files
+	Seen         map[string]time.Time      `json:"seen,omitempty"`
+	SeenUntil    map[string]time.Time      `json:"seen_until,omitempty"`
+	AuditCursors map[string]AuditCursor    `json:"audit_cursors,omitempty"`
+	Columns      map[string][]string       `json:"columns,omitempty"`
+	RejectedRows map[string][]RejectedRows `json:"rejected_rows,omitempty"`
 }
 
 // StateManager handles persistence and retrieval of vulnerability states
 type StateManager struct {
-	states    map[string]VulnerabilityState
-	statePath string
-	mu        sync.RWMutex
+	states           map[string]VulnerabilityState
+	checkpoints      map[string]ExportCheckpoint
+	processedExports map[int64]ProcessedExport
+	syncCursors      map[string]time.Time
+	syncCursorIDs    map[string][]string
+	// seen holds when each seen item expires
+	seen         map[string]time.Time
+	auditCursors map[string]AuditCursor
+	columns      map[string][]string
+	rejectedRows map[string][]RejectedRows
+	statePath    string
+	mu           sync.RWMutex
+
+	// With a flush interval, changes are written by a background flush or once
+	// maxPendingSaves accumulate instead of one write per change
+	flushInterval time.Duration
+	pending       int
+	stopFlushing  chan struct{}
+	flushingDone  chan struct{}
+	// writeMu serializes writes of the state file
+	writeMu sync.Mutex
+	// fileSize is the size of the state file last read or written
+	fileSize int64
+	// onWrite is called with the duration of every write of the state file
+	onWrite func(time.Duration)
+	// lock holds the lock file while the state file is in use
+	lock *os.File
+	// compress writes the state file gzip-compressed
+	compress bool
+}
+
+// Option configures a StateManager
+type Option func(*StateManager)
+
+// WithCompression writes the state file gzip-compressed. Compressed and plain
+// files are both read, so it can be turned on or off for an existing file.
+func WithCompression() Option {
+	return func(sm *StateManager) {
+		sm.compress = true
+	}
 }
 
 // NewStateManager creates a new state manager
-func NewStateManager(statePath string) (*StateManager, error) {
+func NewStateManager(statePath string, opts ...Option) (*StateManager, error) {
 	sm := &StateManager{
-		states:    make(map[string]VulnerabilityState),
-		statePath: statePath,
+		states:           make(map[string]VulnerabilityState),
+		checkpoints:      make(map[string]ExportCheckpoint),
+		processedExports: make(map[int64]ProcessedExport),
+		syncCursors:      make(map[string]time.Time),
+		syncCursorIDs:    make(map[string][]string),
+		seen:             make(map[string]time.Time),
+		auditCursors:     make(map[string]AuditCursor),
+		columns:          make(map[string][]string),
+		rejectedRows:     make(map[string][]RejectedRows),
+		statePath:        statePath,
+	}
+	for _, opt := range opts {
+		opt(sm)
 	}
 
+	if err := sm.acquireLock(); err != nil {
+		return nil, err
+	}
 	if err := sm.load(); err != nil {
+		sm.releaseLock()
 		return nil, err
 	}
 
 	return sm, nil
 }
 
-// ComputeKey generates a stable key for a vulnerability
+// acquireLock locks the state file for this process, so that two processes
+// configured with the same file fail instead of overwriting each other's state
+func (sm *StateManager) acquireLock() error {
+	if sm.statePath == "" {
+		return nil
+	}
+
+	lockPath := sm.statePath + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open state lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, errLocked) {
+			return fmt.Errorf("state file %s is in use by another process (%s is locked)", sm.statePath, lockPath)
+		}
+		return fmt.Errorf("failed to lock state file: %w", err)
+	}
+	sm.lock = f
+	return nil
+}
+
+// releaseLock unlocks the state file
+func (sm *StateManager) releaseLock() {
+	if sm.lock != nil {
+		sm.lock.Close()
+		sm.lock = nil
+	}
+}
+
+// IDColumns are the export columns carrying GitLab's own vulnerability
+// identifier, in order of preference
+var IDColumns = []string{"Vulnerability ID", "UUID", "ID"}
+
+// ComputeKey generates a stable key for a vulnerability. GitLab's identifier is
+// used when the record has one, as it survives renames and tells apart findings
+// of the same CVE in the same file.
 func (sm *StateManager) ComputeKey(record map[string]string) string {
+	for _, field := range IDColumns {
+		if id := strings.TrimSpace(record[field]); id != "" {
+			return "id:" + id
+		}
+	}
+
 	// Key fields that uniquely identify a vulnerability
 	keyFields := []string{
 		record["Project Name"],
@@ -64,7 +312,12 @@ func (sm *StateManager) ComputeVersionHash(record map[string]string) string {
 	}
 
 	hash := sha256.New()
-	hash.Write([]byte(strings.Join(versionFields, "|")))
+	for i, field := range versionFields {
+		if i > 0 {
+			io.WriteString(hash, "|")
+		}
+		io.WriteString(hash, field)
+	}
 	return hex.EncodeToString(hash.Sum(nil))
 }
 
@@ -93,7 +346,6 @@ func (sm *StateManager) UpdateState(record map[string]string) error {
 	sm.states[key] = VulnerabilityState{
 		LastSeenHash: hash,
 		LastScanTime: time.Now(),
-		ProcessedIDs: []string{},
 	}
 	sm.mu.Unlock()
 
@@ -114,55 +366,497 @@ func (sm *StateManager) load() error {
 		return fmt.Errorf("failed to read state file: %w", err)
 	}
 
-	return json.Unmarshal(data, &sm.states)
+	sm.fileSize = int64(len(data))
+
+	raw := data
+	if data, err = decompress(data); err != nil {
+		return fmt.Errorf("failed to decompress state file: %w", err)
+	}
+
+	var persisted persistedState
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	if persisted.Version > stateVersion {
+		return fmt.Errorf("state file version %d is newer than the supported version %d", persisted.Version, stateVersion)
+	}
+	migrated := persisted.Version < stateVersion
+	if migrated {
+		// Keep the file as it was in case the new version has to be rolled back
+		backup := fmt.Sprintf("%s.v%d.bak", sm.statePath, persisted.Version)
+		if err := os.WriteFile(backup, raw, 0600); err != nil {
+			return fmt.Errorf("failed to back up state file: %w", err)
+		}
+	}
+	for version := persisted.Version; version < stateVersion; version++ {
+		if err := stateMigrations[version](data, &persisted); err != nil {
+			return fmt.Errorf("failed to migrate state file from version %d: %w", version, err)
+		}
+	}
+
+	if persisted.States != nil {
+		sm.states = persisted.States
+	}
+	if persisted.Checkpoints != nil {
+		sm.checkpoints = persisted.Checkpoints
+	}
+	if persisted.ProcessedExports != nil {
+		sm.processedExports = persisted.ProcessedExports
+	}
+	if persisted.SyncCursors != nil {
+		sm.syncCursors = persisted.SyncCursors
+	}
+	if persisted.SyncCursorIDs != nil {
+		sm.syncCursorIDs = persisted.SyncCursorIDs
+	}
+	if persisted.SeenUntil != nil {
+		sm.seen = persisted.SeenUntil
+	}
+	if persisted.AuditCursors != nil {
+		sm.auditCursors = persisted.AuditCursors
+	}
+	if persisted.Columns != nil {
+		sm.columns = persisted.Columns
+	}
+	if persisted.RejectedRows != nil {
+		sm.rejectedRows = persisted.RejectedRows
+	}
+
+	if migrated {
+		return sm.save()
+	}
+	return nil
 }
 
-// save writes the state to disk
+// save persists the state, deferring the write to the next flush when flushing periodically
 func (sm *StateManager) save() error {
 	if sm.statePath == "" {
 		return nil
 	}
 
-	sm.mu.RLock()
-	data, err := json.Marshal(sm.states)
-	sm.mu.RUnlock()
+	sm.mu.Lock()
+	if sm.flushInterval > 0 && sm.pending+1 < maxPendingSaves {
+		sm.pending++
+		sm.mu.Unlock()
+		return nil
+	}
+	sm.mu.Unlock()
+
+	return sm.write()
+}
+
+// write writes the state to disk
+func (sm *StateManager) write() error {
+	sm.writeMu.Lock()
+	defer sm.writeMu.Unlock()
+
+	start := time.Now()
+	sm.mu.Lock()
+	data, err := json.Marshal(persistedState{
+		Version:          stateVersion,
+		States:           sm.states,
+		Checkpoints:      sm.checkpoints,
+		ProcessedExports: sm.processedExports,
+		SyncCursors:      sm.syncCursors,
+		SyncCursorIDs:    sm.syncCursorIDs,
+		SeenUntil:        sm.seen,
+		AuditCursors:     sm.auditCursors,
+		Columns:          sm.columns,
+		RejectedRows:     sm.rejectedRows,
+	})
+	sm.pending = 0
+	sm.mu.Unlock()
 
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
+	if sm.compress {
+		if data, err = compress(data); err != nil {
+			return fmt.Errorf("failed to compress state: %w", err)
+		}
+	}
+
+	if err := writeFileAtomic(sm.statePath, data); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	sm.fileSize = int64(len(data))
+	onWrite := sm.onWrite
+	sm.mu.Unlock()
+	if onWrite != nil {
+		onWrite(time.Since(start))
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data. The data is written and
+// synced to a temporary file in the same directory first, then renamed over
+// path, so a crash during the write leaves the previous file whole.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compress gzips the serialized state
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns the serialized state of a state file, gunzipping it when
+// it's compressed
+func decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// OnWrite sets a function called with the duration of every write of the state file
+func (sm *StateManager) OnWrite(fn func(time.Duration)) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.onWrite = fn
+}
+
+// Len returns the number of vulnerability states tracked
+func (sm *StateManager) Len() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return len(sm.states)
+}
+
+// FileSize returns the size in bytes of the state file last read or written
+func (sm *StateManager) FileSize() int64 {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return sm.fileSize
+}
+
+// StartFlushing defers writes of the state to a flush every interval, calling
+// onError when one fails. Close writes the changes left.
+func (sm *StateManager) StartFlushing(interval time.Duration, onError func(error)) {
+	if interval <= 0 || sm.statePath == "" {
+		return
+	}
+
+	sm.mu.Lock()
+	sm.flushInterval = interval
+	sm.mu.Unlock()
+	sm.stopFlushing = make(chan struct{})
+	sm.flushingDone = make(chan struct{})
+
+	go func() {
+		defer close(sm.flushingDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sm.stopFlushing:
+				return
+			case <-ticker.C:
+				if err := sm.Flush(); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
+
+// Flush writes the changes not written yet
+func (sm *StateManager) Flush() error {
+	sm.mu.RLock()
+	pending := sm.pending
+	sm.mu.RUnlock()
+
+	if pending == 0 {
+		return nil
+	}
+	return sm.write()
+}
 
-	return os.WriteFile(sm.statePath, data, 0600)
+// Close stops periodic flushing, writes the changes left and unlocks the state file
+func (sm *StateManager) Close() error {
+	if sm.stopFlushing != nil {
+		close(sm.stopFlushing)
+		<-sm.flushingDone
+		sm.stopFlushing = nil
+	}
+	defer sm.releaseLock()
+	return sm.Flush()
 }
 
-// GetState retrieves the state for a given key
-func (sm *StateManager) GetState(key map[string]string) map[string]string {
+// GetFinding returns the tracked state of a finding
+func (sm *StateManager) GetFinding(key string) (VulnerabilityState, bool) {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	stateKey := sm.ComputeKey(key)
-	if state, exists := sm.states[stateKey]; exists {
-		return map[string]string{
-			"LastSeenHash": state.LastSeenHash,
-			"LastScanTime": state.LastScanTime.Format(time.RFC3339),
-			"ProcessedIDs": strings.Join(state.ProcessedIDs, ","),
+	finding, ok := sm.states[key]
+	return finding, ok
+}
+
+// SetFindings records the state of findings and persists it
+func (sm *StateManager) SetFindings(findings map[string]VulnerabilityState) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	sm.mu.Lock()
+	for key, finding := range findings {
+		sm.states[key] = finding
+	}
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// PathFindings returns the tracked findings of a path by key
+func (sm *StateManager) PathFindings(pathID string) map[string]VulnerabilityState {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	findings := make(map[string]VulnerabilityState)
+	for key, finding := range sm.states {
+		if finding.PathID == pathID {
+			findings[key] = finding
 		}
 	}
-	return nil
+	return findings
+}
+
+// GetCheckpoint returns the checkpoint of the export in progress for a path
+func (sm *StateManager) GetCheckpoint(pathID string) (ExportCheckpoint, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	cp, ok := sm.checkpoints[pathID]
+	return cp, ok
 }
 
-func (sm *StateManager) SetState(key map[string]string, value map[string]string) error {
+// SetCheckpoint records progress through an export and persists it
+func (sm *StateManager) SetCheckpoint(pathID string, cp ExportCheckpoint) error {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	cp.UpdatedAt = time.Now()
+	sm.checkpoints[pathID] = cp
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// ClearCheckpoint removes the checkpoint once an export has been fully processed
+func (sm *StateManager) ClearCheckpoint(pathID string) error {
+	sm.mu.Lock()
+	_, exists := sm.checkpoints[pathID]
+	delete(sm.checkpoints, pathID)
+	sm.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+	return sm.save()
+}
+
+// MarkExportProcessed records that all records of an export were emitted and
+// clears the checkpoint of its path
+func (sm *StateManager) MarkExportProcessed(pathID string, exportID int64) error {
+	now := time.Now()
+
+	sm.mu.Lock()
+	sm.processedExports[exportID] = ProcessedExport{
+		PathID:      pathID,
+		ProcessedAt: now,
+	}
+	for id, processed := range sm.processedExports {
+		if now.Sub(processed.ProcessedAt) > processedExportRetention {
+			delete(sm.processedExports, id)
+		}
+	}
+	delete(sm.checkpoints, pathID)
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// IsExportProcessed reports whether all records of an export were already emitted
+func (sm *StateManager) IsExportProcessed(exportID int64) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	_, ok := sm.processedExports[exportID]
+	return ok
+}
+
+// LastProcessedExport returns when an export of the path was last fully processed
+func (sm *StateManager) LastProcessedExport(pathID string) (time.Time, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var last time.Time
+	for _, processed := range sm.processedExports {
+		if processed.PathID == pathID && processed.ProcessedAt.After(last) {
+			last = processed.ProcessedAt
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// GetSyncCursor returns up to when a path's vulnerabilities have been synced
+func (sm *StateManager) GetSyncCursor(pathID string) (time.Time, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	cursor, ok := sm.syncCursors[pathID]
+	return cursor, ok
+}
+
+// SyncCursorIDs returns a copy of the IDs of the vulnerabilities synced with
+// an update time equal to the cursor
+func (sm *StateManager) SyncCursorIDs(pathID string) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 
-	stateKey := sm.ComputeKey(key)
-	lastScanTime, _ := time.Parse(time.RFC3339, value["LastScanTime"])
-	processedIDs := strings.Split(value["ProcessedIDs"], ",")
+	return slices.Clone(sm.syncCursorIDs[pathID])
+}
 
-	sm.states[stateKey] = VulnerabilityState{
-		LastSeenHash: value["LastSeenHash"],
-		LastScanTime: lastScanTime,
-		ProcessedIDs: processedIDs,
+// SetSyncCursor records up to when a path's vulnerabilities have been synced and
+// the IDs of those updated exactly at the cursor
+func (sm *StateManager) SetSyncCursor(pathID string, cursor time.Time, ids []string) error {
+	sm.mu.Lock()
+	sm.syncCursors[pathID] = cursor
+	if len(ids) > 0 {
+		sm.syncCursorIDs[pathID] = slices.Clone(ids)
+	} else {
+		delete(sm.syncCursorIDs, pathID)
 	}
+	sm.mu.Unlock()
 
 	return sm.save()
 }
+
+// IsSeen reports whether an item was marked as seen within its retention period
+func (sm *StateManager) IsSeen(key string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	expiry, ok := sm.seen[key]
+	return ok && !expiry.Before(time.Now())
+}
+
+// MarkSeen records items as seen for retention, forgetting the expired items of
+// every retention
+func (sm *StateManager) MarkSeen(keys []string, retention time.Duration) error {
+	now := time.Now()
+
+	sm.mu.Lock()
+	for key, expiry := range sm.seen {
+		if expiry.Before(now) {
+			delete(sm.seen, key)
+		}
+	}
+	for _, key := range keys {
+		sm.seen[key] = now.Add(retention)
+	}
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// GetAuditCursor returns how far the audit trail of a path has been emitted
+func (sm *StateManager) GetAuditCursor(pathID string) (AuditCursor, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	cursor, ok := sm.auditCursors[pathID]
+	return cursor, ok
+}
+
+// SetAuditCursor records how far the audit trail of a path has been emitted and persists it
+func (sm *StateManager) SetAuditCursor(pathID string, cursor AuditCursor) error {
+	sm.mu.Lock()
+	sm.auditCursors[pathID] = cursor
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// GetColumns returns the columns of the last export read for a path
+func (sm *StateManager) GetColumns(pathID string) ([]string, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	columns, ok := sm.columns[pathID]
+	return columns, ok
+}
+
+// SetColumns records the columns of the last export read for a path
+func (sm *StateManager) SetColumns(pathID string, columns []string) error {
+	sm.mu.Lock()
+	sm.columns[pathID] = columns
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// AddRejectedRows records findings of a path the pipeline permanently rejected
+// and persists them, keeping the latest maxRejectedRows batches of the path
+func (sm *StateManager) AddRejectedRows(pathID string, rejected RejectedRows) error {
+	sm.mu.Lock()
+	rejected.RejectedAt = time.Now()
+	rows := append(sm.rejectedRows[pathID], rejected)
+	if len(rows) > maxRejectedRows {
+		rows = slices.Clone(rows[len(rows)-maxRejectedRows:])
+	}
+	sm.rejectedRows[pathID] = rows
+	sm.mu.Unlock()
+
+	return sm.save()
+}
+
+// GetRejectedRows returns the findings of a path the pipeline permanently
+// rejected, oldest first
+func (sm *StateManager) GetRejectedRows(pathID string) []RejectedRows {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return slices.Clone(sm.rejectedRows[pathID])
+}
@@ -0,0 +1,254 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStateManager_MigratesVersion0(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	legacy := `{"proj|tool|scanner|CVE-1|loc": {"last_seen_hash": "abc", "last_scan_time": "2024-05-01T10:00:00Z", "processed_ids": []}}`
+	require.NoError(t, os.WriteFile(statePath, []byte(legacy), 0600))
+
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", sm.states["proj|tool|scanner|CVE-1|loc"].LastSeenHash)
+
+	// The file is rewritten in the current layout and the original kept
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	var persisted persistedState
+	require.NoError(t, json.Unmarshal(data, &persisted))
+	assert.Equal(t, stateVersion, persisted.Version)
+	assert.Contains(t, persisted.States, "proj|tool|scanner|CVE-1|loc")
+
+	backup, err := os.ReadFile(statePath + ".v0.bak")
+	require.NoError(t, err)
+	assert.JSONEq(t, legacy, string(backup))
+}
+
+func TestNewStateManager_NewerVersion(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(`{"version": 99, "states": {}}`), 0600))
+
+	_, err := NewStateManager(statePath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "state file version 99 is newer than the supported version")
+}
+
+func TestStateManager_DeferredFlush(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	sm.StartFlushing(time.Hour, func(err error) { t.Error(err) })
+
+	require.NoError(t, sm.MarkSeen([]string{"a"}, time.Hour))
+	_, err = os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err), "changes are written by the next flush")
+
+	require.NoError(t, sm.Close())
+	reloaded, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	assert.True(t, reloaded.IsSeen("a"))
+}
+
+func TestStateManager_FlushesPendingChanges(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	sm.StartFlushing(time.Hour, func(err error) { t.Error(err) })
+
+	for i := 0; i < maxPendingSaves; i++ {
+		require.NoError(t, sm.MarkSeen([]string{fmt.Sprint(i)}, time.Hour))
+	}
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	var persisted persistedState
+	require.NoError(t, json.Unmarshal(data, &persisted))
+	assert.Contains(t, persisted.SeenUntil, fmt.Sprint(maxPendingSaves-1))
+	require.NoError(t, sm.Close())
+}
+
+func TestStateManager_WritesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	require.NoError(t, sm.MarkSeen([]string{"a"}, time.Hour))
+	require.NoError(t, sm.MarkSeen([]string{"b"}, time.Hour))
+	require.NoError(t, sm.Close())
+
+	// The temporary files written are renamed over the state file
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"state.json", "state.json.lock"}, names)
+
+	reloaded, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	assert.True(t, reloaded.IsSeen("a"))
+	assert.True(t, reloaded.IsSeen("b"))
+	require.NoError(t, reloaded.Close())
+}
+
+func TestComputeKey(t *testing.T) {
+	sm, err := NewStateManager("")
+	require.NoError(t, err)
+
+	finding := map[string]string{"Project Name": "app", "Tool": "sast", "CVE": "CVE-2024-1", "Location": "main.go"}
+	assert.Equal(t, "app|sast||CVE-2024-1|main.go", sm.ComputeKey(finding))
+
+	// Findings of the same CVE in the same file are told apart by their UUID
+	first := map[string]string{"UUID": "b1f3", "Project Name": "app", "CVE": "CVE-2024-1", "Location": "main.go"}
+	second := map[string]string{"UUID": "c2e4", "Project Name": "app", "CVE": "CVE-2024-1", "Location": "main.go"}
+	assert.Equal(t, "id:b1f3", sm.ComputeKey(first))
+	assert.NotEqual(t, sm.ComputeKey(first), sm.ComputeKey(second))
+
+	// and keep their key when renamed
+	first["Project Name"] = "renamed"
+	assert.Equal(t, "id:b1f3", sm.ComputeKey(first))
+
+	assert.Equal(t, "id:42", sm.ComputeKey(map[string]string{"Vulnerability ID": "42", "UUID": "b1f3"}))
+}
+
+func TestNewStateManager_MigratesProcessedIDs(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	v1 := `{"version": 1, "states": {"||||": {"last_seen_hash": "abc", "processed_ids": ["1", "2", ""]}}}`
+	require.NoError(t, os.WriteFile(statePath, []byte(v1), 0600))
+
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	assert.True(t, sm.IsSeen(ProcessedKeyPrefix+"1"))
+	assert.True(t, sm.IsSeen(ProcessedKeyPrefix+"2"))
+	assert.False(t, sm.IsSeen(ProcessedKeyPrefix))
+	assert.Equal(t, 0, sm.Len())
+}
+
+func TestNewStateManager_Locked(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+
+	_, err = NewStateManager(statePath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is in use by another process")
+
+	// The lock is released on close
+	require.NoError(t, sm.Close())
+	sm, err = NewStateManager(statePath)
+	require.NoError(t, err)
+	require.NoError(t, sm.Close())
+}
+
+func TestStateManager_Compression(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	findings := map[string]VulnerabilityState{}
+	for i := range 100 {
+		findings[fmt.Sprintf("id:%d", i)] = VulnerabilityState{LastSeenHash: "abc", PathID: "group"}
+	}
+
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	require.NoError(t, sm.SetFindings(findings))
+	plainSize := sm.FileSize()
+	require.NoError(t, sm.Close())
+
+	// A plain file is read and rewritten compressed
+	sm, err = NewStateManager(statePath, WithCompression())
+	require.NoError(t, err)
+	assert.Equal(t, 100, sm.Len())
+	require.NoError(t, sm.SetFindings(findings))
+	assert.Less(t, sm.FileSize(), plainSize/4)
+	require.NoError(t, sm.Close())
+
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, gzipMagic, data[:2])
+
+	// And a compressed file is read without the option
+	sm, err = NewStateManager(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, 100, sm.Len())
+	finding, ok := sm.GetFinding("id:42")
+	require.True(t, ok)
+	assert.Equal(t, "abc", finding.LastSeenHash)
+	require.NoError(t, sm.Close())
+}
+
+func TestStateManager_RejectedRows(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+
+	for i := range maxRejectedRows + 1 {
+		require.NoError(t, sm.AddRejectedRows("12345", RejectedRows{ExportID: int64(i), Keys: []string{ProcessedKeyPrefix + "1"}}))
+	}
+	require.NoError(t, sm.Close())
+
+	// Rejected rows are persisted, the oldest dropped past the limit
+	sm, err = NewStateManager(statePath)
+	require.NoError(t, err)
+	defer sm.Close()
+	rejected := sm.GetRejectedRows("12345")
+	require.Len(t, rejected, maxRejectedRows)
+	assert.Equal(t, int64(1), rejected[0].ExportID)
+	assert.False(t, rejected[0].RejectedAt.IsZero())
+	assert.Empty(t, sm.GetRejectedRows("67890"))
+}
+
+func TestStateManager_MarkSeenRetentions(t *testing.T) {
+	sm, err := NewStateManager(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	defer sm.Close()
+
+	require.NoError(t, sm.MarkSeen([]string{"sbom:1"}, 90*24*time.Hour))
+	require.NoError(t, sm.MarkSeen([]string{"processed:1"}, time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+
+	// Each item keeps its own retention, whatever the retention of later calls
+	require.NoError(t, sm.MarkSeen([]string{"processed:2"}, time.Millisecond))
+	assert.True(t, sm.IsSeen("sbom:1"))
+	assert.False(t, sm.IsSeen("processed:1"))
+	assert.NotContains(t, sm.seen, "processed:1")
+}
+
+func TestNewStateManager_MigratesSeenTimes(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	seenAt := time.Now().Add(-60 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	v2 := `{"version": 2, "states": {}, "seen": {"sbom:1": "` + seenAt + `", "processed:1": "2000-01-01T00:00:00Z"}}`
+	require.NoError(t, os.WriteFile(statePath, []byte(v2), 0600))
+
+	// Seen times become expiries with the longest retention
+	sm, err := NewStateManager(statePath)
+	require.NoError(t, err)
+	defer sm.Close()
+	assert.True(t, sm.IsSeen("sbom:1"))
+	assert.False(t, sm.IsSeen("processed:1"))
+}
+
+func TestStateManager_SyncCursorIDsCopy(t *testing.T) {
+	sm, err := NewStateManager(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	defer sm.Close()
+
+	ids := make([]string, 1, 4)
+	ids[0] = "1"
+	require.NoError(t, sm.SetSyncCursor("12345", time.Now(), ids))
+	ids[0] = "changed"
+
+	// Neither the slice passed in nor the one returned share the state's
+	synced := sm.SyncCursorIDs("12345")
+	_ = append(synced[:1], "2")
+	synced[0] = "changed"
+	assert.Equal(t, []string{"1"}, sm.SyncCursorIDs("12345"))
+}
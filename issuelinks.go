@@ -0,0 +1,142 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// issueLinkConcurrency bounds the issue link lookups sent at once
+const issueLinkConcurrency = 8
+
+// issueLinkCache keeps the issue links looked up during one export or sync, so
+// a vulnerability is only looked up once
+type issueLinkCache struct {
+	links map[string][]IssueLink
+}
+
+func newIssueLinkCache() *issueLinkCache {
+	return &issueLinkCache{links: make(map[string][]IssueLink)}
+}
+
+// putIssueLinks attaches the issues linked to the vulnerabilities of a batch, so
+// downstream can tell whether remediation is already tracked. Distinct
+// vulnerabilities are looked up in parallel; lookup failures are logged once
+// per batch and those findings are emitted without them.
+func (r *vulnerabilityReceiver) putIssueLinks(ctx context.Context, cache *issueLinkCache, logs plog.Logs) {
+	if !r.cfg.Enrichment.IssueLinks {
+		return
+	}
+
+	var missing []string
+	pending := make(map[string]bool)
+	forEachLogRecord(logs, func(lr plog.LogRecord) {
+		id := r.numericVulnerabilityID(lr)
+		if _, ok := cache.links[id]; id != "" && !ok && !pending[id] {
+			pending[id] = true
+			missing = append(missing, id)
+		}
+	})
+	r.lookupIssueLinks(ctx, cache, missing)
+
+	forEachLogRecord(logs, func(lr plog.LogRecord) {
+		links, ok := cache.links[r.numericVulnerabilityID(lr)]
+		if !ok {
+			return
+		}
+
+		attrs := lr.Attributes()
+		tracked := false
+		if len(links) > 0 {
+			iids := attrs.PutEmptySlice("gitlab.issue.iids")
+			urls := attrs.PutEmptySlice("gitlab.issue.urls")
+			states := attrs.PutEmptySlice("gitlab.issue.states")
+			for _, link := range links {
+				iids.AppendEmpty().SetInt(link.IID)
+				urls.AppendEmpty().SetStr(link.WebURL)
+				states.AppendEmpty().SetStr(link.State)
+				if link.State == "opened" {
+					tracked = true
+				}
+			}
+		}
+		attrs.PutBool("gitlab.issue.tracked", tracked)
+	})
+}
+
+// lookupIssueLinks fetches the issue links of vulnerabilities into the cache.
+// Failed lookups aren't cached, so they're tried again with the next batch.
+func (r *vulnerabilityReceiver) lookupIssueLinks(ctx context.Context, cache *issueLinkCache, ids []string) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failed   int
+		firstErr error
+	)
+	sem := make(chan struct{}, issueLinkConcurrency)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			links, err := r.client.GetIssueLinks(ctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			cache.links[id] = links
+		}()
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		r.logger.Warn("Failed to get issue links",
+			zap.Int("failed", failed),
+			zap.Int("vulnerabilities", len(ids)),
+			zap.Error(firstErr))
+	}
+}
+
+// numericVulnerabilityID returns the vulnerability ID of a converted finding,
+// empty when it only has a UUID or fingerprint
+func (r *vulnerabilityReceiver) numericVulnerabilityID(lr plog.LogRecord) string {
+	for _, column := range state.IDColumns {
+		value, ok := lr.Attributes().Get(r.attributeName(column))
+		if !ok {
+			continue
+		}
+		id := strings.TrimSpace(value.AsString())
+		if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+			return id
+		}
+	}
+	return ""
+}
+
+// forEachLogRecord calls fn for every log record of logs
+func forEachLogRecord(logs plog.Logs, fn func(plog.LogRecord)) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		scopeLogs := logs.ResourceLogs().At(i).ScopeLogs()
+		for j := 0; j < scopeLogs.Len(); j++ {
+			records := scopeLogs.At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				fn(records.At(k))
+			}
+		}
+	}
+}
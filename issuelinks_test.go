@@ -0,0 +1,163 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestPutIssueLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    []string
+		values    []string
+		links     []IssueLink
+		err       error
+		wantAttrs map[string]interface{}
+	}{
+		{
+			name:   "open issue",
+			header: []string{"Vulnerability ID", "Title"},
+			values: []string{"42", "SQL injection"},
+			links: []IssueLink{
+				{IID: 3, State: "closed", WebURL: "https://gitlab.com/group/project/-/issues/3"},
+				{IID: 9, State: "opened", WebURL: "https://gitlab.com/group/project/-/issues/9"},
+			},
+			wantAttrs: map[string]interface{}{
+				"gitlab.issue.iids":    []interface{}{int64(3), int64(9)},
+				"gitlab.issue.urls":    []interface{}{"https://gitlab.com/group/project/-/issues/3", "https://gitlab.com/group/project/-/issues/9"},
+				"gitlab.issue.states":  []interface{}{"closed", "opened"},
+				"gitlab.issue.tracked": true,
+			},
+		},
+		{
+			name:   "no issues",
+			header: []string{"Vulnerability ID", "Title"},
+			values: []string{"42", "SQL injection"},
+			wantAttrs: map[string]interface{}{
+				"gitlab.issue.tracked": false,
+			},
+		},
+		{
+			name:      "no numeric id",
+			header:    []string{"UUID", "Title"},
+			values:    []string{"b1f3", "SQL injection"},
+			wantAttrs: map[string]interface{}{},
+		},
+		{
+			name:      "lookup failure",
+			header:    []string{"Vulnerability ID", "Title"},
+			values:    []string{"42", "SQL injection"},
+			err:       errors.New("forbidden"),
+			wantAttrs: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Enrichment.IssueLinks = true
+			recv := &vulnerabilityReceiver{
+				cfg:    cfg,
+				logger: zap.NewNop(),
+				client: &mockGitLabClient{
+					getIssueLinksFunc: func(ctx context.Context, vulnerabilityID string) ([]IssueLink, error) {
+						assert.Equal(t, "42", vulnerabilityID)
+						return tt.links, tt.err
+					},
+				},
+			}
+
+			record := &exportRecord{header: tt.header, values: tt.values}
+			logs := recv.convertRecord(record, &Export{ID: 123, ProjectID: "test-project"})
+			recv.putIssueLinks(context.Background(), newIssueLinkCache(), logs)
+
+			attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+			for _, key := range []string{"gitlab.issue.iids", "gitlab.issue.urls", "gitlab.issue.states", "gitlab.issue.tracked"} {
+				want, ok := tt.wantAttrs[key]
+				if !ok {
+					assert.NotContains(t, attrs, key)
+					continue
+				}
+				require.Contains(t, attrs, key)
+				assert.Equal(t, want, attrs[key])
+			}
+		})
+	}
+}
+
+func TestPutIssueLinks_Batch(t *testing.T) {
+	var (
+		mu                    sync.Mutex
+		calls                 map[string]int
+		inflight, maxInflight atomic.Int32
+	)
+	calls = make(map[string]int)
+	cfg := createDefaultConfig().(*Config)
+	cfg.Enrichment.IssueLinks = true
+	recv := &vulnerabilityReceiver{
+		cfg:    cfg,
+		logger: zap.NewNop(),
+		client: &mockGitLabClient{
+			getIssueLinksFunc: func(ctx context.Context, vulnerabilityID string) ([]IssueLink, error) {
+				n := inflight.Add(1)
+				defer inflight.Add(-1)
+				for {
+					m := maxInflight.Load()
+					if n <= m || maxInflight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				calls[vulnerabilityID]++
+				mu.Unlock()
+				if vulnerabilityID == "13" {
+					return nil, errors.New("forbidden")
+				}
+				return []IssueLink{{IID: 1, State: "opened"}}, nil
+			},
+		},
+	}
+
+	// Every vulnerability appears twice in the batch
+	export := &Export{ID: 123, ProjectID: "test-project"}
+	batch := newLogBatch()
+	for i := 0; i < 2*issueLinkConcurrency*2; i++ {
+		record := &exportRecord{header: []string{"Vulnerability ID"}, values: []string{fmt.Sprint(i % (issueLinkConcurrency * 2))}}
+		batch.add("", recv.convertRecord(record, export))
+	}
+	logs := batch.take()
+
+	cache := newIssueLinkCache()
+	recv.putIssueLinks(context.Background(), cache, logs)
+
+	assert.Len(t, calls, issueLinkConcurrency*2)
+	for id, n := range calls {
+		assert.Equal(t, 1, n, "vulnerability %s looked up more than once", id)
+	}
+	assert.LessOrEqual(t, maxInflight.Load(), int32(issueLinkConcurrency))
+
+	tracked := 0
+	forEachLogRecord(logs, func(lr plog.LogRecord) {
+		if _, ok := lr.Attributes().Get("gitlab.issue.tracked"); ok {
+			tracked++
+		}
+	})
+	assert.Equal(t, logs.LogRecordCount()-2, tracked, "only the failed lookup is missing")
+
+	// Cached vulnerabilities aren't looked up again, failed ones are retried
+	recv.putIssueLinks(context.Background(), cache, logs)
+	assert.Equal(t, 1, calls["1"])
+	assert.Equal(t, 2, calls["13"])
+}
@@ -0,0 +1,146 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+)
+
+// kevEntry is a vulnerability of the CISA Known Exploited Vulnerabilities catalog
+type kevEntry struct {
+	CVEID                      string `json:"cveID"`
+	DateAdded                  string `json:"dateAdded"`
+	DueDate                    string `json:"dueDate"`
+	RequiredAction             string `json:"requiredAction"`
+	KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+}
+
+// kevCatalog caches the KEV catalog in memory, downloading it again once it's
+// older than the refresh interval
+type kevCatalog struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+	logger  *zap.Logger
+
+	mu        sync.RWMutex
+	entries   map[string]kevEntry
+	fetchedAt time.Time
+}
+
+func newKEVCatalog(cfg KEVConfig, client *http.Client, logger *zap.Logger) *kevCatalog {
+	return &kevCatalog{
+		url:     cfg.URL,
+		refresh: cfg.RefreshInterval,
+		client:  client,
+		logger:  logger,
+	}
+}
+
+// refreshIfStale downloads the catalog when it's missing or stale. A failed
+// download keeps the previous catalog.
+func (c *kevCatalog) refreshIfStale(ctx context.Context) {
+	c.mu.RLock()
+	fresh := !c.fetchedAt.IsZero() && time.Since(c.fetchedAt) < c.refresh
+	c.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	entries, err := c.download(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to download KEV catalog", zap.String("url", c.url), zap.Error(err))
+		return
+	}
+
+	c.mu.Lock()
+	c.entries = entries
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	c.logger.Debug("Downloaded KEV catalog", zap.Int("vulnerabilities", len(entries)))
+}
+
+func (c *kevCatalog) download(ctx context.Context) (map[string]kevEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var catalog struct {
+		Vulnerabilities []kevEntry `json:"vulnerabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode catalog: %w", err)
+	}
+
+	entries := make(map[string]kevEntry, len(catalog.Vulnerabilities))
+	for _, entry := range catalog.Vulnerabilities {
+		entries[entry.CVEID] = entry
+	}
+	return entries, nil
+}
+
+// loaded reports whether the catalog was downloaded at least once
+func (c *kevCatalog) loaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.fetchedAt.IsZero()
+}
+
+// lookup returns the catalog entry of a CVE
+func (c *kevCatalog) lookup(cve string) (kevEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[cve]
+	return entry, ok
+}
+
+// putKEVAttributes flags findings whose CVEs are known to be exploited. With
+// several listed CVEs, the earliest remediation due date is kept. Nothing is
+// set until the catalog could be downloaded, so a finding is never reported as
+// not exploited without having been checked.
+func (r *vulnerabilityReceiver) putKEVAttributes(attrs pcommon.Map) {
+	if r.kev == nil || !r.kev.loaded() {
+		return
+	}
+	cves, ok := attrs.Get("vulnerability.cve_ids")
+	if !ok || cves.Type() != pcommon.ValueTypeSlice {
+		return
+	}
+
+	var match *kevEntry
+	for i := 0; i < cves.Slice().Len(); i++ {
+		entry, ok := r.kev.lookup(cves.Slice().At(i).Str())
+		if !ok {
+			continue
+		}
+		if match == nil || entry.DueDate < match.DueDate {
+			match = &entry
+		}
+	}
+
+	attrs.PutBool("vulnerability.known_exploited", match != nil)
+	if match == nil {
+		return
+	}
+	putNonEmpty(attrs, "vulnerability.kev.date_added", match.DateAdded)
+	putNonEmpty(attrs, "vulnerability.kev.due_date", match.DueDate)
+	putNonEmpty(attrs, "vulnerability.kev.required_action", match.RequiredAction)
+	putNonEmpty(attrs, "vulnerability.kev.ransomware_use", match.KnownRansomwareCampaignUse)
+}
@@ -0,0 +1,165 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.uber.org/zap"
+)
+
+const testKEVCatalog = `{
+	"vulnerabilities": [
+		{"cveID": "CVE-2021-44228", "dateAdded": "2021-12-10", "dueDate": "2021-12-24",
+		 "requiredAction": "Apply updates per vendor instructions.", "knownRansomwareCampaignUse": "Known"},
+		{"cveID": "CVE-2021-45046", "dateAdded": "2023-05-01", "dueDate": "2023-05-22",
+		 "requiredAction": "Apply updates per vendor instructions.", "knownRansomwareCampaignUse": "Unknown"}
+	]
+}`
+
+func TestPutKEVAttributes(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(testKEVCatalog))
+	}))
+	defer server.Close()
+
+	kev := newKEVCatalog(KEVConfig{URL: server.URL, RefreshInterval: time.Hour}, http.DefaultClient, zap.NewNop())
+	kev.refreshIfStale(context.Background())
+	kev.refreshIfStale(context.Background())
+	assert.Equal(t, 1, requests, "a fresh catalog must not be downloaded again")
+
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+		kev:    kev,
+	}
+
+	tests := []struct {
+		name      string
+		cve       string
+		wantAttrs map[string]interface{}
+	}{
+		{
+			name: "known exploited",
+			cve:  "CVE-2021-45046, CVE-2021-44228",
+			wantAttrs: map[string]interface{}{
+				"vulnerability.known_exploited":     true,
+				"vulnerability.kev.date_added":      "2021-12-10",
+				"vulnerability.kev.due_date":        "2021-12-24",
+				"vulnerability.kev.required_action": "Apply updates per vendor instructions.",
+				"vulnerability.kev.ransomware_use":  "Known",
+			},
+		},
+		{
+			name: "not in catalog",
+			cve:  "CVE-2024-0001",
+			wantAttrs: map[string]interface{}{
+				"vulnerability.known_exploited": false,
+			},
+		},
+		{
+			name:      "no cve",
+			cve:       "",
+			wantAttrs: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := recv.convertToLogs([]string{"Title", "CVE"}, []string{"Log4Shell", tt.cve}, &Export{ID: 1, ProjectID: "1"})
+			attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+			for _, key := range []string{
+				"vulnerability.known_exploited",
+				"vulnerability.kev.date_added",
+				"vulnerability.kev.due_date",
+				"vulnerability.kev.required_action",
+				"vulnerability.kev.ransomware_use",
+			} {
+				want, ok := tt.wantAttrs[key]
+				if !ok {
+					assert.NotContains(t, attrs, key)
+					continue
+				}
+				require.Contains(t, attrs, key)
+				assert.Equal(t, want, attrs[key])
+			}
+		})
+	}
+}
+
+func TestKEVCatalog_KeepsCatalogOnFailure(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testKEVCatalog))
+	}))
+	defer server.Close()
+
+	kev := newKEVCatalog(KEVConfig{URL: server.URL, RefreshInterval: time.Nanosecond}, http.DefaultClient, zap.NewNop())
+	kev.refreshIfStale(context.Background())
+
+	fail = true
+	kev.refreshIfStale(context.Background())
+
+	_, ok := kev.lookup("CVE-2021-44228")
+	assert.True(t, ok)
+}
+
+func TestPutKEVAttributes_CatalogNotLoaded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	kev := newKEVCatalog(KEVConfig{URL: server.URL, RefreshInterval: time.Hour}, http.DefaultClient, zap.NewNop())
+	kev.refreshIfStale(context.Background())
+
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+		kev:    kev,
+	}
+	logs := recv.convertToLogs([]string{"Title", "CVE"}, []string{"Log4Shell", "CVE-2021-44228"}, &Export{ID: 1, ProjectID: "1"})
+	attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.NotContains(t, attrs, "vulnerability.known_exploited")
+}
+
+func TestStartEnrichment_UsesClientConfig(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"), "GitLab headers must not be sent to third parties")
+		w.Write([]byte(testKEVCatalog))
+	}))
+	defer proxy.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ProxyURL = proxy.URL
+	cfg.Headers = map[string]configopaque.String{"PRIVATE-TOKEN": "secret"}
+	cfg.Enrichment.KEV.Enabled = true
+	cfg.Enrichment.KEV.URL = "http://kev.example.com/feed.json"
+
+	recv := &vulnerabilityReceiver{
+		cfg:      cfg,
+		settings: componenttest.NewNopTelemetrySettings(),
+		logger:   zap.NewNop(),
+	}
+	require.NoError(t, recv.startEnrichment(context.Background(), componenttest.NewNopHost()))
+	require.NotNil(t, recv.kev)
+	assert.Nil(t, recv.osv)
+
+	recv.kev.refreshIfStale(context.Background())
+	assert.Equal(t, []string{"http://kev.example.com/feed.json"}, proxied)
+	assert.True(t, recv.kev.loaded())
+}
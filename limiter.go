@@ -0,0 +1,93 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// exportLimiter holds the export slots of a GitLab instance
+type exportLimiter struct {
+	slots chan struct{}
+	limit int
+	refs  int
+}
+
+// Export slots are shared by the receivers of this collector process talking to
+// the same GitLab instance, keyed by base URL. Receivers must agree on the limit.
+var (
+	exportLimitersMu sync.Mutex
+	exportLimiters   = make(map[string]*exportLimiter)
+)
+
+// registerExportLimiter returns the semaphore limiting in-flight exports on a
+// GitLab instance, failing when another receiver registered a different limit
+func registerExportLimiter(baseURL string, limit int) (chan struct{}, error) {
+	exportLimitersMu.Lock()
+	defer exportLimitersMu.Unlock()
+
+	limiter, ok := exportLimiters[baseURL]
+	if !ok {
+		limiter = &exportLimiter{slots: make(chan struct{}, limit), limit: limit}
+		exportLimiters[baseURL] = limiter
+	}
+	if limiter.limit != limit {
+		return nil, fmt.Errorf("max_inflight_exports %d conflicts with %d configured by another receiver for %q", limit, limiter.limit, baseURL)
+	}
+	limiter.refs++
+	return limiter.slots, nil
+}
+
+// unregisterExportLimiter releases a receiver's use of a GitLab instance's
+// semaphore, forgetting it once no receiver uses it
+func unregisterExportLimiter(baseURL string) {
+	exportLimitersMu.Lock()
+	defer exportLimitersMu.Unlock()
+
+	limiter, ok := exportLimiters[baseURL]
+	if !ok {
+		return
+	}
+	limiter.refs--
+	if limiter.refs <= 0 {
+		delete(exportLimiters, baseURL)
+	}
+}
+
+// startExportLimiter joins the export limit of the GitLab instance, if any
+func (r *vulnerabilityReceiver) startExportLimiter() error {
+	if r.cfg.MaxInflightExports <= 0 {
+		return nil
+	}
+	slots, err := registerExportLimiter(r.cfg.BaseURL, r.cfg.MaxInflightExports)
+	if err != nil {
+		return err
+	}
+	r.exportSlots = slots
+	return nil
+}
+
+// stopExportLimiter leaves the export limit joined by startExportLimiter
+func (r *vulnerabilityReceiver) stopExportLimiter() {
+	if r.exportSlots == nil {
+		return
+	}
+	unregisterExportLimiter(r.cfg.BaseURL)
+	r.exportSlots = nil
+}
+
+// acquireExportSlot blocks until an export may be started on the GitLab
+// instance and returns a function releasing the slot
+func (r *vulnerabilityReceiver) acquireExportSlot(ctx context.Context) (func(), error) {
+	slots := r.exportSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,68 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAcquireExportSlot(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.BaseURL = "https://limiter.example.com"
+	cfg.MaxInflightExports = 1
+
+	// Two receivers on the same instance share the limit
+	first := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+	second := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+	require.NoError(t, first.startExportLimiter())
+	defer first.stopExportLimiter()
+	require.NoError(t, second.startExportLimiter())
+	defer second.stopExportLimiter()
+
+	release, err := first.acquireExportSlot(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = second.acquireExportSlot(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	releaseSecond, err := second.acquireExportSlot(context.Background())
+	require.NoError(t, err)
+	releaseSecond()
+}
+
+func TestAcquireExportSlot_Unlimited(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+
+	for i := 0; i < 3; i++ {
+		_, err := recv.acquireExportSlot(context.Background())
+		require.NoError(t, err)
+	}
+}
+
+func TestStartExportLimiter_Conflict(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.BaseURL = "https://conflict.example.com"
+	cfg.MaxInflightExports = 1
+	first := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+	require.NoError(t, first.startExportLimiter())
+
+	other := *cfg
+	other.MaxInflightExports = 2
+	second := &vulnerabilityReceiver{cfg: &other, logger: zap.NewNop()}
+	err := second.startExportLimiter()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max_inflight_exports 2 conflicts with 1")
+
+	// The limit can change once no receiver uses it anymore
+	first.stopExportLimiter()
+	require.NoError(t, second.startExportLimiter())
+	second.stopExportLimiter()
+}
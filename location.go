@@ -0,0 +1,114 @@
+package gitlabvulnreceiver
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// codeLocation is the location of a SAST or dependency scanning finding
+type codeLocation struct {
+	File       string             `json:"file"`
+	StartLine  json.Number        `json:"start_line"`
+	EndLine    json.Number        `json:"end_line"`
+	Class      string             `json:"class"`
+	Method     string             `json:"method"`
+	Dependency dependencyLocation `json:"dependency"`
+}
+
+// dependencyLocation is the package a dependency or container scanning finding
+// was found in
+type dependencyLocation struct {
+	Package struct {
+		Name string `json:"name"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+// parseCodeLocation reads the Location column of a finding, a JSON object in
+// most exports and "file:line" in some
+func parseCodeLocation(value string) (codeLocation, bool) {
+	var location codeLocation
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		return location, json.Unmarshal([]byte(value), &location) == nil
+	}
+	if match := locationLinePattern.FindStringSubmatch(value); match != nil {
+		location.File = match[1]
+		location.StartLine = json.Number(match[2])
+		location.EndLine = json.Number(match[3])
+		return location, true
+	}
+	return location, false
+}
+
+// putLocationAttributes maps the location of SAST and dependency scanning
+// findings to structured attributes: the file and lines of the code, or the
+// package, version and manifest of the dependency. It returns the lowercased
+// columns it consumed so they aren't emitted again as generic attributes.
+// Container scanning and DAST locations are mapped with their other attributes.
+func putLocationAttributes(attrs pcommon.Map, header []string, record []string) map[string]bool {
+	typ := reportType(header, record)
+	if typ != reportTypeSAST && typ != reportTypeDependencyScanning {
+		return nil
+	}
+	consumed := make(map[string]bool)
+	field := func(columns ...string) string {
+		for _, column := range columns {
+			if value, ok := findField(header, record, column); ok && value != "" {
+				consumed[strings.ToLower(column)] = true
+				return value
+			}
+		}
+		return ""
+	}
+
+	locationValue, _ := findField(header, record, "Location")
+	location, ok := parseCodeLocation(locationValue)
+	if ok {
+		consumed["location"] = true
+	}
+	file := field("File")
+	if file == "" {
+		file = location.File
+	}
+
+	if typ == reportTypeDependencyScanning {
+		packageName := field("Package Name", "Package")
+		if packageName == "" {
+			packageName = location.Dependency.Package.Name
+		}
+		putNonEmpty(attrs, "package.name", packageName)
+		packageVersion := field("Package Version")
+		if packageVersion == "" {
+			packageVersion = location.Dependency.Version
+		}
+		putNonEmpty(attrs, "package.version", packageVersion)
+		putNonEmpty(attrs, "vulnerability.dependency.file", file)
+		return consumed
+	}
+
+	putNonEmpty(attrs, "code.filepath", file)
+	startLine := field("Start Line", "Line")
+	if startLine == "" {
+		startLine = location.StartLine.String()
+	}
+	putLine(attrs, "code.lineno", startLine)
+	endLine := field("End Line")
+	if endLine == "" {
+		endLine = location.EndLine.String()
+	}
+	putLine(attrs, "vulnerability.location.end_line", endLine)
+	putNonEmpty(attrs, "code.namespace", location.Class)
+	putNonEmpty(attrs, "code.function", location.Method)
+	return consumed
+}
+
+// putLine sets a line number attribute, skipping values that aren't one
+func putLine(attrs pcommon.Map, key, value string) {
+	if line, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil && line > 0 {
+		attrs.PutInt(key, line)
+	}
+}
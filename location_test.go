@@ -0,0 +1,87 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestPutLocationAttributes(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       []string
+		values       []string
+		expected     map[string]interface{}
+		wantConsumed []string
+	}{
+		{
+			name:   "sast JSON location",
+			header: []string{"Tool", "Location"},
+			values: []string{"sast", `{"file":"app/user.rb","start_line":10,"end_line":12,"class":"User","method":"find"}`},
+			expected: map[string]interface{}{
+				"code.filepath":                   "app/user.rb",
+				"code.lineno":                     int64(10),
+				"vulnerability.location.end_line": int64(12),
+				"code.namespace":                  "User",
+				"code.function":                   "find",
+			},
+			wantConsumed: []string{"location"},
+		},
+		{
+			name:   "sast file and line",
+			header: []string{"Tool", "Location"},
+			values: []string{"SAST", "src/main.go:42"},
+			expected: map[string]interface{}{
+				"code.filepath": "src/main.go",
+				"code.lineno":   int64(42),
+			},
+			wantConsumed: []string{"location"},
+		},
+		{
+			name:   "sast columns from API records",
+			header: []string{"report_type", "File", "Start Line", "End Line"},
+			values: []string{"sast", "src/main.go", "3", "5"},
+			expected: map[string]interface{}{
+				"code.filepath":                   "src/main.go",
+				"code.lineno":                     int64(3),
+				"vulnerability.location.end_line": int64(5),
+			},
+			wantConsumed: []string{"file", "start line", "end line"},
+		},
+		{
+			name:   "dependency scanning",
+			header: []string{"Tool", "Location"},
+			values: []string{"dependency_scanning", `{"file":"package-lock.json","dependency":{"package":{"name":"lodash"},"version":"4.17.20"}}`},
+			expected: map[string]interface{}{
+				"package.name":                  "lodash",
+				"package.version":               "4.17.20",
+				"vulnerability.dependency.file": "package-lock.json",
+			},
+			wantConsumed: []string{"location"},
+		},
+		{
+			name:     "unparseable location",
+			header:   []string{"Tool", "Location"},
+			values:   []string{"sast", "somewhere"},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "other scanners",
+			header:   []string{"Tool", "Location"},
+			values:   []string{"dast", "src/main.go:42"},
+			expected: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := pcommon.NewMap()
+			consumed := putLocationAttributes(attrs, tt.header, tt.values)
+			assert.Equal(t, tt.expected, attrs.AsRaw())
+			for _, column := range tt.wantConsumed {
+				assert.True(t, consumed[column], "column %s should be consumed", column)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package gitlabvulnreceiver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Bytes of a 503 body searched for a maintenance message
+const maintenanceBodyPeek = 4096
+
+// maintenanceWindow holds off polling while GitLab is down for maintenance,
+// instead of failing every path on every tick until it's back
+type maintenanceWindow struct {
+	backoff time.Duration
+	logger  *zap.Logger
+
+	mu    sync.Mutex
+	until time.Time
+	// entered is closed when a window starts, and replaced for the next one
+	entered chan struct{}
+}
+
+// newMaintenanceWindow returns nil, never entering maintenance, when backoff is 0
+func newMaintenanceWindow(backoff time.Duration, logger *zap.Logger) *maintenanceWindow {
+	if backoff <= 0 {
+		return nil
+	}
+	return &maintenanceWindow{backoff: backoff, logger: logger, entered: make(chan struct{})}
+}
+
+// enter starts or extends the window by the backoff, or by retryAfter when
+// GitLab asked for a longer wait. Only the start of a window is logged.
+func (w *maintenanceWindow) enter(retryAfter time.Duration) {
+	until := time.Now().Add(max(w.backoff, retryAfter))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if until.Before(w.until) {
+		return
+	}
+	if time.Now().After(w.until) {
+		w.logger.Warn("GitLab is under maintenance, pausing polls",
+			zap.Time("until", until))
+		close(w.entered)
+		w.entered = make(chan struct{})
+	}
+	w.until = until
+}
+
+// active reports whether polls are held off, and until when
+func (w *maintenanceWindow) active() (time.Time, bool) {
+	if w == nil {
+		return time.Time{}, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.until, time.Now().Before(w.until)
+}
+
+// watch returns a context canceled when a maintenance window starts, so work
+// waiting on GitLab, such as an export status poll, stops with it rather than
+// retrying until it times out
+func (w *maintenanceWindow) watch(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w == nil {
+		return context.WithCancel(ctx)
+	}
+	w.mu.Lock()
+	entered := w.entered
+	w.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-entered:
+			cancel(errMaintenance)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// errMaintenance cancels the work on a path when GitLab goes into maintenance
+var errMaintenance = errors.New("GitLab is under maintenance")
+
+// maintenanceTransport opens the maintenance window on GitLab's maintenance
+// responses: a 503 with a Retry-After header, or whose body mentions
+// maintenance, as served by GitLab's maintenance mode and maintenance pages.
+// Other 503s are left to the usual retries.
+type maintenanceTransport struct {
+	next   http.RoundTripper
+	window *maintenanceWindow
+}
+
+func (t *maintenanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		return resp, err
+	}
+
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if hasRetryAfter {
+		t.window.enter(retryAfter)
+		return resp, nil
+	}
+
+	// Peek at the body, leaving it whole for the client
+	peek, err := io.ReadAll(io.LimitReader(resp.Body, maintenanceBodyPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+	if err == nil && strings.Contains(strings.ToLower(string(peek)), "maintenance") {
+		t.window.enter(0)
+	}
+	return resp, nil
+}
+
+// parseRetryAfter reads a Retry-After header, a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
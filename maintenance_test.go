@@ -0,0 +1,160 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "Fri, 16 Oct 2026 13:00:00 GMT", want: time.Hour, wantOK: true},
+		{value: "Fri, 16 Oct 2026 11:00:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMaintenanceTransport(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		retryAfter      string
+		body            string
+		wantMaintenance bool
+		wantUntil       time.Duration
+	}{
+		{
+			name:            "retry after",
+			status:          http.StatusServiceUnavailable,
+			retryAfter:      "7200",
+			wantMaintenance: true,
+			wantUntil:       2 * time.Hour,
+		},
+		{
+			name:            "maintenance mode message",
+			status:          http.StatusServiceUnavailable,
+			body:            `{"message":"GitLab Maintenance: Upgrading to 18.5"}`,
+			wantMaintenance: true,
+			wantUntil:       15 * time.Minute,
+		},
+		{
+			name:   "other unavailable",
+			status: http.StatusServiceUnavailable,
+			body:   "upstream connect error",
+		},
+		{
+			name:       "rate limited",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "60",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			window := newMaintenanceWindow(15*time.Minute, zap.NewNop())
+			client := &http.Client{Transport: &maintenanceTransport{next: http.DefaultTransport, window: window}}
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			// The body is left whole for the client
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+
+			until, ok := window.active()
+			assert.Equal(t, tt.wantMaintenance, ok)
+			if tt.wantMaintenance {
+				assert.WithinDuration(t, time.Now().Add(tt.wantUntil), until, time.Minute)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow_Disabled(t *testing.T) {
+	window := newMaintenanceWindow(0, zap.NewNop())
+	assert.Nil(t, window)
+	_, ok := window.active()
+	assert.False(t, ok)
+}
+
+func TestMaintenanceWindow_Watch(t *testing.T) {
+	window := newMaintenanceWindow(time.Minute, zap.NewNop())
+	ctx, cancel := window.watch(context.Background())
+	defer cancel()
+
+	window.enter(0)
+	select {
+	case <-ctx.Done():
+		assert.ErrorIs(t, context.Cause(ctx), errMaintenance)
+	case <-time.After(time.Second):
+		t.Fatal("work on GitLab wasn't stopped by the maintenance")
+	}
+}
+
+func TestCheckExports_Maintenance(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "1", Type: "project"}, {ID: "2", Type: "project"}}
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          new(consumertest.LogsSink),
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		maintenance:       newMaintenanceWindow(cfg.MaintenanceBackoff, zap.NewNop()),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	var validations int
+	receiver.client = &mockGitLabClient{
+		validateProjectFunc: func(ctx context.Context, projectID string) error {
+			validations++
+			// As the maintenance transport does on GitLab's maintenance response
+			receiver.maintenance.enter(0)
+			return fmt.Errorf("failed to validate project: %w", &gitlab.APIError{StatusCode: 503})
+		},
+	}
+
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	assert.Equal(t, 1, validations, "paths after the maintenance response are skipped")
+	assertNoPathErrors(t, receiver)
+	assert.Equal(t, "maintenance", receiver.pathStatusSnapshot()[0].ExportStatus)
+
+	// Polls during the window don't call GitLab
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	assert.Equal(t, 1, validations)
+}
@@ -0,0 +1,122 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Report types compared by the merge request security widget
+var defaultMergeRequestReportTypes = []string{
+	"sast",
+	"secret_detection",
+	"dependency_scanning",
+	"container_scanning",
+	"dast",
+}
+
+// How long emitted merge request findings are remembered
+const mergeRequestFindingRetention = 90 * 24 * time.Hour
+
+// processMergeRequestFindings emits the findings introduced by open merge
+// requests, each only once, so they can be alerted on before they are merged
+func (r *vulnerabilityReceiver) processMergeRequestFindings(ctx context.Context, projectID string) error {
+	project, err := r.client.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	reportTypes := r.cfg.MergeRequests.ReportTypes
+	if len(reportTypes) == 0 {
+		reportTypes = defaultMergeRequestReportTypes
+	}
+
+	export := &Export{ProjectID: projectID, Format: "merge_request"}
+	batchSize := r.batchSize()
+	batch := newLogBatch()
+	var seen []string
+	flush := func() error {
+		if batch.records == 0 {
+			return nil
+		}
+		logs := batch.take()
+		r.applyOutputSchema(logs)
+		if err := r.consumeLogs(ctx, logs); err != nil {
+			return fmt.Errorf("failed to consume logs: %w", err)
+		}
+		if err := r.stateManager.MarkSeen(seen, mergeRequestFindingRetention); err != nil {
+			return fmt.Errorf("failed to save seen findings: %w", err)
+		}
+		seen = seen[:0]
+		return nil
+	}
+
+	for page := 1; page != 0; {
+		mergeRequests, nextPage, err := r.client.ListOpenMergeRequests(ctx, projectID, page)
+		if err != nil {
+			return err
+		}
+
+		for _, mr := range mergeRequests {
+			if r.cfg.MergeRequests.DefaultBranchOnly && mr.TargetBranch != project.DefaultBranch {
+				r.logger.Debug("Skipping merge request - not into the default branch",
+					zap.String("projectID", projectID),
+					zap.Int64("iid", mr.IID),
+					zap.String("targetBranch", mr.TargetBranch))
+				continue
+			}
+			for _, reportType := range reportTypes {
+				findings, ready, err := r.client.GetMergeRequestFindings(ctx, project.Path, mr.IID, reportType)
+				if err != nil {
+					return err
+				}
+				if !ready {
+					r.logger.Debug("Merge request reports not compared yet",
+						zap.String("projectID", projectID),
+						zap.Int64("iid", mr.IID),
+						zap.String("reportType", reportType))
+					continue
+				}
+
+				for _, finding := range findings {
+					uuid, _ := finding["uuid"].(string)
+					key := fmt.Sprintf("mr:%s:%d:%s", projectID, mr.IID, uuid)
+					if uuid == "" || r.stateManager.IsSeen(key) {
+						continue
+					}
+
+					finding["report_type"] = reportType
+					record := flattenJSONRecord(finding)
+					record.add("Project Full Path", project.Path)
+					if r.filtered(record) {
+						seen = append(seen, key)
+						continue
+					}
+
+					logs := r.convertRecord(record, export)
+					lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+					setEventName(lr, "gitlab.merge_request.finding")
+					attrs := lr.Attributes()
+					attrs.PutInt("gitlab.merge_request.iid", mr.IID)
+					putNonEmpty(attrs, "gitlab.merge_request.title", mr.Title)
+					putNonEmpty(attrs, "gitlab.merge_request.url", mr.WebURL)
+					putNonEmpty(attrs, "gitlab.merge_request.author", mr.Author.Username)
+					putNonEmpty(attrs, "gitlab.merge_request.source_branch", mr.SourceBranch)
+					putNonEmpty(attrs, "gitlab.merge_request.target_branch", mr.TargetBranch)
+
+					batch.add(project.Path, logs)
+					seen = append(seen, key)
+					if batch.records >= batchSize {
+						if err := flush(); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		page = nextPage
+	}
+	return flush()
+}
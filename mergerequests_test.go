@@ -0,0 +1,106 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestProcessMergeRequestFindings(t *testing.T) {
+	mockClient := &mockGitLabClient{
+		getProjectFunc: func(ctx context.Context, projectID string) (*GitLabProject, error) {
+			return &GitLabProject{ID: 12345, Path: "group/project"}, nil
+		},
+		listOpenMergeRequestsFunc: func(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+			mr := MergeRequest{IID: 7, Title: "Add login", SourceBranch: "login", TargetBranch: "main"}
+			mr.Author.Username = "jdoe"
+			return []MergeRequest{mr}, 0, nil
+		},
+		getMergeRequestFindingsFunc: func(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error) {
+			assert.Equal(t, "group/project", fullPath)
+			switch reportType {
+			case "sast":
+				return []map[string]interface{}{
+					{"uuid": "b1f3", "title": "SQL injection", "severity": "HIGH"},
+				}, true, nil
+			case "dast":
+				return nil, false, nil
+			default:
+				return nil, true, nil
+			}
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.MergeRequests.Enabled = true
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	require.NoError(t, receiver.processMergeRequestFindings(context.Background(), "12345"))
+	require.Equal(t, 1, sink.LogRecordCount())
+
+	rl := sink.AllLogs()[0].ResourceLogs().At(0)
+	projectPath, ok := rl.Resource().Attributes().Get("gitlab.project.path")
+	require.True(t, ok)
+	assert.Equal(t, "group/project", projectPath.Str())
+
+	raw := rl.ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, "gitlab.merge_request.finding", raw["event.name"])
+	assert.Equal(t, int64(7), raw["gitlab.merge_request.iid"])
+	assert.Equal(t, "jdoe", raw["gitlab.merge_request.author"])
+	assert.Equal(t, "sast", raw["report.type"])
+	assert.Equal(t, "SQL injection", raw["vulnerability.title"])
+
+	// Findings are only emitted once
+	require.NoError(t, receiver.processMergeRequestFindings(context.Background(), "12345"))
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestProcessMergeRequestFindings_DefaultBranchOnly(t *testing.T) {
+	var compared []int64
+	mockClient := &mockGitLabClient{
+		getProjectFunc: func(ctx context.Context, projectID string) (*GitLabProject, error) {
+			return &GitLabProject{ID: 12345, Path: "group/project", DefaultBranch: "main"}, nil
+		},
+		listOpenMergeRequestsFunc: func(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+			return []MergeRequest{
+				{IID: 7, SourceBranch: "login", TargetBranch: "main"},
+				{IID: 8, SourceBranch: "login-tests", TargetBranch: "login"},
+			}, 0, nil
+		},
+		getMergeRequestFindingsFunc: func(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error) {
+			compared = append(compared, iid)
+			return []map[string]interface{}{
+				{"uuid": fmt.Sprintf("%d-%s", iid, reportType), "title": "SQL injection", "severity": "HIGH"},
+			}, true, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.MergeRequests.Enabled = true
+	cfg.MergeRequests.ReportTypes = []string{"sast"}
+	cfg.MergeRequests.DefaultBranchOnly = true
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	require.NoError(t, receiver.processMergeRequestFindings(context.Background(), "12345"))
+	assert.Equal(t, []int64{7}, compared, "the merge request between feature branches isn't compared")
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
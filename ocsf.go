@@ -0,0 +1,167 @@
+package gitlabvulnreceiver
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// Schemas the attributes of findings can be emitted in
+const (
+	outputSchemaDefault = "default"
+	outputSchemaOCSF    = "ocsf"
+)
+
+func isValidOutputSchema(schema string) bool {
+	switch schema {
+	case "", outputSchemaDefault, outputSchemaOCSF:
+		return true
+	}
+	return false
+}
+
+// OCSF Vulnerability Finding class (2002) of the Findings category, emitted with
+// the Create activity
+const (
+	ocsfVersion        = "1.1.0"
+	ocsfClassUID       = 2002
+	ocsfCategoryUID    = 2
+	ocsfActivityCreate = 1
+)
+
+// ocsfAttributes lists the OCSF attribute of each converted attribute, as the
+// OCSF path flattened with dots (the first element of arrays). The first
+// source attribute present wins.
+var ocsfAttributes = []struct {
+	name    string
+	sources []string
+}{
+	{"finding_info.uid", []string{"vulnerability.vulnerability_id", "vulnerability.id", "vulnerability.uuid"}},
+	{"finding_info.title", []string{"vulnerability.title", "vulnerability.vulnerability", "vulnerability.name"}},
+	{"finding_info.desc", []string{"vulnerability.description", "vulnerability.details"}},
+	{"finding_info.src_url", []string{"gitlab.vulnerability.url"}},
+	{"finding_info.first_seen_time_dt", []string{"vulnerability.detected_at", "vulnerability.discovered_at"}},
+	{"vulnerabilities.cve.uid", []string{"vulnerability.cve_ids"}},
+	{"vulnerabilities.cve.references", []string{"vulnerability.cve_urls"}},
+	{"vulnerabilities.cwe.uid", []string{"vulnerability.cwe_ids"}},
+	{"vulnerabilities.cve.cvss.base_score", []string{"vulnerability.score.base", "vulnerability.cvss_score"}},
+	{"vulnerabilities.cve.cvss.vector_string", []string{"vulnerability.cvss.vector"}},
+	{"vulnerabilities.cve.cvss.version", []string{"vulnerability.cvss.version"}},
+	{"vulnerabilities.affected_packages.name", []string{"package.name", "vulnerability.package_name", "vulnerability.package"}},
+	{"vulnerabilities.affected_packages.version", []string{"package.version", "vulnerability.package_version"}},
+	{"vulnerabilities.affected_packages.fixed_in_version", []string{"vulnerability.fixed_version"}},
+	{"vulnerabilities.remediation.desc", []string{"vulnerability.solution"}},
+	{"vulnerabilities.is_fix_available", []string{"vulnerability.fix_available"}},
+	{"vulnerabilities.affected_code.file.path", []string{"code.filepath", "vulnerability.file", "vulnerability.location"}},
+	{"vulnerabilities.affected_code.start_line", []string{"code.lineno"}},
+	{"vulnerabilities.affected_code.end_line", []string{"vulnerability.location.end_line"}},
+	{"vulnerabilities.references", []string{"vulnerability.identifier_urls"}},
+}
+
+// ocsfSeverities maps GitLab severities to OCSF severity IDs and names
+var ocsfSeverities = map[string]struct {
+	id   int64
+	name string
+}{
+	"info":     {1, "Informational"},
+	"low":      {2, "Low"},
+	"medium":   {3, "Medium"},
+	"high":     {4, "High"},
+	"critical": {5, "Critical"},
+}
+
+// ocsfStatuses maps GitLab vulnerability states to OCSF finding status IDs and names
+var ocsfStatuses = map[string]struct {
+	id   int64
+	name string
+}{
+	"detected":  {1, "New"},
+	"confirmed": {2, "In Progress"},
+	"dismissed": {3, "Suppressed"},
+	"resolved":  {4, "Resolved"},
+}
+
+// applyOutputSchema renames the attributes of converted findings to the
+// configured output schema
+func (r *vulnerabilityReceiver) applyOutputSchema(logs plog.Logs) {
+	if r.cfg.OutputSchema != outputSchemaOCSF {
+		return
+	}
+	forEachLogRecord(logs, mapOCSFAttributes)
+}
+
+// mapOCSFAttributes rewrites the attributes of a finding as an OCSF Vulnerability
+// Finding. Attributes without an OCSF equivalent are kept under unmapped, except
+// event.name which identifies the record rather than the finding.
+func mapOCSFAttributes(lr plog.LogRecord) {
+	attrs := lr.Attributes()
+	mapped := pcommon.NewMap()
+
+	mapped.PutInt("class_uid", ocsfClassUID)
+	mapped.PutStr("class_name", "Vulnerability Finding")
+	mapped.PutInt("category_uid", ocsfCategoryUID)
+	mapped.PutStr("category_name", "Findings")
+	mapped.PutInt("activity_id", ocsfActivityCreate)
+	mapped.PutStr("activity_name", "Create")
+	mapped.PutInt("type_uid", ocsfClassUID*100+ocsfActivityCreate)
+	mapped.PutStr("metadata.version", ocsfVersion)
+	mapped.PutStr("metadata.product.name", "GitLab")
+	mapped.PutStr("metadata.product.vendor_name", "GitLab")
+	mapped.PutInt("time", lr.Timestamp().AsTime().UnixMilli())
+
+	// The event name and raw record are kept as they are
+	consumed := map[string]bool{"event.name": true, rawRecordAttribute: true}
+	for name := range consumed {
+		if value, ok := attrs.Get(name); ok {
+			value.CopyTo(mapped.PutEmpty(name))
+		}
+	}
+	for _, attribute := range ocsfAttributes {
+		for _, source := range attribute.sources {
+			value, ok := attrs.Get(source)
+			if !ok {
+				continue
+			}
+			consumed[source] = true
+			if _, set := mapped.Get(attribute.name); !set {
+				value.CopyTo(mapped.PutEmpty(attribute.name))
+			}
+		}
+	}
+
+	severity, id := "Unknown", int64(0)
+	if value, ok := attrs.Get("vulnerability.severity"); ok {
+		consumed["vulnerability.severity"] = true
+		if known, ok := ocsfSeverities[strings.ToLower(value.AsString())]; ok {
+			severity, id = known.name, known.id
+		}
+	}
+	mapped.PutStr("severity", severity)
+	mapped.PutInt("severity_id", id)
+
+	status, id := "Unknown", int64(0)
+	for _, source := range []string{"vulnerability.status", "vulnerability.state"} {
+		if value, ok := attrs.Get(source); ok {
+			consumed[source] = true
+			if known, ok := ocsfStatuses[strings.ToLower(value.AsString())]; ok {
+				status, id = known.name, known.id
+			}
+		}
+	}
+	mapped.PutStr("status", status)
+	mapped.PutInt("status_id", id)
+
+	if value, ok := attrs.Get("report.type"); ok {
+		consumed["report.type"] = true
+		mapped.PutEmptySlice("finding_info.types").AppendEmpty().SetStr(value.AsString())
+	}
+
+	attrs.Range(func(key string, value pcommon.Value) bool {
+		if !consumed[key] {
+			value.CopyTo(mapped.PutEmpty("unmapped." + key))
+		}
+		return true
+	})
+	mapped.MoveTo(attrs)
+}
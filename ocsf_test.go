@@ -0,0 +1,69 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+)
+
+func TestMapOCSFAttributes(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.OutputSchema = outputSchemaOCSF
+	recv := &vulnerabilityReceiver{
+		cfg:    cfg,
+		logger: zap.NewNop(),
+	}
+
+	header := []string{"Tool", "Vulnerability ID", "Vulnerability", "Details", "Severity", "Status", "CVE", "Package Name", "Solution", "Scanner Name"}
+	record := []string{"dependency_scanning", "4242", "Prototype pollution", "lodash is vulnerable", "High", "confirmed", "CVE-2021-23337", "lodash", "Upgrade to 4.17.21", "Gemnasium"}
+	logs := recv.convertToLogs(header, record, &Export{ID: 1, ProjectID: "1"})
+	lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(time.UnixMilli(1700000000000)))
+	recv.applyOutputSchema(logs)
+
+	assert.Equal(t, map[string]interface{}{
+		"class_uid":                              int64(2002),
+		"class_name":                             "Vulnerability Finding",
+		"category_uid":                           int64(2),
+		"category_name":                          "Findings",
+		"activity_id":                            int64(1),
+		"activity_name":                          "Create",
+		"type_uid":                               int64(200201),
+		"metadata.version":                       "1.1.0",
+		"metadata.product.name":                  "GitLab",
+		"metadata.product.vendor_name":           "GitLab",
+		"time":                                   int64(1700000000000),
+		"finding_info.uid":                       "4242",
+		"finding_info.title":                     "Prototype pollution",
+		"finding_info.desc":                      "lodash is vulnerable",
+		"finding_info.types":                     []interface{}{"dependency_scanning"},
+		"severity":                               "High",
+		"severity_id":                            int64(4),
+		"status":                                 "In Progress",
+		"status_id":                              int64(2),
+		"vulnerabilities.cve.references":         []interface{}{"https://www.cve.org/CVERecord?id=CVE-2021-23337"},
+		"vulnerabilities.cve.uid":                []interface{}{"CVE-2021-23337"},
+		"vulnerabilities.affected_packages.name": "lodash",
+		"vulnerabilities.affected_packages.fixed_in_version": "4.17.21",
+		"vulnerabilities.remediation.desc":                   "Upgrade to 4.17.21",
+		"vulnerabilities.is_fix_available":                   true,
+		"unmapped.vulnerability.tool":                        "dependency_scanning",
+		"unmapped.vulnerability.scanner_name":                "Gemnasium",
+	}, lr.Attributes().AsRaw())
+}
+
+func TestApplyOutputSchema_Default(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+	}
+	logs := recv.convertToLogs([]string{"Severity"}, []string{"High"}, &Export{ID: 1, ProjectID: "1"})
+	recv.applyOutputSchema(logs)
+
+	attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, "High", attrs["vulnerability.severity"])
+	assert.NotContains(t, attrs, "class_uid")
+}
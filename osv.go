@@ -0,0 +1,390 @@
+package gitlabvulnreceiver
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// osvVulnerability is the part of an OSV record used for enrichment
+type osvVulnerability struct {
+	ID       string `json:"id"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// osvCacheEntry is a cached lookup. Vulnerability is nil when OSV doesn't know
+// the CVE, so unknown CVEs aren't queried again before the entry expires.
+type osvCacheEntry struct {
+	FetchedAt     time.Time         `json:"fetched_at"`
+	Vulnerability *osvVulnerability `json:"vulnerability"`
+}
+
+// osvQueueSize bounds the CVEs waiting to be prefetched; CVEs requested while
+// the queue is full are requested again by the next finding carrying them
+const osvQueueSize = 1000
+
+// osvCacheSize bounds the lookups held in memory, and the CVEs whose lookup
+// failure was logged
+const osvCacheSize = 10000
+
+// osvCVEPattern matches the CVE IDs looked up, which end up in request paths
+var osvCVEPattern = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+
+// osvCache holds the latest lookups, evicting the least recently used one once
+// it's full
+type osvCache struct {
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// osvCacheItem is an element of osvCache
+type osvCacheItem struct {
+	cve   string
+	entry osvCacheEntry
+}
+
+func newOSVCache(maxEntries int) *osvCache {
+	return &osvCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (oc *osvCache) get(cve string) (osvCacheEntry, bool) {
+	element, ok := oc.entries[cve]
+	if !ok {
+		return osvCacheEntry{}, false
+	}
+	oc.order.MoveToFront(element)
+	return element.Value.(*osvCacheItem).entry, true
+}
+
+func (oc *osvCache) put(cve string, entry osvCacheEntry) {
+	if element, ok := oc.entries[cve]; ok {
+		element.Value.(*osvCacheItem).entry = entry
+		oc.order.MoveToFront(element)
+		return
+	}
+	oc.entries[cve] = oc.order.PushFront(&osvCacheItem{cve: cve, entry: entry})
+	for oc.order.Len() > oc.maxEntries {
+		oldest := oc.order.Back()
+		oc.order.Remove(oldest)
+		delete(oc.entries, oldest.Value.(*osvCacheItem).cve)
+	}
+}
+
+// osvClient looks CVEs up in OSV, caching results in memory and in an optional
+// directory and spacing requests out to respect the rate limit
+type osvClient struct {
+	url      string
+	cacheDir string
+	cacheTTL time.Duration
+	interval time.Duration
+	client   *http.Client
+	logger   *zap.Logger
+	queue    chan string
+
+	mu sync.Mutex
+	// cache holds the lookups read from OSV or the cache directory, and an
+	// expired entry for CVEs missing from the directory so it isn't read again
+	cache *osvCache
+	// next is the earliest time the next request may be sent
+	next time.Time
+	// queued holds the CVEs waiting in the queue, failed those whose lookup
+	// failure was already logged
+	queued map[string]bool
+	failed map[string]bool
+}
+
+func newOSVClient(cfg OSVConfig, client *http.Client, logger *zap.Logger) *osvClient {
+	return &osvClient{
+		url:      strings.TrimSuffix(cfg.URL, "/"),
+		cacheDir: cfg.CacheDir,
+		cacheTTL: cfg.CacheTTL,
+		interval: time.Duration(float64(time.Second) / cfg.RateLimit),
+		client:   client,
+		logger:   logger,
+		queue:    make(chan string, osvQueueSize),
+		cache:    newOSVCache(osvCacheSize),
+		queued:   make(map[string]bool),
+		failed:   make(map[string]bool),
+	}
+}
+
+// request queues a CVE to be prefetched, unless it's already queued
+func (c *osvClient) request(cve string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queued[cve] {
+		return
+	}
+	select {
+	case c.queue <- cve:
+		c.queued[cve] = true
+	default:
+	}
+}
+
+// run prefetches the queued CVEs at the rate limit until ctx is done. A
+// failing CVE is only logged once, and is tried again when requested again.
+func (c *osvClient) run(ctx context.Context) {
+	for {
+		var cve string
+		select {
+		case <-ctx.Done():
+			return
+		case cve = <-c.queue:
+		}
+
+		_, err := c.lookup(ctx, cve)
+
+		c.mu.Lock()
+		delete(c.queued, cve)
+		logFailure := err != nil && ctx.Err() == nil && !c.failed[cve]
+		if logFailure {
+			if len(c.failed) >= osvCacheSize {
+				clear(c.failed)
+			}
+			c.failed[cve] = true
+		} else if err == nil {
+			delete(c.failed, cve)
+		}
+		c.mu.Unlock()
+
+		if logFailure {
+			c.logger.Warn("Failed to look up OSV record", zap.String("cve", cve), zap.Error(err))
+		}
+	}
+}
+
+// lookup returns the OSV record of a CVE, nil when OSV doesn't know it
+func (c *osvClient) lookup(ctx context.Context, cve string) (*osvVulnerability, error) {
+	if entry, ok := c.cached(cve); ok {
+		return entry.Vulnerability, nil
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	vulnerability, err := c.fetch(ctx, cve)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(cve, osvCacheEntry{FetchedAt: time.Now(), Vulnerability: vulnerability})
+	return vulnerability, nil
+}
+
+// wait blocks until a request may be sent without exceeding the rate limit
+func (c *osvClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	delay := c.next.Sub(now)
+	c.next = now.Add(max(delay, 0) + c.interval)
+	c.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+func (c *osvClient) fetch(ctx context.Context, cve string) (*osvVulnerability, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/v1/vulns/"+url.PathEscape(cve), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var vulnerability osvVulnerability
+	if err := json.NewDecoder(resp.Body).Decode(&vulnerability); err != nil {
+		return nil, fmt.Errorf("failed to decode OSV record: %w", err)
+	}
+	return &vulnerability, nil
+}
+
+// cached returns an unexpired cache entry, loading it from disk the first time
+// the CVE is missing from memory
+func (c *osvClient) cached(cve string) (osvCacheEntry, bool) {
+	c.mu.Lock()
+	entry, ok := c.cache.get(cve)
+	c.mu.Unlock()
+
+	if !ok && c.cacheDir != "" {
+		data, err := os.ReadFile(c.cachePath(cve))
+		if err == nil && json.Unmarshal(data, &entry) != nil {
+			entry = osvCacheEntry{}
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			c.logger.Debug("Failed to read OSV cache entry", zap.String("cve", cve), zap.Error(err))
+		}
+		ok = true
+		c.mu.Lock()
+		c.cache.put(cve, entry)
+		c.mu.Unlock()
+	}
+
+	if !ok || time.Since(entry.FetchedAt) >= c.cacheTTL {
+		return osvCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *osvClient) store(cve string, entry osvCacheEntry) {
+	c.mu.Lock()
+	c.cache.put(cve, entry)
+	c.mu.Unlock()
+
+	if c.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(c.cacheDir, 0o700)
+	}
+	if err == nil {
+		err = os.WriteFile(c.cachePath(cve), data, 0o600)
+	}
+	if err != nil {
+		c.logger.Warn("Failed to write OSV cache entry", zap.String("cve", cve), zap.Error(err))
+	}
+}
+
+func (c *osvClient) cachePath(cve string) string {
+	return filepath.Join(c.cacheDir, filepath.Base(cve)+".json")
+}
+
+// putOSVAttributes fills in the CVSS vector, references and affected ranges of
+// findings GitLab has no CVSS vector for, from the first of their CVEs OSV knows.
+// Only cached lookups are used so processing never waits on the rate limit;
+// CVEs that aren't cached yet are prefetched for the findings of later exports.
+func (r *vulnerabilityReceiver) putOSVAttributes(lr plog.LogRecord) {
+	if r.osv == nil {
+		return
+	}
+	attrs := lr.Attributes()
+	if _, ok := attrs.Get("vulnerability.cvss.vector"); ok {
+		return
+	}
+	cves, ok := attrs.Get("vulnerability.cve_ids")
+	if !ok || cves.Type() != pcommon.ValueTypeSlice {
+		return
+	}
+
+	for i := 0; i < cves.Slice().Len(); i++ {
+		cve := cves.Slice().At(i).Str()
+		if !osvCVEPattern.MatchString(cve) {
+			continue
+		}
+		entry, ok := r.osv.cached(cve)
+		if !ok {
+			r.osv.request(cve)
+			continue
+		}
+		if entry.Vulnerability != nil {
+			putOSVVulnerability(attrs, entry.Vulnerability)
+			return
+		}
+	}
+}
+
+func putOSVVulnerability(attrs pcommon.Map, vulnerability *osvVulnerability) {
+	attrs.PutStr("vulnerability.enrichment.source", "osv")
+
+	for _, severity := range vulnerability.Severity {
+		if severity.Type != "CVSS_V3" {
+			continue
+		}
+		if vector, err := parseCVSSVector(severity.Score); err == nil {
+			putCVSSVector(attrs, vector)
+			break
+		}
+	}
+
+	var references []string
+	for _, reference := range vulnerability.References {
+		if !slices.Contains(references, reference.URL) {
+			references = append(references, reference.URL)
+		}
+	}
+	putStringSlice(attrs, "vulnerability.references", references)
+
+	var ranges []string
+	for _, affected := range vulnerability.Affected {
+		for _, r := range affected.Ranges {
+			var events []string
+			for _, event := range r.Events {
+				switch {
+				case event.Introduced != "":
+					events = append(events, "introduced "+event.Introduced)
+				case event.Fixed != "":
+					events = append(events, "fixed "+event.Fixed)
+				case event.LastAffected != "":
+					events = append(events, "last affected "+event.LastAffected)
+				}
+			}
+			if len(events) == 0 {
+				continue
+			}
+			pkg := affected.Package.Name
+			if affected.Package.Ecosystem != "" {
+				pkg = affected.Package.Ecosystem + "/" + pkg
+			}
+			ranges = append(ranges, fmt.Sprintf("%s: %s", pkg, strings.Join(events, ", ")))
+		}
+	}
+	putStringSlice(attrs, "vulnerability.affected_ranges", ranges)
+}
@@ -0,0 +1,243 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testOSVRecord = `{
+	"id": "CVE-2021-44228",
+	"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}],
+	"references": [{"type": "ADVISORY", "url": "https://nvd.nist.gov/vuln/detail/CVE-2021-44228"}],
+	"affected": [{
+		"package": {"ecosystem": "Maven", "name": "org.apache.logging.log4j:log4j-core"},
+		"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.0-beta9"}, {"fixed": "2.15.0"}]}]
+	}]
+}`
+
+func newTestOSVServer(t *testing.T, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Path != "/v1/vulns/CVE-2021-44228" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(testOSVRecord))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPutOSVAttributes(t *testing.T) {
+	var requests int
+	server := newTestOSVServer(t, &requests)
+
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+		osv: newOSVClient(OSVConfig{
+			URL:       server.URL,
+			CacheTTL:  time.Hour,
+			RateLimit: 100,
+		}, http.DefaultClient, zap.NewNop()),
+	}
+
+	t.Run("sparse finding", func(t *testing.T) {
+		convert := func() plog.Logs {
+			return recv.convertToLogs([]string{"Title", "CVE"}, []string{"Log4Shell", "CVE-2024-0001, CVE-2021-44228"}, &Export{ID: 1, ProjectID: "1"})
+		}
+
+		// Uncached CVEs are prefetched rather than looked up inline
+		logs := convert()
+		recv.putOSVAttributes(logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0))
+		attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		assert.NotContains(t, attrs, "vulnerability.enrichment.source")
+		assert.Zero(t, requests)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			recv.osv.run(ctx)
+		}()
+		require.Eventually(t, func() bool {
+			_, known := recv.osv.cached("CVE-2021-44228")
+			_, unknown := recv.osv.cached("CVE-2024-0001")
+			return known && unknown
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		logs = convert()
+		recv.putOSVAttributes(logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0))
+		attrs = logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		assert.Equal(t, "osv", attrs["vulnerability.enrichment.source"])
+		assert.Equal(t, "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", attrs["vulnerability.cvss.vector"])
+		assert.Equal(t, 10.0, attrs["vulnerability.score.base"])
+		assert.Equal(t, []interface{}{"https://nvd.nist.gov/vuln/detail/CVE-2021-44228"}, attrs["vulnerability.references"])
+		assert.Equal(t, []interface{}{"Maven/org.apache.logging.log4j:log4j-core: introduced 2.0-beta9, fixed 2.15.0"}, attrs["vulnerability.affected_ranges"])
+	})
+
+	t.Run("finding with CVSS vector", func(t *testing.T) {
+		requests = 0
+		logs := recv.convertToLogs(
+			[]string{"Title", "CVE", "CVSS Vectors"},
+			[]string{"Log4Shell", "CVE-2021-44228", "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+			&Export{ID: 1, ProjectID: "1"})
+		recv.putOSVAttributes(logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0))
+
+		attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		assert.NotContains(t, attrs, "vulnerability.enrichment.source")
+		assert.Equal(t, "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:H", attrs["vulnerability.cvss.vector"])
+		assert.Zero(t, requests)
+	})
+}
+
+func TestOSVClient_Cache(t *testing.T) {
+	var requests int
+	server := newTestOSVServer(t, &requests)
+	cfg := OSVConfig{
+		URL:       server.URL,
+		CacheDir:  t.TempDir(),
+		CacheTTL:  time.Hour,
+		RateLimit: 100,
+	}
+
+	client := newOSVClient(cfg, http.DefaultClient, zap.NewNop())
+	vulnerability, err := client.lookup(context.Background(), "CVE-2021-44228")
+	require.NoError(t, err)
+	require.NotNil(t, vulnerability)
+	unknown, err := client.lookup(context.Background(), "CVE-2024-0001")
+	require.NoError(t, err)
+	assert.Nil(t, unknown)
+	require.Equal(t, 2, requests)
+
+	// A new client reads both lookups from the cache directory
+	client = newOSVClient(cfg, http.DefaultClient, zap.NewNop())
+	vulnerability, err = client.lookup(context.Background(), "CVE-2021-44228")
+	require.NoError(t, err)
+	require.NotNil(t, vulnerability)
+	assert.Equal(t, "CVE-2021-44228", vulnerability.ID)
+	unknown, err = client.lookup(context.Background(), "CVE-2024-0001")
+	require.NoError(t, err)
+	assert.Nil(t, unknown)
+	assert.Equal(t, 2, requests)
+}
+
+func TestOSVClient_RateLimit(t *testing.T) {
+	var requests int
+	server := newTestOSVServer(t, &requests)
+	client := newOSVClient(OSVConfig{
+		URL:       server.URL,
+		CacheTTL:  time.Hour,
+		RateLimit: 20,
+	}, http.DefaultClient, zap.NewNop())
+
+	start := time.Now()
+	for _, cve := range []string{"CVE-2024-0001", "CVE-2024-0002", "CVE-2024-0003"} {
+		_, err := client.lookup(context.Background(), cve)
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, 3, requests)
+}
+
+func TestOSVClient_Run(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	core, observed := observer.New(zap.WarnLevel)
+	client := newOSVClient(OSVConfig{
+		URL:       server.URL,
+		CacheTTL:  time.Hour,
+		RateLimit: 1000,
+	}, http.DefaultClient, zap.New(core))
+
+	// A CVE already queued isn't queued twice
+	client.request("CVE-2021-44228")
+	client.request("CVE-2021-44228")
+	assert.Len(t, client.queue, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitIdle := func() {
+		require.Eventually(t, func() bool {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			return len(client.queued) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	waitIdle()
+
+	// Failures are retried when requested again but only logged once
+	client.request("CVE-2021-44228")
+	waitIdle()
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, observed.FilterMessage("Failed to look up OSV record").Len())
+}
+
+func TestOSVClient_CacheDirectoryReadOnce(t *testing.T) {
+	cfg := OSVConfig{URL: "http://localhost", CacheDir: t.TempDir(), CacheTTL: time.Hour, RateLimit: 100}
+	client := newOSVClient(cfg, http.DefaultClient, zap.NewNop())
+
+	// A CVE missing from the directory isn't looked for there again
+	_, ok := client.cached("CVE-2021-44228")
+	assert.False(t, ok)
+	require.NoError(t, os.WriteFile(client.cachePath("CVE-2021-44228"), []byte(`{"fetched_at": "`+time.Now().Format(time.RFC3339)+`"}`), 0o600))
+	_, ok = client.cached("CVE-2021-44228")
+	assert.False(t, ok)
+}
+
+func TestOSVCache_Bounded(t *testing.T) {
+	cache := newOSVCache(2)
+	cache.put("CVE-2024-0001", osvCacheEntry{})
+	cache.put("CVE-2024-0002", osvCacheEntry{})
+	_, ok := cache.get("CVE-2024-0001")
+	require.True(t, ok)
+
+	// The least recently used entry is evicted
+	cache.put("CVE-2024-0003", osvCacheEntry{})
+	_, ok = cache.get("CVE-2024-0002")
+	assert.False(t, ok)
+	_, ok = cache.get("CVE-2024-0001")
+	assert.True(t, ok)
+	assert.Len(t, cache.entries, 2)
+}
+
+func TestPutOSVAttributes_InvalidCVE(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+		osv:    newOSVClient(OSVConfig{URL: "http://localhost", CacheTTL: time.Hour, RateLimit: 100}, http.DefaultClient, zap.NewNop()),
+	}
+	lr := plog.NewLogRecord()
+	cves := lr.Attributes().PutEmptySlice("vulnerability.cve_ids")
+	cves.AppendEmpty().SetStr("CVE-2021-44228/../../admin")
+
+	// IDs that aren't CVEs are never requested
+	recv.putOSVAttributes(lr)
+	assert.Empty(t, recv.osv.queue)
+}
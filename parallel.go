@@ -0,0 +1,257 @@
+package gitlabvulnreceiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// Size of the chunks a spilled CSV export is split into for parallel parsing
+const parseChunkSize = 4 << 20
+
+// decodedRow is a record or the error of a row, in the order of the export
+type decodedRow struct {
+	record *exportRecord
+	err    error
+}
+
+// parallelCSVDecoder parses a CSV export on disk in chunks of whole records on
+// several workers, returning the records in the order of the file. At most one
+// chunk per worker is held ahead of the reader.
+type parallelCSVDecoder struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// chunks holds the result of each chunk in order, filled once it's parsed
+	chunks chan chan []decodedRow
+	rows   []decodedRow
+	// wg tracks the workers and the splitter, which read the file until done
+	wg sync.WaitGroup
+}
+
+// newParallelCSVDecoder starts parsing the CSV export in file. prepare is called
+// by the workers with every record they parse.
+func newParallelCSVDecoder(
+	ctx context.Context,
+	file io.ReaderAt,
+	size int64,
+	workers int,
+	chunkSize int64,
+	prepare func(*exportRecord) error,
+) (*parallelCSVDecoder, error) {
+	header, offset, err := readCSVHeader(file, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	d := &parallelCSVDecoder{
+		ctx:    ctx,
+		cancel: cancel,
+		chunks: make(chan chan []decodedRow, workers),
+	}
+
+	type job struct {
+		start, end int64
+		result     chan []decodedRow
+	}
+	jobs := make(chan job)
+	d.wg.Add(workers + 1)
+	for range workers {
+		go func() {
+			defer d.wg.Done()
+			for j := range jobs {
+				j.result <- parseCSVChunk(file, header, j.start, j.end, prepare)
+			}
+		}()
+	}
+
+	go func() {
+		defer d.wg.Done()
+		defer close(d.chunks)
+		defer close(jobs)
+		err := splitCSV(file, offset, size, chunkSize, func(start, end int64) bool {
+			result := make(chan []decodedRow, 1)
+			select {
+			case d.chunks <- result:
+			case <-ctx.Done():
+				return false
+			}
+			select {
+			case jobs <- job{start: start, end: end, result: result}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil {
+			result := make(chan []decodedRow, 1)
+			result <- []decodedRow{{err: fmt.Errorf("failed to read CSV record: %w", err)}}
+			select {
+			case d.chunks <- result:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return d, nil
+}
+
+func (d *parallelCSVDecoder) Read() (*exportRecord, error) {
+	for len(d.rows) == 0 {
+		result, ok := <-d.chunks
+		if !ok {
+			// The rest of the export wasn't read when parsing was canceled
+			if err := d.ctx.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		select {
+		case d.rows = <-result:
+		case <-d.ctx.Done():
+			return nil, d.ctx.Err()
+		}
+	}
+	row := d.rows[0]
+	d.rows = d.rows[1:]
+	return row.record, row.err
+}
+
+// Close stops the workers and waits for them to return, so the file can be
+// removed afterwards. The records not read yet are discarded
+func (d *parallelCSVDecoder) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// parseCSVChunk parses the records between start and end, stopping at the
+// first error the rest of the chunk can't be read after
+func parseCSVChunk(file io.ReaderAt, header []string, start, end int64, prepare func(*exportRecord) error) []decodedRow {
+	reader := csv.NewReader(io.NewSectionReader(file, start, end-start))
+	reader.FieldsPerRecord = len(header)
+	decoder := &csvDecoder{reader: reader, header: header}
+
+	var rows []decodedRow
+	for {
+		record, err := decoder.Read()
+		if err == io.EOF {
+			return rows
+		}
+		if record != nil && prepare != nil {
+			if err = prepare(record); err != nil {
+				record = nil
+			}
+		}
+		rows = append(rows, decodedRow{record: record, err: err})
+		var malformed *malformedRowError
+		if err != nil && !errors.As(err, &malformed) {
+			return rows
+		}
+	}
+}
+
+// readCSVHeader returns the header of a CSV file and the offset of its first record
+func readCSVHeader(file io.ReaderAt, size int64) ([]string, int64, error) {
+	var end int64
+	err := splitCSV(file, 0, size, 1, func(_, recordEnd int64) bool {
+		end = recordEnd
+		return false
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	header, err := csv.NewReader(io.NewSectionReader(file, 0, end)).Read()
+	if err != nil {
+		return nil, 0, err
+	}
+	return header, end, nil
+}
+
+// splitCSV calls emit with consecutive ranges of whole records from offset to
+// size, each at least chunkSize bytes long except the last one, until emit
+// returns false. Newlines in quoted fields are told apart from record ends by
+// counting quotes, escaped quotes being doubled.
+func splitCSV(file io.ReaderAt, offset, size, chunkSize int64, emit func(start, end int64) bool) error {
+	reader := io.NewSectionReader(file, offset, size-offset)
+	buf := make([]byte, 64<<10)
+	start, pos := offset, offset
+	quoted := false
+	for {
+		n, err := reader.Read(buf)
+		data := buf[:n]
+		for len(data) > 0 {
+			i := bytes.IndexAny(data, "\"\n")
+			if i < 0 {
+				pos += int64(len(data))
+				break
+			}
+			pos += int64(i) + 1
+			if data[i] == '"' {
+				quoted = !quoted
+			} else if !quoted && pos-start >= chunkSize {
+				if !emit(start, pos) {
+					return nil
+				}
+				start = pos
+			}
+			data = data[i+1:]
+		}
+		if err == io.EOF {
+			if pos > start {
+				emit(start, pos)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// prepareRecord applies the field limits to a record and converts it on the
+// parsing workers, unless processRecords is going to skip it as already emitted
+// or filtered. The check only saves work: processRecords checks again and
+// decides what is emitted. Records are marked seen once the whole export is
+// processed, so both see the same state, and a row repeated in the export is
+// emitted twice as it is when parsed serially.
+func (r *vulnerabilityReceiver) prepareRecord(record *exportRecord, export *Export) error {
+	if err := r.limitFields(record); err != nil {
+		return err
+	}
+	vulnID := generateVulnID(record.header, record.values)
+	if r.stateManager.IsSeen(state.ProcessedKeyPrefix+vulnID) || r.filtered(record) {
+		return nil
+	}
+	record.logs = r.convertRecord(record, export)
+	record.converted = true
+	return nil
+}
+
+// newSpilledDecoder returns the decoder of an export spilled to disk, parsing
+// CSV exports on parse_workers workers when there are several
+func (r *vulnerabilityReceiver) newSpilledDecoder(
+	ctx context.Context,
+	spilled *spillFile,
+	contentType string,
+	export *Export,
+) (exportDecoder, func(), error) {
+	if r.cfg.ParseWorkers <= 1 || isJSONFormat(export.Format) || isJSONFormat(contentType) {
+		return &fieldLimitDecoder{exportDecoder: newExportDecoder(spilled, contentType, export.Format), receiver: r}, func() {}, nil
+	}
+
+	info, err := spilled.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat temp file: %w", err)
+	}
+	prepare := func(record *exportRecord) error { return r.prepareRecord(record, export) }
+	decoder, err := newParallelCSVDecoder(ctx, spilled, info.Size(), r.cfg.ParseWorkers, parseChunkSize, prepare)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decoder, decoder.Close, nil
+}
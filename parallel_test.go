@@ -0,0 +1,256 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+const parallelTestCSV = "Tool,Severity,Vulnerability,Details\n" +
+	"sast,high,First,plain\n" +
+	"sast,low,\"Second, quoted\",\"line one\nline two\"\n" +
+	"sast,medium,Third,\"say \"\"hi\"\"\n\"\n" +
+	"sast,low,Malformed\n" +
+	"sast,critical,Fifth,\"a\r\nb\"\r\n" +
+	"sast,info,Sixth,last"
+
+// readRows reads a decoder to the end, recording malformed rows by their error
+func readRows(t *testing.T, decoder exportDecoder) []string {
+	t.Helper()
+	var rows []string
+	for {
+		record, err := decoder.Read()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			var malformed *malformedRowError
+			require.ErrorAs(t, err, &malformed)
+			rows = append(rows, "malformed")
+			continue
+		}
+		rows = append(rows, strings.Join(record.values, "|"))
+	}
+}
+
+func TestSplitCSV(t *testing.T) {
+	file := strings.NewReader(parallelTestCSV)
+	var chunks []string
+	err := splitCSV(file, 0, file.Size(), 1, func(start, end int64) bool {
+		chunks = append(chunks, parallelTestCSV[start:end])
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Tool,Severity,Vulnerability,Details\n",
+		"sast,high,First,plain\n",
+		"sast,low,\"Second, quoted\",\"line one\nline two\"\n",
+		"sast,medium,Third,\"say \"\"hi\"\"\n\"\n",
+		"sast,low,Malformed\n",
+		"sast,critical,Fifth,\"a\r\nb\"\r\n",
+		"sast,info,Sixth,last",
+	}, chunks)
+
+	chunks = nil
+	require.NoError(t, splitCSV(file, 0, file.Size(), 60, func(start, end int64) bool {
+		chunks = append(chunks, parallelTestCSV[start:end])
+		return true
+	}))
+	assert.Equal(t, parallelTestCSV, strings.Join(chunks, ""))
+	for _, chunk := range chunks[:len(chunks)-1] {
+		assert.GreaterOrEqual(t, len(chunk), 60)
+	}
+}
+
+func TestParallelCSVDecoder_MatchesSequential(t *testing.T) {
+	want := readRows(t, newCSVDecoder(csv.NewReader(strings.NewReader(parallelTestCSV))))
+	require.Len(t, want, 6)
+
+	for _, chunkSize := range []int64{1, 30, 1 << 20} {
+		t.Run(fmt.Sprintf("chunk size %d", chunkSize), func(t *testing.T) {
+			file := strings.NewReader(parallelTestCSV)
+			decoder, err := newParallelCSVDecoder(context.Background(), file, file.Size(), 3, chunkSize, nil)
+			require.NoError(t, err)
+			defer decoder.Close()
+			assert.Equal(t, want, readRows(t, decoder))
+		})
+	}
+}
+
+func TestParallelCSVDecoder_EmptyExport(t *testing.T) {
+	_, err := newParallelCSVDecoder(context.Background(), strings.NewReader(""), 0, 2, 1, nil)
+	assert.ErrorContains(t, err, "failed to read CSV header")
+}
+
+func TestParallelCSVDecoder_Canceled(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Tool,Severity,Vulnerability\n")
+	for i := range 1000 {
+		fmt.Fprintf(&b, "sast,high,Finding %d\n", i)
+	}
+	file := strings.NewReader(b.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	decoder, err := newParallelCSVDecoder(ctx, file, file.Size(), 2, 64, nil)
+	require.NoError(t, err)
+	defer decoder.Close()
+
+	_, err = decoder.Read()
+	require.NoError(t, err)
+	cancel()
+
+	// A canceled export isn't mistaken for a complete one
+	for err == nil {
+		_, err = decoder.Read()
+	}
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// gatedReaderAt blocks reads past the header until released, counting the
+// reads in progress
+type gatedReaderAt struct {
+	r        io.ReaderAt
+	entered  chan struct{}
+	once     sync.Once
+	release  chan struct{}
+	inFlight atomic.Int32
+}
+
+func (g *gatedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	g.inFlight.Add(1)
+	defer g.inFlight.Add(-1)
+	if off > 0 {
+		g.once.Do(func() { close(g.entered) })
+		<-g.release
+	}
+	return g.r.ReadAt(p, off)
+}
+
+func TestParallelCSVDecoder_CloseWaitsForWorkers(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Tool,Severity,Vulnerability\n")
+	for i := range 5000 {
+		fmt.Fprintf(&b, "sast,high,Finding %d\n", i)
+	}
+	content := strings.NewReader(b.String())
+	file := &gatedReaderAt{r: content, entered: make(chan struct{}), release: make(chan struct{})}
+
+	decoder, err := newParallelCSVDecoder(context.Background(), file, content.Size(), 4, 64, nil)
+	require.NoError(t, err)
+	// Reads are held until Close cancels the decoder, which must then wait for them
+	context.AfterFunc(decoder.ctx, func() { close(file.release) })
+	<-file.entered
+
+	decoder.Close()
+	assert.Zero(t, file.inFlight.Load(), "file still read after the decoder was closed")
+}
+
+func TestProcessExport_ParallelParsing(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Tool,Severity,Vulnerability,Project Name,Details\n")
+	for i := range 200 {
+		fmt.Fprintf(&b, "sast,high,Finding %d,group/project-%d,\"multi\nline\"\n", i, i%3)
+	}
+	csvData := b.String()
+
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, groupID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, GroupID: &groupID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData)), ContentType: "text/csv", Size: -1}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SpillThreshold = 1
+	cfg.SpillDirectory = t.TempDir()
+	cfg.ParseWorkers = 4
+	cfg.BatchSize = 1000
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	groupID := "42"
+	require.NoError(t, receiver.processExport(context.Background(), groupID, &Export{ID: 123, GroupID: &groupID}))
+	require.Equal(t, 200, sink.LogRecordCount())
+
+	// Records are grouped by project and keep the order of the export
+	logs := sink.AllLogs()[0]
+	require.Equal(t, 3, logs.ResourceLogs().Len())
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		path, ok := rl.Resource().Attributes().Get("gitlab.project.path")
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("group/project-%d", i), path.Str())
+
+		records := rl.ScopeLogs().At(0).LogRecords()
+		for j := 0; j < records.Len(); j++ {
+			name, ok := records.At(j).Attributes().Get("vulnerability.vulnerability")
+			require.True(t, ok)
+			assert.Equal(t, fmt.Sprintf("Finding %d", j*3+i), name.Str())
+		}
+	}
+}
+
+func TestProcessExport_ParallelParsingDuplicates(t *testing.T) {
+	// Repeats land in other chunks, prepared by the workers before either is emitted
+	var b strings.Builder
+	b.WriteString("Tool,Severity,Vulnerability,Details\n")
+	for range 2 {
+		for i := range 100 {
+			fmt.Fprintf(&b, "sast,high,Finding %d,Details %d\n", i, i)
+		}
+	}
+	csvData := b.String()
+
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, groupID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, GroupID: &groupID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData)), ContentType: "text/csv", Size: -1}, nil
+		},
+	}
+
+	// Parallel parsing emits the same records as serial parsing, for this
+	// export and the next one
+	for _, workers := range []int{1, 4} {
+		cfg := createDefaultConfig().(*Config)
+		cfg.SpillThreshold = 1
+		cfg.SpillDirectory = t.TempDir()
+		cfg.ParseWorkers = workers
+		sink := new(consumertest.LogsSink)
+		receiver := &vulnerabilityReceiver{
+			cfg:          cfg,
+			consumer:     sink,
+			client:       mockClient,
+			logger:       zap.NewNop(),
+			stateManager: newTestStateManager(t),
+		}
+
+		groupID := "42"
+		require.NoError(t, receiver.processExport(context.Background(), groupID, &Export{ID: 123, GroupID: &groupID}))
+		assert.Equal(t, 200, sink.LogRecordCount(), "workers: %d", workers)
+
+		sink.Reset()
+		require.NoError(t, receiver.processExport(context.Background(), groupID, &Export{ID: 124, GroupID: &groupID}))
+		assert.Zero(t, sink.LogRecordCount(), "workers: %d", workers)
+	}
+}
@@ -0,0 +1,109 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+// isPathURL reports whether a path ID is a GitLab URL rather than an ID or full path
+func isPathURL(id string) bool {
+	return strings.HasPrefix(id, "https://") || strings.HasPrefix(id, "http://")
+}
+
+// parsePathURL splits the URL of a project or group into the GitLab instance's
+// URL and the full path of the project or group. A base_url the URL starts with
+// is kept, so instances served under a relative URL work; otherwise the instance
+// is the URL's scheme and host, which base_url must match when it's set.
+func parsePathURL(id, baseURL string) (string, string, error) {
+	u, err := url.Parse(id)
+	if err != nil || u.Host == "" {
+		return "", "", fmt.Errorf("invalid path URL %q", id)
+	}
+
+	instance := u.Scheme + "://" + u.Host
+	trimmedBase := strings.TrimSuffix(baseURL, "/")
+	switch {
+	case trimmedBase != "" && strings.HasPrefix(id, trimmedBase+"/"):
+		instance = trimmedBase
+	case trimmedBase != "" && trimmedBase != instance:
+		return "", "", fmt.Errorf("path URL %q is not on base_url %s", id, baseURL)
+	}
+
+	// Web URLs of pages under a project or group, e.g. its vulnerability report,
+	// hold the path before the /-/ separator
+	fullPath, _, _ := strings.Cut(strings.TrimPrefix(u.Path, strings.TrimPrefix(instance, u.Scheme+"://"+u.Host)), "/-/")
+	fullPath = strings.Trim(fullPath, "/")
+	if fullPath == "" {
+		return "", "", fmt.Errorf("path URL %q has no project or group path", id)
+	}
+	return instance, fullPath, nil
+}
+
+// pathResolver looks up projects and groups by full path
+type pathResolver interface {
+	GetProject(ctx context.Context, projectID string) (*GitLabProject, error)
+	ValidateGroup(ctx context.Context, groupID string) (*GitLabGroup, error)
+}
+
+// withResolvedPaths returns the configuration with the paths given as URLs
+// replaced by the numeric ID of their project or group, looking up their type
+// when it isn't set, and base_url set to their instance. The configuration is returned as is without such paths.
+func withResolvedPaths(ctx context.Context, client pathResolver, cfg *Config) (*Config, error) {
+	if !slices.ContainsFunc(cfg.Paths, func(path PathConfig) bool { return isPathURL(path.ID) }) {
+		return cfg, nil
+	}
+
+	paths := make([]PathConfig, 0, len(cfg.Paths))
+	for _, path := range cfg.Paths {
+		if !isPathURL(path.ID) {
+			paths = append(paths, path)
+			continue
+		}
+
+		_, fullPath, err := parsePathURL(path.ID, cfg.pathBaseURL())
+		if err != nil {
+			return nil, err
+		}
+		resolved, err := resolvePath(ctx, client, fullPath, path.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %s: %w", path.ID, err)
+		}
+		if err := cfg.validatePathFeatures(resolved.Type); err != nil {
+			return nil, err
+		}
+		paths = append(paths, resolved)
+	}
+
+	resolved := *cfg
+	resolved.BaseURL = cfg.instanceURL()
+	resolved.Paths = paths
+	return &resolved, nil
+}
+
+// resolvePath looks up the project or group of a full path, trying the project
+// first when the type isn't known
+func resolvePath(ctx context.Context, client pathResolver, fullPath, pathType string) (PathConfig, error) {
+	escaped := url.PathEscape(fullPath)
+	if pathType != "group" {
+		project, err := client.GetProject(ctx, escaped)
+		if err == nil {
+			return PathConfig{ID: strconv.Itoa(project.ID), Type: "project"}, nil
+		}
+		if code, ok := gitlab.StatusCode(err); pathType == "project" || !ok || code != http.StatusNotFound {
+			return PathConfig{}, err
+		}
+	}
+
+	group, err := client.ValidateGroup(ctx, escaped)
+	if err != nil {
+		return PathConfig{}, err
+	}
+	return PathConfig{ID: strconv.Itoa(group.ID), Type: "group"}, nil
+}
@@ -0,0 +1,140 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestParsePathURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		baseURL      string
+		wantBaseURL  string
+		wantFullPath string
+		wantErr      string
+	}{
+		{
+			name:         "project",
+			id:           "https://gitlab.com/mygroup/myproject",
+			wantBaseURL:  "https://gitlab.com",
+			wantFullPath: "mygroup/myproject",
+		},
+		{
+			name:         "page of a project",
+			id:           "https://gitlab.example.com:8443/mygroup/sub/myproject/-/security/vulnerability_report",
+			wantBaseURL:  "https://gitlab.example.com:8443",
+			wantFullPath: "mygroup/sub/myproject",
+		},
+		{
+			name:         "instance under a relative URL",
+			id:           "https://example.com/gitlab/mygroup/",
+			baseURL:      "https://example.com/gitlab",
+			wantBaseURL:  "https://example.com/gitlab",
+			wantFullPath: "mygroup",
+		},
+		{
+			name:    "other instance",
+			id:      "https://gitlab.example.com/mygroup",
+			baseURL: "https://gitlab.com",
+			wantErr: "is not on base_url",
+		},
+		{
+			name:    "no path",
+			id:      "https://gitlab.com/",
+			wantErr: "has no project or group path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, fullPath, err := parsePathURL(tt.id, tt.baseURL)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBaseURL, baseURL)
+			assert.Equal(t, tt.wantFullPath, fullPath)
+		})
+	}
+}
+
+func TestWithResolvedPaths(t *testing.T) {
+	var lookups []string
+	client := &mockGitLabClient{
+		getProjectFunc: func(ctx context.Context, projectID string) (*GitLabProject, error) {
+			lookups = append(lookups, "project "+projectID)
+			if projectID == "mygroup%2Fmyproject" {
+				return &GitLabProject{ID: 12345, Path: "mygroup/myproject"}, nil
+			}
+			return nil, fmt.Errorf("failed to get project: %w", &gitlab.APIError{StatusCode: 404})
+		},
+		validateGroupFunc: func(ctx context.Context, groupID string) (*GitLabGroup, error) {
+			lookups = append(lookups, "group "+groupID)
+			return &GitLabGroup{ID: 67890, Path: "mygroup"}, nil
+		},
+	}
+
+	resolve := func(path PathConfig) (*Config, error) {
+		cfg := createDefaultConfig().(*Config)
+		cfg.Token = "test-token"
+		cfg.Paths = []PathConfig{path}
+		require.NoError(t, cfg.Validate())
+		return withResolvedPaths(context.Background(), client, cfg)
+	}
+
+	cfg, err := resolve(PathConfig{ID: "https://gitlab.com/mygroup/myproject"})
+	require.NoError(t, err)
+	assert.Equal(t, []PathConfig{{ID: "12345", Type: "project"}}, cfg.Paths)
+	assert.Equal(t, "https://gitlab.com", cfg.BaseURL)
+
+	// A path that isn't a project is looked up as a group
+	lookups = nil
+	cfg, err = resolve(PathConfig{ID: "https://gitlab.com/mygroup"})
+	require.NoError(t, err)
+	assert.Equal(t, []PathConfig{{ID: "67890", Type: "group"}}, cfg.Paths)
+	assert.Equal(t, []string{"project mygroup", "group mygroup"}, lookups)
+
+	// A set type is looked up only
+	lookups = nil
+	_, err = resolve(PathConfig{ID: "https://gitlab.com/mygroup", Type: "group"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"group mygroup"}, lookups)
+
+	// base_url is set to the instance of the URL
+	cfg, err = resolve(PathConfig{ID: "https://gitlab.example.com/mygroup/myproject"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com", cfg.BaseURL)
+
+	// Other paths are kept
+	cfg, err = resolve(PathConfig{ID: "12345", Type: "project"})
+	require.NoError(t, err)
+	assert.Equal(t, []PathConfig{{ID: "12345", Type: "project"}}, cfg.Paths)
+}
+
+func TestWithResolvedPaths_UnsupportedFeature(t *testing.T) {
+	client := &mockGitLabClient{
+		getProjectFunc: func(ctx context.Context, projectID string) (*GitLabProject, error) {
+			return nil, &gitlab.APIError{StatusCode: 404}
+		},
+		validateGroupFunc: func(ctx context.Context, groupID string) (*GitLabGroup, error) {
+			return &GitLabGroup{ID: 67890, Path: "mygroup"}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Token = "test-token"
+	cfg.Paths = []PathConfig{{ID: "https://gitlab.com/mygroup"}}
+	cfg.AuditTrail = true
+	require.NoError(t, cfg.Validate())
+
+	_, err := withResolvedPaths(context.Background(), client, cfg)
+	require.EqualError(t, err, "audit_trail is only supported for project paths")
+}
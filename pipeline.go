@@ -0,0 +1,52 @@
+package gitlabvulnreceiver
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// Columns holding the pipeline a finding was detected in, in order of preference
+var pipelineIDColumns = []string{"Pipeline ID", "Detected In Pipeline", "Detected In Pipeline ID", "Pipeline"}
+
+// Columns holding the commit a finding was detected at, in order of preference
+var commitSHAColumns = []string{"Commit SHA", "Pipeline SHA", "Commit"}
+
+// putPipelineAttributes sets the pipeline and commit a finding was detected
+// in, under the CI/CD and VCS semantic convention names with semconv_mapping.
+// With pipeline_trace_correlation the record also joins the pipeline's trace.
+func (r *vulnerabilityReceiver) putPipelineAttributes(lr plog.LogRecord, header []string, record []string) {
+	pipelineKey, commitKey := "gitlab.pipeline.id", "gitlab.commit.sha"
+	if r.semconvMapping() {
+		pipelineKey, commitKey = "cicd.pipeline.run.id", "vcs.ref.head.revision"
+	}
+
+	attrs := lr.Attributes()
+	if sha := firstField(header, record, commitSHAColumns...); sha != "" {
+		attrs.PutStr(commitKey, strings.TrimSpace(sha))
+	}
+	value := strings.TrimSpace(firstField(header, record, pipelineIDColumns...))
+	if value == "" {
+		return
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		attrs.PutStr(pipelineKey, value)
+		return
+	}
+	attrs.PutInt(pipelineKey, id)
+	if r.cfg.PipelineTraceCorrelation {
+		lr.SetTraceID(pipelineTraceID(id))
+	}
+}
+
+// pipelineTraceID is the trace ID of a pipeline: its ID as a 128-bit number,
+// which CI jobs can reproduce with printf '%032x' "$CI_PIPELINE_ID"
+func pipelineTraceID(pipelineID int64) pcommon.TraceID {
+	var traceID pcommon.TraceID
+	binary.BigEndian.PutUint64(traceID[8:], uint64(pipelineID))
+	return traceID
+}
@@ -0,0 +1,46 @@
+package gitlabvulnreceiver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPutPipelineAttributes(t *testing.T) {
+	export := &Export{ID: 123, ProjectID: "test-project"}
+	header := []string{"Title", "Detected In Pipeline", "Commit SHA"}
+	record := []string{"SQL injection", "1234567", "a1b2c3d"}
+
+	cfg := createDefaultConfig().(*Config)
+	recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+	lr := recv.convertToLogs(header, record, export).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	raw := lr.Attributes().AsRaw()
+	assert.Equal(t, int64(1234567), raw["gitlab.pipeline.id"])
+	assert.Equal(t, "a1b2c3d", raw["gitlab.commit.sha"])
+	assert.True(t, lr.TraceID().IsEmpty())
+
+	// Semantic convention names, and the trace of the pipeline
+	cfg.SemconvMapping = true
+	cfg.PipelineTraceCorrelation = true
+	lr = recv.convertToLogs(header, record, export).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	raw = lr.Attributes().AsRaw()
+	assert.Equal(t, int64(1234567), raw["cicd.pipeline.run.id"])
+	assert.Equal(t, "a1b2c3d", raw["vcs.ref.head.revision"])
+	assert.Equal(t, "0000000000000000000000000012d687", lr.TraceID().String())
+
+	// Pipelines that aren't numeric IDs are kept but not correlated
+	lr = recv.convertToLogs([]string{"Pipeline"}, []string{"nightly"}, export).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, "nightly", lr.Attributes().AsRaw()["cicd.pipeline.run.id"])
+	assert.True(t, lr.TraceID().IsEmpty())
+}
+
+func TestJSONDecoder_Pipeline(t *testing.T) {
+	records := readAllRecords(t, newJSONDecoder(strings.NewReader(`{"id": 1, "pipeline": {"id": 42, "sha": "a1b2c3d", "ref": "main"}}`)))
+	require.Len(t, records, 1)
+
+	assert.Equal(t, "42", firstField(records[0].header, records[0].values, pipelineIDColumns...))
+	assert.Equal(t, "a1b2c3d", firstField(records[0].header, records[0].values, commitSHAColumns...))
+}
@@ -0,0 +1,788 @@
+// Package gitlab is a client for the GitLab API used to export and read
+// vulnerabilities, usable outside the collector.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Client sends requests to the GitLab API of an instance
+type Client struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	logger  *zap.Logger
+	// conditional holds the responses conditional requests are made on, nil
+	// when they're disabled
+	conditional *responseCache
+	// pollBackoff sets the waits between export status checks
+	pollBackoff PollBackoff
+	// unknownStatus is what WaitForExport does with an unknown export status
+	unknownStatus UnknownStatusPolicy
+	// stuckThreshold is how long WaitForExport waits for a created export to
+	// start, 0 for as long as the timeout allows
+	stuckThreshold time.Duration
+	// exportParams are sent in the body of the requests creating exports
+	exportParams map[string]any
+	// vulnerabilityOrder is the order ListVulnerabilities requests
+	vulnerabilityOrder VulnerabilityOrder
+	// apiPath and graphQLPath are where the APIs are below baseURL
+	apiPath     string
+	graphQLPath string
+}
+
+// ExportStatus is the status of a vulnerability export
+type ExportStatus string
+
+const (
+	ExportStatusCreated  ExportStatus = "created"
+	ExportStatusStarted  ExportStatus = "running"
+	ExportStatusFinished ExportStatus = "finished"
+	ExportStatusFailed   ExportStatus = "failed"
+)
+
+// Paths of the REST and GraphQL APIs of an instance, unless set otherwise
+const (
+	DefaultAPIPath     = "/api/v4"
+	DefaultGraphQLPath = "/api/graphql"
+)
+
+// Export is a vulnerability export of a project or group
+type Export struct {
+	ID         int64        `json:"id"`
+	ProjectID  interface{}  `json:"project_id"`
+	GroupID    interface{}  `json:"group_id"`
+	Status     ExportStatus `json:"status"`
+	CreatedAt  time.Time    `json:"created_at"`
+	StartedAt  *time.Time   `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at"`
+	Format     string       `json:"format"`
+	Links      struct {
+		Self     string `json:"self"`
+		Download string `json:"download"`
+	} `json:"_links"`
+}
+
+// GetProjectID returns project ID as string regardless of original type
+func (e *Export) GetProjectID() string {
+	return idToString(e.ProjectID)
+}
+
+// GetGroupID returns group ID as string regardless of original type
+func (e *Export) GetGroupID() string {
+	return idToString(e.GroupID)
+}
+
+// idToString converts a JSON-decoded ID to a string
+func idToString(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+// Project is a GitLab project
+type Project struct {
+	ID            int              `json:"id"`
+	Name          string           `json:"name"`
+	Path          string           `json:"path_with_namespace"`
+	Namespace     ProjectNamespace `json:"namespace"`
+	DefaultBranch string           `json:"default_branch"`
+	Visibility    string           `json:"visibility"`
+	Topics        []string         `json:"topics"`
+}
+
+// ProjectNamespace is the group or user namespace a project belongs to
+type ProjectNamespace struct {
+	FullPath string `json:"full_path"`
+}
+
+// Group is a GitLab group
+type Group struct {
+	ID   int    `json:"id"`
+	Path string `json:"full_path"`
+}
+
+// API is the GitLab API covered by Client, for faking it in tests of code
+// using the client
+type API interface {
+	CreateExport(ctx context.Context, projectID string) (*Export, error)
+	CreateGroupExport(ctx context.Context, groupID string) (*Export, error)
+	GetExport(ctx context.Context, projectID string, exportID int64) (*Export, error)
+	GetGroupExport(ctx context.Context, groupID string, exportID int64) (*Export, error)
+	WaitForExport(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error)
+	WaitForGroupExport(ctx context.Context, groupID string, exportID int64, timeout time.Duration) (*Export, error)
+	GetExportData(ctx context.Context, downloadURL string) (*ExportData, error)
+	ListVulnerabilities(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error)
+	ListDependencies(ctx context.Context, projectID string, page int) ([]Dependency, int, error)
+	ListOpenMergeRequests(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error)
+	GetApprovalRules(ctx context.Context, projectID string, iid int64) ([]ApprovalRule, error)
+	GetMergeRequestFindings(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error)
+	GetIssueLinks(ctx context.Context, vulnerabilityID string) ([]IssueLink, error)
+	GetStateTransitions(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error)
+	GetLatestJob(ctx context.Context, projectID, ref, name string) (*Job, error)
+	GetJobArtifacts(ctx context.Context, projectID string, jobID int64) (io.ReadCloser, error)
+	GetProject(ctx context.Context, projectID string) (*Project, error)
+	GetProjectStatistics(ctx context.Context, fullPath string) (*VulnerabilityStatistics, error)
+	GetGroupStatistics(ctx context.Context, fullPath string) (*VulnerabilityStatistics, error)
+	ValidateProject(ctx context.Context, projectID string) error
+	ValidateGroup(ctx context.Context, groupID string) (*Group, error)
+}
+
+var _ API = (*Client)(nil)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client requests are sent with
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) { c.client = client }
+}
+
+// WithLogger sets the logger of the client's progress, which logs nothing by default
+func WithLogger(logger *zap.Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithExportParams sends params in the JSON body of the requests creating
+// exports, passing filters and options through to GitLab as they are
+func WithExportParams(params map[string]any) Option {
+	return func(c *Client) { c.exportParams = params }
+}
+
+// WithAPIPaths sets where the REST and GraphQL APIs are below the base URL, for
+// instances behind a reverse proxy rewriting paths. An empty path keeps the
+// default one, "/" is the base URL itself.
+func WithAPIPaths(apiPath, graphQLPath string) Option {
+	return func(c *Client) {
+		if apiPath != "" {
+			c.apiPath = cleanAPIPath(apiPath)
+		}
+		if graphQLPath != "" {
+			c.graphQLPath = cleanAPIPath(graphQLPath)
+		}
+	}
+}
+
+// cleanAPIPath returns p with a leading slash and without a trailing one, so
+// it can be put between the base URL and an endpoint
+func cleanAPIPath(p string) string {
+	if p = strings.Trim(p, "/"); p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// NewClient creates a client of the GitLab instance at baseURL authenticating
+// with token, a personal, group or project access token. The token may be
+// empty when the HTTP client set with WithHTTPClient authenticates requests.
+func NewClient(baseURL, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     baseURL,
+		token:       token,
+		apiPath:     DefaultAPIPath,
+		graphQLPath: DefaultGraphQLPath,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.client == nil {
+		c.client = NewHTTPClient()
+	}
+	if c.logger == nil {
+		c.logger = zap.NewNop()
+	}
+	if c.pollBackoff.InitialInterval <= 0 {
+		c.pollBackoff = DefaultPollBackoff()
+	}
+	if c.unknownStatus == "" {
+		c.unknownStatus = UnknownStatusWait
+	}
+	if c.vulnerabilityOrder == (VulnerabilityOrder{}) {
+		c.vulnerabilityOrder = DefaultVulnerabilityOrder()
+	}
+	return c
+}
+
+// NewHTTPClient creates the HTTP client used by default, with timeouts fit for
+// downloading large exports
+func NewHTTPClient() *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{},
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   10 * time.Minute,
+		Transport: transport,
+	}
+}
+
+// CreateExport initiates a new vulnerability export
+func (c *Client) CreateExport(ctx context.Context, projectID string) (*Export, error) {
+	endpoint := c.buildURL(fmt.Sprintf("/security/projects/%s/vulnerability_exports", projectID))
+
+	req, err := c.newExportRequest(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export request: %w", err)
+	}
+
+	c.authenticate(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create export: %w", &APIError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	var export Export
+	if err := json.NewDecoder(resp.Body).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &export, nil
+}
+
+// newExportRequest builds the request creating an export at endpoint, with the
+// export params as its body
+func (c *Client) newExportRequest(ctx context.Context, endpoint string) (*http.Request, error) {
+	var body io.Reader
+	if len(c.exportParams) > 0 {
+		encoded, err := json.Marshal(c.exportParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode export params: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+	return http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+}
+
+// isTemporaryError reports whether a failed request may succeed when retried:
+// a server error response, or a timeout reaching the server
+func isTemporaryError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// GetExport gets the status of a project export
+func (c *Client) GetExport(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+	return c.getExport(ctx, exportID)
+}
+
+// getExport gets the status of an export, of a project or a group
+func (c *Client) getExport(ctx context.Context, exportID int64) (*Export, error) {
+	endpoint := c.buildURL(fmt.Sprintf("/security/vulnerability_exports/%d", exportID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authenticate(req)
+	resp, err := c.doGet(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Accept both 200 OK and 202 Accepted responses
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		if isGone(resp.StatusCode) {
+			return nil, fmt.Errorf("failed to get export: %w: %w", ErrExportGone, apiErr)
+		}
+		return nil, fmt.Errorf("failed to get export: %w", apiErr)
+	}
+
+	var export Export
+	if err := json.NewDecoder(resp.Body).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &export, nil
+}
+
+// VulnerabilityOrder is the order vulnerabilities are listed in: OrderBy is the
+// field sorted on and Sort asc or desc
+type VulnerabilityOrder struct {
+	OrderBy string
+	Sort    string
+}
+
+// DefaultVulnerabilityOrder lists the most recently updated vulnerabilities first
+func DefaultVulnerabilityOrder() VulnerabilityOrder {
+	return VulnerabilityOrder{OrderBy: "updated_at", Sort: "desc"}
+}
+
+// WithVulnerabilityOrder sets the order ListVulnerabilities requests
+func WithVulnerabilityOrder(order VulnerabilityOrder) Option {
+	return func(c *Client) { c.vulnerabilityOrder = order }
+}
+
+// ListVulnerabilities returns one page of a project's vulnerabilities updated
+// on or after updatedAfter (all of them when it's zero), most recently updated
+// first unless another order was set, and the next page number, 0 once the
+// last page has been read
+func (c *Client) ListVulnerabilities(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+	var vulnerabilities []map[string]interface{}
+	query := url.Values{"order_by": {c.vulnerabilityOrder.OrderBy}, "sort": {c.vulnerabilityOrder.Sort}}
+	if !updatedAfter.IsZero() {
+		query.Set("updated_after", updatedAfter.UTC().Format(time.RFC3339))
+	}
+	nextPage, err := c.getPage(ctx, fmt.Sprintf("/projects/%s/vulnerabilities", projectID), query, page, &vulnerabilities)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list vulnerabilities: %w", err)
+	}
+	return vulnerabilities, nextPage, nil
+}
+
+// Dependency is a project dependency with the licenses found by license scanning
+type Dependency struct {
+	Name               string              `json:"name"`
+	Version            string              `json:"version"`
+	PackageManager     string              `json:"package_manager"`
+	DependencyFilePath string              `json:"dependency_file_path"`
+	Licenses           []DependencyLicense `json:"licenses"`
+}
+
+// DependencyLicense is a license detected for a dependency
+type DependencyLicense struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// ListDependencies returns one page of a project's dependencies and the next page number
+func (c *Client) ListDependencies(ctx context.Context, projectID string, page int) ([]Dependency, int, error) {
+	var dependencies []Dependency
+	nextPage, err := c.getPage(ctx, fmt.Sprintf("/projects/%s/dependencies", projectID), nil, page, &dependencies)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dependencies: %w", err)
+	}
+	return dependencies, nextPage, nil
+}
+
+// MergeRequest is the part of a merge request needed to attribute policy violations
+type MergeRequest struct {
+	IID          int64  `json:"iid"`
+	Title        string `json:"title"`
+	WebURL       string `json:"web_url"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Author       struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+// ListOpenMergeRequests returns one page of a project's open merge requests and the next page number
+func (c *Client) ListOpenMergeRequests(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+	var mergeRequests []MergeRequest
+	query := url.Values{"state": {"opened"}}
+	nextPage, err := c.getPage(ctx, fmt.Sprintf("/projects/%s/merge_requests", projectID), query, page, &mergeRequests)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+	return mergeRequests, nextPage, nil
+}
+
+// ApprovalRule is an approval rule of a merge request. Rules created by security
+// policies carry the report type they enforce.
+type ApprovalRule struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	RuleType   string `json:"rule_type"`
+	ReportType string `json:"report_type"`
+	Approved   bool   `json:"approved"`
+}
+
+// GetApprovalRules returns the approval rules of a merge request and whether they're satisfied
+func (c *Client) GetApprovalRules(ctx context.Context, projectID string, iid int64) ([]ApprovalRule, error) {
+	var state struct {
+		Rules []ApprovalRule `json:"rules"`
+	}
+	endpoint := fmt.Sprintf("/projects/%s/merge_requests/%d/approval_state", projectID, iid)
+	if _, err := c.getPage(ctx, endpoint, nil, 0, &state); err != nil {
+		return nil, fmt.Errorf("failed to get approval state: %w", err)
+	}
+	return state.Rules, nil
+}
+
+// IssueLink is an issue linked to a vulnerability
+type IssueLink struct {
+	IID      int64  `json:"iid"`
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	WebURL   string `json:"web_url"`
+	LinkType string `json:"link_type"`
+}
+
+// GetIssueLinks returns the issues linked to a vulnerability
+func (c *Client) GetIssueLinks(ctx context.Context, vulnerabilityID string) ([]IssueLink, error) {
+	var links []IssueLink
+	endpoint := fmt.Sprintf("/vulnerabilities/%s/issue_links", vulnerabilityID)
+	if _, err := c.getPage(ctx, endpoint, nil, 0, &links); err != nil {
+		return nil, fmt.Errorf("failed to get issue links: %w", err)
+	}
+	return links, nil
+}
+
+// getPage sends a GET request and decodes the response into v. A page greater
+// than zero requests that page of a paginated resource, and the next page
+// number is returned, 0 once the last page has been read.
+func (c *Client) getPage(ctx context.Context, endpoint string, query url.Values, page int, v interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.buildURL(endpoint), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+	if page > 0 {
+		query.Set("per_page", "100")
+		query.Set("page", strconv.Itoa(page))
+	}
+	req.URL.RawQuery = query.Encode()
+
+	c.authenticate(req)
+	resp, err := c.doGet(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	nextPage, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return nextPage, nil
+}
+
+// ExportData is the downloaded content of an export
+type ExportData struct {
+	io.ReadCloser
+	ContentType string
+	// Size is the length of the download in bytes, -1 when the server didn't tell
+	Size int64
+}
+
+// GetExportData downloads the export data once it's ready
+func (c *Client) GetExportData(ctx context.Context, downloadURL string) (*ExportData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	c.authenticate(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download export: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if isGone(resp.StatusCode) {
+			return nil, fmt.Errorf("failed to download export: %w: %w", ErrExportGone, apiErr)
+		}
+		return nil, fmt.Errorf("failed to download export: %w", apiErr)
+	}
+
+	return &ExportData{
+		ReadCloser:  resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}, nil
+}
+
+// Job is a CI job of a project
+type Job struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Ref      string `json:"ref"`
+	Pipeline struct {
+		ID int64 `json:"id"`
+	} `json:"pipeline"`
+}
+
+// Number of pages of successful jobs searched for the latest job of a name
+const maxJobPages = 5
+
+// GetLatestJob returns the most recent successful job with the given name on a
+// ref, nil when none is found among the latest successful jobs
+func (c *Client) GetLatestJob(ctx context.Context, projectID, ref, name string) (*Job, error) {
+	query := url.Values{"scope[]": {"success"}}
+	for page, pages := 1, 0; page != 0 && pages < maxJobPages; pages++ {
+		var jobs []Job
+		nextPage, err := c.getPage(ctx, fmt.Sprintf("/projects/%s/jobs", projectID), query, page, &jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		// Jobs are listed most recent first
+		for i := range jobs {
+			if jobs[i].Name == name && jobs[i].Ref == ref {
+				return &jobs[i], nil
+			}
+		}
+		page = nextPage
+	}
+	return nil, nil
+}
+
+// GetJobArtifacts downloads the artifacts archive of a job
+func (c *Client) GetJobArtifacts(ctx context.Context, projectID string, jobID int64) (io.ReadCloser, error) {
+	endpoint := c.buildURL(fmt.Sprintf("/projects/%s/jobs/%d/artifacts", projectID, jobID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authenticate(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download job artifacts: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download job artifacts: %w", &APIError{StatusCode: resp.StatusCode})
+	}
+
+	return resp.Body, nil
+}
+
+// WaitForExport waits for a project export to complete
+func (c *Client) WaitForExport(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+	return c.waitForExport(ctx, exportID, timeout)
+}
+
+// waitForExport polls the status of an export, of a project or a group, until
+// it completes, fails or is stuck, retrying temporary errors
+func (c *Client) waitForExport(ctx context.Context, exportID int64, timeout time.Duration) (*Export, error) {
+	startTime := time.Now()
+	deadline := startTime.Add(timeout)
+	poller := c.newPoller(deadline)
+	dots := 0
+
+	for {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for export completion after %v", time.Since(startTime))
+		}
+
+		export, err := c.getExport(ctx, exportID)
+		if err != nil {
+			if isTemporaryError(err) {
+				c.logger.Warn("Temporary error getting export status, retrying...",
+					zap.Error(err),
+					zap.Int64("exportID", exportID))
+				if err := poller.wait(ctx); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		switch export.Status {
+		case ExportStatusFinished:
+			c.logger.Info("Export completed",
+				zap.Duration("duration", time.Since(startTime)))
+			return export, nil
+		case ExportStatusFailed:
+			return nil, fmt.Errorf("export failed after %v", time.Since(startTime))
+		case ExportStatusCreated, ExportStatusStarted:
+			if export.Status == ExportStatusCreated && c.stuckThreshold > 0 && time.Since(startTime) >= c.stuckThreshold {
+				return nil, fmt.Errorf("%w: still %s after %v", ErrExportStuck, export.Status, time.Since(startTime).Round(time.Second))
+			}
+			dots = (dots + 1) % 3
+			progress := strings.Repeat(".", dots+1)
+			c.logger.Info("Export in progress"+progress,
+				zap.Duration("elapsed", time.Since(startTime)))
+
+			if err := poller.wait(ctx); err != nil {
+				return nil, err
+			}
+		default:
+			if c.unknownStatus == UnknownStatusFail {
+				return nil, fmt.Errorf("unknown export status: %s", export.Status)
+			}
+			c.logger.Warn("Unknown export status, waiting as if it was running",
+				zap.String("status", string(export.Status)),
+				zap.Int64("exportID", exportID),
+				zap.Duration("elapsed", time.Since(startTime)))
+
+			if err := poller.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// CreateGroupExport initiates a new vulnerability export for a group
+func (c *Client) CreateGroupExport(ctx context.Context, groupID string) (*Export, error) {
+	c.logger.Info("Creating new vulnerability export", zap.String("groupID", groupID))
+
+	endpoint := c.buildURL(fmt.Sprintf("/security/groups/%s/vulnerability_exports", groupID))
+
+	req, err := c.newExportRequest(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group export request: %w", err)
+	}
+
+	c.authenticate(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group export: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create group export: %w", &APIError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	var export Export
+	if err := json.NewDecoder(resp.Body).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to decode group export response: %w", err)
+	}
+
+	c.logger.Info("Created new vulnerability export",
+		zap.Int64("exportID", export.ID))
+	return &export, nil
+}
+
+// GetGroupExport gets the status of a group export
+func (c *Client) GetGroupExport(ctx context.Context, groupID string, exportID int64) (*Export, error) {
+	return c.getExport(ctx, exportID)
+}
+
+// WaitForGroupExport waits for a group export to complete
+func (c *Client) WaitForGroupExport(ctx context.Context, groupID string, exportID int64, timeout time.Duration) (*Export, error) {
+	return c.waitForExport(ctx, exportID, timeout)
+}
+
+// authenticate sets the token of a request. Without a token, requests are
+// authenticated by the HTTP client, e.g. with a transport adding the header.
+func (c *Client) authenticate(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+}
+
+// buildURL returns the URL of a REST API endpoint, below the API path. The
+// endpoint is kept as it is, so escaped project paths stay escaped.
+func (c *Client) buildURL(endpoint string) string {
+	return strings.TrimSuffix(c.baseURL, "/") + c.apiPath + endpoint
+}
+
+// graphQLURL returns the URL of the GraphQL API
+func (c *Client) graphQLURL() string {
+	return strings.TrimSuffix(c.baseURL, "/") + c.graphQLPath
+}
+
+// ValidateProject checks that the project exists
+func (c *Client) ValidateProject(ctx context.Context, projectID string) error {
+	url := c.buildURL(fmt.Sprintf("/projects/%s", projectID))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authenticate(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to validate project: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("project ID %s not found: %w", projectID, &APIError{StatusCode: resp.StatusCode})
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to validate project: %w", &APIError{StatusCode: resp.StatusCode})
+	}
+	return nil
+}
+
+// ValidateGroup checks that the group exists and returns it
+func (c *Client) ValidateGroup(ctx context.Context, groupID string) (*Group, error) {
+	url := c.buildURL(fmt.Sprintf("/groups/%s", groupID))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authenticate(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate group: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("group ID %s not found: %w", groupID, &APIError{StatusCode: resp.StatusCode})
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to validate group: %w", &APIError{StatusCode: resp.StatusCode})
+	}
+
+	var group Group
+	if err := json.NewDecoder(resp.Body).Decode(&group); err != nil {
+		return nil, fmt.Errorf("failed to decode group response: %w", err)
+	}
+
+	c.logger.Info("Found group ID",
+		zap.String("id", groupID),
+		zap.String("path", group.Path))
+	return &group, nil
+}
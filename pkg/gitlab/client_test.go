@@ -0,0 +1,481 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabClient_CreateExport(t *testing.T) {
+	// Setup test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Set status code first
+		w.WriteHeader(http.StatusCreated)
+		// Then write response
+		json.NewEncoder(w).Encode(Export{
+			ID:        123,
+			ProjectID: "mygroup/myproject",
+			Status:    ExportStatus("created"),
+			Format:    "csv",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	// Test
+	export, err := client.CreateExport(context.Background(), "mygroup/myproject")
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), export.ID)
+	assert.Equal(t, "mygroup/myproject", export.ProjectID)
+}
+
+func TestGetExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/security/vulnerability_exports/123", r.URL.Path)
+		assert.Equal(t, "GET", r.Method)
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Export{
+			ID:        123,
+			ProjectID: "test-project",
+			Status:    ExportStatus("finished"),
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	export, err := client.GetExport(context.Background(), "test-project", 123)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatus("finished"), export.Status)
+}
+
+func TestWaitForExport(t *testing.T) {
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{
+			status: http.StatusAccepted,
+			body: `{
+				"id": 123,
+				"project_id": "test-project",
+				"status": "running",
+				"started_at": "2024-02-12T03:34:02.151Z"
+			}`,
+		},
+		{
+			status: http.StatusOK,
+			body: `{
+				"id": 123,
+				"project_id": "test-project",
+				"status": "finished",
+				"started_at": "2024-02-12T03:34:02.151Z",
+				"finished_at": "2024-02-12T03:34:12.151Z"
+			}`,
+		},
+	}
+
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := responses[callCount]
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+		callCount++
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	export, err := client.WaitForExport(context.Background(), "test-project", 123, 1*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, ExportStatusFinished, export.Status)
+}
+
+func TestCreateGroupExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/security/groups/test-group/vulnerability_exports", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Export{
+			ID:        123,
+			ProjectID: "", // Group exports don't have project ID
+			Status:    ExportStatus("created"),
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	export, err := client.CreateGroupExport(context.Background(), "test-group")
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), export.ID)
+	assert.Equal(t, ExportStatus("created"), export.Status)
+}
+
+func TestCreateExport_ExportParams(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: ExportStatusCreated})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithExportParams(map[string]any{
+		"report_type": []any{"sast", "dependency_scanning"},
+		"send_email":  false,
+	}))
+
+	_, err := client.CreateExport(context.Background(), "test-project")
+	require.NoError(t, err)
+	_, err = client.CreateGroupExport(context.Background(), "test-group")
+	require.NoError(t, err)
+
+	want := map[string]any{"report_type": []any{"sast", "dependency_scanning"}, "send_email": false}
+	assert.Equal(t, []map[string]any{want, want}, bodies)
+}
+
+func TestCreateExport_NoExportParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Empty(t, body)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: ExportStatusCreated})
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "test-token").CreateExport(context.Background(), "test-project")
+	require.NoError(t, err)
+}
+
+func TestGetGroupExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/security/vulnerability_exports/123", r.URL.Path)
+		assert.Equal(t, "GET", r.Method)
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Export{
+			ID:        123,
+			ProjectID: "",
+			Status:    ExportStatus("finished"),
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	export, err := client.GetGroupExport(context.Background(), "test-group", 123)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatus("finished"), export.Status)
+}
+
+func TestWaitForGroupExport(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		status := "running"
+		if attempts > 2 {
+			status = "finished"
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Export{
+			ID:        123,
+			ProjectID: "",
+			Status:    ExportStatus(status),
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	export, err := client.WaitForGroupExport(context.Background(), "test-group", 123, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatus("finished"), export.Status)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestValidateProjectID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		projectID := "12345"
+		expectedPath := fmt.Sprintf("/api/v4/projects/%s", projectID)
+
+		if r.URL.Path == expectedPath {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Project{ID: 12345})
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "404 Project Not Found"})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	// Test valid project ID
+	err := client.ValidateProject(context.Background(), "12345")
+	require.NoError(t, err)
+
+	// Test invalid project ID
+	err = client.ValidateProject(context.Background(), "99999")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "project ID 99999 not found")
+}
+
+func TestValidateGroupID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+
+		if r.URL.Path == "/api/v4/groups/67890" {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Group{
+				ID:   67890,
+				Path: "test-group",
+			})
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": "404 Group Not Found",
+			})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	// Test valid group ID
+	group, err := client.ValidateGroup(context.Background(), "67890")
+	require.NoError(t, err)
+	assert.Equal(t, "test-group", group.Path)
+
+	// Test invalid group ID
+	_, err = client.ValidateGroup(context.Background(), "99999")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "group ID 99999 not found")
+}
+
+func TestListVulnerabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/123/vulnerabilities", r.URL.Path)
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		assert.False(t, r.URL.Query().Has("updated_after"))
+
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `[{"id": %s, "title": "Test Vuln"}]`, r.URL.Query().Get("page"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	vulnerabilities, nextPage, err := client.ListVulnerabilities(context.Background(), "123", time.Time{}, 1)
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 1)
+	assert.Equal(t, json.Number("1"), vulnerabilities[0]["id"])
+	assert.Equal(t, 2, nextPage)
+
+	_, nextPage, err = client.ListVulnerabilities(context.Background(), "123", time.Time{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, nextPage)
+}
+
+func TestListVulnerabilities_UpdatedAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2024-02-12T10:00:00Z", r.URL.Query().Get("updated_after"))
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	since := time.Date(2024, 2, 12, 11, 0, 0, 0, time.FixedZone("CET", 3600))
+	_, _, err := NewClient(server.URL, "test-token").ListVulnerabilities(context.Background(), "123", since, 1)
+	require.NoError(t, err)
+}
+
+func TestListVulnerabilities_Order(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantOrderBy string
+		wantSort    string
+	}{
+		{name: "default", wantOrderBy: "updated_at", wantSort: "desc"},
+		{
+			name:        "oldest first",
+			opts:        []Option{WithVulnerabilityOrder(VulnerabilityOrder{OrderBy: "created_at", Sort: "asc"})},
+			wantOrderBy: "created_at",
+			wantSort:    "asc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantOrderBy, r.URL.Query().Get("order_by"))
+				assert.Equal(t, tt.wantSort, r.URL.Query().Get("sort"))
+				fmt.Fprint(w, `[]`)
+			}))
+			defer server.Close()
+
+			_, _, err := NewClient(server.URL, "test-token", tt.opts...).ListVulnerabilities(context.Background(), "123", time.Time{}, 1)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetLatestJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/12345/jobs", r.URL.Path)
+		assert.Equal(t, "success", r.URL.Query().Get("scope[]"))
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			w.Write([]byte(`[{"id": 9, "name": "gemnasium-dependency_scanning", "ref": "feature"}, {"id": 8, "name": "test", "ref": "release/1.0"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 7, "name": "gemnasium-dependency_scanning", "ref": "release/1.0", "pipeline": {"id": 70}}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	job, err := client.GetLatestJob(context.Background(), "12345", "release/1.0", "gemnasium-dependency_scanning")
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, int64(7), job.ID)
+	assert.Equal(t, int64(70), job.Pipeline.ID)
+
+	job, err = client.GetLatestJob(context.Background(), "12345", "main", "gemnasium-dependency_scanning")
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestClient_WithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests are left to the HTTP client to authenticate
+		assert.Empty(t, r.Header.Values("PRIVATE-TOKEN"))
+		assert.Equal(t, "Bearer extension-token", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(Project{ID: 12345})
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req.Header.Set("Authorization", "Bearer extension-token")
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	client := NewClient(server.URL, "", WithHTTPClient(httpClient))
+
+	require.NoError(t, client.ValidateProject(context.Background(), "12345"))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestExportGone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	_, err := client.GetExport(context.Background(), "test-project", 123)
+	assert.ErrorIs(t, err, ErrExportGone)
+	code, ok := StatusCode(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	_, err = client.GetExportData(context.Background(), server.URL+"/download")
+	assert.ErrorIs(t, err, ErrExportGone)
+}
+
+func TestStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	_, createErr := client.CreateExport(context.Background(), "test-project")
+	_, groupErr := client.ValidateGroup(context.Background(), "test-group")
+	_, downloadErr := client.GetExportData(context.Background(), server.URL+"/download")
+	for _, err := range []error{
+		client.ValidateProject(context.Background(), "test-project"),
+		groupErr,
+		createErr,
+		downloadErr,
+	} {
+		code, ok := StatusCode(err)
+		require.True(t, ok, err)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	}
+
+	// Only an APIError has a status, whatever the message
+	_, ok := StatusCode(errors.New("failed to create export, status: 401"))
+	assert.False(t, ok)
+}
+
+func TestGetTokenInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/personal_access_tokens/self", r.URL.Path)
+		assert.Equal(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+		fmt.Fprint(w, `{"id": 4, "name": "collector", "scopes": ["read_api"], "active": true, "revoked": false, "expires_at": "2027-01-01"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	token, err := client.GetTokenInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "collector", token.Name)
+	assert.True(t, token.Active)
+	assert.True(t, token.HasScope("read_api"))
+	assert.False(t, token.HasScope("api"))
+}
+
+func TestWithAPIPaths(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/gitlab/rest/projects/group/project":
+			json.NewEncoder(w).Encode(Project{ID: 12345})
+		case "/gitlab/gql":
+			w.Write([]byte(`{"data": {}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "test-token", WithAPIPaths("gitlab/rest/", "/gitlab/gql"))
+
+	require.NoError(t, client.ValidateProject(context.Background(), "group%2Fproject"))
+	var data struct{}
+	require.NoError(t, client.graphQL(context.Background(), "{ foo }", nil, &data))
+	assert.Equal(t, []string{"/gitlab/rest/projects/group%2Fproject", "/gitlab/gql"}, paths)
+}
+
+func TestWithAPIPaths_Root(t *testing.T) {
+	client := NewClient("https://gitlab.example.com", "test-token", WithAPIPaths("/", ""))
+	assert.Equal(t, "https://gitlab.example.com/projects/1", client.buildURL("/projects/1"))
+	assert.Equal(t, "https://gitlab.example.com/api/graphql", client.graphQLURL())
+}
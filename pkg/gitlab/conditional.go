@@ -0,0 +1,110 @@
+package gitlab
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithConditionalRequests makes API reads and export status polls conditional
+// on the last response to the same URL, keeping up to maxEntries responses.
+// GitLab answers 304 Not Modified when its ETag or Last-Modified still match,
+// and the cached response is used instead.
+func WithConditionalRequests(maxEntries int) Option {
+	return func(c *Client) {
+		c.conditional = &responseCache{
+			maxEntries: maxEntries,
+			entries:    make(map[string]*list.Element),
+			order:      list.New(),
+		}
+	}
+}
+
+// cachedResponse is a response kept for a conditional request
+type cachedResponse struct {
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// responseCache holds the last responses with validators, evicting the least
+// recently used one once it's full
+type responseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+func (rc *responseCache) get(url string) (*cachedResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	element, ok := rc.entries[url]
+	if !ok {
+		return nil, false
+	}
+	rc.order.MoveToFront(element)
+	return element.Value.(*cachedResponse), true
+}
+
+func (rc *responseCache) put(response *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if element, ok := rc.entries[response.url]; ok {
+		element.Value = response
+		rc.order.MoveToFront(element)
+		return
+	}
+	rc.entries[response.url] = rc.order.PushFront(response)
+	for rc.order.Len() > rc.maxEntries {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cachedResponse).url)
+	}
+}
+
+// doGet sends a GET request, conditional on the cached response for its URL
+// when conditional requests are enabled. A 304 Not Modified is returned as the
+// cached 200 OK response.
+func (c *Client) doGet(req *http.Request) (*http.Response, error) {
+	if c.conditional == nil {
+		return c.client.Do(req)
+	}
+
+	key := req.URL.String()
+	cached, ok := c.conditional.get(key)
+	if ok {
+		if etag := cached.header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		resp.Body.Close()
+		resp.StatusCode, resp.Status = http.StatusOK, "200 OK"
+		resp.Header = cached.header.Clone()
+		resp.Body = io.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		c.conditional.put(&cachedResponse{url: key, header: resp.Header.Clone(), body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
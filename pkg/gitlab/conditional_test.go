@@ -0,0 +1,105 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalRequests_ETag(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := fmt.Sprintf(`"page-%s"`, r.URL.Query().Get("page"))
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `[{"id": 1, "title": "Test Vuln"}]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithConditionalRequests(10))
+
+	for range 3 {
+		vulnerabilities, nextPage, err := client.ListVulnerabilities(context.Background(), "123", time.Time{}, 1)
+		require.NoError(t, err)
+		require.Len(t, vulnerabilities, 1)
+		assert.Equal(t, "Test Vuln", vulnerabilities[0]["title"])
+		assert.Equal(t, 2, nextPage)
+	}
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 2, notModified)
+
+	// Another page isn't answered from the first one
+	_, _, err := client.ListVulnerabilities(context.Background(), "123", time.Time{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, notModified)
+}
+
+func TestConditionalRequests_LastModified(t *testing.T) {
+	const lastModified = "Wed, 14 Oct 2026 10:00:00 GMT"
+	var notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: ExportStatusStarted})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithConditionalRequests(10))
+
+	for range 2 {
+		export, err := client.GetExport(context.Background(), "test-project", 123)
+		require.NoError(t, err)
+		assert.Equal(t, ExportStatusStarted, export.Status)
+	}
+	assert.Equal(t, 1, notModified)
+}
+
+func TestConditionalRequests_Disabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"export"`)
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: ExportStatusStarted})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	for range 2 {
+		_, err := client.GetExport(context.Background(), "test-project", 123)
+		require.NoError(t, err)
+	}
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	client := NewClient("http://gitlab.example.com", "", WithConditionalRequests(2))
+	cache := client.conditional
+
+	cache.put(&cachedResponse{url: "a"})
+	cache.put(&cachedResponse{url: "b"})
+	_, ok := cache.get("a")
+	require.True(t, ok)
+	cache.put(&cachedResponse{url: "c"})
+
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	_, ok = cache.get("c")
+	assert.True(t, ok)
+}
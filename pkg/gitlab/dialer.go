@@ -0,0 +1,24 @@
+package gitlab
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DialContextFunc opens the connections of an HTTP client, like net.Dialer.DialContext
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// NewFixedDialer returns a dialer connecting to address on network whatever
+// the host of the request, e.g. to the Unix socket of a local sidecar proxy
+// forwarding requests to GitLab. HTTPS requests still verify the certificate
+// of the requested host.
+func NewFixedDialer(network, address string, timeout time.Duration) DialContextFunc {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+}
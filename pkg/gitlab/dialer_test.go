@@ -0,0 +1,40 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFixedDialer_UnixSocket(t *testing.T) {
+	// Unix socket paths are short, t.TempDir may be too long
+	dir, err := os.MkdirTemp("", "gitlab")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "proxy.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gitlab.example.com", r.Host)
+		assert.Equal(t, "/api/v4/projects/12345", r.URL.Path)
+		json.NewEncoder(w).Encode(Project{ID: 12345})
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &http.Transport{DialContext: NewFixedDialer("unix", socket, time.Second)}}
+	client := NewClient("http://gitlab.example.com", "test-token", WithHTTPClient(httpClient))
+
+	require.NoError(t, client.ValidateProject(context.Background(), "12345"))
+}
@@ -0,0 +1,38 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrExportGone is returned when GitLab no longer has an export, because its
+// download expired or it was deleted
+var ErrExportGone = errors.New("export expired or deleted")
+
+// isGone reports whether a status means the requested export doesn't exist anymore
+func isGone(statusCode int) bool {
+	return statusCode == http.StatusNotFound || statusCode == http.StatusGone
+}
+
+// APIError is returned for a response with an unexpected status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// StatusCode returns the HTTP status of a request failed with an APIError
+func StatusCode(err error) (int, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, true
+	}
+	return 0, false
+}
@@ -0,0 +1,24 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQL_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": null, "errors": [{"message": "Field 'foo' doesn't exist"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	var data struct{}
+	err := client.graphQL(context.Background(), "{ foo }", nil, &data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Field 'foo' doesn't exist")
+}
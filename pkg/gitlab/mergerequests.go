@@ -0,0 +1,67 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const mergeRequestFindingsQuery = `query($fullPath: ID!, $iid: String!, $reportType: ComparableSecurityReportType!) {
+  project(fullPath: $fullPath) {
+    mergeRequest(iid: $iid) {
+      findingReportsComparer(reportType: $reportType) {
+        status
+        report {
+          added {
+            uuid
+            title
+            description
+            severity
+            state
+            identifiers { externalType externalId name url }
+            scanner { name }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// GetMergeRequestFindings returns the findings a merge request introduces for a
+// report type, as shown in the security widget. ready is false while GitLab is
+// still comparing the reports.
+func (c *Client) GetMergeRequestFindings(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error) {
+	var data struct {
+		Project *struct {
+			MergeRequest *struct {
+				Comparer *struct {
+					Status string `json:"status"`
+					Report *struct {
+						Added []map[string]interface{} `json:"added"`
+					} `json:"report"`
+				} `json:"findingReportsComparer"`
+			} `json:"mergeRequest"`
+		} `json:"project"`
+	}
+	variables := map[string]interface{}{
+		"fullPath":   fullPath,
+		"iid":        strconv.FormatInt(iid, 10),
+		"reportType": strings.ToUpper(reportType),
+	}
+	if err := c.graphQL(ctx, mergeRequestFindingsQuery, variables, &data); err != nil {
+		return nil, false, fmt.Errorf("failed to get merge request findings: %w", err)
+	}
+	if data.Project == nil || data.Project.MergeRequest == nil {
+		return nil, false, fmt.Errorf("merge request !%d of %s not found", iid, fullPath)
+	}
+
+	comparer := data.Project.MergeRequest.Comparer
+	if comparer == nil || strings.EqualFold(comparer.Status, "parsing") {
+		return nil, false, nil
+	}
+	if comparer.Report == nil {
+		return nil, true, nil
+	}
+	return comparer.Report.Added, true, nil
+}
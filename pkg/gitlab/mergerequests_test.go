@@ -0,0 +1,35 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMergeRequestFindings(t *testing.T) {
+	status := "PARSING"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"project": {"mergeRequest": {"findingReportsComparer": {
+			"status": "` + status + `",
+			"report": {"added": [{"uuid": "b1f3", "title": "SQL injection"}]}
+		}}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	_, ready, err := client.GetMergeRequestFindings(context.Background(), "group/project", 7, "sast")
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	status = "PARSED"
+	findings, ready, err := client.GetMergeRequestFindings(context.Background(), "group/project", 7, "sast")
+	require.NoError(t, err)
+	assert.True(t, ready)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "b1f3", findings[0]["uuid"])
+}
@@ -0,0 +1,97 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// PollBackoff sets the waits between status checks of an export: the first
+// check waits InitialInterval, every following wait is Multiplier times longer,
+// up to MaxInterval. A Multiplier of 1 checks at a fixed interval.
+type PollBackoff struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+}
+
+// DefaultPollBackoff checks small exports within seconds and long group exports
+// about once a minute
+func DefaultPollBackoff() PollBackoff {
+	return PollBackoff{
+		InitialInterval: 2 * time.Second,
+		MaxInterval:     time.Minute,
+		Multiplier:      1.5,
+	}
+}
+
+// WithPollBackoff sets the waits between export status checks
+func WithPollBackoff(backoff PollBackoff) Option {
+	return func(c *Client) { c.pollBackoff = backoff }
+}
+
+// UnknownStatusPolicy is what WaitForExport does with an export status it
+// doesn't know, such as one added by a newer GitLab
+type UnknownStatusPolicy string
+
+const (
+	// UnknownStatusWait keeps waiting as if the export was running
+	UnknownStatusWait UnknownStatusPolicy = "wait"
+	// UnknownStatusFail fails the wait
+	UnknownStatusFail UnknownStatusPolicy = "fail"
+)
+
+// WithUnknownStatusPolicy sets what WaitForExport does with an unknown export
+// status, UnknownStatusWait by default
+func WithUnknownStatusPolicy(policy UnknownStatusPolicy) Option {
+	return func(c *Client) { c.unknownStatus = policy }
+}
+
+// ErrExportStuck is returned by WaitForExport when an export stays created,
+// not picked up by GitLab's background jobs, for longer than the stuck threshold
+var ErrExportStuck = errors.New("export stuck")
+
+// WithStuckThreshold makes WaitForExport give up with ErrExportStuck once an
+// export it waits for has been created for threshold without starting
+func WithStuckThreshold(threshold time.Duration) Option {
+	return func(c *Client) { c.stuckThreshold = threshold }
+}
+
+// next returns the wait following interval
+func (b PollBackoff) next(interval time.Duration) time.Duration {
+	next := time.Duration(float64(interval) * b.Multiplier)
+	if next > b.MaxInterval || next <= 0 {
+		return b.MaxInterval
+	}
+	return next
+}
+
+// poller hands out the waits of one export's status checks
+type poller struct {
+	backoff  PollBackoff
+	deadline time.Time
+	interval time.Duration
+}
+
+func (c *Client) newPoller(deadline time.Time) *poller {
+	return &poller{backoff: c.pollBackoff, deadline: deadline}
+}
+
+// wait blocks until the next status check is due, no later than the deadline,
+// or ctx is done
+func (p *poller) wait(ctx context.Context) error {
+	if p.interval == 0 {
+		p.interval = min(p.backoff.InitialInterval, p.backoff.MaxInterval)
+	} else {
+		p.interval = p.backoff.next(p.interval)
+	}
+
+	timer := time.NewTimer(min(p.interval, max(time.Until(p.deadline), 0)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
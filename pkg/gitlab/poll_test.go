@@ -0,0 +1,160 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollBackoff_Next(t *testing.T) {
+	backoff := DefaultPollBackoff()
+	var waits []time.Duration
+	for interval := backoff.InitialInterval; len(waits) < 12; interval = backoff.next(interval) {
+		waits = append(waits, interval)
+	}
+	assert.Equal(t, 2*time.Second, waits[0])
+	assert.Equal(t, 3*time.Second, waits[1])
+	assert.Equal(t, time.Minute, waits[len(waits)-1])
+
+	fixed := PollBackoff{InitialInterval: 5 * time.Second, MaxInterval: time.Minute, Multiplier: 1}
+	assert.Equal(t, 5*time.Second, fixed.next(5*time.Second))
+}
+
+func TestWaitForExport_Backoff(t *testing.T) {
+	var checks []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks = append(checks, time.Now())
+		status := ExportStatusStarted
+		if len(checks) == 4 {
+			status = ExportStatusFinished
+		}
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: status})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithPollBackoff(PollBackoff{
+		InitialInterval: 20 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      3,
+	}))
+
+	export, err := client.WaitForExport(context.Background(), "test-project", 123, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatusFinished, export.Status)
+	require.Len(t, checks, 4)
+	// Waits of 20ms, 60ms and 180ms
+	assert.GreaterOrEqual(t, checks[3].Sub(checks[2]), 180*time.Millisecond)
+	assert.Less(t, checks[1].Sub(checks[0]), 180*time.Millisecond)
+}
+
+func TestWaitForExport_WaitEndsAtDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: ExportStatusStarted})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithPollBackoff(PollBackoff{
+		InitialInterval: time.Hour,
+		MaxInterval:     time.Hour,
+		Multiplier:      1,
+	}))
+
+	start := time.Now()
+	_, err := client.WaitForExport(context.Background(), "test-project", 123, 100*time.Millisecond)
+	assert.ErrorContains(t, err, "timeout waiting for export completion")
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestWaitForExport_UnknownStatus(t *testing.T) {
+	newServer := func() *httptest.Server {
+		checks := 0
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checks++
+			status := ExportStatus("none")
+			if checks == 3 {
+				status = ExportStatusFinished
+			}
+			json.NewEncoder(w).Encode(Export{ID: 123, Status: status})
+		}))
+	}
+	backoff := WithPollBackoff(PollBackoff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1})
+
+	t.Run("wait", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		client := NewClient(server.URL, "test-token", backoff)
+		export, err := client.WaitForExport(context.Background(), "test-project", 123, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, ExportStatusFinished, export.Status)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		client := NewClient(server.URL, "test-token", backoff, WithUnknownStatusPolicy(UnknownStatusFail))
+		_, err := client.WaitForExport(context.Background(), "test-project", 123, time.Minute)
+		assert.ErrorContains(t, err, "unknown export status: none")
+	})
+}
+
+func TestWaitForExport_Stuck(t *testing.T) {
+	var status ExportStatus = ExportStatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: status})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token",
+		WithPollBackoff(PollBackoff{InitialInterval: 5 * time.Millisecond, MaxInterval: 5 * time.Millisecond, Multiplier: 1}),
+		WithStuckThreshold(20*time.Millisecond))
+
+	_, err := client.WaitForExport(context.Background(), "test-project", 123, time.Minute)
+	assert.ErrorIs(t, err, ErrExportStuck)
+	_, err = client.WaitForGroupExport(context.Background(), "test-group", 123, time.Minute)
+	assert.ErrorIs(t, err, ErrExportStuck)
+
+	// A running export isn't stuck, however long it takes
+	status = ExportStatusStarted
+	_, err = client.WaitForExport(context.Background(), "test-project", 123, 50*time.Millisecond)
+	assert.ErrorContains(t, err, "timeout waiting for export completion")
+}
+
+func TestWaitForExport_RetriesServerErrors(t *testing.T) {
+	checks := 0
+	errorStatus := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks++
+		if checks < 3 {
+			w.WriteHeader(errorStatus)
+			return
+		}
+		json.NewEncoder(w).Encode(Export{ID: 123, Status: ExportStatusFinished})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token",
+		WithPollBackoff(PollBackoff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1}))
+
+	export, err := client.WaitForExport(context.Background(), "test-project", 123, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatusFinished, export.Status)
+	assert.Equal(t, 3, checks)
+
+	// Client errors aren't retried
+	checks = 0
+	errorStatus = http.StatusForbidden
+	_, err = client.WaitForExport(context.Background(), "test-project", 123, time.Minute)
+	require.Error(t, err)
+	code, ok := StatusCode(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, 1, checks)
+}
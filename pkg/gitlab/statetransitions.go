@@ -0,0 +1,63 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Vulnerabilities whose state transitions are requested in one GraphQL query
+const stateTransitionQuerySize = 20
+
+// StateTransition is a change of a vulnerability's state, such as a dismissal
+type StateTransition struct {
+	FromState       string    `json:"fromState"`
+	ToState         string    `json:"toState"`
+	CreatedAt       time.Time `json:"createdAt"`
+	Comment         string    `json:"comment"`
+	DismissalReason string    `json:"dismissalReason"`
+	Author          *struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+const stateTransitionFields = `stateTransitions(last: 100) {
+      nodes { fromState toState createdAt comment dismissalReason author { username } }
+    }`
+
+// GetStateTransitions returns the state transitions of vulnerabilities by their
+// numeric ID, requesting several vulnerabilities per query
+func (c *Client) GetStateTransitions(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error) {
+	transitions := make(map[string][]StateTransition, len(vulnerabilityIDs))
+	for chunk := range slices.Chunk(vulnerabilityIDs, stateTransitionQuerySize) {
+		var query strings.Builder
+		params := make([]string, len(chunk))
+		variables := make(map[string]interface{}, len(chunk))
+		for i, id := range chunk {
+			params[i] = fmt.Sprintf("$v%d: VulnerabilityID!", i)
+			variables[fmt.Sprintf("v%d", i)] = "gid://gitlab/Vulnerability/" + id
+		}
+		fmt.Fprintf(&query, "query(%s) {\n", strings.Join(params, ", "))
+		for i := range chunk {
+			fmt.Fprintf(&query, "  v%d: vulnerability(id: $v%d) {\n    %s\n  }\n", i, i, stateTransitionFields)
+		}
+		query.WriteString("}")
+
+		var data map[string]*struct {
+			StateTransitions struct {
+				Nodes []StateTransition `json:"nodes"`
+			} `json:"stateTransitions"`
+		}
+		if err := c.graphQL(ctx, query.String(), variables, &data); err != nil {
+			return nil, fmt.Errorf("failed to get state transitions: %w", err)
+		}
+		for i, id := range chunk {
+			if vulnerability := data[fmt.Sprintf("v%d", i)]; vulnerability != nil {
+				transitions[id] = vulnerability.StateTransitions.Nodes
+			}
+		}
+	}
+	return transitions, nil
+}
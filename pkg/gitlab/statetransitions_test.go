@@ -0,0 +1,33 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStateTransitions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "gid://gitlab/Vulnerability/42", req.Variables["v0"])
+		assert.Contains(t, req.Query, "v0: vulnerability(id: $v0)")
+		w.Write([]byte(`{"data": {"v0": {"stateTransitions": {"nodes": [{
+			"fromState": "DETECTED", "toState": "DISMISSED", "createdAt": "2024-05-01T10:02:00Z",
+			"comment": "Accepted risk", "dismissalReason": "ACCEPTABLE_RISK", "author": {"username": "jdoe"}
+		}]}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	transitions, err := client.GetStateTransitions(context.Background(), []string{"42"})
+	require.NoError(t, err)
+	require.Len(t, transitions["42"], 1)
+	assert.Equal(t, "DISMISSED", transitions["42"][0].ToState)
+	assert.Equal(t, "jdoe", transitions["42"][0].Author.Username)
+}
@@ -0,0 +1,90 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+)
+
+// VulnerabilitySeverities is the number of vulnerabilities per severity
+type VulnerabilitySeverities struct {
+	Critical int64 `json:"critical"`
+	High     int64 `json:"high"`
+	Medium   int64 `json:"medium"`
+	Low      int64 `json:"low"`
+	Info     int64 `json:"info"`
+	Unknown  int64 `json:"unknown"`
+}
+
+// VulnerabilityGrade is the number of projects of a group with a security grade
+type VulnerabilityGrade struct {
+	Grade    string `json:"grade"`
+	Count    int64  `json:"count"`
+	Projects struct {
+		Nodes []struct {
+			ID       string `json:"id"`
+			FullPath string `json:"fullPath"`
+		} `json:"nodes"`
+	} `json:"projects"`
+}
+
+// VulnerabilityStatistics is the security posture of a project or group
+type VulnerabilityStatistics struct {
+	Severities VulnerabilitySeverities
+	// Grades is only set for groups
+	Grades []VulnerabilityGrade
+}
+
+const projectStatisticsQuery = `query($fullPath: ID!) {
+  project(fullPath: $fullPath) {
+    vulnerabilitySeveritiesCount { critical high medium low info unknown }
+  }
+}`
+
+const groupStatisticsQuery = `query($fullPath: ID!) {
+  group(fullPath: $fullPath) {
+    vulnerabilitySeveritiesCount { critical high medium low info unknown }
+    vulnerabilityGrades { grade count projects { nodes { id fullPath } } }
+  }
+}`
+
+// GetProject returns a project by ID or full path
+func (c *Client) GetProject(ctx context.Context, projectID string) (*Project, error) {
+	var project Project
+	if _, err := c.getPage(ctx, fmt.Sprintf("/projects/%s", projectID), nil, 0, &project); err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return &project, nil
+}
+
+// GetProjectStatistics returns the vulnerability counts of a project
+func (c *Client) GetProjectStatistics(ctx context.Context, fullPath string) (*VulnerabilityStatistics, error) {
+	var data struct {
+		Project *struct {
+			Severities VulnerabilitySeverities `json:"vulnerabilitySeveritiesCount"`
+		} `json:"project"`
+	}
+	if err := c.graphQL(ctx, projectStatisticsQuery, map[string]interface{}{"fullPath": fullPath}, &data); err != nil {
+		return nil, fmt.Errorf("failed to get project statistics: %w", err)
+	}
+	if data.Project == nil {
+		return nil, fmt.Errorf("project %s not found", fullPath)
+	}
+	return &VulnerabilityStatistics{Severities: data.Project.Severities}, nil
+}
+
+// GetGroupStatistics returns the vulnerability counts and project grades of a group
+func (c *Client) GetGroupStatistics(ctx context.Context, fullPath string) (*VulnerabilityStatistics, error) {
+	var data struct {
+		Group *struct {
+			Severities VulnerabilitySeverities `json:"vulnerabilitySeveritiesCount"`
+			Grades     []VulnerabilityGrade    `json:"vulnerabilityGrades"`
+		} `json:"group"`
+	}
+	if err := c.graphQL(ctx, groupStatisticsQuery, map[string]interface{}{"fullPath": fullPath}, &data); err != nil {
+		return nil, fmt.Errorf("failed to get group statistics: %w", err)
+	}
+	if data.Group == nil {
+		return nil, fmt.Errorf("group %s not found", fullPath)
+	}
+	return &VulnerabilityStatistics{Severities: data.Group.Severities, Grades: data.Group.Grades}, nil
+}
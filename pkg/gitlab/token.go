@@ -0,0 +1,32 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// TokenInfo describes the access token the client authenticates with
+type TokenInfo struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Active    bool     `json:"active"`
+	Revoked   bool     `json:"revoked"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// HasScope reports whether the token was granted scope
+func (t *TokenInfo) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// GetTokenInfo introspects the personal, group or project access token of the
+// client
+func (c *Client) GetTokenInfo(ctx context.Context) (*TokenInfo, error) {
+	var token TokenInfo
+	if _, err := c.getPage(ctx, "/personal_access_tokens/self", nil, 0, &token); err != nil {
+		return nil, fmt.Errorf("failed to get token info: %w", err)
+	}
+	return &token, nil
+}
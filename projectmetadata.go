@@ -0,0 +1,86 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"net/url"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// putProjectMetadata attaches the metadata of their project to the resources of
+// a batch, so downstream routing and ownership mapping need no lookup of their
+// own. Each project is looked up once for the receiver's lifetime; failed
+// lookups are logged once per batch and tried again with the next one.
+func (r *vulnerabilityReceiver) putProjectMetadata(ctx context.Context, logs plog.Logs) {
+	if !r.cfg.Enrichment.ProjectMetadata {
+		return
+	}
+
+	var (
+		failed   int
+		firstErr error
+	)
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		attrs := logs.ResourceLogs().At(i).Resource().Attributes()
+		// Group exports tell projects apart by path, project exports by ID
+		key := ""
+		if path, ok := attrs.Get("gitlab.project.path"); ok {
+			key = path.Str()
+		} else if id, ok := attrs.Get("gitlab.project.id"); ok {
+			key = id.Str()
+		}
+		if key == "" {
+			continue
+		}
+
+		project, err := r.lookupProject(ctx, key)
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		putNonEmpty(attrs, "gitlab.project.name", project.Name)
+		putNonEmpty(attrs, "gitlab.project.namespace", project.Namespace.FullPath)
+		putNonEmpty(attrs, "gitlab.project.default_branch", project.DefaultBranch)
+		putNonEmpty(attrs, "gitlab.project.visibility", project.Visibility)
+		if len(project.Topics) > 0 {
+			topics := attrs.PutEmptySlice("gitlab.project.topics")
+			for _, topic := range project.Topics {
+				topics.AppendEmpty().SetStr(topic)
+			}
+		}
+	}
+
+	if failed > 0 {
+		r.logger.Warn("Failed to get project metadata",
+			zap.Int("failed", failed),
+			zap.Error(firstErr))
+	}
+}
+
+// lookupProject returns a project by ID or full path, from the cache when it
+// was looked up before
+func (r *vulnerabilityReceiver) lookupProject(ctx context.Context, key string) (*GitLabProject, error) {
+	r.projectMetadataMutex.Lock()
+	project, ok := r.projectMetadata[key]
+	r.projectMetadataMutex.Unlock()
+	if ok {
+		return project, nil
+	}
+
+	project, err := r.client.GetProject(ctx, url.PathEscape(key))
+	if err != nil {
+		return nil, err
+	}
+
+	r.projectMetadataMutex.Lock()
+	defer r.projectMetadataMutex.Unlock()
+	if r.projectMetadata == nil {
+		r.projectMetadata = make(map[string]*GitLabProject)
+	}
+	r.projectMetadata[key] = project
+	return project, nil
+}
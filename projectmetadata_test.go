@@ -0,0 +1,89 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestPutProjectMetadata(t *testing.T) {
+	var lookups []string
+	fail := true
+	cfg := createDefaultConfig().(*Config)
+	cfg.Enrichment.ProjectMetadata = true
+	recv := &vulnerabilityReceiver{
+		cfg:    cfg,
+		logger: zap.NewNop(),
+		client: &mockGitLabClient{
+			getProjectFunc: func(_ context.Context, projectID string) (*GitLabProject, error) {
+				lookups = append(lookups, projectID)
+				if projectID == "group%2Fbroken" && fail {
+					return nil, errors.New("unavailable")
+				}
+				return &GitLabProject{
+					ID:            7,
+					Name:          "App",
+					Path:          "group/app",
+					Namespace:     ProjectNamespace{FullPath: "group"},
+					DefaultBranch: "main",
+					Visibility:    "private",
+					Topics:        []string{"payments", "tier-1"},
+				}, nil
+			},
+		},
+	}
+
+	newLogs := func() plog.Logs {
+		logs := plog.NewLogs()
+		for _, path := range []string{"group/app", "group/broken"} {
+			rl := logs.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("gitlab.group.id", "42")
+			rl.Resource().Attributes().PutStr("gitlab.project.path", path)
+		}
+		return logs
+	}
+
+	logs := newLogs()
+	recv.putProjectMetadata(context.Background(), logs)
+	assert.Equal(t, map[string]interface{}{
+		"gitlab.group.id":               "42",
+		"gitlab.project.path":           "group/app",
+		"gitlab.project.name":           "App",
+		"gitlab.project.namespace":      "group",
+		"gitlab.project.default_branch": "main",
+		"gitlab.project.visibility":     "private",
+		"gitlab.project.topics":         []interface{}{"payments", "tier-1"},
+	}, logs.ResourceLogs().At(0).Resource().Attributes().AsRaw())
+	// A failed lookup leaves the resource as it was
+	assert.Equal(t, 2, logs.ResourceLogs().At(1).Resource().Attributes().Len())
+
+	// Projects are looked up once, failed lookups again
+	fail = false
+	logs = newLogs()
+	recv.putProjectMetadata(context.Background(), logs)
+	_, ok := logs.ResourceLogs().At(1).Resource().Attributes().Get("gitlab.project.name")
+	assert.True(t, ok)
+	require.Equal(t, []string{"group%2Fapp", "group%2Fbroken", "group%2Fbroken"}, lookups)
+}
+
+func TestPutProjectMetadata_Disabled(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:    createDefaultConfig().(*Config),
+		logger: zap.NewNop(),
+		client: &mockGitLabClient{
+			getProjectFunc: func(context.Context, string) (*GitLabProject, error) {
+				t.Fatal("project looked up while disabled")
+				return nil, nil
+			},
+		},
+	}
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("gitlab.project.id", "7")
+	recv.putProjectMetadata(context.Background(), logs)
+	assert.Equal(t, 1, logs.ResourceLogs().At(0).Resource().Attributes().Len())
+}
@@ -0,0 +1,103 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// isQuarantined reports whether polling of a path is suspended after repeated failures
+func (r *vulnerabilityReceiver) isQuarantined(pathID string) (time.Time, bool) {
+	r.exportMutex.RLock()
+	defer r.exportMutex.RUnlock()
+
+	until, ok := r.quarantinedUntil[pathID]
+	return until, ok && time.Now().Before(until)
+}
+
+// recordPathSuccess resets the failure count of a path
+func (r *vulnerabilityReceiver) recordPathSuccess(pathID string) {
+	r.exportMutex.Lock()
+	delete(r.pathFailures, pathID)
+	delete(r.quarantinedUntil, pathID)
+	r.exportMutex.Unlock()
+}
+
+// recordPathFailure counts a failed cycle and quarantines the path once
+// quarantine.failure_threshold consecutive cycles have failed. Cycles canceled
+// by shutdown say nothing about the path and aren't counted.
+func (r *vulnerabilityReceiver) recordPathFailure(ctx context.Context, path PathConfig, cause error) {
+	cfg := r.cfg.Quarantine
+	if cfg.FailureThreshold <= 0 || errors.Is(cause, context.Canceled) {
+		return
+	}
+
+	r.exportMutex.Lock()
+	if r.pathFailures == nil {
+		r.pathFailures = make(map[string]int)
+	}
+	if r.quarantinedUntil == nil {
+		r.quarantinedUntil = make(map[string]time.Time)
+	}
+	r.pathFailures[path.ID]++
+	failures := r.pathFailures[path.ID]
+	quarantine := failures >= cfg.FailureThreshold
+	var until time.Time
+	if quarantine {
+		until = time.Now().Add(cfg.Duration)
+		r.quarantinedUntil[path.ID] = until
+		delete(r.pathFailures, path.ID)
+	}
+	r.exportMutex.Unlock()
+
+	if !quarantine {
+		return
+	}
+
+	r.logger.Warn("Quarantining path after repeated failures",
+		zap.String("id", path.ID),
+		zap.String("type", path.Type),
+		zap.Int("failures", failures),
+		zap.Time("until", until),
+		zap.Error(cause))
+
+	if err := r.consumer.ConsumeLogs(ctx, r.quarantineEvent(path, failures, until, cause)); err != nil {
+		r.logger.Warn("Failed to emit quarantine event", zap.Error(err))
+	}
+}
+
+// quarantineEvent builds the diagnostic log record emitted when a path is quarantined
+func (r *vulnerabilityReceiver) quarantineEvent(path PathConfig, failures int, until time.Time, cause error) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	if path.Type == "group" {
+		rl.Resource().Attributes().PutStr("gitlab.group.id", path.ID)
+	} else {
+		rl.Resource().Attributes().PutStr("gitlab.project.id", path.ID)
+	}
+
+	sl := r.newScopeLogs(rl)
+
+	lr := sl.LogRecords().AppendEmpty()
+	now := pcommon.NewTimestampFromTime(time.Now())
+	lr.SetTimestamp(now)
+	lr.SetObservedTimestamp(now)
+	lr.SetSeverityNumber(plog.SeverityNumberWarn)
+	lr.SetSeverityText("WARN")
+	lr.Body().SetStr("Path quarantined after repeated failures")
+
+	setEventName(lr, "gitlab.path.quarantined")
+	attrs := lr.Attributes()
+	attrs.PutStr("gitlab.path.id", path.ID)
+	attrs.PutStr("gitlab.path.type", path.Type)
+	attrs.PutInt("gitlab.quarantine.failures", int64(failures))
+	attrs.PutStr("gitlab.quarantine.until", until.UTC().Format(time.RFC3339))
+	if cause != nil {
+		attrs.PutStr("error.message", cause.Error())
+	}
+	return logs
+}
@@ -0,0 +1,78 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestCheckExports_Quarantine(t *testing.T) {
+	validations := 0
+	mockClient := &mockGitLabClient{
+		validateProjectFunc: func(ctx context.Context, projectID string) error {
+			validations++
+			return fmt.Errorf("404 Project Not Found")
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	cfg.Quarantine = QuarantineConfig{FailureThreshold: 2, Duration: time.Hour}
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	}
+
+	// The path is skipped once the threshold is reached
+	assert.Equal(t, 2, validations)
+	_, quarantined := receiver.isQuarantined("12345")
+	assert.True(t, quarantined)
+
+	require.Equal(t, 1, sink.LogRecordCount())
+	attrs := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+	name, ok := attrs.Get("event.name")
+	require.True(t, ok)
+	assert.Equal(t, "gitlab.path.quarantined", name.Str())
+	failures, ok := attrs.Get("gitlab.quarantine.failures")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), failures.Int())
+
+	// A successful cycle clears the quarantine
+	receiver.recordPathSuccess("12345")
+	_, quarantined = receiver.isQuarantined("12345")
+	assert.False(t, quarantined)
+}
+
+func TestRecordPathFailure_IgnoresCanceled(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Quarantine = QuarantineConfig{FailureThreshold: 1, Duration: time.Hour}
+	receiver := &vulnerabilityReceiver{
+		cfg:      cfg,
+		consumer: new(consumertest.LogsSink),
+		logger:   zap.NewNop(),
+	}
+
+	path := PathConfig{ID: "12345", Type: "project"}
+	receiver.recordPathFailure(context.Background(), path, fmt.Errorf("failed to wait for export: %w", context.Canceled))
+	_, quarantined := receiver.isQuarantined("12345")
+	assert.False(t, quarantined)
+
+	receiver.recordPathFailure(context.Background(), path, fmt.Errorf("404 Project Not Found"))
+	_, quarantined = receiver.isQuarantined("12345")
+	assert.True(t, quarantined)
+}
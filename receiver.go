@@ -1,257 +1,1187 @@
 package gitlabvulnreceiver
 
 import (
+	"bufio"
 	"context"
-	"crypto/sha256"
 	"encoding/csv"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
 	"go.uber.org/zap"
 )
 
 type GitLabClientInterface interface {
 	WaitForExport(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error)
-	GetExportData(ctx context.Context, url string) (io.ReadCloser, error)
+	GetExportData(ctx context.Context, url string) (*ExportData, error)
 	GetExport(ctx context.Context, projectID string, exportID int64) (*Export, error)
 	CreateExport(ctx context.Context, projectID string) (*Export, error)
 	CreateGroupExport(ctx context.Context, groupID string) (*Export, error)
-	validateProjectID(ctx context.Context, projectID string) error
-	validateGroupID(ctx context.Context, groupID string) error
+	ListVulnerabilities(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error)
+	GetLatestJob(ctx context.Context, projectID, ref, name string) (*Job, error)
+	GetJobArtifacts(ctx context.Context, projectID string, jobID int64) (io.ReadCloser, error)
+	ListDependencies(ctx context.Context, projectID string, page int) ([]Dependency, int, error)
+	ListOpenMergeRequests(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error)
+	GetApprovalRules(ctx context.Context, projectID string, iid int64) ([]ApprovalRule, error)
+	GetProject(ctx context.Context, projectID string) (*GitLabProject, error)
+	GetMergeRequestFindings(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error)
+	GetIssueLinks(ctx context.Context, vulnerabilityID string) ([]IssueLink, error)
+	GetStateTransitions(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error)
+	ValidateProject(ctx context.Context, projectID string) error
+	ValidateGroup(ctx context.Context, groupID string) (*GitLabGroup, error)
 }
 
+// Instrumentation scope name set on emitted logs
+const scopeName = "github.com/iamabhimadan/gitlabvulnreceiver"
+
+// Schema URL of the semantic conventions emitted resources and scopes follow
+const schemaURL = semconv.SchemaURL
+
 type vulnerabilityReceiver struct {
 	cfg               *Config
 	settings          component.TelemetrySettings
+	buildInfo         component.BuildInfo
 	consumer          consumer.Logs
 	client            GitLabClientInterface
 	logger            *zap.Logger
 	cancel            context.CancelFunc
+	stopPolling       context.CancelFunc
 	wg                sync.WaitGroup
 	stateManager      *state.StateManager
 	lastExportTime    map[string]time.Time
 	exportMutex       sync.RWMutex
 	exportsInProgress map[string]bool
+	groupPaths        map[string]string
+	attributeTypes    map[string]string
+	pathFailures      map[string]int
+	quarantinedUntil  map[string]time.Time
+	kev               *kevCatalog
+	osv               *osvClient
+	telemetry         *receiverTelemetry
+	// timestamps parses the dates of records, the built-in layouts in UTC when nil
+	timestamps *timestampParser
+	// maintenance holds off polls while GitLab is under maintenance, nil when disabled
+	maintenance *maintenanceWindow
+	// blackouts are the windows during which no export is created
+	blackouts []blackoutWindow
+	// complianceDisabled holds the compliance sources refused for a project
+	complianceDisabled sync.Map
+	// exportSlots limits the exports in flight on the GitLab instance, nil when unlimited
+	exportSlots chan struct{}
+	// pollStats counts the work done by the current poll for its summary
+	pollStats pollStats
+	// host receives the status reports, failedPolls counts the consecutive polls
+	// with errors and unhealthy is set once a permanent error was reported
+	host        component.Host
+	failedPolls int
+	unhealthy   bool
+	// pathStatuses holds the live status of each path for the status page
+	statusMutex  sync.Mutex
+	pathStatuses map[string]*pathStatus
+	statusServer *http.Server
+	// projectMetadata caches the projects looked up for enrichment, by ID or path
+	projectMetadataMutex sync.Mutex
+	projectMetadata      map[string]*GitLabProject
+	// columns caches the names derived from the column names of exports
+	columnsMutex sync.RWMutex
+	columns      map[string]columnNames
+	// id is the component ID, shared with the metrics receiver of the same
+	// component that export snapshot metrics are sent through
+	id component.ID
+}
+
+// pollStats counts the exports created and records emitted during a poll
+type pollStats struct {
+	exportsCreated atomic.Int64
+	recordsEmitted atomic.Int64
 }
 
 // Starts the receiver
-func (r *vulnerabilityReceiver) Start(ctx context.Context, _ component.Host) error {
+func (r *vulnerabilityReceiver) Start(ctx context.Context, host component.Host) error {
+	if err := r.startEnrichment(ctx, host); err != nil {
+		return err
+	}
+	r.host = host
+
+	// The client is created on start, as auth extensions are only available from the host
+	if r.client == nil {
+		client, err := newGitLabClient(ctx, r.cfg, host, r.settings, r.telemetry, r.maintenance)
+		if err != nil {
+			return err
+		}
+		r.client = client
+	}
+
+	// Only a token set in the configuration can be introspected
+	if inspector, ok := r.client.(tokenInspector); ok && r.cfg.Token != "" {
+		if err := verifyTokenScopes(ctx, inspector, r.logger); err != nil {
+			return err
+		}
+	}
+
+	resolved, err := withResolvedPaths(ctx, r.client, r.cfg)
+	if err != nil {
+		return err
+	}
+	r.cfg = resolved
+
 	ctx, r.cancel = context.WithCancel(ctx)
 
 	// Initialize state manager
-	var err error
-	r.stateManager, err = state.NewStateManager(r.cfg.StateFile)
+	var stateOpts []state.Option
+	if r.cfg.StateCompression == stateCompressionGzip {
+		stateOpts = append(stateOpts, state.WithCompression())
+	}
+	r.stateManager, err = state.NewStateManager(r.cfg.StateFile, stateOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize state manager: %w", err)
 	}
+	if err := r.telemetry.observeState(r.stateManager); err != nil {
+		r.stateManager.Close()
+		return err
+	}
+	r.stateManager.StartFlushing(r.cfg.StateFlushInterval, func(err error) {
+		r.logger.Warn("Failed to save state", zap.Error(err))
+	})
+
+	// Restore when paths were last exported so a restart doesn't start over
+	r.exportMutex.Lock()
+	for _, path := range r.cfg.Paths {
+		if last, ok := r.stateManager.LastProcessedExport(path.ID); ok {
+			r.lastExportTime[path.ID] = last
+		}
+	}
+	r.exportMutex.Unlock()
+
+	if err := r.startExportLimiter(); err != nil {
+		r.telemetry.stopObservingState()
+		r.stateManager.Close()
+		return err
+	}
+	if err := r.startStatusPage(ctx, host); err != nil {
+		r.stopExportLimiter()
+		r.telemetry.stopObservingState()
+		r.stateManager.Close()
+		return err
+	}
+
+	// Polling stops as soon as shutdown begins, in-flight exports get the drain timeout
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	r.stopPolling = stopPolling
 
 	r.wg.Add(1)
 	go func() {
 		defer r.wg.Done()
-		r.pollForExports(ctx)
+		r.pollForExports(pollCtx, ctx)
 	}()
 
+	if r.osv != nil {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.osv.run(pollCtx)
+		}()
+	}
+
+	return nil
+}
+
+// startEnrichment creates the clients of the enabled third-party enrichment sources
+func (r *vulnerabilityReceiver) startEnrichment(ctx context.Context, host component.Host) error {
+	enrichment := r.cfg.Enrichment
+	if !enrichment.KEV.Enabled && !enrichment.OSV.Enabled {
+		return nil
+	}
+
+	client, err := r.newEnrichmentClient(ctx, host)
+	if err != nil {
+		return err
+	}
+	if enrichment.KEV.Enabled {
+		r.kev = newKEVCatalog(enrichment.KEV, client, r.logger)
+	}
+	if enrichment.OSV.Enabled {
+		r.osv = newOSVClient(enrichment.OSV, client, r.logger)
+	}
 	return nil
 }
 
-// Handles the polling loop
-func (r *vulnerabilityReceiver) pollForExports(ctx context.Context) {
-	ticker := time.NewTicker(r.cfg.PollInterval)
-	defer ticker.Stop()
+// newEnrichmentClient creates an HTTP client for third-party sources from the
+// configured HTTP client settings, so they go through the same proxy and TLS
+// settings. Headers and auth are meant for GitLab and aren't sent to them.
+func (r *vulnerabilityReceiver) newEnrichmentClient(ctx context.Context, host component.Host) (*http.Client, error) {
+	clientCfg := r.cfg.ClientConfig
+	clientCfg.Endpoint = ""
+	clientCfg.Headers = nil
+	clientCfg.Auth = nil
+	clientCfg.Cookies = nil
+
+	client, err := clientCfg.ToClient(ctx, host, r.settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create enrichment HTTP client: %w", err)
+	}
+	if client.Timeout == 0 {
+		client.Timeout = defaultEnrichmentTimeout
+	}
+	return client, nil
+}
+
+// Handles the polling loop until pollCtx is done, processing exports with ctx
+func (r *vulnerabilityReceiver) pollForExports(pollCtx, ctx context.Context) {
+	initialDelay := r.cfg.InitialDelay
+	if initialDelay <= 0 {
+		initialDelay = r.cfg.PollInterval
+	}
+	timer := time.NewTimer(initialDelay + r.pollJitter())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-pollCtx.Done():
 			return
-		case <-ticker.C:
-			if err := r.checkExports(ctx); err != nil {
+		case <-timer.C:
+			if err := r.checkExports(pollCtx, ctx); err != nil {
 				r.logger.Error("Failed to check exports", zap.Error(err))
 			}
+			timer.Reset(r.cfg.PollInterval + r.pollJitter())
 		}
 	}
 }
 
-// Checks for new exports and processes them
-func (r *vulnerabilityReceiver) checkExports(ctx context.Context) error {
+// Returns a random delay in [0, poll_jitter)
+func (r *vulnerabilityReceiver) pollJitter() time.Duration {
+	return randomJitter(r.cfg.PollJitter)
+}
+
+// Returns a random delay in [0, limit)
+func randomJitter(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
+// Checks for new exports and processes them with ctx. No new path is started
+// once pollCtx is done, so shutdown only drains exports already in flight.
+func (r *vulnerabilityReceiver) checkExports(pollCtx, ctx context.Context) error {
+	if r.kev != nil {
+		r.kev.refreshIfStale(ctx)
+	}
+
+	// Log a summary of the poll so its health shows without debug logging
+	start := time.Now()
+	var pathsProcessed, pathsSkipped int
+	var errs []error
+	r.pollStats.exportsCreated.Store(0)
+	r.pollStats.recordsEmitted.Store(0)
+	defer func() {
+		r.logger.Info("Poll completed",
+			zap.Int("pathsProcessed", pathsProcessed),
+			zap.Int("pathsSkipped", pathsSkipped),
+			zap.Int64("exportsCreated", r.pollStats.exportsCreated.Load()),
+			zap.Int64("recordsEmitted", r.pollStats.recordsEmitted.Load()),
+			zap.Int("errors", len(errs)),
+			zap.Duration("duration", time.Since(start)))
+		if pollCtx.Err() == nil {
+			r.reportPollHealth(errs)
+		}
+	}()
+
 	for _, path := range r.cfg.Paths {
+		if pollCtx.Err() != nil {
+			return nil
+		}
+		if until, ok := r.maintenance.active(); ok {
+			r.logger.Debug("Skipping export - GitLab under maintenance",
+				zap.String("id", path.ID),
+				zap.Time("until", until))
+			pathsSkipped++
+			continue
+		}
+		if until, quarantined := r.isQuarantined(path.ID); quarantined {
+			r.logger.Debug("Skipping export - path quarantined",
+				zap.String("id", path.ID),
+				zap.Time("until", until))
+			pathsSkipped++
+			continue
+		}
+
+		// Bound the time the path takes so it can't starve the paths after it, and
+		// stop it as soon as GitLab goes into maintenance
+		pathCtx, cancel := r.withPathBudget(ctx)
+		pathCtx, stopWatching := r.maintenance.watch(pathCtx)
+
+		// Merge request findings are polled every cycle so they're caught before merge
+		if r.cfg.MergeRequests.Enabled && path.Type == "project" {
+			if err := r.processMergeRequestFindings(pathCtx, path.ID); err != nil {
+				category := errorCategory(err)
+				r.telemetry.recordError(ctx, category)
+				r.logger.Error("Failed to process merge request findings",
+					zap.String("id", path.ID),
+					zap.String("errorCategory", category),
+					zap.Error(err))
+				errs = append(errs, err)
+			}
+		}
+
+		// State transitions are polled every cycle so the audit trail stays current
+		if r.cfg.AuditTrail && path.Type == "project" {
+			if err := r.processStateTransitions(pathCtx, path.ID); err != nil {
+				category := errorCategory(err)
+				r.telemetry.recordError(ctx, category)
+				r.logger.Error("Failed to process vulnerability state transitions",
+					zap.String("id", path.ID),
+					zap.String("errorCategory", category),
+					zap.Error(err))
+				errs = append(errs, err)
+			}
+		}
+
 		// Check if we've exported recently
 		r.exportMutex.RLock()
 		lastExport, exists := r.lastExportTime[path.ID]
 		r.exportMutex.RUnlock()
 
-		// Only export if it's been more than 24 hours or never exported
-		if exists && time.Since(lastExport) < 24*time.Hour {
+		// Only export once min_export_interval has passed since the last export was
+		// processed. Interrupted exports are resumed right away and incremental
+		// syncs after the baseline run every poll.
+		coolingDown := exists && time.Since(lastExport) < r.cfg.MinExportInterval && !r.hasPendingExport(path.ID)
+		if coolingDown && !r.hasSyncBaseline(path.ID) {
 			r.logger.Debug("Skipping export - too soon since last export",
 				zap.String("id", path.ID),
-				zap.Time("lastExport", lastExport))
+				zap.Time("lastExport", lastExport),
+				zap.Duration("minExportInterval", r.cfg.MinExportInterval))
+			stopWatching()
+			cancel()
+			pathsSkipped++
 			continue
 		}
 
 		var err error
 		switch path.Type {
 		case "project":
-			err = r.processProjectExports(ctx, path.ID)
+			err = r.processProjectExports(pathCtx, path.ID)
+			// SBOMs and compliance follow the export cooldown, not the incremental sync
+			if err == nil && !coolingDown && r.cfg.SBOM.Job != "" {
+				err = r.processSBOM(pathCtx, path.ID)
+			}
+			if err == nil && !coolingDown {
+				err = r.processCompliance(pathCtx, path.ID)
+			}
 		case "group":
-			err = r.processGroupExports(ctx, path.ID)
+			err = r.processGroupExports(pathCtx, path.ID)
 		default:
 			err = fmt.Errorf("unknown path type: %s", path.Type)
 		}
+		overBudget := err != nil && isPathBudgetExceeded(pathCtx)
+		stopWatching()
+		cancel()
 
+		pathsProcessed++
+		if overBudget {
+			// The path isn't failing, it's deferred so the other paths get their turn
+			r.logger.Warn("Path exceeded max_elapsed_per_path, resuming next poll",
+				zap.String("id", path.ID),
+				zap.String("type", path.Type),
+				zap.Duration("maxElapsedPerPath", r.cfg.MaxElapsedPerPath),
+				zap.Error(err))
+			r.telemetry.recordPathOverBudget(ctx)
+			r.updatePathStatus(path.ID, func(s *pathStatus) { s.ExportStatus = "deferred" })
+			continue
+		}
+		if _, ok := r.maintenance.active(); ok && err != nil {
+			// GitLab went into maintenance during the path, it's retried once it's back
+			r.logger.Info("Export interrupted by GitLab maintenance, retrying after it",
+				zap.String("id", path.ID),
+				zap.String("type", path.Type),
+				zap.Error(err))
+			r.updatePathStatus(path.ID, func(s *pathStatus) { s.ExportStatus = "maintenance" })
+			continue
+		}
+		if errors.Is(err, errBlackoutWindow) {
+			r.logger.Debug("Skipping export - in a blackout window",
+				zap.String("id", path.ID),
+				zap.String("type", path.Type))
+			r.updatePathStatus(path.ID, func(s *pathStatus) { s.ExportStatus = "blackout" })
+			continue
+		}
+		if isBackpressure(err) {
+			// The pipeline is catching up, the export isn't failing
+			r.logger.Info("Pausing export until the pipeline catches up, resuming from checkpoint next poll",
+				zap.String("id", path.ID),
+				zap.String("type", path.Type),
+				zap.Error(err))
+			r.updatePathStatus(path.ID, func(s *pathStatus) { s.ExportStatus = "paused" })
+			continue
+		}
 		if err != nil {
+			category := errorCategory(err)
+			r.telemetry.recordError(ctx, category)
 			r.logger.Error("Failed to process exports",
 				zap.String("id", path.ID),
 				zap.String("type", path.Type),
+				zap.String("errorCategory", category),
 				zap.Error(err))
+			r.recordPathFailure(ctx, path, err)
+			errs = append(errs, err)
+			r.updatePathStatus(path.ID, func(s *pathStatus) {
+				s.LastError, s.LastErrorTime = err.Error(), time.Now()
+			})
 			continue
 		}
-
-		// Update last export time on success
-		r.exportMutex.Lock()
-		r.lastExportTime[path.ID] = time.Now()
-		r.exportMutex.Unlock()
+		r.recordPathSuccess(path.ID)
+		r.updatePathStatus(path.ID, func(s *pathStatus) { s.LastSuccess = time.Now() })
 	}
 	return nil
 }
 
+// Marks a path as exporting, returning false if an export is already in progress
+func (r *vulnerabilityReceiver) beginExport(pathID string) bool {
+	r.exportMutex.Lock()
+	defer r.exportMutex.Unlock()
+
+	if r.exportsInProgress[pathID] {
+		return false
+	}
+	if r.exportsInProgress == nil {
+		r.exportsInProgress = make(map[string]bool)
+	}
+	r.exportsInProgress[pathID] = true
+	return true
+}
+
+// Reports whether a path has an export in progress or one to resume from a checkpoint
+func (r *vulnerabilityReceiver) hasPendingExport(pathID string) bool {
+	r.exportMutex.RLock()
+	inProgress := r.exportsInProgress[pathID]
+	r.exportMutex.RUnlock()
+	if inProgress {
+		return true
+	}
+	_, ok := r.stateManager.GetCheckpoint(pathID)
+	return ok
+}
+
+// Clears the in-progress flag of a path
+func (r *vulnerabilityReceiver) endExport(pathID string) {
+	r.exportMutex.Lock()
+	delete(r.exportsInProgress, pathID)
+	r.exportMutex.Unlock()
+}
+
+// Number of rows between checkpoint writes while processing an export
+const checkpointInterval = 1000
+
 // Processes a single export
-func (r *vulnerabilityReceiver) processExport(ctx context.Context, export *Export) error {
+func (r *vulnerabilityReceiver) processExport(ctx context.Context, pathID string, export *Export) (err error) {
+	defer func() {
+		// An expired or abandoned export is counted once it has been replaced, one
+		// out of time for the poll once it's resumed
+		if !errors.Is(err, context.Canceled) && !isExportGone(err) && !isExportStuck(err) && !isPathBudgetExceeded(ctx) {
+			r.telemetry.recordExportResult(ctx, err)
+		}
+	}()
+
 	// Wait for export to complete
-	export, err := r.client.WaitForExport(ctx, export.GetProjectID(), export.ID, r.cfg.ExportTimeout)
+	groupID := export.GroupID
+	waitStart := time.Now()
+	export, err = r.waitForExport(ctx, pathID, export)
+	r.telemetry.recordExportWait(ctx, time.Since(waitStart))
 	if err != nil {
 		return fmt.Errorf("failed to wait for export: %w", err)
 	}
+	if export.GroupID == nil {
+		export.GroupID = groupID
+	}
+	r.telemetry.recordExportGeneration(ctx, export)
+	r.recordExportStatus(pathID, export.ID, string(export.Status))
+
+	if r.stateManager.IsExportProcessed(export.ID) {
+		r.logger.Info("Skipping export - already processed",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID))
+		return r.stateManager.ClearCheckpoint(pathID)
+	}
 
 	// Download the export
-	reader, err := r.client.GetExportData(ctx, export.Links.Download)
+	data, err := r.client.GetExportData(ctx, export.Links.Download)
 	if err != nil {
 		return fmt.Errorf("failed to download export: %w", err)
 	}
-	defer reader.Close()
+	defer data.Close()
+	downloaded := &countingReader{r: data}
+	defer func() { r.telemetry.recordDownload(ctx, downloaded.n) }()
 
-	// Process the CSV
-	return r.processCSVData(ctx, csv.NewReader(reader), export)
+	// Process the CSV or JSON records. Large downloads are read from disk, so
+	// slow parsing doesn't keep the connection open until the server times it out.
+	buffered := bufio.NewReaderSize(downloaded, max(r.cfg.CSV.BufferSize, sniffLength))
+	if err := checkExportPayload(buffered, data.ContentType); err != nil {
+		return err
+	}
+	text := newCharsetReader(buffered, r.cfg.Encoding)
+	var decoder exportDecoder
+	if r.shouldSpill(data.Size) {
+		spilled, err := r.spillDownload(text)
+		if err != nil {
+			return fmt.Errorf("failed to spill export download: %w", err)
+		}
+		defer spilled.Close()
+		data.Close()
+
+		var closeDecoder func()
+		decoder, closeDecoder, err = r.newSpilledDecoder(ctx, spilled, data.ContentType, export)
+		if err != nil {
+			return err
+		}
+		defer closeDecoder()
+	} else {
+		decoder = &fieldLimitDecoder{exportDecoder: newExportDecoder(text, data.ContentType, export.Format), receiver: r}
+	}
+	if err := r.processRecords(ctx, decoder, pathID, export); err != nil {
+		return err
+	}
+
+	// The export is fully processed, a new one will be created once the cooldown has passed
+	if err := r.stateManager.MarkExportProcessed(pathID, export.ID); err != nil {
+		return err
+	}
+	r.recordExportStatus(pathID, export.ID, "processed")
+	r.exportMutex.Lock()
+	if r.lastExportTime == nil {
+		r.lastExportTime = make(map[string]time.Time)
+	}
+	r.lastExportTime[pathID] = time.Now()
+	r.exportMutex.Unlock()
+	return nil
 }
 
-// Processes a CSV data
-func (r *vulnerabilityReceiver) processCSVData(ctx context.Context, reader *csv.Reader, export *Export) error {
-	header, err := reader.Read()
+// resumeOrCreateExport returns the export recorded in the path's checkpoint when
+// it can still be processed and isn't stale, otherwise it creates a new export
+func (r *vulnerabilityReceiver) resumeOrCreateExport(
+	ctx context.Context,
+	pathID string,
+	create func(ctx context.Context, id string) (*Export, error),
+) (*Export, error) {
+	if cp, ok := r.stateManager.GetCheckpoint(pathID); ok {
+		export, err := r.client.GetExport(ctx, pathID, cp.ExportID)
+		age, stale := r.exportStaleness(export)
+		switch {
+		case err == nil && export.Status != ExportStatusFailed && !stale:
+			r.logger.Info("Resuming export from checkpoint",
+				zap.String("id", pathID),
+				zap.Int64("exportID", cp.ExportID),
+				zap.Int64("rowsProcessed", cp.RowsProcessed))
+			r.recordExportStatus(pathID, export.ID, string(export.Status))
+			return export, nil
+		case err == nil && stale:
+			r.logger.Info("Discarding checkpoint for stale export, generating a new one",
+				zap.String("id", pathID),
+				zap.Int64("exportID", cp.ExportID),
+				zap.Duration("age", age),
+				zap.Duration("maxExportAge", r.cfg.MaxExportAge))
+		default:
+			r.logger.Warn("Discarding checkpoint for unusable export",
+				zap.String("id", pathID),
+				zap.Int64("exportID", cp.ExportID),
+				zap.Error(err))
+		}
+		if err := r.stateManager.ClearCheckpoint(pathID); err != nil {
+			return nil, fmt.Errorf("failed to clear checkpoint: %w", err)
+		}
+	}
+
+	return r.createExport(ctx, pathID, create)
+}
+
+// exportStaleness returns how long ago a finished export finished, and whether
+// that's beyond max_export_age. Exports still being generated aren't stale.
+func (r *vulnerabilityReceiver) exportStaleness(export *Export) (time.Duration, bool) {
+	if export == nil || export.Status != ExportStatusFinished {
+		return 0, false
+	}
+	finished := export.CreatedAt
+	if export.FinishedAt != nil {
+		finished = *export.FinishedAt
+	}
+	if finished.IsZero() {
+		return 0, false
+	}
+	age := time.Since(finished)
+	return age, r.cfg.MaxExportAge > 0 && age > r.cfg.MaxExportAge
+}
+
+// createExport creates a new export of a path and checkpoints it
+func (r *vulnerabilityReceiver) createExport(
+	ctx context.Context,
+	pathID string,
+	create func(ctx context.Context, id string) (*Export, error),
+) (*Export, error) {
+	if r.inBlackout(time.Now()) {
+		return nil, errBlackoutWindow
+	}
+	export, err := create(ctx, pathID)
 	if err != nil {
-		return fmt.Errorf("failed to read CSV header: %w", err)
+		return nil, err
 	}
+	r.telemetry.recordExportCreated(ctx)
+	r.pollStats.exportsCreated.Add(1)
+	r.recordExportStatus(pathID, export.ID, string(export.Status))
+
+	// Record the export right away so a restart picks it up instead of creating another
+	r.saveCheckpoint(pathID, export.ID, 0)
+	return export, nil
+}
 
-	// Get existing state
-	state := r.stateManager.GetState(map[string]string{
-		"ProjectID": export.GetProjectID(),
-		"ExportID":  fmt.Sprintf("%d", export.ID),
+// processExportOrRecreate processes an export, replacing it with a new one in
+// the same cycle when GitLab has expired or deleted it, or it's stuck and
+// stuck_exports recreate is set. It returns the export that was processed.
+func (r *vulnerabilityReceiver) processExportOrRecreate(
+	ctx context.Context,
+	pathID string,
+	export *Export,
+	create func(ctx context.Context, id string) (*Export, error),
+) (*Export, error) {
+	err := r.processExport(ctx, pathID, export)
+	switch {
+	case isExportGone(err):
+		r.logger.Warn("Export expired or was deleted, creating a new one",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID),
+			zap.Error(err))
+		r.telemetry.recordExportExpired(ctx)
+	case isExportStuck(err):
+		r.logger.Warn("Abandoning stuck export, creating a new one",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID),
+			zap.Error(err))
+	default:
+		return export, err
+	}
+
+	if err := r.stateManager.ClearCheckpoint(pathID); err != nil {
+		return nil, fmt.Errorf("failed to clear checkpoint: %w", err)
+	}
+
+	groupID := export.GroupID
+	export, err = r.createExport(ctx, pathID, create)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recreate expired export: %w", err)
+	}
+	if export.GroupID == nil {
+		export.GroupID = groupID
+	}
+	err = r.processExport(ctx, pathID, export)
+	if isExportGone(err) || isExportStuck(err) {
+		// The replacement isn't replaced again, it failed
+		r.telemetry.recordExportResult(ctx, err)
+	}
+	return export, err
+}
+
+// saveCheckpoint persists the number of rows processed for an export
+func (r *vulnerabilityReceiver) saveCheckpoint(pathID string, exportID int64, rows int64) {
+	err := r.stateManager.SetCheckpoint(pathID, state.ExportCheckpoint{
+		ExportID:      exportID,
+		RowsProcessed: rows,
 	})
+	if err != nil {
+		r.logger.Warn("Failed to save export checkpoint",
+			zap.String("id", pathID),
+			zap.Int64("exportID", exportID),
+			zap.Error(err))
+	}
+}
+
+// Processes a CSV data
+func (r *vulnerabilityReceiver) processCSVData(ctx context.Context, reader *csv.Reader, pathID string, export *Export) error {
+	return r.processRecords(ctx, newCSVDecoder(reader), pathID, export)
+}
+
+// How long an emitted vulnerability is remembered after it was last exported
+const processedRetention = 30 * 24 * time.Hour
 
-	processedIDs := make(map[string]bool)
-	if state != nil && state["ProcessedIDs"] != "" {
-		for _, id := range strings.Split(state["ProcessedIDs"], ",") {
-			processedIDs[id] = true
+// processRecords emits the records of an export that haven't been processed yet
+func (r *vulnerabilityReceiver) processRecords(ctx context.Context, decoder exportDecoder, pathID string, export *Export) error {
+	// Shared findings are only emitted once the whole export has been read, so
+	// no row is delivered before the end and the export isn't checkpointed
+	var aggregator *findingAggregator
+	if r.cfg.GroupDeduplication && export.GetGroupID() != "" {
+		aggregator = newFindingAggregator(maxAggregatedFindings)
+	}
+
+	// Rows before the checkpoint were emitted by a previous run of this export
+	var skipRows, rows int64
+	if cp, ok := r.stateManager.GetCheckpoint(pathID); ok && cp.ExportID == export.ID && aggregator == nil {
+		skipRows = cp.RowsProcessed
+	}
+
+	// Rows the pipeline rejected before the export was resumed stay unseen
+	rejectedKeys := make(map[string]bool)
+	if skipRows > 0 {
+		for _, rejected := range r.stateManager.GetRejectedRows(pathID) {
+			if rejected.ExportID == export.ID {
+				for _, key := range rejected.Keys {
+					rejectedKeys[key] = true
+				}
+			}
+		}
+	}
+
+	// Rows up to flushedRows have been delivered to the pipeline
+	lastCheckpoint, flushedRows := skipRows, skipRows
+	counts := rowCounts{skipped: make(map[string]int64)}
+	// ages holds the age of the batched findings, recorded once they're delivered
+	var ages []time.Duration
+	defer func() {
+		counts.parsed = rows
+		r.telemetry.recordRows(ctx, counts)
+	}()
+	// processed are the keys to mark seen once the export is done, batched
+	// those of the records in the batch until it's delivered
+	var processed, batched []string
+	schemaChecked := false
+	batchSize := r.batchSize()
+	batch := newLogBatch()
+	issueLinks := newIssueLinkCache()
+	findings := newFindingTracker(pathID)
+	snapshot := newExportSnapshot()
+	flush := func() error {
+		if batch.records == 0 {
+			return nil
+		}
+		emitted, err := r.deliverBatch(ctx, pathID, batch, issueLinks)
+		if emitted > 0 {
+			counts.emitted += int64(emitted)
+			r.telemetry.recordFindingAges(ctx, ages)
+		}
+		ages = ages[:0]
+		if consumererror.IsPermanent(err) {
+			// Sending the batch again can't succeed: its rows are recorded and
+			// left unseen instead, so the next export emits them again
+			rejected := state.RejectedRows{ExportID: export.ID, Keys: batched, Error: err.Error()}
+			if aggregator == nil {
+				rejected.FirstRow, rejected.LastRow = flushedRows+1, rows
+			}
+			if err := r.stateManager.AddRejectedRows(pathID, rejected); err != nil {
+				return fmt.Errorf("failed to save rejected rows: %w", err)
+			}
+			err = nil
+		} else if err == nil {
+			processed = append(processed, batched...)
+		}
+		batched = nil
+		if err == nil {
+			if err := r.saveFindings(findings); err != nil {
+				return err
+			}
+		}
+		if aggregator != nil {
+			// The whole export is read again by the next cycle
+			if err != nil {
+				return fmt.Errorf("failed to consume logs: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			// Keep a cursor at the last delivered row so the rest of the export
+			// is picked up by the next cycle instead of being dropped
+			r.saveCheckpoint(pathID, export.ID, flushedRows)
+			return fmt.Errorf("failed to consume logs: %w", err)
+		}
+		flushedRows = rows
+		if rows-lastCheckpoint >= checkpointInterval {
+			r.saveCheckpoint(pathID, export.ID, rows)
+			lastCheckpoint = rows
 		}
+		return nil
 	}
 
-	var newProcessedIDs []string
 	for {
-		record, err := reader.Read()
+		record, err := decoder.Read()
 		if err == io.EOF {
 			break
 		}
+		var malformed *malformedRowError
+		if errors.As(err, &malformed) {
+			// The rest of the export can still be read
+			rows++
+			counts.skipped[skipReasonParseError]++
+			r.logger.Warn("Skipping malformed export row",
+				zap.String("id", pathID),
+				zap.Int64("exportID", export.ID),
+				zap.Int64("row", rows),
+				zap.Error(err))
+			continue
+		}
 		if err != nil {
-			return fmt.Errorf("failed to read CSV record: %w", err)
+			return err
+		}
+		rows++
+
+		// CSV exports share their columns, JSON records each have their own
+		if !schemaChecked && record.source == nil {
+			r.checkSchemaDrift(ctx, pathID, export, record.header)
+			schemaChecked = true
 		}
 
 		// Generate unique ID for vulnerability
-		vulnID := generateVulnID(record) // Implement this based on your needs
+		vulnID := generateVulnID(record.header, record.values)
 
-		// Skip if already processed
-		if processedIDs[vulnID] {
+		// Status changes are tracked for every row, including those already emitted
+		key := r.findingKey(record.header, record.values)
+		event := r.findingEvent(key, record)
+		if closure, closed := r.trackFinding(findings, key, record, export); closed {
+			batch.add(findProjectPath(record.header, record.values), closure)
+		}
+		r.addToSnapshot(snapshot, key, record)
+
+		// Skip if already processed, keeping it remembered while it's exported
+		processedKey := state.ProcessedKeyPrefix + vulnID
+		if rows <= skipRows {
+			if !rejectedKeys[processedKey] {
+				processed = append(processed, processedKey)
+			}
+			continue
+		}
+		if r.stateManager.IsSeen(processedKey) {
+			processed = append(processed, processedKey)
+			counts.skipped[skipReasonDuplicate]++
+			continue
+		}
+		if r.filtered(record) {
+			processed = append(processed, processedKey)
+			counts.skipped[skipReasonFiltered]++
 			continue
 		}
 
-		// Convert and send logs
-		logs := r.convertToLogs(header, record, export)
-		if err := r.consumer.ConsumeLogs(ctx, logs); err != nil {
-			return fmt.Errorf("failed to consume logs: %w", err)
+		// Convert and batch logs, sending them once the batch is full
+		projectPath := findProjectPath(record.header, record.values)
+		if aggregator != nil {
+			if key, ok := dedupKey(record.header, record.values); ok {
+				if !record.converted {
+					record.logs, record.converted = r.convertRecord(record, export), true
+				}
+				lr := record.logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+				setEventName(lr, event)
+				if aggregated, first := aggregator.add(key, projectPath, processedKey, record.logs); aggregated {
+					if first {
+						r.enrichRecord(lr)
+					} else {
+						counts.skipped[skipReasonDuplicate]++
+					}
+					continue
+				}
+			}
+		}
+		lr := r.batchRecord(batch, projectPath, record, export)
+		setEventName(lr, event)
+		r.enrichRecord(lr)
+		batched = append(batched, processedKey)
+		if age, ok := r.findingAge(record); ok {
+			ages = append(ages, age)
 		}
 
-		newProcessedIDs = append(newProcessedIDs, vulnID)
+		if batch.records >= batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if aggregator != nil {
+		for _, aggregate := range aggregator.take() {
+			batch.add("", aggregate.logs)
+			batched = append(batched, aggregate.seenKeys...)
+			if batch.records >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
-	// Update state with new processed IDs
-	if len(newProcessedIDs) > 0 {
-		return r.stateManager.SetState(map[string]string{
-			"ProjectID": export.GetProjectID(),
-			"ExportID":  fmt.Sprintf("%d", export.ID),
-		}, map[string]string{
-			"LastSeenHash": generateHash(newProcessedIDs),
-			"LastScanTime": time.Now().Format(time.RFC3339),
-			"ProcessedIDs": strings.Join(append(newProcessedIDs, strings.Split(state["ProcessedIDs"], ",")...), ","),
-		})
+	// Tracked findings missing from the whole export were closed. A malformed
+	// row may be one of them, so nothing is closed for an export with some.
+	if counts.skipped[skipReasonParseError] > 0 {
+		r.logger.Warn("Skipping detection of closed findings, the export has malformed rows",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID),
+			zap.Int64("malformedRows", counts.skipped[skipReasonParseError]))
+	} else {
+		for _, closure := range r.absentFindings(findings, export) {
+			batch.add(closure.projectPath, closure.logs)
+			if batch.records >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+	if err := r.saveFindings(findings); err != nil {
+		return err
 	}
+	r.emitSnapshotMetrics(ctx, pathID, export, snapshot)
 
+	if len(processed) == 0 {
+		return nil
+	}
+	if err := r.stateManager.MarkSeen(processed, processedRetention); err != nil {
+		return fmt.Errorf("failed to save processed vulnerabilities: %w", err)
+	}
+	return nil
+}
+
+// deliverBatch sends the batched records to the pipeline with the attributes
+// looked up from other APIs, returning the number delivered. Errors of records
+// the pipeline permanently rejects are reported and returned as they are:
+// sending them again can't succeed, so the caller records the rejected
+// findings instead of retrying them.
+func (r *vulnerabilityReceiver) deliverBatch(ctx context.Context, pathID string, batch *logBatch, issueLinks *issueLinkCache) (int, error) {
+	records := batch.records
+	logs := batch.take()
+	r.putIssueLinks(ctx, issueLinks, logs)
+	r.putProjectMetadata(ctx, logs)
+	r.applyOutputSchema(logs)
+	err := r.consumeLogs(ctx, logs)
+	if consumererror.IsPermanent(err) {
+		r.reportRejected(ctx, pathID, records, err)
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return records, nil
+}
+
+// reportRejected reports records permanently rejected by the pipeline
+func (r *vulnerabilityReceiver) reportRejected(ctx context.Context, pathID string, records int, err error) {
+	var logsErr consumererror.Logs
+	if errors.As(err, &logsErr) {
+		records = logsErr.Data().LogRecordCount()
+	}
+	r.logger.Warn("Consumer permanently rejected logs, recording them to emit again with the next export",
+		zap.String("id", pathID),
+		zap.Int("records", records),
+		zap.Error(err))
+	r.telemetry.recordDropped(ctx, int64(records))
+}
+
+// consumeLogs sends logs to the next consumer, retrying retryable errors with
+// exponential backoff until the retry budget is exhausted. Its errors are
+// categorized as consumer errors.
+func (r *vulnerabilityReceiver) consumeLogs(ctx context.Context, logs plog.Logs) error {
+	if err := r.deliverLogs(ctx, logs); err != nil {
+		return &consumerError{err: err}
+	}
 	return nil
 }
 
+// deliverLogs sends logs to the next consumer with consumeLogs' retries
+func (r *vulnerabilityReceiver) deliverLogs(ctx context.Context, logs plog.Logs) error {
+	retry := r.cfg.ConsumerRetry
+	interval := retry.InitialInterval
+	deadline := time.Now().Add(retry.MaxElapsedTime)
+	records := int64(logs.LogRecordCount())
+
+	for {
+		err := r.consumer.ConsumeLogs(ctx, logs)
+		if err == nil {
+			r.pollStats.recordsEmitted.Add(records)
+			return nil
+		}
+		if consumererror.IsPermanent(err) || !retry.Enabled {
+			return err
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("giving up after %v: %w", retry.MaxElapsedTime, err)
+		}
+
+		// Only resend the part of the data the consumer rejected
+		var logsErr consumererror.Logs
+		if errors.As(err, &logsErr) {
+			logs = logsErr.Data()
+		}
+
+		r.logger.Warn("Consumer rejected logs, retrying",
+			zap.Duration("interval", interval),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, retry.MaxInterval)
+	}
+}
+
+// Attribute holding the exported row of a finding with preserve_raw_record
+const rawRecordAttribute = "gitlab.raw"
+
+// convertRecord converts a decoded export record to OpenTelemetry logs
+func (r *vulnerabilityReceiver) convertRecord(record *exportRecord, export *Export) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	r.putResource(rl, record.header, record.values, export)
+	r.convertRecordTo(rl.ScopeLogs().At(0).LogRecords().AppendEmpty(), record)
+	return logs
+}
+
+// convertRecordTo converts a record into an empty log record
+func (r *vulnerabilityReceiver) convertRecordTo(lr plog.LogRecord, record *exportRecord) {
+	redactNestedSecrets(record.nested)
+	r.convertToLogRecord(lr, record.header, record.values)
+	r.putTruncatedFields(lr, record)
+	attrs := lr.Attributes()
+	if r.cfg.PreserveRawRecord {
+		if raw, err := record.raw(); err != nil {
+			r.logger.Debug("Failed to preserve raw record", zap.Error(err))
+		} else {
+			attrs.PutStr(rawRecordAttribute, raw)
+		}
+	}
+
+	// Keep the structure of nested JSON values (identifiers, location, scanner)
+	for key, value := range record.nested {
+		if err := attrs.PutEmpty(r.attributeName(key)).FromRaw(value); err != nil {
+			r.logger.Debug("Failed to convert nested field",
+				zap.String("field", key),
+				zap.Error(err))
+		}
+	}
+}
+
+// batchRecord adds a record to the resource of its project in a batch. Records
+// converted ahead are moved there, others are converted in place so rows don't
+// each build a resource and scope of their own.
+func (r *vulnerabilityReceiver) batchRecord(batch *logBatch, projectPath string, record *exportRecord, export *Export) plog.LogRecord {
+	if record.converted {
+		return batch.add(projectPath, record.logs)
+	}
+	lr := batch.appendRecord(projectPath, func(rl plog.ResourceLogs) {
+		r.putResource(rl, record.header, record.values, export)
+	})
+	r.convertRecordTo(lr, record)
+	return lr
+}
+
+// enrichRecord adds the attributes looked up from other APIs to a converted record
+func (r *vulnerabilityReceiver) enrichRecord(lr plog.LogRecord) {
+	r.putOSVAttributes(lr)
+}
+
 // Converts a CSV record to OpenTelemetry logs
 func (r *vulnerabilityReceiver) convertToLogs(header []string, record []string, export *Export) plog.Logs {
 	logs := plog.NewLogs()
 	rl := logs.ResourceLogs().AppendEmpty()
+	r.putResource(rl, header, record, export)
+	r.convertToLogRecord(rl.ScopeLogs().At(0).LogRecords().AppendEmpty(), header, record)
+	return logs
+}
+
+// putResource sets the resource and scope of the findings of a record's project
+func (r *vulnerabilityReceiver) putResource(rl plog.ResourceLogs, header []string, record []string, export *Export) {
+	r.putProjectResource(rl, findProjectPath(header, record), export)
+}
 
-	// Add resource attributes
+// putProjectResource sets the resource and scope of the findings of a project,
+// identified by its path in group exports
+func (r *vulnerabilityReceiver) putProjectResource(rl plog.ResourceLogs, projectPath string, export *Export) {
 	attrs := rl.Resource().Attributes()
-	attrs.PutStr("gitlab.project.id", export.GetProjectID())
-	attrs.PutStr("gitlab.export.id", fmt.Sprintf("%d", export.ID))
+	if projectID := export.GetProjectID(); projectID != "" {
+		attrs.PutStr("gitlab.project.id", projectID)
+	}
+	if groupID := export.GetGroupID(); groupID != "" {
+		attrs.PutStr("gitlab.group.id", groupID)
+		if groupPath := r.groupPath(groupID); groupPath != "" {
+			attrs.PutStr("gitlab.group.path", groupPath)
+		}
+	}
 
-	// Create log record
-	sl := rl.ScopeLogs().AppendEmpty()
-	lr := sl.LogRecords().AppendEmpty()
+	// Group exports mix projects, so attribute each finding to its project
+	if projectPath != "" {
+		attrs.PutStr("gitlab.project.path", projectPath)
+	}
 
-	// Set timestamp based on discovered_at if available
-	timestamp := time.Now()
-	if discoveredAt, ok := findField(header, record, "discovered_at"); ok {
-		if t, err := time.Parse(time.RFC3339, discoveredAt); err == nil {
-			timestamp = t
-		}
+	sl := r.newScopeLogs(rl)
+	r.setScope(sl.Scope(), export)
+}
+
+// convertToLogRecord converts a CSV record into an empty log record
+func (r *vulnerabilityReceiver) convertToLogRecord(lr plog.LogRecord, header []string, record []string) {
+	// Matched secrets never reach an attribute
+	record = redactSecrets(header, record)
+
+	// The record is observed now, and happened when the finding was detected
+	now := time.Now()
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(now))
+	timestamp := now
+	if detected, ok := r.detectedAt(header, record); ok {
+		timestamp = detected
 	}
 	lr.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
 
-	// Set severity level
-	if severity, ok := findField(header, record, "severity"); ok {
-		lr.SetSeverityText(severity)
-		switch strings.ToLower(severity) {
-		case "critical":
-			lr.SetSeverityNumber(plog.SeverityNumberFatal)
-		case "high":
-			lr.SetSeverityNumber(plog.SeverityNumberError)
-		case "medium":
-			lr.SetSeverityNumber(plog.SeverityNumberWarn)
-		case "low":
-			lr.SetSeverityNumber(plog.SeverityNumberInfo)
-		case "info":
-			lr.SetSeverityNumber(plog.SeverityNumberTrace)
-		}
-	}
-
-	// Map all fields to attributes
-	attrs = lr.Attributes()
+	r.setSeverity(lr, header, record)
+
+	// Map all fields to attributes, except those with a structured mapping
+	attrs := lr.Attributes()
+	typ := reportType(header, record)
+	putNonEmpty(attrs, "report.type", typ)
+	structured := make(map[string]bool)
+	maps.Copy(structured, putDASTAttributes(attrs, header, record))
+	maps.Copy(structured, putContainerAttributes(attrs, header, record))
+	maps.Copy(structured, putLocationAttributes(attrs, header, record))
 	for i, field := range header {
+		column := r.column(field)
+		if identifierSliceColumns[column.lower] || structured[column.lower] {
+			continue
+		}
 		if i < len(record) && record[i] != "" {
-			attrKey := normalizeFieldName(field)
-			attrs.PutStr(attrKey, record[i])
+			putTypedAttribute(attrs, r.locationName(attrs, column, typ), record[i], r.attributeTypes[column.lower], r.timestamps)
+			continue
+		}
+		// Rows shorter than the header have the missing columns empty
+		switch r.cfg.EmptyValues {
+		case emptyValuesEmpty:
+			attrs.PutStr(r.locationName(attrs, column, typ), "")
+		case emptyValuesNull:
+			attrs.PutEmpty(r.locationName(attrs, column, typ))
 		}
 	}
 
+	putIdentifierAttributes(attrs, header, record)
+	r.putVulnerabilityURL(attrs, header, record)
+	r.putKEVAttributes(attrs)
+	putCVSSAttributes(attrs, header, record)
+	putSecretAttributes(attrs, header, record)
+	putBooleanAttributes(attrs, header, record)
+	putRemediationAttributes(attrs, header, record)
+	r.putPipelineAttributes(lr, header, record)
+
+	if r.cfg.BodyFormat == bodyFormatSARIF {
+		lr.Body().SetEmptyMap().FromRaw(sarifResult(attrs, header, record))
+		return
+	}
+
 	// Set the body to include the full vulnerability details
 	body := make(map[string]interface{})
 	if title, ok := findField(header, record, "title"); ok {
@@ -265,8 +1195,44 @@ func (r *vulnerabilityReceiver) convertToLogs(header []string, record []string,
 	}
 
 	lr.Body().SetEmptyMap().FromRaw(body)
+}
 
-	return logs
+// newScopeLogs appends the receiver's scope to a resource, setting the schema
+// URL of both
+func (r *vulnerabilityReceiver) newScopeLogs(rl plog.ResourceLogs) plog.ScopeLogs {
+	rl.SetSchemaUrl(schemaURL)
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.SetSchemaUrl(schemaURL)
+	sl.Scope().SetName(scopeName)
+	sl.Scope().SetVersion(r.buildInfo.Version)
+	return sl
+}
+
+// setEventName sets the event name of a record, also put in the event.name
+// attribute for backends that don't read the field
+func setEventName(lr plog.LogRecord, name string) {
+	lr.SetEventName(name)
+	lr.Attributes().PutStr("event.name", name)
+}
+
+// setScope attaches metadata of the export the logs were read from to the scope
+func (r *vulnerabilityReceiver) setScope(scope pcommon.InstrumentationScope, export *Export) {
+	attrs := scope.Attributes()
+	if export.ID != 0 {
+		attrs.PutStr("gitlab.export.id", fmt.Sprintf("%d", export.ID))
+	}
+	if export.Format != "" {
+		attrs.PutStr("gitlab.export.format", export.Format)
+	}
+	if !export.CreatedAt.IsZero() {
+		attrs.PutStr("gitlab.export.created_at", export.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	if export.FinishedAt != nil {
+		attrs.PutStr("gitlab.export.finished_at", export.FinishedAt.UTC().Format(time.RFC3339))
+	}
+	if r.cfg.BaseURL != "" {
+		attrs.PutStr("gitlab.base_url", r.cfg.BaseURL)
+	}
 }
 
 // Helper function to find a field in the CSV record
@@ -279,21 +1245,120 @@ func findField(header []string, record []string, fieldName string) (string, bool
 	return "", false
 }
 
-// Helper function to normalize field names to OTel attribute format
-func normalizeFieldName(field string) string {
+// findingAge returns how long ago the finding of a record was detected
+func (r *vulnerabilityReceiver) findingAge(record *exportRecord) (time.Duration, bool) {
+	detected, ok := r.detectedAt(record.header, record.values)
+	if !ok {
+		return 0, false
+	}
+	return max(time.Since(detected), 0), true
+}
+
+// detectedAt returns when the finding of a record was detected
+func (r *vulnerabilityReceiver) detectedAt(header []string, values []string) (time.Time, bool) {
+	return r.timestamps.parse(firstField(header, values, "Detected At", "detected_at", "Discovered At", "discovered_at", "created_at"))
+}
+
+// columnNames are the names derived from a CSV column
+type columnNames struct {
+	lower     string
+	attribute string
+}
+
+// column returns the names derived from a CSV column, computed once per column
+// name since every row of an export repeats them
+func (r *vulnerabilityReceiver) column(field string) columnNames {
+	r.columnsMutex.RLock()
+	names, ok := r.columns[field]
+	r.columnsMutex.RUnlock()
+	if ok {
+		return names
+	}
+
+	names = columnNames{lower: strings.ToLower(field), attribute: r.newAttributeName(field)}
+	r.columnsMutex.Lock()
+	defer r.columnsMutex.Unlock()
+	if r.columns == nil {
+		r.columns = make(map[string]columnNames)
+	}
+	r.columns[field] = names
+	return names
+}
+
+// attributeName returns the attribute key used for a CSV column
+func (r *vulnerabilityReceiver) attributeName(field string) string {
+	return r.column(field).attribute
+}
+
+// newAttributeName derives the attribute key of a CSV column
+func (r *vulnerabilityReceiver) newAttributeName(field string) string {
+	if r.semconvMapping() {
+		if name, ok := semconvAttributeNames[strings.ToLower(field)]; ok {
+			return name
+		}
+	}
+	if name, ok := lifecycleDateAttributes[strings.ToLower(field)]; ok {
+		return r.cfg.AttributePrefix + name
+	}
+	return normalizeFieldName(field, r.cfg.AttributePrefix)
+}
+
+// locationName returns the attribute key of a column of a finding of the given
+// scanner type: with semconv_mapping the Location column is named by the scanner
+// type unless a structured attribute already took that name
+func (r *vulnerabilityReceiver) locationName(attrs pcommon.Map, column columnNames, typ string) string {
+	if column.lower != "location" || !r.semconvMapping() {
+		return column.attribute
+	}
+	name, ok := semconvLocationNames[typ]
+	if !ok {
+		return column.attribute
+	}
+	if _, exists := attrs.Get(name); exists {
+		return column.attribute
+	}
+	return name
+}
+
+// Columns identifying the project a finding belongs to, in order of preference
+var projectPathColumns = []string{"Full Path", "Project Full Path", "Project Name"}
+
+// findProjectPath returns the project path of a CSV record
+func findProjectPath(header []string, record []string) string {
+	for _, column := range projectPathColumns {
+		if value, ok := findField(header, record, column); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// groupPath returns the full path of a group validated by this receiver
+func (r *vulnerabilityReceiver) groupPath(groupID string) string {
+	r.exportMutex.RLock()
+	defer r.exportMutex.RUnlock()
+	return r.groupPaths[groupID]
+}
+
+// normalizeFieldName converts a column name to an attribute name with the prefix
+func normalizeFieldName(field string, prefix string) string {
 	// Convert to lowercase and replace spaces with underscores
 	normalized := strings.ToLower(strings.ReplaceAll(field, " ", "_"))
-	// Add vulnerability prefix if not present
-	if !strings.HasPrefix(normalized, "vulnerability.") {
-		normalized = "vulnerability." + normalized
+	if !strings.HasPrefix(normalized, prefix) {
+		normalized = prefix + normalized
 	}
 	return normalized
 }
 
-// Shutdown stops the receiver
+// Shutdown stops the receiver, letting in-flight exports finish within the drain timeout
 func (r *vulnerabilityReceiver) Shutdown(ctx context.Context) error {
-	if r.cancel != nil {
-		r.cancel()
+	defer r.stopExportLimiter()
+	defer r.telemetry.stopObservingState()
+	if r.stopPolling != nil {
+		r.stopPolling()
+	}
+	if err := r.stopStatusPage(); err != nil {
+		r.logger.Warn("Failed to stop the status page", zap.Error(err))
 	}
 	// Add timeout handling
 	done := make(chan struct{})
@@ -302,85 +1367,147 @@ func (r *vulnerabilityReceiver) Shutdown(ctx context.Context) error {
 		close(done)
 	}()
 
+	if r.cfg.ShutdownDrainTimeout > 0 {
+		drain := time.NewTimer(r.cfg.ShutdownDrainTimeout)
+		defer drain.Stop()
+
+		select {
+		case <-done:
+		case <-drain.C:
+			r.logger.Warn("Drain timeout elapsed, canceling in-flight exports",
+				zap.Duration("drainTimeout", r.cfg.ShutdownDrainTimeout))
+		case <-ctx.Done():
+		}
+	}
+
+	// Canceled exports keep their checkpoint and resume after restart
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	var err error
 	select {
 	case <-done:
-		return nil
 	case <-ctx.Done():
-		return ctx.Err()
+		err = ctx.Err()
+	}
+
+	if r.stateManager != nil {
+		if closeErr := r.stateManager.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to save state: %w", closeErr))
+		}
 	}
+	return err
 }
 
 func (r *vulnerabilityReceiver) processProjectExports(ctx context.Context, projectID string) error {
 	// Check if export already in progress
-	r.exportMutex.RLock()
-	if r.exportsInProgress[projectID] {
-		r.exportMutex.RUnlock()
+	if !r.beginExport(projectID) {
 		r.logger.Debug("Skipping export - already in progress",
 			zap.String("projectID", projectID))
 		return nil
 	}
-	r.exportMutex.RUnlock()
-
-	// Mark export as in progress
-	r.exportMutex.Lock()
-	r.exportsInProgress[projectID] = true
-	r.exportMutex.Unlock()
-
-	// Ensure we clear the in-progress flag when done
-	defer func() {
-		r.exportMutex.Lock()
-		delete(r.exportsInProgress, projectID)
-		r.exportMutex.Unlock()
-	}()
+	defer r.endExport(projectID)
 
 	// First validate the project ID
-	if err := r.client.validateProjectID(ctx, projectID); err != nil {
+	if err := r.client.ValidateProject(ctx, projectID); err != nil {
 		r.logger.Error("Invalid project ID",
 			zap.String("id", projectID),
 			zap.Error(err))
 		return fmt.Errorf("invalid project ID: %w", err)
 	}
 
-	// Create new export
-	export, err := r.client.CreateExport(ctx, projectID)
+	// Once a baseline has been ingested, only fetch what changed since, with a
+	// complete export every full_export_interval to reconcile
+	reconcile := r.hasSyncBaseline(projectID)
+	if reconcile && !r.fullExportDue(projectID) {
+		return r.syncVulnerabilityUpdates(ctx, projectID)
+	}
+	if reconcile {
+		r.logger.Info("Reconciling incremental sync with a full export",
+			zap.String("id", projectID),
+			zap.Duration("fullExportInterval", r.cfg.FullExportInterval))
+	}
+
+	// Wait for room on the GitLab instance before generating an export
+	release, err := r.acquireExportSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("failed waiting for export slot: %w", err)
+	}
+	defer release()
+
+	// Resume an interrupted export or create a new one
+	export, err := r.resumeOrCreateExport(ctx, projectID, r.client.CreateExport)
 	if err != nil {
 		return fmt.Errorf("failed to create export: %w", err)
 	}
 
 	// Process the export
-	return r.processExport(ctx, export)
+	export, err = r.processExportOrRecreate(ctx, projectID, export, r.client.CreateExport)
+	if err != nil {
+		return err
+	}
+
+	// A reconciliation keeps the cursor, updates since the export are still to sync
+	if r.cfg.SyncMode == syncModeIncremental && !reconcile {
+		return r.setSyncBaseline(projectID, export)
+	}
+	return nil
 }
 
 func (r *vulnerabilityReceiver) processGroupExports(ctx context.Context, groupID string) error {
+	// Check if export already in progress
+	if !r.beginExport(groupID) {
+		r.logger.Debug("Skipping export - already in progress",
+			zap.String("groupID", groupID))
+		return nil
+	}
+	defer r.endExport(groupID)
+
 	// First validate the group ID
-	if err := r.client.validateGroupID(ctx, groupID); err != nil {
+	group, err := r.client.ValidateGroup(ctx, groupID)
+	if err != nil {
 		r.logger.Error("Invalid group ID",
 			zap.String("id", groupID),
 			zap.Error(err))
 		return fmt.Errorf("invalid group ID: %w", err)
 	}
 
-	// Create new export
-	export, err := r.client.CreateGroupExport(ctx, groupID)
+	r.exportMutex.Lock()
+	r.groupPaths[groupID] = group.Path
+	r.exportMutex.Unlock()
+
+	// Wait for room on the GitLab instance before generating an export
+	release, err := r.acquireExportSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("failed waiting for export slot: %w", err)
+	}
+	defer release()
+
+	// Resume an interrupted export or create a new one
+	export, err := r.resumeOrCreateExport(ctx, groupID, r.client.CreateGroupExport)
 	if err != nil {
 		return fmt.Errorf("failed to create group export: %w", err)
 	}
+	if export.GroupID == nil {
+		export.GroupID = groupID
+	}
 
 	// Process the export
-	return r.processExport(ctx, export)
+	_, err = r.processExportOrRecreate(ctx, groupID, export, r.client.CreateGroupExport)
+	return err
 }
 
-// generateVulnID creates a unique ID for a vulnerability record
-func generateVulnID(record []string) string {
-	// Combine relevant fields to create a unique identifier
-	h := sha256.New()
-	h.Write([]byte(strings.Join(record, "|")))
-	return hex.EncodeToString(h.Sum(nil))
-}
+// generateVulnID creates a unique ID for a vulnerability record. The export's
+// ID column is used when present so changes to other fields (e.g. detection
+// timestamps) don't make a known vulnerability look new.
+func generateVulnID(header []string, record []string) string {
+	for _, column := range state.IDColumns {
+		if id, ok := findField(header, record, column); ok && strings.TrimSpace(id) != "" {
+			return strings.TrimSpace(id)
+		}
+	}
 
-// generateHash creates a hash of a slice of strings
-func generateHash(ids []string) string {
-	h := sha256.New()
-	h.Write([]byte(strings.Join(ids, "|")))
-	return hex.EncodeToString(h.Sum(nil))
+	// Fall back to combining all fields when the export has no ID column
+	return generateHash(record)
 }
@@ -2,28 +2,48 @@ package gitlabvulnreceiver
 
 import (
 	"context"
+	"encoding/csv"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type mockGitLabClient struct {
-	getExportFunc         func(ctx context.Context, projectID string, exportID int64) (*Export, error)
-	createExportFunc      func(ctx context.Context, projectID string) (*Export, error)
-	getExportDataFunc     func(ctx context.Context, url string) (io.ReadCloser, error)
-	waitForExportFunc     func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error)
-	createGroupExportFunc func(ctx context.Context, groupID string) (*Export, error)
-	validateProjectIDFunc func(ctx context.Context, projectID string) error
-	validateGroupIDFunc   func(ctx context.Context, groupID string) error
+	getExportFunc               func(ctx context.Context, projectID string, exportID int64) (*Export, error)
+	createExportFunc            func(ctx context.Context, projectID string) (*Export, error)
+	getExportDataFunc           func(ctx context.Context, url string) (*ExportData, error)
+	waitForExportFunc           func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error)
+	createGroupExportFunc       func(ctx context.Context, groupID string) (*Export, error)
+	validateProjectFunc         func(ctx context.Context, projectID string) error
+	validateGroupFunc           func(ctx context.Context, groupID string) (*GitLabGroup, error)
+	listVulnerabilitiesFunc     func(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error)
+	getLatestJobFunc            func(ctx context.Context, projectID, ref, name string) (*Job, error)
+	getJobArtifactsFunc         func(ctx context.Context, projectID string, jobID int64) (io.ReadCloser, error)
+	listDependenciesFunc        func(ctx context.Context, projectID string, page int) ([]Dependency, int, error)
+	listOpenMergeRequestsFunc   func(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error)
+	getApprovalRulesFunc        func(ctx context.Context, projectID string, iid int64) ([]ApprovalRule, error)
+	getProjectFunc              func(ctx context.Context, projectID string) (*GitLabProject, error)
+	getMergeRequestFindingsFunc func(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error)
+	getIssueLinksFunc           func(ctx context.Context, vulnerabilityID string) ([]IssueLink, error)
+	getStateTransitionsFunc     func(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error)
 }
 
 func (m *mockGitLabClient) GetExport(ctx context.Context, projectID string, exportID int64) (*Export, error) {
@@ -37,7 +57,7 @@ func (m *mockGitLabClient) CreateExport(ctx context.Context, projectID string) (
 	return nil, nil
 }
 
-func (m *mockGitLabClient) GetExportData(ctx context.Context, url string) (io.ReadCloser, error) {
+func (m *mockGitLabClient) GetExportData(ctx context.Context, url string) (*ExportData, error) {
 	if m.getExportDataFunc != nil {
 		return m.getExportDataFunc(ctx, url)
 	}
@@ -58,20 +78,96 @@ func (m *mockGitLabClient) CreateGroupExport(ctx context.Context, groupID string
 	return nil, nil
 }
 
-func (m *mockGitLabClient) validateProjectID(ctx context.Context, projectID string) error {
-	if m.validateProjectIDFunc != nil {
-		return m.validateProjectIDFunc(ctx, projectID)
+func (m *mockGitLabClient) ListVulnerabilities(ctx context.Context, projectID string, updatedAfter time.Time, page int) ([]map[string]interface{}, int, error) {
+	if m.listVulnerabilitiesFunc != nil {
+		return m.listVulnerabilitiesFunc(ctx, projectID, updatedAfter, page)
 	}
-	return nil
+	return nil, 0, nil
+}
+
+func (m *mockGitLabClient) GetLatestJob(ctx context.Context, projectID, ref, name string) (*Job, error) {
+	if m.getLatestJobFunc != nil {
+		return m.getLatestJobFunc(ctx, projectID, ref, name)
+	}
+	return &Job{ID: 1, Name: name, Ref: ref}, nil
+}
+
+func (m *mockGitLabClient) GetJobArtifacts(ctx context.Context, projectID string, jobID int64) (io.ReadCloser, error) {
+	if m.getJobArtifactsFunc != nil {
+		return m.getJobArtifactsFunc(ctx, projectID, jobID)
+	}
+	return nil, fmt.Errorf("no artifacts")
+}
+
+func (m *mockGitLabClient) ListDependencies(ctx context.Context, projectID string, page int) ([]Dependency, int, error) {
+	if m.listDependenciesFunc != nil {
+		return m.listDependenciesFunc(ctx, projectID, page)
+	}
+	return nil, 0, nil
+}
+
+func (m *mockGitLabClient) ListOpenMergeRequests(ctx context.Context, projectID string, page int) ([]MergeRequest, int, error) {
+	if m.listOpenMergeRequestsFunc != nil {
+		return m.listOpenMergeRequestsFunc(ctx, projectID, page)
+	}
+	return nil, 0, nil
+}
+
+func (m *mockGitLabClient) GetApprovalRules(ctx context.Context, projectID string, iid int64) ([]ApprovalRule, error) {
+	if m.getApprovalRulesFunc != nil {
+		return m.getApprovalRulesFunc(ctx, projectID, iid)
+	}
+	return nil, nil
+}
+
+func (m *mockGitLabClient) GetProject(ctx context.Context, projectID string) (*GitLabProject, error) {
+	if m.getProjectFunc != nil {
+		return m.getProjectFunc(ctx, projectID)
+	}
+	return &GitLabProject{}, nil
+}
+
+func (m *mockGitLabClient) GetMergeRequestFindings(ctx context.Context, fullPath string, iid int64, reportType string) ([]map[string]interface{}, bool, error) {
+	if m.getMergeRequestFindingsFunc != nil {
+		return m.getMergeRequestFindingsFunc(ctx, fullPath, iid, reportType)
+	}
+	return nil, true, nil
+}
+
+func (m *mockGitLabClient) GetIssueLinks(ctx context.Context, vulnerabilityID string) ([]IssueLink, error) {
+	if m.getIssueLinksFunc != nil {
+		return m.getIssueLinksFunc(ctx, vulnerabilityID)
+	}
+	return nil, nil
 }
 
-func (m *mockGitLabClient) validateGroupID(ctx context.Context, groupID string) error {
-	if m.validateGroupIDFunc != nil {
-		return m.validateGroupIDFunc(ctx, groupID)
+func (m *mockGitLabClient) GetStateTransitions(ctx context.Context, vulnerabilityIDs []string) (map[string][]StateTransition, error) {
+	if m.getStateTransitionsFunc != nil {
+		return m.getStateTransitionsFunc(ctx, vulnerabilityIDs)
+	}
+	return nil, nil
+}
+
+func (m *mockGitLabClient) ValidateProject(ctx context.Context, projectID string) error {
+	if m.validateProjectFunc != nil {
+		return m.validateProjectFunc(ctx, projectID)
 	}
 	return nil
 }
 
+func (m *mockGitLabClient) ValidateGroup(ctx context.Context, groupID string) (*GitLabGroup, error) {
+	if m.validateGroupFunc != nil {
+		return m.validateGroupFunc(ctx, groupID)
+	}
+	return &GitLabGroup{}, nil
+}
+
+func newTestStateManager(t testing.TB) *state.StateManager {
+	sm, err := state.NewStateManager(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	return sm
+}
+
 func TestVulnerabilityReceiver_ConvertToLogs(t *testing.T) {
 	// Setup
 	cfg := createDefaultConfig().(*Config)
@@ -114,6 +210,289 @@ func TestVulnerabilityReceiver_ConvertToLogs(t *testing.T) {
 	assert.Equal(t, plog.SeverityNumberError, lr.SeverityNumber())
 }
 
+func TestVulnerabilityReceiver_ConvertToLogsScope(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.BaseURL = "https://gitlab.example.com"
+	recv := &vulnerabilityReceiver{
+		cfg:       cfg,
+		logger:    zap.NewNop(),
+		buildInfo: component.BuildInfo{Version: "1.2.3"},
+	}
+
+	createdAt := time.Date(2024, 2, 12, 3, 34, 2, 0, time.UTC)
+	finishedAt := createdAt.Add(10 * time.Second)
+	export := &Export{
+		ID:         123,
+		ProjectID:  "test-project",
+		Format:     "csv",
+		CreatedAt:  createdAt,
+		FinishedAt: &finishedAt,
+	}
+	logs := recv.convertToLogs([]string{"Title"}, []string{"Test Vuln"}, export)
+
+	rl := logs.ResourceLogs().At(0)
+	assert.Equal(t, "https://opentelemetry.io/schemas/1.27.0", rl.SchemaUrl())
+	assert.Equal(t, "https://opentelemetry.io/schemas/1.27.0", rl.ScopeLogs().At(0).SchemaUrl())
+	scope := rl.ScopeLogs().At(0).Scope()
+	assert.Equal(t, scopeName, scope.Name())
+	assert.Equal(t, "1.2.3", scope.Version())
+	assert.Equal(t, map[string]interface{}{
+		"gitlab.export.id":          "123",
+		"gitlab.export.format":      "csv",
+		"gitlab.export.created_at":  "2024-02-12T03:34:02Z",
+		"gitlab.export.finished_at": "2024-02-12T03:34:12Z",
+		"gitlab.base_url":           "https://gitlab.example.com",
+	}, scope.Attributes().AsRaw())
+}
+
+func TestVulnerabilityReceiver_ConvertToLogsTimestamps(t *testing.T) {
+	recv := &vulnerabilityReceiver{cfg: createDefaultConfig().(*Config), logger: zap.NewNop()}
+	export := &Export{ID: 123, ProjectID: "test-project"}
+
+	// The timestamp is when the finding was detected, the observed timestamp when it was read
+	before := time.Now()
+	logs := recv.convertToLogs([]string{"Title", "Detected At"}, []string{"Test Vuln", "2024-02-12T03:34:02Z"}, export)
+	lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, time.Date(2024, 2, 12, 3, 34, 2, 0, time.UTC), lr.Timestamp().AsTime())
+	assert.False(t, lr.ObservedTimestamp().AsTime().Before(before))
+
+	// Without a detection time both are the time it was read
+	logs = recv.convertToLogs([]string{"Title"}, []string{"Test Vuln"}, export)
+	lr = logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, lr.ObservedTimestamp(), lr.Timestamp())
+}
+
+func TestVulnerabilityReceiver_ConvertToLogsLifecycleDates(t *testing.T) {
+	recv := &vulnerabilityReceiver{
+		cfg:            createDefaultConfig().(*Config),
+		logger:         zap.NewNop(),
+		attributeTypes: resolveAttributeTypes(nil),
+	}
+	export := &Export{ID: 123, ProjectID: "test-project"}
+
+	// CSV columns and API fields are emitted under the same names
+	tests := []struct {
+		header []string
+		values []string
+	}{
+		{
+			header: []string{"Discovered At", "Confirmed At", "Resolved At", "Dismissed At"},
+			values: []string{"2024-02-12 03:34:02 UTC", "2024-02-13T00:00:00Z", "2024-02-14T02:00:00+02:00", ""},
+		},
+		{
+			header: []string{"detected_at", "confirmed_at", "resolved_at", "dismissed_at"},
+			values: []string{"2024-02-12T03:34:02Z", "2024-02-13T00:00:00Z", "2024-02-14T00:00:00.000Z", ""},
+		},
+	}
+	for _, tt := range tests {
+		logs := recv.convertToLogs(tt.header, tt.values, export)
+		raw := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		assert.Equal(t, "2024-02-12T03:34:02Z", raw["vulnerability.detected_at"])
+		assert.Equal(t, "2024-02-13T00:00:00Z", raw["vulnerability.confirmed_at"])
+		assert.Equal(t, "2024-02-14T00:00:00Z", raw["vulnerability.resolved_at"])
+		assert.NotContains(t, raw, "vulnerability.dismissed_at")
+		assert.NotContains(t, raw, "vulnerability.discovered_at")
+	}
+}
+
+func TestVulnerabilityReceiver_ConvertToLogsAttributePrefix(t *testing.T) {
+	header := []string{"Vulnerability ID", "Severity", "Detected At", "CVSS Score", "Dismissed"}
+	record := []string{"4242", "High", "2024-02-12T03:34:02Z", "7.5", "false"}
+	export := &Export{ID: 123, ProjectID: "test-project"}
+
+	for prefix, expected := range map[string][]string{
+		"gitlab.vuln.": {"gitlab.vuln.vulnerability_id", "gitlab.vuln.severity", "gitlab.vuln.detected_at"},
+		"":             {"vulnerability_id", "severity", "detected_at"},
+	} {
+		// Derived attributes keep their names whatever the prefix
+		expected = append(expected, "vulnerability.score.base", "vulnerability.is_dismissed")
+		cfg := createDefaultConfig().(*Config)
+		cfg.AttributePrefix = prefix
+		recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+
+		attrs := recv.convertToLogs(header, record, export).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+		for _, key := range expected {
+			_, ok := attrs.Get(key)
+			assert.True(t, ok, "missing attribute %s", key)
+		}
+		_, ok := attrs.Get("vulnerability.severity")
+		assert.False(t, ok)
+	}
+}
+
+func TestVulnerabilityReceiver_ConvertToLogsEmptyValues(t *testing.T) {
+	header := []string{"Title", "Severity", "Dismissal Reason", "Comment"}
+	record := []string{"SQL injection", "High", ""}
+	export := &Export{ID: 123, ProjectID: "test-project"}
+
+	tests := []struct {
+		mode     string
+		expected map[string]interface{}
+	}{
+		{mode: emptyValuesOmit, expected: map[string]interface{}{}},
+		{mode: emptyValuesEmpty, expected: map[string]interface{}{"vulnerability.dismissal_reason": "", "vulnerability.comment": ""}},
+		{mode: emptyValuesNull, expected: map[string]interface{}{"vulnerability.dismissal_reason": nil, "vulnerability.comment": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.EmptyValues = tt.mode
+			recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+
+			raw := recv.convertToLogs(header, record, export).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+			assert.Equal(t, "SQL injection", raw["vulnerability.title"])
+			empty := make(map[string]interface{})
+			for _, key := range []string{"vulnerability.dismissal_reason", "vulnerability.comment"} {
+				if value, ok := raw[key]; ok {
+					empty[key] = value
+				}
+			}
+			assert.Equal(t, tt.expected, empty)
+		})
+	}
+}
+
+func TestVulnerabilityReceiver_ConvertToLogsSemconv(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.SemconvMapping = true
+	recv := &vulnerabilityReceiver{
+		cfg:    cfg,
+		logger: zap.NewNop(),
+	}
+
+	header := []string{"Vulnerability ID", "Severity", "Location", "Package Name", "Project Name"}
+	record := []string{"4242", "High", "app/main.go", "lodash", "test-project"}
+	logs := recv.convertToLogs(header, record, &Export{ID: 123, ProjectID: "test-project"})
+
+	attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+	expected := map[string]string{
+		"vulnerability.id":           "4242",
+		"vulnerability.severity":     "High",
+		"file.path":                  "app/main.go",
+		"package.name":               "lodash",
+		"vulnerability.project_name": "test-project",
+	}
+	for key, value := range expected {
+		v, ok := attrs.Get(key)
+		require.True(t, ok, "missing attribute %s", key)
+		assert.Equal(t, value, v.Str())
+	}
+}
+
+func TestVulnerabilityReceiver_ConvertToLogsSemconvColumns(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.SemconvMapping = true
+	recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+	export := &Export{ID: 123, ProjectID: "test-project"}
+
+	tests := []struct {
+		name     string
+		header   []string
+		record   []string
+		expected map[string]string
+		absent   []string
+	}{
+		{
+			name:   "each column has its own attribute",
+			header: []string{"Tool", "Vulnerability", "Title", "Details", "Description", "Location"},
+			record: []string{"Secret Detection", "Leaked token", "GitLab token", "Found in history", "A token was committed", "config/app.yml"},
+			expected: map[string]string{
+				"vulnerability.scanner.type": "Secret Detection",
+				"vulnerability.name":         "Leaked token",
+				"vulnerability.title":        "GitLab token",
+				"vulnerability.details":      "Found in history",
+				"vulnerability.description":  "A token was committed",
+				"file.path":                  "config/app.yml",
+			},
+			absent: []string{"vulnerability.category"},
+		},
+		{
+			name:     "dast location is a url",
+			header:   []string{"Tool", "Location"},
+			record:   []string{"DAST", "https://example.com/login"},
+			expected: map[string]string{"url.full": "https://example.com/login"},
+			absent:   []string{"file.path"},
+		},
+		{
+			name:   "dast location next to a url column",
+			header: []string{"Tool", "URL", "Location"},
+			record: []string{"DAST", "https://example.com/login", "login form"},
+			expected: map[string]string{
+				"url.full":               "https://example.com/login",
+				"vulnerability.location": "login form",
+			},
+			absent: []string{"file.path"},
+		},
+		{
+			name:     "unknown scanner type keeps the normalized name",
+			header:   []string{"Tool", "Location"},
+			record:   []string{"Fuzzing", "api/v1/users"},
+			expected: map[string]string{"vulnerability.location": "api/v1/users"},
+			absent:   []string{"file.path", "url.full"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := recv.convertToLogs(tt.header, tt.record, export).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+			for key, value := range tt.expected {
+				v, ok := attrs.Get(key)
+				require.True(t, ok, "missing attribute %s", key)
+				assert.Equal(t, value, v.Str())
+			}
+			for _, key := range tt.absent {
+				_, ok := attrs.Get(key)
+				assert.False(t, ok, "unexpected attribute %s", key)
+			}
+		})
+	}
+}
+
+func TestVulnerabilityReceiver_ConvertRecordPreservesRaw(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.PreserveRawRecord = true
+	cfg.SemconvMapping = true
+	recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+	export := &Export{ID: 123, ProjectID: "test-project"}
+
+	// CSV rows are kept as a CSV line, with the matched secret hashed
+	record := &exportRecord{
+		header: []string{"Vulnerability ID", "Title", "Secret"},
+		values: []string{"4242", "Leaked token, in config", "glpat-123"},
+	}
+	logs := recv.convertRecord(record, export)
+	raw, ok := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("gitlab.raw")
+	require.True(t, ok)
+	assert.Equal(t, `4242,"Leaked token, in config",`+hashSecret("glpat-123"), raw.Str())
+
+	// JSON and NDJSON records are kept as the exported bytes, numbers included
+	for _, export := range []string{
+		`[{"severity": "high", "id": 7, "cvss": 7.50, "location": {"file": "go.sum", "start_line": 3}}]`,
+		`{"severity": "high", "id": 7, "cvss": 7.50, "location": {"file": "go.sum", "start_line": 3}}` + "\n",
+	} {
+		decoded := readAllRecords(t, newJSONDecoder(strings.NewReader(export)))
+		require.Len(t, decoded, 1)
+		logs = recv.convertRecord(decoded[0], &Export{ID: 123, ProjectID: "test-project"})
+		raw, ok = logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("gitlab.raw")
+		require.True(t, ok)
+		assert.Equal(t, `{"severity": "high", "id": 7, "cvss": 7.50, "location": {"file": "go.sum", "start_line": 3}}`, raw.Str())
+	}
+
+	// A JSON record holding a secret is re-encoded with it hashed
+	decoded := readAllRecords(t, newJSONDecoder(strings.NewReader(`{"id": 7, "raw_source_code_extract": "glpat-123"}`)))
+	require.Len(t, decoded, 1)
+	logs = recv.convertRecord(decoded[0], export)
+	raw, ok = logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("gitlab.raw")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"id": 7, "raw_source_code_extract": "`+hashSecret("glpat-123")+`"}`, raw.Str())
+
+	// Nothing is kept by default
+	recv.cfg = createDefaultConfig().(*Config)
+	logs = recv.convertRecord(record, export)
+	_, ok = logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("gitlab.raw")
+	assert.False(t, ok)
+}
+
 func TestExportTimeout(t *testing.T) {
 	cfg := &Config{
 		ExportTimeout: 2 * time.Second,
@@ -124,7 +503,7 @@ func TestExportTimeout(t *testing.T) {
 	}
 
 	mockClient := &mockGitLabClient{
-		validateProjectIDFunc: func(ctx context.Context, projectID string) error {
+		validateProjectFunc: func(ctx context.Context, projectID string) error {
 			return nil
 		},
 		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
@@ -140,6 +519,7 @@ func TestExportTimeout(t *testing.T) {
 		cfg:               cfg,
 		client:            mockClient,
 		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
 		lastExportTime:    make(map[string]time.Time),
 		exportsInProgress: make(map[string]bool),
 		exportMutex:       sync.RWMutex{},
@@ -167,7 +547,7 @@ func TestProcessExportErrors(t *testing.T) {
 				}},
 			},
 			client: &mockGitLabClient{
-				validateProjectIDFunc: func(ctx context.Context, projectID string) error {
+				validateProjectFunc: func(ctx context.Context, projectID string) error {
 					return fmt.Errorf("project not found")
 				},
 				createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
@@ -189,8 +569,8 @@ func TestProcessExportErrors(t *testing.T) {
 				}},
 			},
 			client: &mockGitLabClient{
-				validateGroupIDFunc: func(ctx context.Context, groupID string) error {
-					return fmt.Errorf("group not found")
+				validateGroupFunc: func(ctx context.Context, groupID string) (*GitLabGroup, error) {
+					return nil, fmt.Errorf("group not found")
 				},
 				createGroupExportFunc: func(ctx context.Context, groupID string) (*Export, error) {
 					return &Export{
@@ -233,6 +613,7 @@ func TestProcessExportErrors(t *testing.T) {
 				cfg:               &tt.config,
 				client:            tt.client,
 				logger:            zap.NewNop(),
+				stateManager:      newTestStateManager(t),
 				lastExportTime:    make(map[string]time.Time),
 				exportsInProgress: make(map[string]bool),
 				exportMutex:       sync.RWMutex{},
@@ -254,3 +635,825 @@ func TestProcessExportErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestResumeExportFromCheckpoint(t *testing.T) {
+	sm := newTestStateManager(t)
+	require.NoError(t, sm.SetCheckpoint("12345", state.ExportCheckpoint{
+		ExportID:      123,
+		RowsProcessed: 2,
+	}))
+
+	csvData := "Title,Severity\nfirst,High\nsecond,Low\nthird,Medium\n"
+	mockClient := &mockGitLabClient{
+		getExportFunc: func(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			t.Fatal("export should be resumed, not created")
+			return nil, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               createDefaultConfig().(*Config),
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      sm,
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+
+	// Only the row after the checkpoint is emitted
+	require.Equal(t, 1, sink.LogRecordCount())
+	title, ok := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get("vulnerability.title")
+	require.True(t, ok)
+	assert.Equal(t, "third", title.Str())
+
+	_, ok = sm.GetCheckpoint("12345")
+	assert.False(t, ok, "checkpoint should be cleared after the export completes")
+}
+
+func TestResumeExportFromCheckpoint_StaleExport(t *testing.T) {
+	sm := newTestStateManager(t)
+	require.NoError(t, sm.SetCheckpoint("12345", state.ExportCheckpoint{
+		ExportID:      123,
+		RowsProcessed: 1,
+	}))
+
+	finishedAt := time.Now().Add(-3 * time.Hour)
+	var created int
+	mockClient := &mockGitLabClient{
+		getExportFunc: func(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished, FinishedAt: &finishedAt}, nil
+		},
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			created++
+			return &Export{ID: 124, ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\nfirst,High\nsecond,Low\n"))}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.MaxExportAge = time.Hour
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      sm,
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+
+	// The stale export is replaced and the new one read from its first row
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 2, sink.LogRecordCount())
+	assert.True(t, sm.IsExportProcessed(124))
+	assert.False(t, sm.IsExportProcessed(123))
+}
+
+func TestExportStaleness(t *testing.T) {
+	finishedAt := time.Now().Add(-2 * time.Hour)
+	receiver := &vulnerabilityReceiver{cfg: createDefaultConfig().(*Config)}
+
+	finished := &Export{Status: ExportStatusFinished, FinishedAt: &finishedAt}
+	age, stale := receiver.exportStaleness(finished)
+	assert.False(t, stale, "any age by default")
+	assert.InDelta(t, 2*time.Hour, age, float64(time.Minute))
+
+	receiver.cfg.MaxExportAge = time.Hour
+	_, stale = receiver.exportStaleness(finished)
+	assert.True(t, stale)
+
+	// An export still being generated will be fresh once it's done
+	_, stale = receiver.exportStaleness(&Export{Status: ExportStatusStarted, CreatedAt: finishedAt})
+	assert.False(t, stale)
+
+	// Without a finish time, it's as old as its creation
+	_, stale = receiver.exportStaleness(&Export{Status: ExportStatusFinished, CreatedAt: finishedAt})
+	assert.True(t, stale)
+}
+
+func TestGenerateVulnID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []string
+		record   []string
+		expected string
+	}{
+		{
+			name:     "vulnerability id column",
+			header:   []string{"Title", "Vulnerability ID", "Detected At"},
+			record:   []string{"Test Vuln", "4242", "2024-02-12T03:34:02Z"},
+			expected: "4242",
+		},
+		{
+			name:     "uuid column",
+			header:   []string{"uuid", "Title"},
+			record:   []string{" 6f1a4c3e-5e0b-4f0e-9a54-3c1f0b8f4c1d ", "Test Vuln"},
+			expected: "6f1a4c3e-5e0b-4f0e-9a54-3c1f0b8f4c1d",
+		},
+		{
+			name:     "empty id falls back to hash",
+			header:   []string{"Title", "Vulnerability ID"},
+			record:   []string{"Test Vuln", ""},
+			expected: generateHash([]string{"Test Vuln", ""}),
+		},
+		{
+			name:     "no id column falls back to hash",
+			header:   []string{"Title", "Severity"},
+			record:   []string{"Test Vuln", "High"},
+			expected: generateHash([]string{"Test Vuln", "High"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, generateVulnID(tt.header, tt.record))
+		})
+	}
+}
+
+func TestGenerateVulnID_StableAcrossFieldChanges(t *testing.T) {
+	header := []string{"Vulnerability ID", "Title", "Detected At"}
+	first := generateVulnID(header, []string{"4242", "Test Vuln", "2024-02-12T03:34:02Z"})
+	second := generateVulnID(header, []string{"4242", "Test Vuln", "2024-03-01T10:00:00Z"})
+	assert.Equal(t, first, second)
+}
+
+func TestProcessGroupExports_Attribution(t *testing.T) {
+	csvData := "Group Name,Project Name,Full Path,Title,Severity\n" +
+		"mygroup,api,mygroup/api,first,High\n"
+	mockClient := &mockGitLabClient{
+		validateGroupFunc: func(ctx context.Context, groupID string) (*GitLabGroup, error) {
+			return &GitLabGroup{ID: 67890, Path: "mygroup"}, nil
+		},
+		createGroupExportFunc: func(ctx context.Context, groupID string) (*Export, error) {
+			return &Export{ID: 123, Status: ExportStatusCreated}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:               createDefaultConfig().(*Config),
+		consumer:          sink,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+		groupPaths:        make(map[string]string),
+	}
+
+	require.NoError(t, receiver.processGroupExports(context.Background(), "67890"))
+	require.Equal(t, 1, sink.LogRecordCount())
+
+	rl := sink.AllLogs()[0].ResourceLogs().At(0)
+	resourceAttrs := rl.Resource().Attributes()
+	_, ok := resourceAttrs.Get("gitlab.project.id")
+	assert.False(t, ok, "group exports have no project ID")
+	groupID, ok := resourceAttrs.Get("gitlab.group.id")
+	require.True(t, ok)
+	assert.Equal(t, "67890", groupID.Str())
+	groupPath, ok := resourceAttrs.Get("gitlab.group.path")
+	require.True(t, ok)
+	assert.Equal(t, "mygroup", groupPath.Str())
+
+	projectPath, ok := resourceAttrs.Get("gitlab.project.path")
+	require.True(t, ok)
+	assert.Equal(t, "mygroup/api", projectPath.Str())
+}
+
+func TestProcessCSVData_ResourcePerProject(t *testing.T) {
+	csvData := "Full Path,Title,Severity\n" +
+		"mygroup/api,first,High\n" +
+		"mygroup/web,second,Low\n" +
+		"mygroup/api,third,Medium\n"
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	export := &Export{ID: 123, GroupID: "67890"}
+	err := receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "67890", export)
+	require.NoError(t, err)
+
+	// All rows fit one batch, split into one resource per project
+	require.Len(t, sink.AllLogs(), 1)
+	logs := sink.AllLogs()[0]
+	require.Equal(t, 2, logs.ResourceLogs().Len())
+
+	recordsByProject := make(map[string]int)
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		path, ok := rl.Resource().Attributes().Get("gitlab.project.path")
+		require.True(t, ok)
+		recordsByProject[path.Str()] = rl.ScopeLogs().At(0).LogRecords().Len()
+	}
+	assert.Equal(t, map[string]int{"mygroup/api": 2, "mygroup/web": 1}, recordsByProject)
+}
+
+func TestProcessCSVData_BatchSize(t *testing.T) {
+	csvData := "Title,Severity\nfirst,High\nsecond,Low\nthird,Medium\n"
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.BatchSize = 2
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	export := &Export{ID: 123, ProjectID: "12345"}
+	err := receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export)
+	require.NoError(t, err)
+
+	require.Len(t, sink.AllLogs(), 2)
+	assert.Equal(t, 2, sink.AllLogs()[0].LogRecordCount())
+	assert.Equal(t, 1, sink.AllLogs()[1].LogRecordCount())
+}
+
+func TestConsumeLogs_Retry(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		permanent     bool
+		wantErr       bool
+		expectedCalls int
+	}{
+		{
+			name:          "retryable errors are retried",
+			failures:      2,
+			expectedCalls: 3,
+		},
+		{
+			name:          "permanent errors are not retried",
+			failures:      1,
+			permanent:     true,
+			wantErr:       true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "retries stop when the budget is exhausted",
+			failures:      1000,
+			wantErr:       true,
+			expectedCalls: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			next, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+				calls++
+				if calls > tt.failures {
+					return nil
+				}
+				err := fmt.Errorf("memory limit exceeded")
+				if tt.permanent {
+					return consumererror.NewPermanent(err)
+				}
+				return err
+			})
+			require.NoError(t, err)
+
+			cfg := createDefaultConfig().(*Config)
+			cfg.ConsumerRetry = ConsumerRetryConfig{
+				Enabled:         true,
+				InitialInterval: 10 * time.Millisecond,
+				MaxInterval:     40 * time.Millisecond,
+				MaxElapsedTime:  100 * time.Millisecond,
+			}
+			receiver := &vulnerabilityReceiver{
+				cfg:      cfg,
+				consumer: next,
+				logger:   zap.NewNop(),
+			}
+
+			err = receiver.consumeLogs(context.Background(), plog.NewLogs())
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestProcessCSVData_PermanentErrorRecordsRows(t *testing.T) {
+	csvData := "Title,Severity\nfirst,High\nsecond,Low\nthird,Medium\n"
+
+	calls := 0
+	next, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		calls++
+		if calls == 1 {
+			return consumererror.NewPermanent(fmt.Errorf("rejected"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := newReceiverTelemetry(component.TelemetrySettings{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.BatchSize = 2
+	sm := newTestStateManager(t)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     next,
+		logger:       zap.NewNop(),
+		stateManager: sm,
+		telemetry:    telemetry,
+	}
+
+	// The rejected batch is recorded and the rest of the export is delivered
+	export := &Export{ID: 123, ProjectID: "12345"}
+	err = receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// Rejected rows aren't marked seen, delivered ones are
+	header := []string{"Title", "Severity"}
+	first := state.ProcessedKeyPrefix + generateVulnID(header, []string{"first", "High"})
+	second := state.ProcessedKeyPrefix + generateVulnID(header, []string{"second", "Low"})
+	third := state.ProcessedKeyPrefix + generateVulnID(header, []string{"third", "Medium"})
+	assert.False(t, sm.IsSeen(first))
+	assert.False(t, sm.IsSeen(second))
+	assert.True(t, sm.IsSeen(third))
+
+	rejected := sm.GetRejectedRows("12345")
+	require.Len(t, rejected, 1)
+	assert.Equal(t, int64(123), rejected[0].ExportID)
+	assert.Equal(t, int64(1), rejected[0].FirstRow)
+	assert.Equal(t, int64(2), rejected[0].LastRow)
+	assert.Equal(t, []string{first, second}, rejected[0].Keys)
+	assert.Contains(t, rejected[0].Error, "rejected")
+
+	metrics := collectMetrics(t, reader)
+	require.Contains(t, metrics, "gitlab_vulnerability_receiver_records_dropped")
+	assert.Equal(t, int64(2), metrics["gitlab_vulnerability_receiver_records_dropped"].(metricdata.Sum[int64]).DataPoints[0].Value)
+	// Rejected records aren't counted as emitted
+	assert.Equal(t, int64(1), metrics["gitlab_vulnerability_receiver_rows_emitted"].(metricdata.Sum[int64]).DataPoints[0].Value)
+
+	// The next export emits the rejected rows once the pipeline accepts them
+	export = &Export{ID: 124, ProjectID: "12345"}
+	err = receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.True(t, sm.IsSeen(first))
+	assert.True(t, sm.IsSeen(second))
+}
+
+func TestProcessExport_JSON(t *testing.T) {
+	jsonData := `{"id": 4242, "name": "Test Vuln", "severity": "high", "identifiers": [{"external_type": "cve", "name": "CVE-2024-1234", "url": "https://nvd.nist.gov/vuln/detail/CVE-2024-1234"}], "location": {"file": "go.sum", "dependency": {"package": {"name": "golang.org/x/net"}, "version": "0.1.0"}}}
+{"id": 4243, "name": "Other Vuln", "severity": "low"}
+`
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{
+				ReadCloser:  io.NopCloser(strings.NewReader(jsonData)),
+				ContentType: "application/x-ndjson",
+			}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	require.NoError(t, receiver.processExport(context.Background(), "12345", &Export{ID: 123, ProjectID: "12345"}))
+	require.Equal(t, 2, sink.LogRecordCount())
+
+	attrs := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+	id, ok := attrs.Get("vulnerability.id")
+	require.True(t, ok)
+	assert.Equal(t, "4242", id.Str())
+
+	cves, ok := attrs.Get("vulnerability.cve_ids")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"CVE-2024-1234"}, cves.Slice().AsRaw())
+
+	file, ok := attrs.Get("vulnerability.file")
+	require.True(t, ok)
+	assert.Equal(t, "go.sum", file.Str())
+
+	// Nested structures are kept as maps and slices
+	identifiers, ok := attrs.Get("vulnerability.identifiers")
+	require.True(t, ok)
+	assert.Equal(t, pcommon.ValueTypeSlice, identifiers.Type())
+	location, ok := attrs.Get("vulnerability.location")
+	require.True(t, ok)
+	assert.Equal(t, "golang.org/x/net", location.Map().AsRaw()["dependency"].(map[string]interface{})["package"].(map[string]interface{})["name"])
+}
+
+func TestProcessExport_RejectsLoginPage(t *testing.T) {
+	loginPage := "<!DOCTYPE html>\n<html><head><title>Sign in · GitLab</title></head><body></body></html>\n"
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(loginPage)), ContentType: "text/csv"}, nil
+		},
+	}
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	err := receiver.processExport(context.Background(), "12345", &Export{ID: 123, ProjectID: "12345"})
+	require.ErrorIs(t, err, errNotExport)
+	assert.Equal(t, errorCategoryAuth, errorCategory(err))
+	assert.Zero(t, sink.LogRecordCount())
+	assert.False(t, receiver.stateManager.IsExportProcessed(123))
+}
+
+func TestProcessExport_SkipsProcessedExportAfterRestart(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	csvData := "Title,Severity\nfirst,High\nsecond,Low\n"
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+		},
+	}
+
+	newReceiver := func(sink *consumertest.LogsSink) *vulnerabilityReceiver {
+		sm, err := state.NewStateManager(statePath)
+		require.NoError(t, err)
+		return &vulnerabilityReceiver{
+			cfg:          createDefaultConfig().(*Config),
+			consumer:     sink,
+			client:       mockClient,
+			logger:       zap.NewNop(),
+			stateManager: sm,
+		}
+	}
+
+	sink := new(consumertest.LogsSink)
+	first := newReceiver(sink)
+	require.NoError(t, first.processExport(context.Background(), "12345", &Export{ID: 123, ProjectID: "12345"}))
+	require.Equal(t, 2, sink.LogRecordCount())
+	require.NoError(t, first.stateManager.Close())
+
+	// A restarted receiver doesn't emit the same export again
+	restarted := newReceiver(new(consumertest.LogsSink))
+	assert.True(t, restarted.stateManager.IsExportProcessed(123))
+	_, ok := restarted.stateManager.LastProcessedExport("12345")
+	assert.True(t, ok)
+
+	restartedSink := restarted.consumer.(*consumertest.LogsSink)
+	require.NoError(t, restarted.processExport(context.Background(), "12345", &Export{ID: 123, ProjectID: "12345"}))
+	assert.Equal(t, 0, restartedSink.LogRecordCount())
+}
+
+func TestPollJitter(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	receiver := &vulnerabilityReceiver{cfg: cfg}
+	assert.Zero(t, receiver.pollJitter())
+
+	cfg.PollJitter = 10 * time.Second
+	for i := 0; i < 100; i++ {
+		jitter := receiver.pollJitter()
+		assert.GreaterOrEqual(t, jitter, time.Duration(0))
+		assert.Less(t, jitter, cfg.PollJitter)
+	}
+}
+
+func TestShutdown_DrainsInflightExports(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		workTime     time.Duration
+		wantCanceled bool
+	}{
+		{
+			name:         "export finishes within drain timeout",
+			drainTimeout: time.Second,
+			workTime:     50 * time.Millisecond,
+			wantCanceled: false,
+		},
+		{
+			name:         "export canceled after drain timeout",
+			drainTimeout: 50 * time.Millisecond,
+			workTime:     time.Minute,
+			wantCanceled: true,
+		},
+		{
+			name:         "no drain cancels immediately",
+			drainTimeout: 0,
+			workTime:     time.Minute,
+			wantCanceled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.ShutdownDrainTimeout = tt.drainTimeout
+			receiver := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			receiver.cancel = cancel
+			_, receiver.stopPolling = context.WithCancel(ctx)
+
+			var canceled bool
+			receiver.wg.Add(1)
+			go func() {
+				defer receiver.wg.Done()
+				select {
+				case <-ctx.Done():
+					canceled = true
+				case <-time.After(tt.workTime):
+				}
+			}()
+
+			require.NoError(t, receiver.Shutdown(context.Background()))
+			assert.Equal(t, tt.wantCanceled, canceled)
+		})
+	}
+}
+
+func TestShutdown_StopsStartingPaths(t *testing.T) {
+	started := make(chan struct{})
+	var created []string
+	var receiver *vulnerabilityReceiver
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			created = append(created, projectID)
+			return &Export{ID: int64(len(created)), ProjectID: projectID}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			// Shutdown begins while the first export is in flight
+			if exportID == 1 {
+				close(started)
+				time.Sleep(50 * time.Millisecond)
+			}
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\n"))}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ShutdownDrainTimeout = time.Second
+	cfg.Paths = []PathConfig{
+		{ID: "1", Type: "project"},
+		{ID: "2", Type: "project"},
+		{ID: "3", Type: "project"},
+	}
+	receiver = &vulnerabilityReceiver{
+		cfg:               cfg,
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	receiver.cancel = cancel
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	receiver.stopPolling = stopPolling
+
+	receiver.wg.Add(1)
+	go func() {
+		defer receiver.wg.Done()
+		assert.NoError(t, receiver.checkExports(pollCtx, ctx))
+	}()
+
+	<-started
+	require.NoError(t, receiver.Shutdown(context.Background()))
+
+	// The in-flight export was drained, the other paths weren't started
+	assert.Equal(t, []string{"1"}, created)
+	assertNoPathErrors(t, receiver)
+}
+
+func TestCheckExports_MinExportInterval(t *testing.T) {
+	tests := []struct {
+		name              string
+		minExportInterval time.Duration
+		failFirstWait     bool
+		wantCreated       int
+		wantWaits         int
+	}{
+		{
+			name:              "cooldown prevents stacking exports",
+			minExportInterval: time.Hour,
+			wantCreated:       1,
+			wantWaits:         1,
+		},
+		{
+			name:              "no cooldown",
+			minExportInterval: 0,
+			wantCreated:       3,
+			wantWaits:         3,
+		},
+		{
+			name:              "interrupted export is resumed before the cooldown",
+			minExportInterval: time.Hour,
+			failFirstWait:     true,
+			wantCreated:       1,
+			wantWaits:         2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, waits := 0, 0
+			mockClient := &mockGitLabClient{
+				createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+					created++
+					return &Export{ID: int64(created), ProjectID: projectID}, nil
+				},
+				getExportFunc: func(ctx context.Context, projectID string, exportID int64) (*Export, error) {
+					return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+				},
+				waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+					waits++
+					if tt.failFirstWait && waits == 1 {
+						return nil, fmt.Errorf("connection reset")
+					}
+					return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+				},
+				getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+					return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\n"))}, nil
+				},
+			}
+
+			cfg := createDefaultConfig().(*Config)
+			cfg.MinExportInterval = tt.minExportInterval
+			cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+			receiver := &vulnerabilityReceiver{
+				cfg:               cfg,
+				client:            mockClient,
+				logger:            zap.NewNop(),
+				stateManager:      newTestStateManager(t),
+				lastExportTime:    make(map[string]time.Time),
+				exportsInProgress: make(map[string]bool),
+			}
+
+			for i := 0; i < 3; i++ {
+				require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+			}
+			assert.Equal(t, tt.wantCreated, created)
+			assert.Equal(t, tt.wantWaits, waits)
+		})
+	}
+}
+
+func TestCheckExports_LogsPollSummary(t *testing.T) {
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			if projectID == "67890" {
+				return nil, fmt.Errorf("403 Forbidden")
+			}
+			return &Export{ID: 1, ProjectID: projectID}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\nfirst,High\nsecond,Low\n"))}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}, {ID: "67890", Type: "project"}}
+	core, observed := observer.New(zap.InfoLevel)
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          new(consumertest.LogsSink),
+		client:            mockClient,
+		logger:            zap.New(core),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+	summaries := observed.FilterMessage("Poll completed").All()
+	require.Len(t, summaries, 1)
+	fields := summaries[0].ContextMap()
+	assert.Equal(t, int64(2), fields["pathsProcessed"])
+	assert.Equal(t, int64(0), fields["pathsSkipped"])
+	assert.Equal(t, int64(1), fields["exportsCreated"])
+	assert.Equal(t, int64(2), fields["recordsEmitted"])
+	assert.Equal(t, int64(1), fields["errors"])
+}
+
+// benchmarkExport returns a group export CSV with the given number of rows
+// spread over ten projects, without an ID column so rows are hashed
+func benchmarkExport(rows int) string {
+	var b strings.Builder
+	b.WriteString("Status,Group Name,Project Name,Tool,Scanner Name,Vulnerability,Details,Additional Info,Severity,CVE,CWE,Other Identifiers,Detected At,Location,Activity,Comments,Full Path,CVSS Vectors,Dismissal Reason\n")
+	for i := range rows {
+		fmt.Fprintf(&b, "detected,group,project-%d,dependency_scanning,Gemnasium,CVE-2024-%04d in golang.org/x/net,Details of finding %d,,high,CVE-2024-%04d,CWE-79,,2024-02-12 03:34:02 UTC,golang.org/x/net:v0.1.0,true,,group/project-%d,\"GitLab=CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H\",\n",
+			i%10, i, i, i, i%10)
+	}
+	return b.String()
+}
+
+func BenchmarkConvertRecord(b *testing.B) {
+	reader := csv.NewReader(strings.NewReader(benchmarkExport(1)))
+	header, err := reader.Read()
+	require.NoError(b, err)
+	values, err := reader.Read()
+	require.NoError(b, err)
+
+	receiver := &vulnerabilityReceiver{cfg: createDefaultConfig().(*Config), logger: zap.NewNop()}
+	groupID := "42"
+	export := &Export{ID: 123, GroupID: &groupID}
+	record := &exportRecord{header: header, values: values}
+
+	b.ReportAllocs()
+	for range b.N {
+		receiver.convertRecord(record, export)
+	}
+}
+
+func BenchmarkProcessRecords(b *testing.B) {
+	data := benchmarkExport(1000)
+	groupID := "42"
+	export := &Export{ID: 123, GroupID: &groupID}
+
+	b.ReportAllocs()
+	for range b.N {
+		b.StopTimer()
+		receiver := &vulnerabilityReceiver{
+			cfg:          createDefaultConfig().(*Config),
+			consumer:     consumertest.NewNop(),
+			logger:       zap.NewNop(),
+			stateManager: newTestStateManager(b),
+		}
+		decoder := newCSVDecoder(csv.NewReader(strings.NewReader(data)))
+		b.StartTimer()
+
+		require.NoError(b, receiver.processRecords(context.Background(), decoder, groupID, export))
+	}
+}
+
+func BenchmarkGenerateVulnID(b *testing.B) {
+	reader := csv.NewReader(strings.NewReader(benchmarkExport(1)))
+	header, err := reader.Read()
+	require.NoError(b, err)
+	values, err := reader.Read()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for range b.N {
+		generateVulnID(header, values)
+	}
+}
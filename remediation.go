@@ -0,0 +1,33 @@
+package gitlabvulnreceiver
+
+import (
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Matches solutions stating there's nothing to upgrade to yet
+var noFixPattern = regexp.MustCompile(`(?i)\b(no (known )?(fix|solution|patch|remediation)|not (yet )?(fixed|patched)|won'?t fix|will not (be )?fix(ed)?)\b`)
+
+// fixedVersion returns the version fixing a finding, from the Fixed Version
+// column or the upgrade its solution suggests
+func fixedVersion(header []string, record []string) string {
+	if version := firstField(header, record, "Fixed Version", "fixed_version"); version != "" {
+		return version
+	}
+	if match := fixedVersionPattern.FindStringSubmatch(firstField(header, record, "Solution")); match != nil {
+		return strings.TrimRight(match[1], ".")
+	}
+	return ""
+}
+
+// putRemediationAttributes sets whether a fix is known for a finding: a fixed
+// version, or a solution that doesn't say there is none
+func putRemediationAttributes(attrs pcommon.Map, header []string, record []string) {
+	version := fixedVersion(header, record)
+	putNonEmpty(attrs, "vulnerability.fixed_version", version)
+
+	solution := strings.TrimSpace(firstField(header, record, "Solution"))
+	attrs.PutBool("vulnerability.fix_available", version != "" || (solution != "" && !noFixPattern.MatchString(solution)))
+}
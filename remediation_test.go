@@ -0,0 +1,68 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestPutRemediationAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []string
+		values   []string
+		expected map[string]interface{}
+	}{
+		{
+			name:   "fixed version column",
+			header: []string{"Fixed Version", "Solution"},
+			values: []string{"2.0.1", ""},
+			expected: map[string]interface{}{
+				"vulnerability.fixed_version": "2.0.1",
+				"vulnerability.fix_available": true,
+			},
+		},
+		{
+			name:   "upgrade in the solution",
+			header: []string{"Solution"},
+			values: []string{"Upgrade lodash to version 4.17.21."},
+			expected: map[string]interface{}{
+				"vulnerability.fixed_version": "4.17.21",
+				"vulnerability.fix_available": true,
+			},
+		},
+		{
+			name:   "solution without a version",
+			header: []string{"Solution"},
+			values: []string{"Use parameterized queries"},
+			expected: map[string]interface{}{
+				"vulnerability.fix_available": true,
+			},
+		},
+		{
+			name:   "no fix yet",
+			header: []string{"Solution"},
+			values: []string{"No fix is available yet; there is no known patch"},
+			expected: map[string]interface{}{
+				"vulnerability.fix_available": false,
+			},
+		},
+		{
+			name:   "no solution",
+			header: []string{"Title"},
+			values: []string{"SQL injection"},
+			expected: map[string]interface{}{
+				"vulnerability.fix_available": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := pcommon.NewMap()
+			putRemediationAttributes(attrs, tt.header, tt.values)
+			assert.Equal(t, tt.expected, attrs.AsRaw())
+		})
+	}
+}
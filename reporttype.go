@@ -0,0 +1,25 @@
+package gitlabvulnreceiver
+
+import "strings"
+
+// Scanner types of GitLab findings, as used in report_type
+const (
+	reportTypeSAST               = "sast"
+	reportTypeSecretDetection    = "secret_detection"
+	reportTypeContainerScanning  = "container_scanning"
+	reportTypeDependencyScanning = "dependency_scanning"
+)
+
+// Columns naming the scanner type of a finding
+var reportTypeColumns = []string{"Tool", "Report Type", "report_type"}
+
+// reportType returns the scanner type of a finding in snake case
+// ("Container Scanning" becomes container_scanning)
+func reportType(header []string, record []string) string {
+	for _, column := range reportTypeColumns {
+		if value, ok := findField(header, record, column); ok && value != "" {
+			return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), " ", "_")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package gitlabvulnreceiver
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Formats of the log body of a finding
+const (
+	bodyFormatDefault = "default"
+	bodyFormatSARIF   = "sarif"
+)
+
+func isValidBodyFormat(format string) bool {
+	switch format {
+	case "", bodyFormatDefault, bodyFormatSARIF:
+		return true
+	}
+	return false
+}
+
+// Matches a trailing line number or range of a location, e.g. "app/main.go:12" or "app/main.go:12-15"
+var locationLinePattern = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+
+// sarifLevels maps GitLab severities to SARIF result levels
+var sarifLevels = map[string]string{
+	"critical": "error",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "note",
+	"info":     "note",
+}
+
+// sarifResult builds the SARIF 2.1.0 result object of a finding, using the
+// attributes already converted from the record for its identifiers
+func sarifResult(attrs pcommon.Map, header []string, record []string) map[string]interface{} {
+	result := map[string]interface{}{}
+
+	if ruleID := sarifRuleID(attrs, header, record); ruleID != "" {
+		result["ruleId"] = ruleID
+	}
+
+	severity, _ := findField(header, record, "severity")
+	level, ok := sarifLevels[strings.ToLower(strings.TrimSpace(severity))]
+	if !ok {
+		level = "none"
+	}
+	result["level"] = level
+
+	message := firstField(header, record, "Title", "Vulnerability", "Name")
+	if message == "" {
+		message = firstField(header, record, "Description", "Details")
+	}
+	result["message"] = map[string]interface{}{"text": message}
+
+	if location := sarifLocation(header, record); location != nil {
+		result["locations"] = []interface{}{location}
+	}
+
+	properties := map[string]interface{}{}
+	if severity != "" {
+		properties["severity"] = severity
+	}
+	if reportType := reportType(header, record); reportType != "" {
+		properties["reportType"] = reportType
+	}
+	for key, name := range map[string]string{
+		"vulnerability.cve_ids": "cves",
+		"vulnerability.cwe_ids": "cwes",
+	} {
+		if value, ok := attrs.Get(key); ok {
+			properties[name] = value.Slice().AsRaw()
+		}
+	}
+	if description := firstField(header, record, "Description", "Details"); description != "" && description != message {
+		properties["description"] = description
+	}
+	if solution := firstField(header, record, "Solution"); solution != "" {
+		properties["solution"] = solution
+	}
+	if len(properties) > 0 {
+		result["properties"] = properties
+	}
+	return result
+}
+
+// sarifRuleID identifies the rule of a finding by its first CVE or CWE, else
+// by the identifiers GitLab reports
+func sarifRuleID(attrs pcommon.Map, header []string, record []string) string {
+	for _, key := range []string{"vulnerability.cve_ids", "vulnerability.cwe_ids"} {
+		if value, ok := attrs.Get(key); ok && value.Slice().Len() > 0 {
+			return value.Slice().At(0).Str()
+		}
+	}
+	identifiers := firstField(header, record, "Identifiers", "Other Identifiers")
+	if parts := strings.FieldsFunc(identifiers, isIdentifierSeparator); len(parts) > 0 {
+		return parts[0]
+	}
+	return ""
+}
+
+// sarifLocation returns the physical location of a finding, nil when it has no file
+func sarifLocation(header []string, record []string) map[string]interface{} {
+	file := firstField(header, record, "File", "File Path")
+	location := firstField(header, record, "Location")
+	var startLine, endLine string
+	if file == "" && location != "" && !strings.HasPrefix(strings.TrimSpace(location), "{") {
+		file = strings.TrimSpace(location)
+		if match := locationLinePattern.FindStringSubmatch(file); match != nil {
+			file, startLine, endLine = match[1], match[2], match[3]
+		}
+	}
+	if file == "" {
+		return nil
+	}
+	if line := firstField(header, record, "Start Line", "Line"); line != "" {
+		startLine = line
+	}
+	if line := firstField(header, record, "End Line"); line != "" {
+		endLine = line
+	}
+
+	physical := map[string]interface{}{
+		"artifactLocation": map[string]interface{}{"uri": file},
+	}
+	region := map[string]interface{}{}
+	if line, err := strconv.ParseInt(strings.TrimSpace(startLine), 10, 64); err == nil {
+		region["startLine"] = line
+	}
+	if line, err := strconv.ParseInt(strings.TrimSpace(endLine), 10, 64); err == nil {
+		region["endLine"] = line
+	}
+	if len(region) > 0 {
+		physical["region"] = region
+	}
+	return map[string]interface{}{"physicalLocation": physical}
+}
@@ -0,0 +1,75 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestConvertToLogs_SARIFBody(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.BodyFormat = bodyFormatSARIF
+	recv := &vulnerabilityReceiver{
+		cfg:    cfg,
+		logger: zap.NewNop(),
+	}
+
+	tests := []struct {
+		name     string
+		header   []string
+		record   []string
+		expected map[string]interface{}
+	}{
+		{
+			name:   "sast finding",
+			header: []string{"Tool", "Vulnerability", "Details", "Severity", "CWE", "Location", "Solution"},
+			record: []string{"sast", "SQL injection", "User input reaches a query", "High", "89", "app/db.go:12-15", "Use prepared statements"},
+			expected: map[string]interface{}{
+				"ruleId":  "CWE-89",
+				"level":   "error",
+				"message": map[string]interface{}{"text": "SQL injection"},
+				"locations": []interface{}{map[string]interface{}{
+					"physicalLocation": map[string]interface{}{
+						"artifactLocation": map[string]interface{}{"uri": "app/db.go"},
+						"region":           map[string]interface{}{"startLine": int64(12), "endLine": int64(15)},
+					},
+				}},
+				"properties": map[string]interface{}{
+					"severity":    "High",
+					"reportType":  "sast",
+					"cwes":        []interface{}{"CWE-89"},
+					"description": "User input reaches a query",
+					"solution":    "Use prepared statements",
+				},
+			},
+		},
+		{
+			name:   "dependency finding without location",
+			header: []string{"Tool", "Vulnerability", "Severity", "CVE"},
+			record: []string{"dependency_scanning", "Prototype pollution", "Unknown", "CVE-2021-23337"},
+			expected: map[string]interface{}{
+				"ruleId":  "CVE-2021-23337",
+				"level":   "none",
+				"message": map[string]interface{}{"text": "Prototype pollution"},
+				"properties": map[string]interface{}{
+					"severity":   "Unknown",
+					"reportType": "dependency_scanning",
+					"cves":       []interface{}{"CVE-2021-23337"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := recv.convertToLogs(tt.header, tt.record, &Export{ID: 1, ProjectID: "1"})
+			lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			assert.Equal(t, tt.expected, lr.Body().Map().AsRaw())
+
+			// Attributes don't depend on the body format
+			_, ok := lr.Attributes().Get("report.type")
+			assert.True(t, ok)
+		})
+	}
+}
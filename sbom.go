@@ -0,0 +1,220 @@
+package gitlabvulnreceiver
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// Upper bound on the size of a downloaded artifacts archive
+const maxArtifactsSize = 256 << 20
+
+// cycloneDXBOM is the part of a CycloneDX JSON document the receiver emits
+type cycloneDXBOM struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type     string `json:"type"`
+	BOMRef   string `json:"bom-ref"`
+	Name     string `json:"name"`
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	PURL     string `json:"purl"`
+	Licenses []struct {
+		License struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"license"`
+		Expression string `json:"expression"`
+	} `json:"licenses"`
+}
+
+// isSBOMFile matches the CycloneDX files GitLab dependency scanning produces
+// (gl-sbom-*.cdx.json)
+func isSBOMFile(name string) bool {
+	return strings.HasSuffix(path.Base(name), ".cdx.json")
+}
+
+// How long processed SBOM jobs are remembered
+const sbomJobRetention = 90 * 24 * time.Hour
+
+// processSBOM downloads the artifacts of the latest run of the configured job
+// and emits one log record per component of every CycloneDX SBOM in them. A job
+// is only processed once.
+func (r *vulnerabilityReceiver) processSBOM(ctx context.Context, projectID string) error {
+	job, err := r.client.GetLatestJob(ctx, projectID, r.cfg.SBOM.Ref, r.cfg.SBOM.Job)
+	if err != nil {
+		return fmt.Errorf("failed to find SBOM job: %w", err)
+	}
+	if job == nil {
+		r.logger.Warn("No successful SBOM job found",
+			zap.String("projectID", projectID),
+			zap.String("job", r.cfg.SBOM.Job),
+			zap.String("ref", r.cfg.SBOM.Ref))
+		return nil
+	}
+
+	seenKey := fmt.Sprintf("sbom:%s:%d", projectID, job.ID)
+	if r.stateManager.IsSeen(seenKey) {
+		r.logger.Debug("Skipping SBOM - job already processed",
+			zap.String("projectID", projectID),
+			zap.Int64("jobID", job.ID))
+		return nil
+	}
+
+	artifacts, err := r.client.GetJobArtifacts(ctx, projectID, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get SBOM artifacts: %w", err)
+	}
+	defer artifacts.Close()
+
+	// Zip archives need random access, so the archive is spooled to a temporary file
+	spool, err := os.CreateTemp("", "gitlab-artifacts-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, io.LimitReader(artifacts, maxArtifactsSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read SBOM artifacts: %w", err)
+	}
+	if size > maxArtifactsSize {
+		return fmt.Errorf("SBOM artifacts exceed %d bytes", maxArtifactsSize)
+	}
+
+	archive, err := zip.NewReader(spool, size)
+	if err != nil {
+		return fmt.Errorf("failed to open SBOM artifacts: %w", err)
+	}
+
+	var files int
+	for _, file := range archive.File {
+		if !isSBOMFile(file.Name) {
+			continue
+		}
+		bom, err := readCycloneDX(file)
+		if err != nil {
+			return err
+		}
+		if err := r.emitSBOM(ctx, projectID, file.Name, bom); err != nil {
+			return err
+		}
+		files++
+	}
+
+	if files == 0 {
+		r.logger.Warn("No CycloneDX SBOM found in job artifacts",
+			zap.String("projectID", projectID),
+			zap.String("job", r.cfg.SBOM.Job),
+			zap.String("ref", r.cfg.SBOM.Ref),
+			zap.Int64("jobID", job.ID))
+	}
+
+	return r.stateManager.MarkSeen([]string{seenKey}, sbomJobRetention)
+}
+
+func readCycloneDX(file *zip.File) (*cycloneDXBOM, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SBOM %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	var bom cycloneDXBOM
+	if err := json.NewDecoder(rc).Decode(&bom); err != nil {
+		return nil, fmt.Errorf("failed to decode SBOM %s: %w", file.Name, err)
+	}
+	if bom.BOMFormat != "CycloneDX" {
+		return nil, fmt.Errorf("unsupported SBOM format in %s: %q", file.Name, bom.BOMFormat)
+	}
+	return &bom, nil
+}
+
+// emitSBOM sends the components of an SBOM in batches
+func (r *vulnerabilityReceiver) emitSBOM(ctx context.Context, projectID, fileName string, bom *cycloneDXBOM) error {
+	batchSize := r.batchSize()
+	batch := newLogBatch()
+	for i := range bom.Components {
+		batch.add(projectID, r.convertSBOMComponent(projectID, fileName, bom, &bom.Components[i]))
+		if batch.records >= batchSize {
+			if err := r.consumeLogs(ctx, batch.take()); err != nil {
+				return fmt.Errorf("failed to consume logs: %w", err)
+			}
+		}
+	}
+	if batch.records > 0 {
+		if err := r.consumeLogs(ctx, batch.take()); err != nil {
+			return fmt.Errorf("failed to consume logs: %w", err)
+		}
+	}
+	return nil
+}
+
+// convertSBOMComponent converts an SBOM component to a log record
+func (r *vulnerabilityReceiver) convertSBOMComponent(projectID, fileName string, bom *cycloneDXBOM, component *cycloneDXComponent) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("gitlab.project.id", projectID)
+
+	sl := r.newScopeLogs(rl)
+
+	lr := sl.LogRecords().AppendEmpty()
+	now := pcommon.NewTimestampFromTime(time.Now())
+	lr.SetTimestamp(now)
+	lr.SetObservedTimestamp(now)
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.SetSeverityText("INFO")
+	lr.Body().SetStr(strings.TrimSuffix(component.Name+"@"+component.Version, "@"))
+
+	setEventName(lr, "gitlab.sbom.component")
+	attrs := lr.Attributes()
+	attrs.PutStr("gitlab.job.name", r.cfg.SBOM.Job)
+	attrs.PutStr("gitlab.ref", r.cfg.SBOM.Ref)
+	attrs.PutStr("sbom.file", fileName)
+	putNonEmpty(attrs, "sbom.spec_version", bom.SpecVersion)
+	putNonEmpty(attrs, "sbom.serial_number", bom.SerialNumber)
+	putNonEmpty(attrs, "sbom.component.type", component.Type)
+	putNonEmpty(attrs, "sbom.component.bom_ref", component.BOMRef)
+	putNonEmpty(attrs, "sbom.component.group", component.Group)
+	putNonEmpty(attrs, "package.name", component.Name)
+	putNonEmpty(attrs, "package.version", component.Version)
+	putNonEmpty(attrs, "package.purl", component.PURL)
+
+	var licenses []string
+	for _, license := range component.Licenses {
+		for _, value := range []string{license.License.ID, license.License.Name, license.Expression} {
+			if value != "" {
+				if !slices.Contains(licenses, value) {
+					licenses = append(licenses, value)
+				}
+				break
+			}
+		}
+	}
+	putStringSlice(attrs, "package.licenses", licenses)
+	return logs
+}
+
+// putNonEmpty sets a string attribute unless the value is empty
+func putNonEmpty(attrs pcommon.Map, key, value string) {
+	if value != "" {
+		attrs.PutStr(key, value)
+	}
+}
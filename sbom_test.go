@@ -0,0 +1,100 @@
+package gitlabvulnreceiver
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestProcessSBOM(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := map[string]string{
+		"gl-sbom-npm-npm.cdx.json": `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "serialNumber": "urn:uuid:1234",
+  "components": [
+    {"type": "library", "bom-ref": "pkg:npm/lodash@4.17.20", "name": "lodash", "version": "4.17.20", "purl": "pkg:npm/lodash@4.17.20", "licenses": [{"license": {"id": "MIT"}}]},
+    {"type": "library", "name": "left-pad", "version": "1.3.0", "licenses": [{"expression": "MIT OR Apache-2.0"}]}
+  ]
+}`,
+		"gl-dependency-scanning-report.json": `{"vulnerabilities": []}`,
+	}
+	for name, content := range files {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	downloads := 0
+	mockClient := &mockGitLabClient{
+		getLatestJobFunc: func(ctx context.Context, projectID, ref, name string) (*Job, error) {
+			assert.Equal(t, "main", ref)
+			assert.Equal(t, "gemnasium-dependency_scanning", name)
+			return &Job{ID: 4321, Name: name, Ref: ref}, nil
+		},
+		getJobArtifactsFunc: func(ctx context.Context, projectID string, jobID int64) (io.ReadCloser, error) {
+			assert.Equal(t, int64(4321), jobID)
+			downloads++
+			return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SBOM.Job = "gemnasium-dependency_scanning"
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	require.NoError(t, receiver.processSBOM(context.Background(), "12345"))
+	require.Equal(t, 2, sink.LogRecordCount())
+
+	records := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	lodash := records.At(0)
+	assert.Equal(t, "lodash@4.17.20", lodash.Body().Str())
+	assert.Equal(t, map[string]interface{}{
+		"event.name":             "gitlab.sbom.component",
+		"gitlab.job.name":        "gemnasium-dependency_scanning",
+		"gitlab.ref":             "main",
+		"sbom.file":              "gl-sbom-npm-npm.cdx.json",
+		"sbom.spec_version":      "1.4",
+		"sbom.serial_number":     "urn:uuid:1234",
+		"sbom.component.type":    "library",
+		"sbom.component.bom_ref": "pkg:npm/lodash@4.17.20",
+		"package.name":           "lodash",
+		"package.version":        "4.17.20",
+		"package.purl":           "pkg:npm/lodash@4.17.20",
+		"package.licenses":       []interface{}{"MIT"},
+	}, lodash.Attributes().AsRaw())
+
+	licenses, ok := records.At(1).Attributes().Get("package.licenses")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"MIT OR Apache-2.0"}, licenses.Slice().AsRaw())
+
+	// The same job isn't processed again
+	sink.Reset()
+	require.NoError(t, receiver.processSBOM(context.Background(), "12345"))
+	assert.Equal(t, 0, sink.LogRecordCount())
+	assert.Equal(t, 1, downloads)
+}
+
+func TestIsSBOMFile(t *testing.T) {
+	assert.True(t, isSBOMFile("gl-sbom-go-go.cdx.json"))
+	assert.True(t, isSBOMFile("reports/gl-sbom-npm-npm.cdx.json"))
+	assert.False(t, isSBOMFile("gl-dependency-scanning-report.json"))
+}
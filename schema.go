@@ -0,0 +1,139 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// schemaChange lists how the columns of an export differ from the previous one
+type schemaChange struct {
+	added   []string
+	removed []string
+	// renamed holds "old -> new" for columns differing only in case, spacing
+	// or punctuation
+	renamed []string
+}
+
+func (c schemaChange) empty() bool {
+	return len(c.added) == 0 && len(c.removed) == 0 && len(c.renamed) == 0
+}
+
+// diffColumns compares the columns of two exports, ignoring their order
+func diffColumns(previous, current []string) schemaChange {
+	var change schemaChange
+	var removed []string
+	for _, column := range previous {
+		if !slices.Contains(current, column) {
+			removed = append(removed, column)
+		}
+	}
+	for _, column := range current {
+		if slices.Contains(previous, column) {
+			continue
+		}
+		i := slices.IndexFunc(removed, func(old string) bool {
+			return columnShape(old) == columnShape(column)
+		})
+		if i < 0 {
+			change.added = append(change.added, column)
+			continue
+		}
+		change.renamed = append(change.renamed, removed[i]+" -> "+column)
+		removed = slices.Delete(removed, i, i+1)
+	}
+	change.removed = removed
+	return change
+}
+
+// columnShape reduces a column name to its lowercase letters and digits, so
+// "Scanner Name" and "scanner_name" match
+func columnShape(column string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, column)
+}
+
+// checkSchemaDrift compares the columns of a CSV export with those of the
+// path's previous export, warning once with a diagnostic event when GitLab
+// added, removed or renamed columns
+func (r *vulnerabilityReceiver) checkSchemaDrift(ctx context.Context, pathID string, export *Export, header []string) {
+	previous, ok := r.stateManager.GetColumns(pathID)
+	if ok {
+		change := diffColumns(previous, header)
+		if change.empty() {
+			return
+		}
+
+		r.logger.Warn("Export columns changed since the previous export",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID),
+			zap.Strings("added", change.added),
+			zap.Strings("removed", change.removed),
+			zap.Strings("renamed", change.renamed))
+		if err := r.consumer.ConsumeLogs(ctx, r.schemaChangeEvent(pathID, export, change)); err != nil {
+			r.logger.Warn("Failed to emit schema change event", zap.Error(err))
+		}
+	}
+
+	// The change is only reported once, the next export is compared with this one
+	if err := r.stateManager.SetColumns(pathID, slices.Clone(header)); err != nil {
+		r.logger.Warn("Failed to save export columns",
+			zap.String("id", pathID),
+			zap.Error(err))
+	}
+}
+
+// schemaChangeEvent builds the diagnostic log record emitted when the columns
+// of a path's exports change
+func (r *vulnerabilityReceiver) schemaChangeEvent(pathID string, export *Export, change schemaChange) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	pathType := "project"
+	if export.GroupID != nil {
+		pathType = "group"
+		rl.Resource().Attributes().PutStr("gitlab.group.id", pathID)
+	} else {
+		rl.Resource().Attributes().PutStr("gitlab.project.id", pathID)
+	}
+
+	sl := r.newScopeLogs(rl)
+
+	lr := sl.LogRecords().AppendEmpty()
+	now := pcommon.NewTimestampFromTime(time.Now())
+	lr.SetTimestamp(now)
+	lr.SetObservedTimestamp(now)
+	lr.SetSeverityNumber(plog.SeverityNumberWarn)
+	lr.SetSeverityText("WARN")
+	lr.Body().SetStr("Export columns changed since the previous export")
+
+	setEventName(lr, "gitlab.export.schema_changed")
+	attrs := lr.Attributes()
+	attrs.PutStr("gitlab.path.id", pathID)
+	attrs.PutStr("gitlab.path.type", pathType)
+	attrs.PutStr("gitlab.export.id", fmt.Sprintf("%d", export.ID))
+	for name, columns := range map[string][]string{
+		"gitlab.export.columns.added":   change.added,
+		"gitlab.export.columns.removed": change.removed,
+		"gitlab.export.columns.renamed": change.renamed,
+	} {
+		if len(columns) == 0 {
+			continue
+		}
+		slice := attrs.PutEmptySlice(name)
+		for _, column := range columns {
+			slice.AppendEmpty().SetStr(column)
+		}
+	}
+	return logs
+}
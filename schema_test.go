@@ -0,0 +1,66 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+)
+
+func TestDiffColumns(t *testing.T) {
+	change := diffColumns(
+		[]string{"Tool", "Scanner Name", "Severity", "CVE"},
+		[]string{"Severity", "Tool", "scanner_name", "CVSS Vectors"},
+	)
+	assert.Equal(t, []string{"CVSS Vectors"}, change.added)
+	assert.Equal(t, []string{"CVE"}, change.removed)
+	assert.Equal(t, []string{"Scanner Name -> scanner_name"}, change.renamed)
+
+	// Reordered columns aren't a change
+	assert.True(t, diffColumns([]string{"Tool", "Severity"}, []string{"Severity", "Tool"}).empty())
+}
+
+func TestProcessRecords_SchemaDrift(t *testing.T) {
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+	process := func(exportID int64, csvData string) {
+		t.Helper()
+		decoder := newCSVDecoder(csv.NewReader(strings.NewReader(csvData)))
+		require.NoError(t, receiver.processRecords(context.Background(), decoder, "12345", &Export{ID: exportID, ProjectID: "12345"}))
+	}
+	schemaEvents := func() []map[string]any {
+		var events []map[string]any
+		for _, logs := range sink.AllLogs() {
+			lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			if lr.EventName() == "gitlab.export.schema_changed" {
+				events = append(events, lr.Attributes().AsRaw())
+			}
+		}
+		return events
+	}
+
+	// The first export has nothing to be compared with
+	process(1, "Vulnerability ID,Title,Severity\n1,first,High\n")
+	assert.Empty(t, schemaEvents())
+
+	process(2, "Vulnerability ID,Title,Severity,Scanner Name\n2,second,Low,gemnasium\n")
+	events := schemaEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, "12345", events[0]["gitlab.path.id"])
+	assert.Equal(t, []any{"Scanner Name"}, events[0]["gitlab.export.columns.added"])
+	assert.NotContains(t, events[0], "gitlab.export.columns.removed")
+
+	// The change is reported once
+	process(3, "Vulnerability ID,Title,Severity,Scanner Name\n3,third,Low,gemnasium\n")
+	assert.Len(t, schemaEvents(), 1)
+}
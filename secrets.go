@@ -0,0 +1,102 @@
+package gitlabvulnreceiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Columns that may hold the raw matched secret, lowercased. Their values are
+// always replaced by a hash before any attribute is set.
+var secretValueColumns = map[string]bool{
+	"raw source code extract": true,
+	"raw_source_code_extract": true,
+	"secret":                  true,
+	"matched value":           true,
+	"match":                   true,
+}
+
+var secretRuleIDPattern = regexp.MustCompile(`(?i)gitleaks[ _]rule[ _]id[ _:]*([\w.-]+)`)
+
+// hashSecret returns a stable, non-reversible stand-in for a secret value
+func hashSecret(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// redactSecrets returns the record with secret values hashed, copying it only
+// when something had to be redacted
+func redactSecrets(header []string, record []string) []string {
+	var redacted []string
+	for i, field := range header {
+		if i >= len(record) || record[i] == "" || !secretValueColumns[strings.ToLower(field)] {
+			continue
+		}
+		if redacted == nil {
+			redacted = slices.Clone(record)
+		}
+		redacted[i] = hashSecret(record[i])
+	}
+	if redacted == nil {
+		return record
+	}
+	return redacted
+}
+
+// redactNestedSecrets hashes secret values found at any depth of nested JSON
+// values, returning whether any was found
+func redactNestedSecrets(value interface{}) bool {
+	redacted := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if s, ok := item.(string); ok && s != "" && secretValueColumns[strings.ToLower(key)] {
+				v[key] = hashSecret(s)
+				redacted = true
+				continue
+			}
+			redacted = redactNestedSecrets(item) || redacted
+		}
+	case []interface{}:
+		for _, item := range v {
+			redacted = redactNestedSecrets(item) || redacted
+		}
+	}
+	return redacted
+}
+
+// putSecretAttributes sets the rule, file and commit of a secret detection finding
+func putSecretAttributes(attrs pcommon.Map, header []string, record []string) {
+	if reportType(header, record) != reportTypeSecretDetection {
+		return
+	}
+
+	ruleID, _ := findField(header, record, "Secret Rule ID")
+	if ruleID == "" {
+		for _, column := range identifierColumns {
+			value, _ := findField(header, record, column)
+			if match := secretRuleIDPattern.FindStringSubmatch(value); match != nil {
+				ruleID = match[1]
+				break
+			}
+		}
+	}
+	putNonEmpty(attrs, "vulnerability.secret.rule_id", ruleID)
+
+	for _, column := range []string{"File", "Location"} {
+		if file, ok := findField(header, record, column); ok && file != "" {
+			attrs.PutStr("vulnerability.secret.file", file)
+			break
+		}
+	}
+	for _, column := range []string{"Commit", "Commit SHA"} {
+		if commit, ok := findField(header, record, column); ok && commit != "" {
+			attrs.PutStr("vulnerability.secret.commit", commit)
+			break
+		}
+	}
+}
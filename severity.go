@@ -0,0 +1,67 @@
+package gitlabvulnreceiver
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// What is done with findings whose severity is missing or unknown
+const (
+	unknownSeverityKeep = "keep"
+	unknownSeverityFlag = "flag"
+	unknownSeverityDrop = "drop"
+)
+
+func isValidUnknownSeverityAction(action string) bool {
+	switch action {
+	case "", unknownSeverityKeep, unknownSeverityFlag, unknownSeverityDrop:
+		return true
+	}
+	return false
+}
+
+// severityNumbers maps GitLab severities to log severity numbers
+var severityNumbers = map[string]plog.SeverityNumber{
+	"critical": plog.SeverityNumberFatal,
+	"high":     plog.SeverityNumberError,
+	"medium":   plog.SeverityNumberWarn,
+	"low":      plog.SeverityNumberInfo,
+	"info":     plog.SeverityNumberTrace,
+}
+
+// severityNumber returns the log severity number of a GitLab severity, false
+// when the severity is missing or unknown
+func severityNumber(severity string) (plog.SeverityNumber, bool) {
+	number, ok := severityNumbers[strings.ToLower(strings.TrimSpace(severity))]
+	return number, ok
+}
+
+// setSeverity sets the severity of a finding's record. Findings of unknown
+// severity get the configured default severity number, and are flagged when
+// configured so.
+func (r *vulnerabilityReceiver) setSeverity(lr plog.LogRecord, header []string, record []string) {
+	severity, _ := findField(header, record, "severity")
+	lr.SetSeverityText(severity)
+	if number, ok := severityNumber(severity); ok {
+		lr.SetSeverityNumber(number)
+		return
+	}
+
+	if number, ok := severityNumber(r.cfg.UnknownSeverity.Severity); ok {
+		lr.SetSeverityNumber(number)
+	}
+	if r.cfg.UnknownSeverity.Action == unknownSeverityFlag {
+		lr.Attributes().PutBool("vulnerability.severity_unknown", true)
+	}
+}
+
+// dropsSeverity reports whether a finding is dropped for its unknown severity
+func (r *vulnerabilityReceiver) dropsSeverity(record *exportRecord) bool {
+	if r.cfg.UnknownSeverity.Action != unknownSeverityDrop {
+		return false
+	}
+	severity, _ := findField(record.header, record.values, "severity")
+	_, known := severityNumber(severity)
+	return !known
+}
@@ -0,0 +1,81 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestSetSeverity(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     UnknownSeverityConfig
+		severity   string
+		wantNumber plog.SeverityNumber
+		wantFlag   bool
+	}{
+		{
+			name:       "known severity",
+			config:     UnknownSeverityConfig{Severity: "low", Action: unknownSeverityFlag},
+			severity:   "High",
+			wantNumber: plog.SeverityNumberError,
+		},
+		{
+			name:     "unknown severity kept",
+			config:   UnknownSeverityConfig{Action: unknownSeverityKeep},
+			severity: "Unknown",
+		},
+		{
+			name:       "default severity",
+			config:     UnknownSeverityConfig{Severity: "medium", Action: unknownSeverityKeep},
+			severity:   "unknown",
+			wantNumber: plog.SeverityNumberWarn,
+		},
+		{
+			name:       "unknown severity flagged",
+			config:     UnknownSeverityConfig{Severity: "info", Action: unknownSeverityFlag},
+			severity:   "",
+			wantNumber: plog.SeverityNumberTrace,
+			wantFlag:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.UnknownSeverity = tt.config
+			recv := &vulnerabilityReceiver{cfg: cfg, logger: zap.NewNop()}
+
+			lr := plog.NewLogRecord()
+			recv.setSeverity(lr, []string{"Title", "Severity"}, []string{"SQL injection", tt.severity})
+			assert.Equal(t, tt.severity, lr.SeverityText())
+			assert.Equal(t, tt.wantNumber, lr.SeverityNumber())
+			_, flagged := lr.Attributes().Get("vulnerability.severity_unknown")
+			assert.Equal(t, tt.wantFlag, flagged)
+		})
+	}
+}
+
+func TestProcessCSVData_DropsUnknownSeverity(t *testing.T) {
+	sink := new(consumertest.LogsSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.UnknownSeverity.Action = unknownSeverityDrop
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	csvData := "Vulnerability ID,Title,Severity\n1,SQL injection,High\n2,Weak hash,Unknown\n3,Old library,\n"
+	export := &Export{ID: 123, ProjectID: "12345"}
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
@@ -0,0 +1,291 @@
+package gitlabvulnreceiver
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// The metrics of export snapshots are computed by the logs receiver, which reads
+// the exports, and sent to the metrics pipeline of the same receiver, keyed by
+// component ID. They're only sent when the receiver is in both pipelines.
+var (
+	snapshotConsumersMu sync.Mutex
+	snapshotConsumers   = make(map[component.ID]consumer.Metrics)
+)
+
+// registerSnapshotConsumer sends the snapshot metrics of the receiver id to next
+func registerSnapshotConsumer(id component.ID, next consumer.Metrics) {
+	snapshotConsumersMu.Lock()
+	defer snapshotConsumersMu.Unlock()
+	snapshotConsumers[id] = next
+}
+
+// unregisterSnapshotConsumer stops sending the snapshot metrics of the receiver id
+func unregisterSnapshotConsumer(id component.ID) {
+	snapshotConsumersMu.Lock()
+	defer snapshotConsumersMu.Unlock()
+	delete(snapshotConsumers, id)
+}
+
+// snapshotConsumer returns where the snapshot metrics of the receiver id go,
+// nil when it isn't in a metrics pipeline
+func snapshotConsumer(id component.ID) consumer.Metrics {
+	snapshotConsumersMu.Lock()
+	defer snapshotConsumersMu.Unlock()
+	return snapshotConsumers[id]
+}
+
+// openFindingKey is what open findings are counted by
+type openFindingKey struct {
+	projectPath string
+	severity    string
+	reportType  string
+}
+
+// projectSeverity is what the distributions of findings are split by
+type projectSeverity struct {
+	projectPath string
+	severity    string
+}
+
+// exportSnapshot aggregates the findings of a whole export
+type exportSnapshot struct {
+	open map[openFindingKey]int64
+	// ages holds the days since the open findings were detected
+	ages map[projectSeverity][]float64
+	// remediations holds the days taken to resolve the findings first seen resolved
+	remediations map[projectSeverity][]float64
+	// resolved holds the seen keys of the resolved findings read, refreshed once
+	// the snapshot is emitted so they aren't recorded again
+	resolved []string
+}
+
+func newExportSnapshot() *exportSnapshot {
+	return &exportSnapshot{
+		open:         make(map[openFindingKey]int64),
+		ages:         make(map[projectSeverity][]float64),
+		remediations: make(map[projectSeverity][]float64),
+	}
+}
+
+// Bucket bounds, in days, of the distributions of findings
+var dayBuckets = []float64{1, 3, 7, 14, 30, 60, 90, 180, 365}
+
+// addToSnapshot adds the finding of a record to the snapshot, whatever the
+// filters of the logs
+func (r *vulnerabilityReceiver) addToSnapshot(snapshot *exportSnapshot, key string, record *exportRecord) {
+	if closedStatuses[findingStatus(record)] {
+		r.addRemediation(snapshot, key, record)
+		return
+	}
+	projectPath, severity := findProjectPath(record.header, record.values), recordSeverity(record)
+	snapshot.open[openFindingKey{
+		projectPath: projectPath,
+		severity:    severity,
+		reportType:  reportType(record.header, record.values),
+	}]++
+	if age, ok := r.findingAge(record); ok {
+		dims := projectSeverity{projectPath: projectPath, severity: severity}
+		snapshot.ages[dims] = append(snapshot.ages[dims], age.Hours()/24)
+	}
+}
+
+// recordSeverity is the lowercased severity of a finding's record, unknown when
+// it's missing or not a GitLab severity
+func recordSeverity(record *exportRecord) string {
+	severity, _ := findField(record.header, record.values, "severity")
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if _, known := severityNumbers[severity]; !known {
+		return "unknown"
+	}
+	return severity
+}
+
+// addRemediation records the time taken to resolve the finding of a record,
+// once per finding: when it's first read resolved with both its detection and
+// resolution dates
+func (r *vulnerabilityReceiver) addRemediation(snapshot *exportSnapshot, key string, record *exportRecord) {
+	if key == "" || findingStatus(record) != "resolved" {
+		return
+	}
+	seenKey := state.RemediatedKeyPrefix + key
+	snapshot.resolved = append(snapshot.resolved, seenKey)
+	if r.stateManager.IsSeen(seenKey) {
+		return
+	}
+	detected, ok := r.detectedAt(record.header, record.values)
+	if !ok {
+		return
+	}
+	resolved, ok := r.timestamps.parse(firstField(record.header, record.values, "Resolved At", "resolved_at"))
+	if !ok || resolved.Before(detected) {
+		return
+	}
+	dims := projectSeverity{projectPath: findProjectPath(record.header, record.values), severity: recordSeverity(record)}
+	snapshot.remediations[dims] = append(snapshot.remediations[dims], resolved.Sub(detected).Hours()/24)
+}
+
+// emitSnapshotMetrics sends the metrics of a whole export to the metrics
+// pipeline of the receiver, if it's in one
+func (r *vulnerabilityReceiver) emitSnapshotMetrics(ctx context.Context, pathID string, export *Export, snapshot *exportSnapshot) {
+	next := snapshotConsumer(r.id)
+	if next == nil {
+		return
+	}
+	if err := next.ConsumeMetrics(ctx, r.snapshotMetrics(pathID, export, snapshot)); err != nil {
+		r.logger.Warn("Failed to emit export snapshot metrics",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID),
+			zap.Error(err))
+		return
+	}
+	if len(snapshot.resolved) == 0 {
+		return
+	}
+	if err := r.stateManager.MarkSeen(snapshot.resolved, processedRetention); err != nil {
+		r.logger.Warn("Failed to save remediated vulnerabilities",
+			zap.String("id", pathID),
+			zap.Error(err))
+	}
+}
+
+// snapshotMetrics builds the metrics of a whole export of a path
+func (r *vulnerabilityReceiver) snapshotMetrics(pathID string, export *Export, snapshot *exportSnapshot) pmetric.Metrics {
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	rm.SetSchemaUrl(schemaURL)
+	if export.GetGroupID() != "" {
+		rm.Resource().Attributes().PutStr("gitlab.group.id", pathID)
+	} else {
+		rm.Resource().Attributes().PutStr("gitlab.project.id", pathID)
+	}
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.SetSchemaUrl(schemaURL)
+	sm.Scope().SetName(scopeName)
+	sm.Scope().SetVersion(r.buildInfo.Version)
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	m := sm.Metrics().AppendEmpty()
+	m.SetName("gitlab.vulnerability.open")
+	m.SetDescription("Number of open vulnerabilities in the latest export")
+	m.SetUnit("{vulnerability}")
+	gauge := m.SetEmptyGauge()
+	for _, key := range sortedKeys(snapshot.open, compareOpenFindingKeys) {
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.SetTimestamp(now)
+		dp.SetIntValue(snapshot.open[key])
+		putFindingDimensions(dp.Attributes(), key.projectPath, key.severity, key.reportType)
+	}
+
+	// Ages are a snapshot of the export rather than a change since the previous
+	// one, so every export starts a new cumulative histogram
+	putDayHistograms(sm, pmetric.AggregationTemporalityCumulative, now, now, snapshot.ages,
+		"gitlab.vulnerability.age",
+		"Time since the open vulnerabilities in the latest export were detected")
+	start := pcommon.NewTimestampFromTime(r.previousExportTime(pathID, export))
+	putDayHistograms(sm, pmetric.AggregationTemporalityDelta, start, now, snapshot.remediations,
+		"gitlab.vulnerability.time_to_remediate",
+		"Time taken to resolve the vulnerabilities seen resolved since the previous export")
+	return metrics
+}
+
+// putDayHistograms adds a histogram of values in days, with a data point per
+// project and severity, leaving it out when there are no values
+func putDayHistograms(
+	sm pmetric.ScopeMetrics,
+	temporality pmetric.AggregationTemporality,
+	start, now pcommon.Timestamp,
+	values map[projectSeverity][]float64,
+	name, description string,
+) {
+	if len(values) == 0 {
+		return
+	}
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(name)
+	m.SetDescription(description)
+	m.SetUnit("d")
+	histogram := m.SetEmptyHistogram()
+	histogram.SetAggregationTemporality(temporality)
+	for _, key := range sortedKeys(values, compareProjectSeverities) {
+		dp := histogram.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(now)
+		putDayHistogram(dp, values[key])
+		putFindingDimensions(dp.Attributes(), key.projectPath, key.severity, "")
+	}
+}
+
+// previousExportTime is when the export of a path before this one was
+// processed, or when this one was created for the first one
+func (r *vulnerabilityReceiver) previousExportTime(pathID string, export *Export) time.Time {
+	r.exportMutex.RLock()
+	defer r.exportMutex.RUnlock()
+	if last, ok := r.lastExportTime[pathID]; ok {
+		return last
+	}
+	return export.CreatedAt
+}
+
+// putDayHistogram sets a histogram data point from values in days
+func putDayHistogram(dp pmetric.HistogramDataPoint, values []float64) {
+	counts := make([]uint64, len(dayBuckets)+1)
+	for i, value := range values {
+		counts[sort.SearchFloat64s(dayBuckets, value)]++
+		if i == 0 || value < dp.Min() {
+			dp.SetMin(value)
+		}
+		if i == 0 || value > dp.Max() {
+			dp.SetMax(value)
+		}
+		dp.SetSum(dp.Sum() + value)
+	}
+	dp.SetCount(uint64(len(values)))
+	dp.ExplicitBounds().FromRaw(dayBuckets)
+	dp.BucketCounts().FromRaw(counts)
+}
+
+func compareProjectSeverities(a, b projectSeverity) int {
+	return cmp.Or(
+		cmp.Compare(a.projectPath, b.projectPath),
+		cmp.Compare(a.severity, b.severity))
+}
+
+// putFindingDimensions sets the attributes of a data point of findings,
+// leaving out the empty ones
+func putFindingDimensions(attrs pcommon.Map, projectPath, severity, reportType string) {
+	attrs.PutStr("vulnerability.severity", severity)
+	putNonEmpty(attrs, "vulnerability.report_type", reportType)
+	putNonEmpty(attrs, "gitlab.project.path", projectPath)
+}
+
+func compareOpenFindingKeys(a, b openFindingKey) int {
+	return cmp.Or(
+		cmp.Compare(a.projectPath, b.projectPath),
+		cmp.Compare(a.severity, b.severity),
+		cmp.Compare(a.reportType, b.reportType))
+}
+
+// sortedKeys returns the keys of m in order, so data points come in the same
+// order every time
+func sortedKeys[K comparable, V any](m map[K]V, compare func(a, b K) int) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compare)
+	return keys
+}
@@ -0,0 +1,184 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+func newTestSnapshotReceiver(t *testing.T) (*vulnerabilityReceiver, *consumertest.MetricsSink) {
+	id := component.MustNewIDWithName(typeStr, t.Name())
+	sink := new(consumertest.MetricsSink)
+	registerSnapshotConsumer(id, sink)
+	t.Cleanup(func() { unregisterSnapshotConsumer(id) })
+
+	return &vulnerabilityReceiver{
+		id:           id,
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     consumertest.NewNop(),
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}, sink
+}
+
+// snapshotGauge returns the values of a snapshot gauge keyed by the joined
+// values of the given data point attributes
+func snapshotGauge(t *testing.T, metrics pmetric.Metrics, name string, attributes ...string) map[string]int64 {
+	values := make(map[string]int64)
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Gauge().DataPoints()
+		for j := 0; j < dps.Len(); j++ {
+			var key []string
+			for _, attribute := range attributes {
+				value, _ := dps.At(j).Attributes().Get(attribute)
+				key = append(key, value.AsString())
+			}
+			values[strings.Join(key, "/")] = dps.At(j).IntValue()
+		}
+	}
+	return values
+}
+
+func TestProcessCSVData_OpenVulnerabilities(t *testing.T) {
+	receiver, sink := newTestSnapshotReceiver(t)
+	csvData := "Vulnerability ID,Title,Severity,Status,Tool,Full Path\n" +
+		"1,SQL injection,High,detected,SAST,group/api\n" +
+		"2,XSS,High,confirmed,SAST,group/api\n" +
+		"3,Old library,Critical,detected,Dependency Scanning,group/web\n" +
+		"4,Weak hash,Low,resolved,SAST,group/api\n" +
+		"5,Leaked key,Low,dismissed,Secret Detection,group/web\n" +
+		"6,Odd finding,,detected,DAST,group/web\n"
+
+	export := &Export{ID: 1, ProjectID: "12345"}
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+
+	require.Len(t, sink.AllMetrics(), 1)
+	metrics := sink.AllMetrics()[0]
+	projectID, ok := metrics.ResourceMetrics().At(0).Resource().Attributes().Get("gitlab.project.id")
+	require.True(t, ok)
+	assert.Equal(t, "12345", projectID.Str())
+	assert.Equal(t, map[string]int64{
+		"group/api/high/sast":                    2,
+		"group/web/critical/dependency_scanning": 1,
+		"group/web/unknown/dast":                 1,
+	}, snapshotGauge(t, metrics, "gitlab.vulnerability.open", "gitlab.project.path", "vulnerability.severity", "vulnerability.report_type"))
+}
+
+func TestProcessCSVData_NoMetricsPipeline(t *testing.T) {
+	receiver, sink := newTestSnapshotReceiver(t)
+	receiver.id = component.MustNewIDWithName(typeStr, "logs_only")
+
+	export := &Export{ID: 1, ProjectID: "12345"}
+	csvData := "Vulnerability ID,Title,Severity,Status\n1,SQL injection,High,detected\n"
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+	assert.Empty(t, sink.AllMetrics())
+}
+
+func TestProcessCSVData_TimeToRemediate(t *testing.T) {
+	receiver, sink := newTestSnapshotReceiver(t)
+	csvData := "Vulnerability ID,Title,Severity,Status,Full Path,Detected At,Resolved At\n" +
+		"1,SQL injection,High,resolved,group/api,2026-01-01T00:00:00Z,2026-01-03T00:00:00Z\n" +
+		"2,XSS,High,resolved,group/api,2026-01-01T00:00:00Z,2026-01-21T00:00:00Z\n" +
+		"3,Old library,Critical,resolved,group/web,2026-01-01T00:00:00Z,2026-01-01T12:00:00Z\n" +
+		"4,Weak hash,Low,detected,group/api,2026-01-01T00:00:00Z,\n" +
+		"5,Leaked key,Low,resolved,group/web,2026-01-01T00:00:00Z,\n"
+
+	export := &Export{ID: 1, ProjectID: "12345"}
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+
+	require.Len(t, sink.AllMetrics(), 1)
+	histograms := dayHistograms(sink.AllMetrics()[0], "gitlab.vulnerability.time_to_remediate")
+	require.Len(t, histograms, 2)
+
+	high := histograms["group/api/high"]
+	assert.Equal(t, uint64(2), high.Count())
+	assert.Equal(t, 22.0, high.Sum())
+	assert.Equal(t, 2.0, high.Min())
+	assert.Equal(t, 20.0, high.Max())
+	assert.Equal(t, dayBuckets, high.ExplicitBounds().AsRaw())
+	assert.Equal(t, []uint64{0, 1, 0, 0, 1, 0, 0, 0, 0, 0}, high.BucketCounts().AsRaw())
+
+	critical := histograms["group/web/critical"]
+	assert.Equal(t, uint64(1), critical.Count())
+	assert.Equal(t, []uint64{1, 0, 0, 0, 0, 0, 0, 0, 0, 0}, critical.BucketCounts().AsRaw())
+	assert.Equal(t, pmetric.AggregationTemporalityDelta, histogramTemporality(sink.AllMetrics()[0], "gitlab.vulnerability.time_to_remediate"))
+
+	// Findings are only recorded the first time they're read resolved
+	sink.Reset()
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", &Export{ID: 2, ProjectID: "12345"}))
+	require.Len(t, sink.AllMetrics(), 1)
+	assert.Empty(t, dayHistograms(sink.AllMetrics()[0], "gitlab.vulnerability.time_to_remediate"))
+}
+
+func TestProcessCSVData_VulnerabilityAge(t *testing.T) {
+	receiver, sink := newTestSnapshotReceiver(t)
+	daysAgo := func(days int) string {
+		return time.Now().AddDate(0, 0, -days).UTC().Format(time.RFC3339)
+	}
+	csvData := "Vulnerability ID,Title,Severity,Status,Full Path,Detected At\n" +
+		"1,SQL injection,Critical,detected,group/api," + daysAgo(2) + "\n" +
+		"2,XSS,Critical,confirmed,group/api," + daysAgo(10) + "\n" +
+		"3,Old library,Low,detected,group/api," + daysAgo(400) + "\n" +
+		"4,Weak hash,Critical,resolved,group/api," + daysAgo(50) + "\n" +
+		"5,Leaked key,High,detected,group/web,\n"
+
+	export := &Export{ID: 1, ProjectID: "12345"}
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+
+	require.Len(t, sink.AllMetrics(), 1)
+	histograms := dayHistograms(sink.AllMetrics()[0], "gitlab.vulnerability.age")
+	require.Len(t, histograms, 2)
+
+	critical := histograms["group/api/critical"]
+	assert.Equal(t, uint64(2), critical.Count())
+	assert.Equal(t, []uint64{0, 1, 0, 1, 0, 0, 0, 0, 0, 0}, critical.BucketCounts().AsRaw())
+	low := histograms["group/api/low"]
+	assert.Equal(t, []uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, low.BucketCounts().AsRaw())
+
+	// Each export is a snapshot of the open vulnerabilities, not a delta
+	assert.Equal(t, pmetric.AggregationTemporalityCumulative, histogramTemporality(sink.AllMetrics()[0], "gitlab.vulnerability.age"))
+	assert.Equal(t, critical.Timestamp(), critical.StartTimestamp())
+}
+
+// dayHistograms returns the data points of a histogram keyed by project path
+// and severity
+func dayHistograms(metrics pmetric.Metrics, name string) map[string]pmetric.HistogramDataPoint {
+	histograms := make(map[string]pmetric.HistogramDataPoint)
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Histogram().DataPoints()
+		for j := 0; j < dps.Len(); j++ {
+			path, _ := dps.At(j).Attributes().Get("gitlab.project.path")
+			severity, _ := dps.At(j).Attributes().Get("vulnerability.severity")
+			histograms[path.Str()+"/"+severity.Str()] = dps.At(j)
+		}
+	}
+	return histograms
+}
+
+// histogramTemporality returns the aggregation temporality of a histogram
+func histogramTemporality(metrics pmetric.Metrics, name string) pmetric.AggregationTemporality {
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Name() == name {
+			return ms.At(i).Histogram().AggregationTemporality()
+		}
+	}
+	return pmetric.AggregationTemporalityUnspecified
+}
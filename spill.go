@@ -0,0 +1,55 @@
+package gitlabvulnreceiver
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// shouldSpill reports whether a download of the given size is written to disk
+// before it's parsed. Downloads of unknown size are, as they may be large.
+func (r *vulnerabilityReceiver) shouldSpill(size int64) bool {
+	threshold := r.cfg.SpillThreshold
+	return threshold > 0 && (size < 0 || size > threshold)
+}
+
+// spillDownload reads a download to the end into a temp file and returns it
+// rewound, so the HTTP connection is released before parsing starts. The file
+// is removed when it's closed.
+func (r *vulnerabilityReceiver) spillDownload(data io.Reader) (*spillFile, error) {
+	file, err := os.CreateTemp(r.cfg.SpillDirectory, "gitlab-vulnerability-export-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	spilled := &spillFile{File: file}
+
+	n, err := io.Copy(file, data)
+	if err != nil {
+		spilled.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spilled.Close()
+		return nil, fmt.Errorf("failed to rewind temp file: %w", err)
+	}
+
+	r.logger.Debug("Spilled export download to disk",
+		zap.String("file", file.Name()),
+		zap.Int64("bytes", n))
+	return spilled, nil
+}
+
+// spillFile is a temp file removed once it's closed
+type spillFile struct {
+	*os.File
+}
+
+func (f *spillFile) Close() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); removeErr != nil && err == nil {
+		err = removeErr
+	}
+	return err
+}
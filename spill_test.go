@@ -0,0 +1,88 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestShouldSpill(t *testing.T) {
+	r := &vulnerabilityReceiver{cfg: &Config{}}
+	assert.False(t, r.shouldSpill(1<<30), "disabled by default")
+	assert.False(t, r.shouldSpill(-1), "disabled by default")
+
+	r.cfg.SpillThreshold = 100
+	assert.False(t, r.shouldSpill(100))
+	assert.True(t, r.shouldSpill(101))
+	assert.True(t, r.shouldSpill(-1), "unknown sizes are spilled")
+}
+
+// closeTracker records whether the download connection was closed
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestProcessExport_SpillsLargeDownloads(t *testing.T) {
+	csvData := "Tool,Severity,Vulnerability\nsast,high,First\nsast,low,Second\n"
+	dir := t.TempDir()
+	download := &closeTracker{Reader: strings.NewReader(csvData)}
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: download, ContentType: "text/csv", Size: int64(len(csvData))}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.SpillThreshold = 10
+	cfg.SpillDirectory = dir
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          cfg,
+		consumer:     &spillCheckingConsumer{LogsSink: sink, t: t, dir: dir, download: download},
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+	}
+
+	require.NoError(t, receiver.processExport(context.Background(), "12345", &Export{ID: 123, ProjectID: "12345"}))
+	assert.Equal(t, 2, sink.LogRecordCount())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the temp file is removed once the export is processed")
+}
+
+// spillCheckingConsumer checks that records are delivered from the spilled file,
+// after the download was closed
+type spillCheckingConsumer struct {
+	*consumertest.LogsSink
+	t        *testing.T
+	dir      string
+	download *closeTracker
+}
+
+func (c *spillCheckingConsumer) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	assert.True(c.t, c.download.closed, "the download is closed before parsing")
+	entries, err := os.ReadDir(c.dir)
+	require.NoError(c.t, err)
+	assert.Len(c.t, entries, 1)
+	return c.LogsSink.ConsumeLogs(ctx, ld)
+}
@@ -0,0 +1,270 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// statisticsClient is the part of the GitLab API the metrics receiver uses
+type statisticsClient interface {
+	GetProject(ctx context.Context, projectID string) (*GitLabProject, error)
+	GetProjectStatistics(ctx context.Context, fullPath string) (*VulnerabilityStatistics, error)
+	GetGroupStatistics(ctx context.Context, fullPath string) (*VulnerabilityStatistics, error)
+	ValidateGroup(ctx context.Context, groupID string) (*GitLabGroup, error)
+}
+
+// statisticsReceiver polls vulnerability statistics and emits them as metrics,
+// which is far cheaper than exports when only the security posture is needed
+type statisticsReceiver struct {
+	id        component.ID
+	cfg       *Config
+	settings  component.TelemetrySettings
+	buildInfo component.BuildInfo
+	consumer  consumer.Metrics
+	client    statisticsClient
+	logger    *zap.Logger
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// Start begins polling statistics
+func (r *statisticsReceiver) Start(ctx context.Context, host component.Host) error {
+	if r.client == nil {
+		client, err := newGitLabClient(ctx, r.cfg, host, r.settings, nil, nil)
+		if err != nil {
+			return err
+		}
+		r.client = client
+	}
+
+	resolved, err := withResolvedPaths(ctx, r.client, r.cfg)
+	if err != nil {
+		return err
+	}
+	r.cfg = resolved
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	registerSnapshotConsumer(r.id, r.consumer)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		initialDelay := r.cfg.InitialDelay
+		if initialDelay <= 0 {
+			initialDelay = r.cfg.PollInterval
+		}
+		timer := time.NewTimer(initialDelay + randomJitter(r.cfg.PollJitter))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if err := r.scrape(ctx); err != nil {
+					r.logger.Error("Failed to scrape vulnerability statistics", zap.Error(err))
+				}
+				timer.Reset(r.cfg.PollInterval + randomJitter(r.cfg.PollJitter))
+			}
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops polling statistics
+func (r *statisticsReceiver) Shutdown(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+		unregisterSnapshotConsumer(r.id)
+	}
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scrape reads the statistics of every configured path and sends them as metrics
+func (r *statisticsReceiver) scrape(ctx context.Context) error {
+	metrics := pmetric.NewMetrics()
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	for _, path := range r.cfg.Paths {
+		var err error
+		switch path.Type {
+		case "project":
+			err = r.scrapeProject(ctx, metrics, path.ID, now)
+		case "group":
+			err = r.scrapeGroup(ctx, metrics, path.ID, now)
+		default:
+			err = fmt.Errorf("unknown path type: %s", path.Type)
+		}
+		if err != nil {
+			r.logger.Error("Failed to get vulnerability statistics",
+				zap.String("id", path.ID),
+				zap.String("type", path.Type),
+				zap.Error(err))
+		}
+	}
+
+	if metrics.DataPointCount() == 0 {
+		return nil
+	}
+	return r.consumer.ConsumeMetrics(ctx, metrics)
+}
+
+func (r *statisticsReceiver) scrapeProject(ctx context.Context, metrics pmetric.Metrics, projectID string, now pcommon.Timestamp) error {
+	project, err := r.client.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	stats, err := r.client.GetProjectStatistics(ctx, project.Path)
+	if err != nil {
+		return err
+	}
+
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	attrs := rm.Resource().Attributes()
+	attrs.PutStr("gitlab.project.id", projectID)
+	attrs.PutStr("gitlab.project.path", project.Path)
+
+	sm := r.newScopeMetrics(rm)
+	putSeverityCounts(sm, stats.Severities, now)
+	putSecurityGrade(sm, projectGrade(stats.Severities), now)
+	return nil
+}
+
+func (r *statisticsReceiver) scrapeGroup(ctx context.Context, metrics pmetric.Metrics, groupID string, now pcommon.Timestamp) error {
+	group, err := r.client.ValidateGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	stats, err := r.client.GetGroupStatistics(ctx, group.Path)
+	if err != nil {
+		return err
+	}
+
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	attrs := rm.Resource().Attributes()
+	attrs.PutStr("gitlab.group.id", groupID)
+	attrs.PutStr("gitlab.group.path", group.Path)
+
+	sm := r.newScopeMetrics(rm)
+	putSeverityCounts(sm, stats.Severities, now)
+
+	grades := sm.Metrics().AppendEmpty()
+	grades.SetName("gitlab.vulnerability.projects")
+	grades.SetDescription("Number of projects per security grade")
+	grades.SetUnit("{project}")
+	gauge := grades.SetEmptyGauge()
+	for _, grade := range stats.Grades {
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.SetTimestamp(now)
+		dp.SetIntValue(grade.Count)
+		dp.Attributes().PutStr("gitlab.security.grade", strings.ToUpper(grade.Grade))
+	}
+
+	// The grade of each project of the group, under its own resource
+	for _, grade := range stats.Grades {
+		for _, project := range grade.Projects.Nodes {
+			rm := metrics.ResourceMetrics().AppendEmpty()
+			attrs := rm.Resource().Attributes()
+			attrs.PutStr("gitlab.project.id", project.ID[strings.LastIndex(project.ID, "/")+1:])
+			attrs.PutStr("gitlab.project.path", project.FullPath)
+			attrs.PutStr("gitlab.group.id", groupID)
+			attrs.PutStr("gitlab.group.path", group.Path)
+			putSecurityGrade(r.newScopeMetrics(rm), strings.ToUpper(grade.Grade), now)
+		}
+	}
+	return nil
+}
+
+func (r *statisticsReceiver) newScopeMetrics(rm pmetric.ResourceMetrics) pmetric.ScopeMetrics {
+	rm.SetSchemaUrl(schemaURL)
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.SetSchemaUrl(schemaURL)
+	sm.Scope().SetName(scopeName)
+	sm.Scope().SetVersion(r.buildInfo.Version)
+	return sm
+}
+
+// putSeverityCounts adds the number of vulnerabilities per severity as a gauge
+func putSeverityCounts(sm pmetric.ScopeMetrics, severities VulnerabilitySeverities, now pcommon.Timestamp) {
+	m := sm.Metrics().AppendEmpty()
+	m.SetName("gitlab.vulnerability.count")
+	m.SetDescription("Number of vulnerabilities per severity")
+	m.SetUnit("{vulnerability}")
+	gauge := m.SetEmptyGauge()
+
+	for _, count := range []struct {
+		severity string
+		value    int64
+	}{
+		{"critical", severities.Critical},
+		{"high", severities.High},
+		{"medium", severities.Medium},
+		{"low", severities.Low},
+		{"info", severities.Info},
+		{"unknown", severities.Unknown},
+	} {
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.SetTimestamp(now)
+		dp.SetIntValue(count.value)
+		dp.Attributes().PutStr("vulnerability.severity", count.severity)
+	}
+}
+
+// Security grades from best to worst, the grade gauge reports the position (A=1, F=5)
+var securityGrades = []string{"A", "B", "C", "D", "F"}
+
+// projectGrade derives a project's security grade from its most severe
+// vulnerabilities the way the GitLab security dashboard does
+func projectGrade(severities VulnerabilitySeverities) string {
+	switch {
+	case severities.Critical > 0:
+		return "F"
+	case severities.High > 0 || severities.Unknown > 0:
+		return "D"
+	case severities.Medium > 0:
+		return "C"
+	case severities.Low > 0:
+		return "B"
+	default:
+		return "A"
+	}
+}
+
+// putSecurityGrade adds the security grade of a project as a gauge, higher being worse
+func putSecurityGrade(sm pmetric.ScopeMetrics, grade string, now pcommon.Timestamp) {
+	value := slices.Index(securityGrades, grade) + 1
+	if value == 0 {
+		return
+	}
+
+	m := sm.Metrics().AppendEmpty()
+	m.SetName("gitlab.project.security_grade")
+	m.SetDescription("Security grade of the project, from 1 (A) to 5 (F)")
+	m.SetUnit("1")
+	dp := m.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(now)
+	dp.SetIntValue(int64(value))
+	dp.Attributes().PutStr("gitlab.security.grade", grade)
+}
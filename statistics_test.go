@@ -0,0 +1,155 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func newStatisticsServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/12345":
+			json.NewEncoder(w).Encode(GitLabProject{ID: 12345, Path: "group/project"})
+		case "/api/v4/groups/678":
+			json.NewEncoder(w).Encode(GitLabGroup{ID: 678, Path: "group"})
+		case "/api/graphql":
+			assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+			var req struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			if strings.Contains(req.Query, "group(") {
+				assert.Equal(t, "group", req.Variables["fullPath"])
+				w.Write([]byte(`{"data": {"group": {
+					"vulnerabilitySeveritiesCount": {"critical": 1, "high": 2, "medium": 3, "low": 4, "info": 5, "unknown": 0},
+					"vulnerabilityGrades": [
+						{"grade": "F", "count": 1, "projects": {"nodes": [{"id": "gid://gitlab/Project/12345", "fullPath": "group/project"}]}},
+						{"grade": "A", "count": 2, "projects": {"nodes": []}}
+					]
+				}}}`))
+				return
+			}
+			assert.Equal(t, "group/project", req.Variables["fullPath"])
+			w.Write([]byte(`{"data": {"project": {
+				"vulnerabilitySeveritiesCount": {"critical": 0, "high": 1, "medium": 0, "low": 7, "info": 0, "unknown": 0}
+			}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestStatisticsReceiver(t *testing.T, serverURL string, path PathConfig) (*statisticsReceiver, *consumertest.MetricsSink) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Token = "test-token"
+	cfg.BaseURL = serverURL
+	cfg.Paths = []PathConfig{path}
+
+	sink := new(consumertest.MetricsSink)
+	return &statisticsReceiver{
+		cfg:      cfg,
+		consumer: sink,
+		client:   gitlab.NewClient(serverURL, "test-token"),
+		logger:   zap.NewNop(),
+	}, sink
+}
+
+// gaugeValues returns the values of a gauge keyed by the given data point attribute
+func gaugeValues(t *testing.T, metrics pmetric.Metrics, name, attribute string) map[string]int64 {
+	values := make(map[string]int64)
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Gauge().DataPoints()
+		for j := 0; j < dps.Len(); j++ {
+			key, ok := dps.At(j).Attributes().Get(attribute)
+			require.True(t, ok)
+			values[key.Str()] = dps.At(j).IntValue()
+		}
+	}
+	return values
+}
+
+func TestStatisticsReceiver_ScrapeProject(t *testing.T) {
+	server := newStatisticsServer(t)
+	defer server.Close()
+
+	receiver, sink := newTestStatisticsReceiver(t, server.URL, PathConfig{ID: "12345", Type: "project"})
+	require.NoError(t, receiver.scrape(context.Background()))
+	require.Len(t, sink.AllMetrics(), 1)
+
+	metrics := sink.AllMetrics()[0]
+	path, ok := metrics.ResourceMetrics().At(0).Resource().Attributes().Get("gitlab.project.path")
+	require.True(t, ok)
+	assert.Equal(t, "group/project", path.Str())
+	assert.Equal(t, schemaURL, metrics.ResourceMetrics().At(0).SchemaUrl())
+	assert.Equal(t, schemaURL, metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).SchemaUrl())
+
+	assert.Equal(t, map[string]int64{
+		"critical": 0, "high": 1, "medium": 0, "low": 7, "info": 0, "unknown": 0,
+	}, gaugeValues(t, metrics, "gitlab.vulnerability.count", "vulnerability.severity"))
+	assert.Equal(t, map[string]int64{"D": 4},
+		gaugeValues(t, metrics, "gitlab.project.security_grade", "gitlab.security.grade"))
+}
+
+func TestStatisticsReceiver_ScrapeGroup(t *testing.T) {
+	server := newStatisticsServer(t)
+	defer server.Close()
+
+	receiver, sink := newTestStatisticsReceiver(t, server.URL, PathConfig{ID: "678", Type: "group"})
+	require.NoError(t, receiver.scrape(context.Background()))
+	require.Len(t, sink.AllMetrics(), 1)
+
+	metrics := sink.AllMetrics()[0]
+	assert.Equal(t, int64(1), gaugeValues(t, metrics, "gitlab.vulnerability.count", "vulnerability.severity")["critical"])
+	assert.Equal(t, map[string]int64{"F": 1, "A": 2},
+		gaugeValues(t, metrics, "gitlab.vulnerability.projects", "gitlab.security.grade"))
+
+	// Each project of the group gets its grade under its own resource
+	require.Equal(t, 2, metrics.ResourceMetrics().Len())
+	project := metrics.ResourceMetrics().At(1)
+	assert.Equal(t, map[string]interface{}{
+		"gitlab.project.id":   "12345",
+		"gitlab.project.path": "group/project",
+		"gitlab.group.id":     "678",
+		"gitlab.group.path":   "group",
+	}, project.Resource().Attributes().AsRaw())
+	grade := project.ScopeMetrics().At(0).Metrics().At(0)
+	assert.Equal(t, "gitlab.project.security_grade", grade.Name())
+	assert.Equal(t, int64(5), grade.Gauge().DataPoints().At(0).IntValue())
+}
+
+func TestProjectGrade(t *testing.T) {
+	tests := []struct {
+		severities VulnerabilitySeverities
+		expected   string
+	}{
+		{VulnerabilitySeverities{}, "A"},
+		{VulnerabilitySeverities{Info: 3}, "A"},
+		{VulnerabilitySeverities{Low: 1}, "B"},
+		{VulnerabilitySeverities{Medium: 1, Low: 4}, "C"},
+		{VulnerabilitySeverities{High: 1}, "D"},
+		{VulnerabilitySeverities{Unknown: 1}, "D"},
+		{VulnerabilitySeverities{Critical: 1, High: 2}, "F"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, projectGrade(tt.severities))
+	}
+}
@@ -0,0 +1,158 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// pathStatus is the live status of a path shown on the status page
+type pathStatus struct {
+	ID            string
+	Type          string
+	ExportID      int64
+	ExportStatus  string
+	LastSuccess   time.Time
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// updatePathStatus applies update to the status of a path
+func (r *vulnerabilityReceiver) updatePathStatus(pathID string, update func(*pathStatus)) {
+	r.statusMutex.Lock()
+	defer r.statusMutex.Unlock()
+
+	if r.pathStatuses == nil {
+		r.pathStatuses = make(map[string]*pathStatus)
+	}
+	status, ok := r.pathStatuses[pathID]
+	if !ok {
+		status = &pathStatus{ID: pathID}
+		r.pathStatuses[pathID] = status
+	}
+	update(status)
+}
+
+// recordExportStatus shows the export a path is processing on the status page
+func (r *vulnerabilityReceiver) recordExportStatus(pathID string, exportID int64, status string) {
+	r.updatePathStatus(pathID, func(s *pathStatus) {
+		s.ExportID, s.ExportStatus = exportID, status
+	})
+}
+
+// pathStatusSnapshot returns a copy of the statuses of the configured paths
+func (r *vulnerabilityReceiver) pathStatusSnapshot() []pathStatus {
+	r.statusMutex.Lock()
+	defer r.statusMutex.Unlock()
+
+	statuses := make([]pathStatus, 0, len(r.cfg.Paths))
+	for _, path := range r.cfg.Paths {
+		status := pathStatus{ID: path.ID}
+		if s, ok := r.pathStatuses[path.ID]; ok {
+			status = *s
+		}
+		status.Type = path.Type
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>GitLab vulnerability receiver</title></head>
+<body>
+<h1>GitLab vulnerability receiver</h1>
+<table border="1" cellpadding="4">
+<tr><th>Path</th><th>Type</th><th>Export ID</th><th>Export Status</th><th>Last Success</th><th>Last Error</th><th>Last Error Time</th></tr>
+{{range .}}<tr><td>{{.ID}}</td><td>{{.Type}}</td><td>{{if .ExportID}}{{.ExportID}}{{else}}-{{end}}</td><td>{{or .ExportStatus "-"}}</td><td>{{formatTime .LastSuccess}}</td><td>{{or .LastError "-"}}</td><td>{{formatTime .LastErrorTime}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// serveStatusPage renders the status page
+func (r *vulnerabilityReceiver) serveStatusPage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(w, r.pathStatusSnapshot()); err != nil {
+		r.logger.Warn("Failed to render status page", zap.Error(err))
+	}
+}
+
+// zpagesHandler is implemented by zpages extensions that serve the pages of
+// other components on their own listener
+type zpagesHandler interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// statusPagePath is the path of the status page of a receiver on zpages
+func statusPagePath(id component.ID) string {
+	return "/debug/" + strings.ReplaceAll(id.String(), "/", "_")
+}
+
+// registerStatusPage adds the status page to the zpages extensions of the host
+// that serve other components' pages. It reports whether one did.
+func (r *vulnerabilityReceiver) registerStatusPage(host component.Host) bool {
+	registered := false
+	for id, ext := range host.GetExtensions() {
+		zpages, ok := ext.(zpagesHandler)
+		if !ok || id.Type().String() != "zpages" {
+			continue
+		}
+		path := statusPagePath(r.id)
+		zpages.Handle(path, http.HandlerFunc(r.serveStatusPage))
+		r.logger.Info("Serving the status page on zpages", zap.String("extension", id.String()), zap.String("path", path))
+		registered = true
+	}
+	return registered
+}
+
+// startStatusPage serves the status page on the zpages extension when it lets
+// components add pages, or on its own listener when status_page.endpoint is set
+func (r *vulnerabilityReceiver) startStatusPage(ctx context.Context, host component.Host) error {
+	if r.registerStatusPage(host) || r.cfg.StatusPage.Endpoint == "" {
+		return nil
+	}
+
+	listener, err := r.cfg.StatusPage.ToListener(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the status page: %w", err)
+	}
+	server, err := r.cfg.StatusPage.ToServer(ctx, host, r.settings, http.HandlerFunc(r.serveStatusPage))
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to create the status page server: %w", err)
+	}
+	r.statusServer = server
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		// The page is for debugging only, losing it doesn't stop the receiver
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.logger.Error("Status page stopped serving", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// stopStatusPage stops serving the status page
+func (r *vulnerabilityReceiver) stopStatusPage() error {
+	if r.statusServer == nil {
+		return nil
+	}
+	return r.statusServer.Close()
+}
@@ -0,0 +1,134 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestStatusPage(t *testing.T) {
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			if projectID == "67890" {
+				return nil, fmt.Errorf("failed to create export: %w", &gitlab.APIError{StatusCode: 500})
+			}
+			return &Export{ID: 42, ProjectID: projectID, Status: ExportStatusCreated}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader("Title,Severity\n"))}, nil
+		},
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}, {ID: "67890", Type: "project"}}
+	receiver := &vulnerabilityReceiver{
+		cfg:               cfg,
+		consumer:          new(consumertest.LogsSink),
+		client:            mockClient,
+		logger:            zap.NewNop(),
+		stateManager:      newTestStateManager(t),
+		lastExportTime:    make(map[string]time.Time),
+		exportsInProgress: make(map[string]bool),
+	}
+	require.NoError(t, receiver.checkExports(context.Background(), context.Background()))
+
+	statuses := receiver.pathStatusSnapshot()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, int64(42), statuses[0].ExportID)
+	assert.Equal(t, "processed", statuses[0].ExportStatus)
+	assert.False(t, statuses[0].LastSuccess.IsZero())
+	assert.Empty(t, statuses[0].LastError)
+	assert.Contains(t, statuses[1].LastError, "status: 500")
+	assert.True(t, statuses[1].LastSuccess.IsZero())
+
+	recorder := httptest.NewRecorder()
+	receiver.serveStatusPage(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, recorder.Body.String(), "<td>12345</td><td>project</td><td>42</td><td>processed</td>")
+	assert.Contains(t, recorder.Body.String(), "status: 500")
+}
+
+func TestStartStatusPage(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	endpoint := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	cfg.StatusPage.Endpoint = endpoint
+	receiver := &vulnerabilityReceiver{
+		cfg:      cfg,
+		settings: componenttest.NewNopTelemetrySettings(),
+		logger:   zap.NewNop(),
+	}
+	require.NoError(t, receiver.startStatusPage(context.Background(), componenttest.NewNopHost()))
+
+	resp, err := http.Get("http://" + endpoint)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "<td>12345</td>")
+
+	require.NoError(t, receiver.stopStatusPage())
+	receiver.wg.Wait()
+}
+
+// zpagesExtension is a zpages extension serving other components' pages
+type zpagesExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	*http.ServeMux
+}
+
+func TestStartStatusPage_ZPages(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Paths = []PathConfig{{ID: "12345", Type: "project"}}
+	// The zpages extension takes over from the page's own listener
+	cfg.StatusPage.Endpoint = "localhost:0"
+	receiver := &vulnerabilityReceiver{
+		cfg:      cfg,
+		id:       component.MustNewIDWithName("gitlab_vulnerability", "prod"),
+		settings: componenttest.NewNopTelemetrySettings(),
+		logger:   zap.NewNop(),
+	}
+	zpages := &zpagesExtension{ServeMux: http.NewServeMux()}
+	host := &extensionHost{
+		Host:       componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{component.MustNewID("zpages"): zpages},
+	}
+	require.NoError(t, receiver.startStatusPage(context.Background(), host))
+	assert.Nil(t, receiver.statusServer)
+
+	recorder := httptest.NewRecorder()
+	zpages.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/gitlab_vulnerability_prod", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<td>12345</td>")
+}
+
+// assertNoPathErrors asserts that no configured path recorded a failed cycle
+func assertNoPathErrors(t *testing.T, receiver *vulnerabilityReceiver, msgAndArgs ...interface{}) {
+	t.Helper()
+	for _, status := range receiver.pathStatusSnapshot() {
+		assert.Empty(t, status.LastError, msgAndArgs...)
+	}
+}
@@ -0,0 +1,80 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// waitForExport waits for GitLab to generate an export within the export
+// timeout. Every stuck_exports threshold the export stays created, a diagnostic
+// event is emitted; the wait goes on unless stuck_exports recreate is set, in
+// which case the stuck error is returned for the export to be replaced.
+func (r *vulnerabilityReceiver) waitForExport(ctx context.Context, pathID string, export *Export) (*Export, error) {
+	start := time.Now()
+	deadline := start.Add(r.cfg.ExportTimeout)
+	for {
+		done, err := r.client.WaitForExport(ctx, export.GetProjectID(), export.ID, time.Until(deadline))
+		if !isExportStuck(err) {
+			return done, err
+		}
+
+		waited := time.Since(start)
+		r.logger.Warn("Export stuck waiting for GitLab to start it",
+			zap.String("id", pathID),
+			zap.Int64("exportID", export.ID),
+			zap.Duration("waited", waited),
+			zap.Bool("recreate", r.cfg.StuckExports.Recreate))
+		r.telemetry.recordExportStuck(ctx)
+		r.recordExportStatus(pathID, export.ID, "stuck")
+		if err := r.consumer.ConsumeLogs(ctx, r.stuckExportEvent(pathID, export, waited)); err != nil {
+			r.logger.Warn("Failed to emit stuck export event", zap.Error(err))
+		}
+
+		if r.cfg.StuckExports.Recreate {
+			return nil, err
+		}
+	}
+}
+
+// stuckExportEvent builds the diagnostic log record emitted when an export
+// stays created beyond the stuck threshold
+func (r *vulnerabilityReceiver) stuckExportEvent(pathID string, export *Export, waited time.Duration) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	pathType := "project"
+	if export.GroupID != nil {
+		pathType = "group"
+		rl.Resource().Attributes().PutStr("gitlab.group.id", pathID)
+	} else {
+		rl.Resource().Attributes().PutStr("gitlab.project.id", pathID)
+	}
+
+	sl := r.newScopeLogs(rl)
+
+	lr := sl.LogRecords().AppendEmpty()
+	now := pcommon.NewTimestampFromTime(time.Now())
+	lr.SetTimestamp(now)
+	lr.SetObservedTimestamp(now)
+	lr.SetSeverityNumber(plog.SeverityNumberWarn)
+	lr.SetSeverityText("WARN")
+	lr.Body().SetStr("Export not started by GitLab")
+
+	action := "wait"
+	if r.cfg.StuckExports.Recreate {
+		action = "recreate"
+	}
+
+	setEventName(lr, "gitlab.export.stuck")
+	attrs := lr.Attributes()
+	attrs.PutStr("gitlab.path.id", pathID)
+	attrs.PutStr("gitlab.path.type", pathType)
+	attrs.PutStr("gitlab.export.id", fmt.Sprintf("%d", export.ID))
+	attrs.PutDouble("gitlab.export.wait_duration", waited.Seconds())
+	attrs.PutStr("gitlab.export.stuck_action", action)
+	return logs
+}
@@ -0,0 +1,86 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+func TestProcessProjectExports_StuckExport(t *testing.T) {
+	stuck := fmt.Errorf("failed to wait: %w: still created after 10m0s", gitlab.ErrExportStuck)
+	csvData := "Title,Severity\nfirst,High\n"
+
+	for _, tt := range []struct {
+		name     string
+		recreate bool
+		created  int
+	}{
+		// The wait goes on after the event
+		{name: "wait", created: 1},
+		// The stuck export is replaced by a new one
+		{name: "recreate", recreate: true, created: 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var created, waits int
+			mockClient := &mockGitLabClient{
+				createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+					created++
+					return &Export{ID: int64(created), ProjectID: projectID, Status: ExportStatusCreated}, nil
+				},
+				waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+					waits++
+					if waits == 1 {
+						return nil, stuck
+					}
+					return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+				},
+				getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+					return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+				},
+			}
+
+			cfg := createDefaultConfig().(*Config)
+			cfg.StuckExports.Recreate = tt.recreate
+			var events []plog.LogRecord
+			sink := new(consumertest.LogsSink)
+			receiver := &vulnerabilityReceiver{
+				cfg:               cfg,
+				consumer:          sink,
+				client:            mockClient,
+				logger:            zap.NewNop(),
+				stateManager:      newTestStateManager(t),
+				lastExportTime:    make(map[string]time.Time),
+				exportsInProgress: make(map[string]bool),
+			}
+
+			require.NoError(t, receiver.processProjectExports(context.Background(), "12345"))
+			assert.Equal(t, tt.created, created)
+			assert.Equal(t, 2, waits)
+
+			for _, logs := range sink.AllLogs() {
+				lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+				if lr.EventName() == "gitlab.export.stuck" {
+					events = append(events, lr)
+				}
+			}
+			require.Len(t, events, 1)
+			attrs := events[0].Attributes().AsRaw()
+			assert.Equal(t, "12345", attrs["gitlab.path.id"])
+			assert.Equal(t, "project", attrs["gitlab.path.type"])
+			assert.Equal(t, "1", attrs["gitlab.export.id"])
+			assert.Equal(t, map[bool]string{false: "wait", true: "recreate"}[tt.recreate], attrs["gitlab.export.stuck_action"])
+			assert.Equal(t, 1, sink.LogRecordCount()-len(events))
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+// receiverTelemetry holds the receiver's own metrics
+type receiverTelemetry struct {
+	meter              metric.Meter
+	recordsDropped     metric.Int64Counter
+	stateWriteDuration metric.Float64Histogram
+	exportsCreated     metric.Int64Counter
+	exportsSucceeded   metric.Int64Counter
+	exportsFailed      metric.Int64Counter
+	exportsPaused      metric.Int64Counter
+	exportsExpired     metric.Int64Counter
+	exportsStuck       metric.Int64Counter
+	pathsOverBudget    metric.Int64Counter
+	exportWaitDuration metric.Float64Histogram
+	exportGeneration   metric.Float64Histogram
+	findingAge         metric.Float64Histogram
+	downloadBytes      metric.Int64Counter
+	rowsParsed         metric.Int64Counter
+	rowsEmitted        metric.Int64Counter
+	rowsSkipped        metric.Int64Counter
+	apiCalls           metric.Int64Counter
+	apiDuration        metric.Float64Histogram
+	errors             metric.Int64Counter
+	// rateLimitRemaining is the last RateLimit-Remaining header read, -1 until one is
+	rateLimitRemaining atomic.Int64
+	// stateRegistration unregisters the state gauges at shutdown
+	stateRegistration metric.Registration
+}
+
+func newReceiverTelemetry(settings component.TelemetrySettings) (*receiverTelemetry, error) {
+	if settings.MeterProvider == nil {
+		return nil, nil
+	}
+	meter := settings.MeterProvider.Meter(scopeName)
+
+	recordsDropped, err := meter.Int64Counter(
+		"gitlab_vulnerability_receiver_records_dropped",
+		metric.WithDescription("Number of log records dropped after the pipeline permanently rejected them"),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create records dropped counter: %w", err)
+	}
+
+	t := &receiverTelemetry{meter: meter, recordsDropped: recordsDropped}
+	t.rateLimitRemaining.Store(-1)
+	for _, counter := range []struct {
+		counter     *metric.Int64Counter
+		name        string
+		description string
+		unit        string
+	}{
+		{&t.exportsCreated, "gitlab_vulnerability_receiver_exports_created", "Number of exports created", "{export}"},
+		{&t.exportsSucceeded, "gitlab_vulnerability_receiver_exports_succeeded", "Number of exports fully processed", "{export}"},
+		{&t.exportsFailed, "gitlab_vulnerability_receiver_exports_failed", "Number of exports that failed to be processed", "{export}"},
+		{&t.exportsPaused, "gitlab_vulnerability_receiver_exports_paused", "Number of exports paused until the pipeline caught up", "{export}"},
+		{&t.exportsExpired, "gitlab_vulnerability_receiver_exports_expired", "Number of exports GitLab expired or deleted before they were processed", "{export}"},
+		{&t.exportsStuck, "gitlab_vulnerability_receiver_exports_stuck", "Number of times an export stayed created beyond the stuck threshold", "{export}"},
+		{&t.pathsOverBudget, "gitlab_vulnerability_receiver_paths_over_budget", "Number of times a path used up max_elapsed_per_path and was deferred to the next poll", "{path}"},
+		{&t.downloadBytes, "gitlab_vulnerability_receiver_download_bytes", "Bytes of export data downloaded", "By"},
+		{&t.rowsParsed, "gitlab_vulnerability_receiver_rows_parsed", "Number of export rows read", "{row}"},
+		{&t.rowsEmitted, "gitlab_vulnerability_receiver_rows_emitted", "Number of log records delivered to the pipeline", "{record}"},
+		{&t.rowsSkipped, "gitlab_vulnerability_receiver_rows_skipped", "Number of export rows not emitted, by reason", "{row}"},
+		{&t.apiCalls, "gitlab_vulnerability_receiver_api_calls", "Number of GitLab API requests by endpoint and status class", "{request}"},
+		{&t.errors, "gitlab_vulnerability_receiver_errors", "Number of failed cycles of a path by error category", "{error}"},
+	} {
+		*counter.counter, err = meter.Int64Counter(counter.name,
+			metric.WithDescription(counter.description),
+			metric.WithUnit(counter.unit))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s counter: %w", counter.name, err)
+		}
+	}
+
+	t.stateWriteDuration, err = meter.Float64Histogram(
+		"gitlab_vulnerability_receiver_state_write_duration",
+		metric.WithDescription("Time taken to write the state file"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state write duration histogram: %w", err)
+	}
+
+	t.exportWaitDuration, err = meter.Float64Histogram(
+		"gitlab_vulnerability_receiver_export_wait_duration",
+		metric.WithDescription("Time waited for GitLab to generate an export"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export wait duration histogram: %w", err)
+	}
+
+	t.exportGeneration, err = meter.Float64Histogram(
+		"gitlab_vulnerability_receiver_export_generation_duration",
+		metric.WithDescription("Time GitLab took to generate an export, from its creation to its completion"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export generation duration histogram: %w", err)
+	}
+
+	t.findingAge, err = meter.Float64Histogram(
+		"gitlab_vulnerability_receiver_finding_age",
+		metric.WithDescription("Time since a finding was detected when its record was emitted"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create finding age histogram: %w", err)
+	}
+
+	t.apiDuration, err = meter.Float64Histogram(
+		"gitlab_vulnerability_receiver_api_duration",
+		metric.WithDescription("Time until GitLab responded to an API request, by endpoint and method"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API duration histogram: %w", err)
+	}
+
+	_, err = meter.Int64ObservableGauge(
+		"gitlab_vulnerability_receiver_rate_limit_remaining",
+		metric.WithDescription("Requests left in GitLab's current rate limit window, from the last RateLimit-Remaining header"),
+		metric.WithUnit("{request}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			if remaining := t.rateLimitRemaining.Load(); remaining >= 0 {
+				o.Observe(remaining)
+			}
+			return nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limit remaining gauge: %w", err)
+	}
+
+	return t, nil
+}
+
+// recordDropped counts records permanently rejected by the pipeline
+func (t *receiverTelemetry) recordDropped(ctx context.Context, records int64) {
+	if t == nil {
+		return
+	}
+	t.recordsDropped.Add(ctx, records)
+}
+
+// recordExportCreated counts an export created
+func (t *receiverTelemetry) recordExportCreated(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.exportsCreated.Add(ctx, 1)
+}
+
+// recordExportResult counts an export processed or failed
+func (t *receiverTelemetry) recordExportResult(ctx context.Context, err error) {
+	if t == nil {
+		return
+	}
+	if isBackpressure(err) {
+		t.exportsPaused.Add(ctx, 1)
+		return
+	}
+	if err != nil {
+		t.exportsFailed.Add(ctx, 1, metric.WithAttributes(attribute.String("category", errorCategory(err))))
+		return
+	}
+	t.exportsSucceeded.Add(ctx, 1)
+}
+
+// recordExportExpired counts an export replaced because GitLab expired or deleted it
+func (t *receiverTelemetry) recordExportExpired(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.exportsExpired.Add(ctx, 1)
+}
+
+// recordExportStuck counts an export found stuck in created
+func (t *receiverTelemetry) recordExportStuck(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.exportsStuck.Add(ctx, 1)
+}
+
+// recordPathOverBudget counts a path deferred for exceeding max_elapsed_per_path
+func (t *receiverTelemetry) recordPathOverBudget(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.pathsOverBudget.Add(ctx, 1)
+}
+
+// recordError counts a failure of the given category
+func (t *receiverTelemetry) recordError(ctx context.Context, category string) {
+	if t == nil {
+		return
+	}
+	t.errors.Add(ctx, 1, metric.WithAttributes(attribute.String("category", category)))
+}
+
+// recordDownload counts the bytes of export data read
+func (t *receiverTelemetry) recordDownload(ctx context.Context, bytes int64) {
+	if t == nil {
+		return
+	}
+	t.downloadBytes.Add(ctx, bytes)
+}
+
+// Reasons export rows are skipped
+const (
+	skipReasonDuplicate  = "duplicate"
+	skipReasonFiltered   = "filtered"
+	skipReasonParseError = "parse_error"
+)
+
+// rowCounts counts what became of the rows of an export
+type rowCounts struct {
+	parsed  int64
+	emitted int64
+	// skipped counts the rows not emitted by reason
+	skipped map[string]int64
+}
+
+// recordRows counts the rows read from an export, the records delivered and
+// the rows skipped
+func (t *receiverTelemetry) recordRows(ctx context.Context, counts rowCounts) {
+	if t == nil {
+		return
+	}
+	t.rowsParsed.Add(ctx, counts.parsed)
+	t.rowsEmitted.Add(ctx, counts.emitted)
+	for _, reason := range []string{skipReasonDuplicate, skipReasonFiltered, skipReasonParseError} {
+		t.rowsSkipped.Add(ctx, counts.skipped[reason], metric.WithAttributes(attribute.String("reason", reason)))
+	}
+}
+
+// recordAPICall counts a GitLab API request and records how long the response took
+func (t *receiverTelemetry) recordAPICall(ctx context.Context, method, endpoint, statusClass string, duration time.Duration) {
+	if t == nil {
+		return
+	}
+	t.apiCalls.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("endpoint", endpoint),
+		attribute.String("status_class", statusClass)))
+	t.apiDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("endpoint", endpoint),
+		attribute.String("method", method)))
+}
+
+// recordExportWait records how long GitLab took to generate an export
+func (t *receiverTelemetry) recordExportWait(ctx context.Context, duration time.Duration) {
+	if t == nil {
+		return
+	}
+	t.exportWaitDuration.Record(ctx, duration.Seconds())
+}
+
+// recordExportGeneration records how long GitLab took to generate a finished
+// export, when it reports both its creation and completion
+func (t *receiverTelemetry) recordExportGeneration(ctx context.Context, export *Export) {
+	if t == nil || export.CreatedAt.IsZero() || export.FinishedAt == nil {
+		return
+	}
+	t.exportGeneration.Record(ctx, export.FinishedAt.Sub(export.CreatedAt).Seconds())
+}
+
+// recordFindingAges records the age of the findings of the records emitted
+func (t *receiverTelemetry) recordFindingAges(ctx context.Context, ages []time.Duration) {
+	if t == nil {
+		return
+	}
+	for _, age := range ages {
+		t.findingAge.Record(ctx, age.Seconds())
+	}
+}
+
+// observeState reports the size of the state and how long writing it takes
+func (t *receiverTelemetry) observeState(sm *state.StateManager) error {
+	if t == nil {
+		return nil
+	}
+
+	entries, err := t.meter.Int64ObservableGauge(
+		"gitlab_vulnerability_receiver_state_entries",
+		metric.WithDescription("Number of vulnerabilities tracked in the state"),
+		metric.WithUnit("{vulnerability}"))
+	if err != nil {
+		return fmt.Errorf("failed to create state entries gauge: %w", err)
+	}
+	fileSize, err := t.meter.Int64ObservableGauge(
+		"gitlab_vulnerability_receiver_state_file_size",
+		metric.WithDescription("Size of the state file"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return fmt.Errorf("failed to create state file size gauge: %w", err)
+	}
+
+	t.stateRegistration, err = t.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(entries, int64(sm.Len()))
+		o.ObserveInt64(fileSize, sm.FileSize())
+		return nil
+	}, entries, fileSize)
+	if err != nil {
+		return fmt.Errorf("failed to register state metrics: %w", err)
+	}
+
+	sm.OnWrite(func(duration time.Duration) {
+		t.stateWriteDuration.Record(context.Background(), duration.Seconds())
+	})
+	return nil
+}
+
+// stopObservingState stops reporting the state metrics
+func (t *receiverTelemetry) stopObservingState() {
+	if t == nil || t.stateRegistration == nil {
+		return
+	}
+	_ = t.stateRegistration.Unregister()
+	t.stateRegistration = nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// instrumentedTransport counts the GitLab API requests made through it
+type instrumentedTransport struct {
+	next      http.RoundTripper
+	telemetry *receiverTelemetry
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	statusClass := "error"
+	if err == nil {
+		statusClass = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	}
+	t.telemetry.recordAPICall(req.Context(), req.Method, apiEndpoint(req.URL.EscapedPath()), statusClass, time.Since(start))
+	if err == nil {
+		if remaining, parseErr := strconv.ParseInt(resp.Header.Get("RateLimit-Remaining"), 10, 64); parseErr == nil {
+			t.telemetry.rateLimitRemaining.Store(remaining)
+		}
+	}
+	return resp, err
+}
+
+// apiEndpoint templates the identifiers out of an API path, keeping the
+// endpoint attribute's cardinality bounded
+func apiEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if i == 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(segment); err == nil || segments[i-1] == "projects" || segments[i-1] == "groups" {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,249 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/internal/state"
+)
+
+func TestObserveState(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := newReceiverTelemetry(component.TelemetrySettings{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	// No rate limit is reported before GitLab sent one
+	assert.NotContains(t, collectMetrics(t, reader), "gitlab_vulnerability_receiver_rate_limit_remaining")
+
+	sm := newTestStateManager(t)
+	require.NoError(t, telemetry.observeState(sm))
+	require.NoError(t, sm.SetFindings(map[string]state.VulnerabilityState{
+		"id:1": {Status: "detected", PathID: "12345"},
+		"id:2": {Status: "detected", PathID: "12345"},
+	}))
+	require.NoError(t, sm.SetAuditCursor("12345", state.AuditCursor{Since: time.Now()}))
+
+	metrics := collectMetrics(t, reader)
+	assert.Equal(t, int64(2), metrics["gitlab_vulnerability_receiver_state_entries"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+	assert.Equal(t, sm.FileSize(), metrics["gitlab_vulnerability_receiver_state_file_size"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+	assert.Positive(t, sm.FileSize())
+	writes := metrics["gitlab_vulnerability_receiver_state_write_duration"].(metricdata.Histogram[float64])
+	assert.Equal(t, uint64(2), writes.DataPoints[0].Count)
+
+	telemetry.stopObservingState()
+	assert.NotContains(t, collectMetrics(t, reader), "gitlab_vulnerability_receiver_state_entries")
+}
+
+// skippedRows returns the rows_skipped counts by reason
+func skippedRows(t *testing.T, metrics map[string]metricdata.Aggregation) map[string]int64 {
+	skipped := make(map[string]int64)
+	for _, dp := range metrics["gitlab_vulnerability_receiver_rows_skipped"].(metricdata.Sum[int64]).DataPoints {
+		reason, ok := dp.Attributes.Value("reason")
+		require.True(t, ok)
+		skipped[reason.AsString()] = dp.Value
+	}
+	return skipped
+}
+
+// collectMetrics returns the data of the metrics read, by name
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestExportMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := newReceiverTelemetry(component.TelemetrySettings{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	csvData := "Vulnerability ID,Title,Severity\n1,first,High\n2,second,Low\n"
+	mockClient := &mockGitLabClient{
+		createExportFunc: func(ctx context.Context, projectID string) (*Export, error) {
+			return &Export{ID: 123, ProjectID: projectID}, nil
+		},
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+		},
+	}
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     new(consumertest.LogsSink),
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+		telemetry:    telemetry,
+	}
+
+	export, err := receiver.resumeOrCreateExport(context.Background(), "12345", mockClient.CreateExport)
+	require.NoError(t, err)
+	require.NoError(t, receiver.processExport(context.Background(), "12345", export))
+	// The same rows in another export are deduplicated
+	require.NoError(t, receiver.processExport(context.Background(), "12345", &Export{ID: 124, ProjectID: "12345"}))
+
+	mockClient.waitForExportFunc = func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+		return nil, errors.New("export failed")
+	}
+	require.Error(t, receiver.processExport(context.Background(), "12345", &Export{ID: 125, ProjectID: "12345"}))
+
+	metrics := collectMetrics(t, reader)
+	sum := func(name string) int64 {
+		return metrics[name].(metricdata.Sum[int64]).DataPoints[0].Value
+	}
+	assert.Equal(t, int64(1), sum("gitlab_vulnerability_receiver_exports_created"))
+	assert.Equal(t, int64(2), sum("gitlab_vulnerability_receiver_exports_succeeded"))
+	assert.Equal(t, int64(1), sum("gitlab_vulnerability_receiver_exports_failed"))
+	assert.Equal(t, int64(2*len(csvData)), sum("gitlab_vulnerability_receiver_download_bytes"))
+	assert.Equal(t, int64(4), sum("gitlab_vulnerability_receiver_rows_parsed"))
+	assert.Equal(t, int64(2), sum("gitlab_vulnerability_receiver_rows_emitted"))
+	assert.Equal(t, map[string]int64{"duplicate": 2, "filtered": 0, "parse_error": 0}, skippedRows(t, metrics))
+	waits := metrics["gitlab_vulnerability_receiver_export_wait_duration"].(metricdata.Histogram[float64])
+	assert.Equal(t, uint64(3), waits.DataPoints[0].Count)
+}
+
+func TestInstrumentedTransport(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := newReceiverTelemetry(component.TelemetrySettings{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Remaining", "1999")
+		if strings.HasSuffix(r.URL.Path, "/456") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &instrumentedTransport{next: http.DefaultTransport, telemetry: telemetry}}
+	for _, path := range []string{"/api/v4/security/vulnerability_exports/123", "/api/v4/security/vulnerability_exports/456", "/api/v4/projects/group%2Fapp/vulnerabilities"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	metrics := collectMetrics(t, reader)
+	assert.Equal(t, int64(1999), metrics["gitlab_vulnerability_receiver_rate_limit_remaining"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+
+	calls := make(map[string]int64)
+	for _, dp := range metrics["gitlab_vulnerability_receiver_api_calls"].(metricdata.Sum[int64]).DataPoints {
+		endpoint, _ := dp.Attributes.Value("endpoint")
+		statusClass, _ := dp.Attributes.Value("status_class")
+		calls[endpoint.AsString()+" "+statusClass.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{
+		"/api/v4/security/vulnerability_exports/:id 2xx": 1,
+		"/api/v4/security/vulnerability_exports/:id 4xx": 1,
+		"/api/v4/projects/:id/vulnerabilities 2xx":       1,
+	}, calls)
+
+	latencies := make(map[string]uint64)
+	for _, dp := range metrics["gitlab_vulnerability_receiver_api_duration"].(metricdata.Histogram[float64]).DataPoints {
+		endpoint, _ := dp.Attributes.Value("endpoint")
+		method, _ := dp.Attributes.Value("method")
+		latencies[method.AsString()+" "+endpoint.AsString()] = dp.Count
+	}
+	assert.Equal(t, map[string]uint64{
+		"GET /api/v4/security/vulnerability_exports/:id": 2,
+		"GET /api/v4/projects/:id/vulnerabilities":       1,
+	}, latencies)
+}
+
+func TestExportGenerationAndFindingAgeMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := newReceiverTelemetry(component.TelemetrySettings{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	detectedAt := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	csvData := "Vulnerability ID,Title,Detected At\n1,first," + detectedAt + "\n2,second,\n"
+	createdAt := time.Now().Add(-time.Hour)
+	finishedAt := createdAt.Add(90 * time.Second)
+	mockClient := &mockGitLabClient{
+		waitForExportFunc: func(ctx context.Context, projectID string, exportID int64, timeout time.Duration) (*Export, error) {
+			return &Export{ID: exportID, ProjectID: projectID, Status: ExportStatusFinished, CreatedAt: createdAt, FinishedAt: &finishedAt}, nil
+		},
+		getExportDataFunc: func(ctx context.Context, url string) (*ExportData, error) {
+			return &ExportData{ReadCloser: io.NopCloser(strings.NewReader(csvData))}, nil
+		},
+	}
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     new(consumertest.LogsSink),
+		client:       mockClient,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+		telemetry:    telemetry,
+	}
+	require.NoError(t, receiver.processExport(context.Background(), "12345", &Export{ID: 123, ProjectID: "12345"}))
+
+	metrics := collectMetrics(t, reader)
+	generation := metrics["gitlab_vulnerability_receiver_export_generation_duration"].(metricdata.Histogram[float64]).DataPoints[0]
+	assert.Equal(t, uint64(1), generation.Count)
+	assert.InDelta(t, 90, generation.Sum, 0.001)
+
+	// Only findings with a detection time are measured
+	ages := metrics["gitlab_vulnerability_receiver_finding_age"].(metricdata.Histogram[float64]).DataPoints[0]
+	assert.Equal(t, uint64(1), ages.Count)
+	assert.InDelta(t, (48 * time.Hour).Seconds(), ages.Sum, 60)
+}
+
+func TestSkippedRowMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	telemetry, err := newReceiverTelemetry(component.TelemetrySettings{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	sink := new(consumertest.LogsSink)
+	receiver := &vulnerabilityReceiver{
+		cfg:          createDefaultConfig().(*Config),
+		consumer:     sink,
+		logger:       zap.NewNop(),
+		stateManager: newTestStateManager(t),
+		telemetry:    telemetry,
+	}
+
+	// The row with an extra column is skipped and the rest of the export still read
+	csvData := "Vulnerability ID,Title\n1,first\n2,second,extra\n3,third\n"
+	export := &Export{ID: 123, ProjectID: "12345"}
+	require.NoError(t, receiver.processCSVData(context.Background(), csv.NewReader(strings.NewReader(csvData)), "12345", export))
+	assert.Equal(t, 2, sink.LogRecordCount())
+
+	metrics := collectMetrics(t, reader)
+	assert.Equal(t, int64(3), metrics["gitlab_vulnerability_receiver_rows_parsed"].(metricdata.Sum[int64]).DataPoints[0].Value)
+	assert.Equal(t, map[string]int64{"duplicate": 0, "filtered": 0, "parse_error": 1}, skippedRows(t, metrics))
+}
@@ -0,0 +1,58 @@
+package gitlabvulnreceiver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the date formats found in GitLab exports and API records
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// timestampParser parses the dates of export records in the configured layouts,
+// placing those without a zone in the configured timezone
+type timestampParser struct {
+	layouts  []string
+	location *time.Location
+}
+
+// defaultTimestampParser parses the built-in layouts, as UTC when there's no zone
+var defaultTimestampParser = &timestampParser{layouts: timestampLayouts, location: time.UTC}
+
+// newTimestampParser creates the parser of the timestamps settings. Configured
+// layouts are tried before the built-in ones.
+func newTimestampParser(cfg TimestampsConfig) (*timestampParser, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("failed to load timestamps timezone: %w", err)
+		}
+	}
+	return &timestampParser{
+		layouts:  append(append([]string{}, cfg.Layouts...), timestampLayouts...),
+		location: location,
+	}, nil
+}
+
+// parse parses a date in one of the layouts. Dates with a zone or offset keep
+// it, other dates are in the parser's location.
+func (p *timestampParser) parse(value string) (time.Time, bool) {
+	if p == nil {
+		p = defaultTimestampParser
+	}
+	value = strings.TrimSpace(value)
+	for _, layout := range p.layouts {
+		if t, err := time.ParseInLocation(layout, value, p.location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,51 @@
+package gitlabvulnreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampParser(t *testing.T) {
+	parser, err := newTimestampParser(TimestampsConfig{
+		Timezone: "Europe/Berlin",
+		Layouts:  []string{"02.01.2006 15:04"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		// Dates without a zone are in the configured timezone
+		{"2024-02-12 03:34:02", "2024-02-12T02:34:02Z"},
+		{"2024-07-12T03:34:02", "2024-07-12T01:34:02Z"},
+		{"12.02.2024 03:34", "2024-02-12T02:34:00Z"},
+		// Dates with a zone keep it
+		{"2024-02-12T03:34:02Z", "2024-02-12T03:34:02Z"},
+		{"2024-02-12 03:34:02 +0100", "2024-02-12T02:34:02Z"},
+	}
+	for _, tt := range tests {
+		parsed, ok := parser.parse(tt.value)
+		require.True(t, ok, tt.value)
+		assert.Equal(t, tt.expected, parsed.UTC().Format(time.RFC3339), tt.value)
+	}
+
+	_, ok := parser.parse("yesterday")
+	assert.False(t, ok)
+}
+
+func TestTimestampParser_Default(t *testing.T) {
+	var parser *timestampParser
+	parsed, ok := parser.parse("2024-02-12 03:34:02")
+	require.True(t, ok)
+	assert.Equal(t, "2024-02-12T03:34:02Z", parsed.Format(time.RFC3339))
+
+	_, ok = parser.parse("12.02.2024 03:34")
+	assert.False(t, ok)
+
+	_, err := newTimestampParser(TimestampsConfig{Timezone: "Mars/Olympus"})
+	assert.ErrorContains(t, err, "failed to load timestamps timezone")
+}
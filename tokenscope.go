@@ -0,0 +1,45 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+// Scopes granting a token access to vulnerability exports, either one will do
+var exportScopes = []string{"read_api", "api"}
+
+// tokenInspector introspects the token requests are authenticated with
+type tokenInspector interface {
+	GetTokenInfo(ctx context.Context) (*gitlab.TokenInfo, error)
+}
+
+// verifyTokenScopes fails when the token is revoked, expired or lacks the scope
+// needed for exports, so a misconfigured token stops the receiver at start
+// rather than with a 403 at the first poll. The check is skipped with a
+// warning when GitLab can't tell, e.g. for OAuth tokens or older versions.
+func verifyTokenScopes(ctx context.Context, client tokenInspector, logger *zap.Logger) error {
+	token, err := client.GetTokenInfo(ctx)
+	if err != nil {
+		if code, ok := gitlab.StatusCode(err); ok && code == http.StatusUnauthorized {
+			return fmt.Errorf("GitLab rejected the token, it's invalid, expired or revoked: %w", err)
+		}
+		logger.Warn("Could not verify the scopes of the token", zap.Error(err))
+		return nil
+	}
+
+	if token.Revoked || !token.Active {
+		return fmt.Errorf("token %q is revoked or expired", token.Name)
+	}
+	if !slices.ContainsFunc(exportScopes, token.HasScope) {
+		return fmt.Errorf("token %q is missing the read_api or api scope needed for vulnerability exports (it has: %s)",
+			token.Name, strings.Join(token.Scopes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gitlabvulnreceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/iamabhimadan/gitlabvulnreceiver/pkg/gitlab"
+)
+
+type fakeTokenInspector struct {
+	token *gitlab.TokenInfo
+	err   error
+}
+
+func (f *fakeTokenInspector) GetTokenInfo(context.Context) (*gitlab.TokenInfo, error) {
+	return f.token, f.err
+}
+
+func TestVerifyTokenScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   *gitlab.TokenInfo
+		err     error
+		wantErr string
+	}{
+		{
+			name:  "read_api",
+			token: &gitlab.TokenInfo{Name: "collector", Active: true, Scopes: []string{"read_api"}},
+		},
+		{
+			name:  "api",
+			token: &gitlab.TokenInfo{Name: "collector", Active: true, Scopes: []string{"read_repository", "api"}},
+		},
+		{
+			name:    "missing scope",
+			token:   &gitlab.TokenInfo{Name: "collector", Active: true, Scopes: []string{"read_repository", "read_registry"}},
+			wantErr: `token "collector" is missing the read_api or api scope needed for vulnerability exports (it has: read_repository, read_registry)`,
+		},
+		{
+			name:    "revoked",
+			token:   &gitlab.TokenInfo{Name: "collector", Revoked: true, Scopes: []string{"api"}},
+			wantErr: `token "collector" is revoked or expired`,
+		},
+		{
+			name:    "rejected",
+			err:     &gitlab.APIError{StatusCode: 401, Body: `{"message":"401 Unauthorized"}`},
+			wantErr: "GitLab rejected the token",
+		},
+		{
+			// Older GitLab versions have no introspection endpoint
+			name: "not found",
+			err:  &gitlab.APIError{StatusCode: 404},
+		},
+		{
+			name: "unreachable",
+			err:  errors.New("connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyTokenScopes(context.Background(), &fakeTokenInspector{token: tt.token, err: tt.err}, zap.NewNop())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}